@@ -28,6 +28,7 @@ import (
 	"github.com/insolar/insolar/configuration"
 	"github.com/insolar/insolar/core"
 	"github.com/insolar/insolar/log"
+	"github.com/insolar/insolar/tracing"
 	"github.com/pkg/errors"
 	"github.com/satori/go.uuid"
 )
@@ -81,6 +82,22 @@ func processQueryType(rh *RequestHandler, qTypeStr string) map[string]interface{
 		answer, hError = rh.ProcessIsAuthorized()
 	case GetSeed:
 		answer, hError = rh.ProcessGetSeed()
+	case GetEvents:
+		answer, hError = rh.ProcessGetEvents(false)
+	case SubscribeEvents:
+		answer, hError = rh.ProcessGetEvents(true)
+	case GetABI:
+		answer, hError = rh.ProcessGetABI()
+	case DeployClass:
+		answer, hError = rh.ProcessDeployClass()
+	case UpgradeClass:
+		answer, hError = rh.ProcessUpgradeClass()
+	case DeadLetters:
+		answer, hError = rh.ProcessDeadLetters()
+	case ReplayDeadLetter:
+		answer, hError = rh.ProcessReplayDeadLetter()
+	case DropDeadLetter:
+		answer, hError = rh.ProcessDropDeadLetter()
 	default:
 		msg := fmt.Sprintf("Wrong query parameter 'query_type' = '%s'", qTypeStr)
 		answer = writeError(msg, BadRequest)
@@ -98,6 +115,9 @@ func processQueryType(rh *RequestHandler, qTypeStr string) map[string]interface{
 
 const qidQueryParam = "qid"
 
+// traceIDHeader is a response header with id of the request's trace
+const traceIDHeader = "X-Trace-Id"
+
 func preprocessRequest(req *http.Request) (*Params, error) {
 	body, err := ioutil.ReadAll(req.Body)
 	if err != nil {
@@ -125,12 +145,35 @@ func preprocessRequest(req *http.Request) (*Params, error) {
 	return &params, nil
 }
 
-func wrapAPIV1Handler(messageBus core.MessageBus, rootDomainReference core.RecordRef) func(w http.ResponseWriter, r *http.Request) {
+// requestTimeout returns time limit for request processing, limit requested by client
+// can't exceed the configured one
+func requestTimeout(params *Params, timeout uint) time.Duration {
+	if params.Timeout > 0 && (timeout == 0 || params.Timeout < timeout) {
+		timeout = params.Timeout
+	}
+	return time.Duration(timeout) * time.Second
+}
+
+// deployQueries are query types that are accepted only when deploy is allowed in configuration
+var deployQueries = map[QueryType]bool{
+	DeployClass:  true,
+	UpgradeClass: true,
+}
+
+// adminQueries are query types that are accepted only when administration is allowed in configuration
+var adminQueries = map[QueryType]bool{
+	DeadLetters:      true,
+	ReplayDeadLetter: true,
+	DropDeadLetter:   true,
+}
+
+func wrapAPIV1Handler(messageBus core.MessageBus, rootDomainReference core.RecordRef, cfg *configuration.APIRunner) func(w http.ResponseWriter, r *http.Request) {
 	sm := seedmanager.New()
 	return func(response http.ResponseWriter, req *http.Request) {
 		startTime := time.Now()
 		answer := make(map[string]interface{})
 		var params *Params
+		var traceID string
 		defer func() {
 			if answer == nil {
 				answer = make(map[string]interface{})
@@ -144,6 +187,9 @@ func wrapAPIV1Handler(messageBus core.MessageBus, rootDomainReference core.Recor
 				serJSON = handlerMarshalErrorJSON
 			}
 			response.Header().Add("Content-Type", "application/json")
+			if traceID != "" {
+				response.Header().Add(traceIDHeader, traceID)
+			}
 			var newLine byte = '\n'
 			_, err = response.Write(append(serJSON, newLine))
 			if err != nil {
@@ -158,7 +204,32 @@ func wrapAPIV1Handler(messageBus core.MessageBus, rootDomainReference core.Recor
 			log.Errorf("[QID=]Can't parse input request: %s, error: %s\n", req.RequestURI, err)
 			return
 		}
-		rh := NewRequestHandler(params, messageBus, rootDomainReference, sm)
+		if deployQueries[QTypeFromString(params.QType)] && !cfg.AllowDeploy {
+			answer = writeError("Deploy of contracts is disabled on this node", BadRequest)
+			log.Warnf("[QID=%s] Rejected %s query: deploy is disabled\n", params.QID, params.QType)
+			return
+		}
+		if adminQueries[QTypeFromString(params.QType)] && !cfg.AllowAdmin {
+			answer = writeError("Administration is disabled on this node", BadRequest)
+			log.Warnf("[QID=%s] Rejected %s query: administration is disabled\n", params.QID, params.QType)
+			return
+		}
+		var ctx context.Context
+		var cancel context.CancelFunc
+		if t := requestTimeout(params, cfg.Timeout); t > 0 {
+			ctx, cancel = context.WithTimeout(req.Context(), t)
+		} else {
+			ctx, cancel = context.WithCancel(req.Context())
+		}
+		defer cancel()
+
+		ctx, span := tracing.StartSpan(ctx, "api."+params.QType)
+		defer span.End()
+		span.SetAttribute("qid", params.QID)
+		traceID = span.Context().TraceID.String()
+		log.Infof("[QID=%s] Trace: %s\n", params.QID, traceID)
+
+		rh := NewRequestHandler(ctx, params, messageBus, rootDomainReference, sm)
 
 		answer = processQueryType(rh, params.QType)
 	}
@@ -207,7 +278,7 @@ func (ar *Runner) Start(c core.Components) error {
 
 	rootDomainReference := c.Bootstrapper.GetRootDomainRef()
 
-	fw := wrapAPIV1Handler(ar.messageBus, *rootDomainReference)
+	fw := wrapAPIV1Handler(ar.messageBus, *rootDomainReference, ar.cfg)
 	http.HandleFunc(ar.cfg.Location, fw)
 	log.Info("Starting ApiRunner ...")
 	log.Info("Config: ", ar.cfg)
@@ -18,6 +18,8 @@ package api
 
 import (
 	"bytes"
+	"context"
+	"encoding/base64"
 	"encoding/json"
 	"io/ioutil"
 	"net/http"
@@ -25,11 +27,16 @@ import (
 	"reflect"
 	"strconv"
 	"testing"
+	"time"
 
+	"github.com/insolar/insolar/api/seedmanager"
 	"github.com/insolar/insolar/bootstrap"
 	"github.com/insolar/insolar/configuration"
 	"github.com/insolar/insolar/core"
+	"github.com/insolar/insolar/core/message"
 	"github.com/insolar/insolar/core/reply"
+	ecdsa_helper "github.com/insolar/insolar/cryptohelpers/ecdsa"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -147,7 +154,7 @@ func (*TestMessageBus) SendAsync(core.Message) {}
 
 const TestBalance = 100500
 
-func (eb *TestMessageBus) Send(core.Message) (core.Reply, error) {
+func (eb *TestMessageBus) Send(context.Context, core.Message) (core.Reply, error) {
 	data, _ := MarshalArgs(TestBalance)
 
 	return &reply.CallMethod{
@@ -160,7 +167,7 @@ func TestWithFakeMessageBus(t *testing.T) {
 
 	const LOCATION = "/test/test"
 
-	fw := wrapAPIV1Handler(&eb, core.RecordRef{})
+	fw := wrapAPIV1Handler(&eb, core.RecordRef{}, &configuration.APIRunner{})
 	http.HandleFunc(LOCATION, fw)
 
 	const TestUrl2 = HOST + LOCATION + "?query_type=PPPPPPPP"
@@ -173,3 +180,142 @@ func TestWithFakeMessageBus(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Contains(t, string(body[:]), `"amount": `+strconv.Itoa(TestBalance))
 }
+
+func TestRequestTimeout(t *testing.T) {
+	assert.Equal(t, 30*time.Second, requestTimeout(&Params{}, 30))
+	assert.Equal(t, 5*time.Second, requestTimeout(&Params{Timeout: 5}, 30))
+	assert.Equal(t, 30*time.Second, requestTimeout(&Params{Timeout: 60}, 30))
+	assert.Equal(t, 60*time.Second, requestTimeout(&Params{Timeout: 60}, 0))
+	assert.Equal(t, time.Duration(0), requestTimeout(&Params{}, 0))
+}
+
+func TestDeployDisabled(t *testing.T) {
+	eb := TestMessageBus{}
+
+	const LOCATION = "/test/deploy"
+
+	fw := wrapAPIV1Handler(&eb, core.RecordRef{}, &configuration.APIRunner{})
+	http.HandleFunc(LOCATION, fw)
+
+	postParams := map[string]interface{}{"query_type": "deploy_class", "code": []byte("plugin")}
+	jsonValue, _ := json.Marshal(postParams)
+	postResp, err := http.Post(HOST+LOCATION, "application/json", bytes.NewBuffer(jsonValue))
+	assert.NoError(t, err)
+	body, err := ioutil.ReadAll(postResp.Body)
+	assert.NoError(t, err)
+	assert.Contains(t, string(body), "Deploy of contracts is disabled on this node")
+}
+
+type deadLetterBus struct {
+	TestMessageBus
+	letters []core.DeadLetter
+}
+
+func (eb *deadLetterBus) DeadLetters() ([]core.DeadLetter, error) {
+	return eb.letters, nil
+}
+
+func (eb *deadLetterBus) ReplayDeadLetter(id string) error {
+	return eb.DropDeadLetter(id)
+}
+
+func (eb *deadLetterBus) DropDeadLetter(id string) error {
+	for i, l := range eb.letters {
+		if l.ID == id {
+			eb.letters = append(eb.letters[:i], eb.letters[i+1:]...)
+			return nil
+		}
+	}
+	return errors.New("no such letter")
+}
+
+func TestDeadLetters(t *testing.T) {
+	eb := &deadLetterBus{letters: []core.DeadLetter{
+		{ID: "1", Message: &message.GetCode{}, Attempts: 10, LastError: "unreachable"},
+	}}
+	query := func(location string, cfg *configuration.APIRunner, params map[string]interface{}) string {
+		http.HandleFunc(location, wrapAPIV1Handler(eb, core.RecordRef{}, cfg))
+		jsonValue, _ := json.Marshal(params)
+		postResp, err := http.Post(HOST+location, "application/json", bytes.NewBuffer(jsonValue))
+		assert.NoError(t, err)
+		body, err := ioutil.ReadAll(postResp.Body)
+		assert.NoError(t, err)
+		return string(body)
+	}
+
+	body := query("/test/admin_disabled", &configuration.APIRunner{}, map[string]interface{}{"query_type": "dead_letters"})
+	assert.Contains(t, body, "Administration is disabled on this node")
+
+	cfg := &configuration.APIRunner{AllowAdmin: true}
+	body = query("/test/dead_letters", cfg, map[string]interface{}{"query_type": "dead_letters"})
+	assert.Contains(t, body, `"last_error": "unreachable"`)
+	assert.Contains(t, body, `"type": "TypeGetCode"`)
+
+	body = query("/test/drop_dead_letter", cfg, map[string]interface{}{"query_type": "drop_dead_letter", "letter_id": "1"})
+	assert.Contains(t, body, `"letter_id": "1"`)
+	assert.Empty(t, eb.letters)
+}
+
+type deployBus struct {
+	TestMessageBus
+	deployed *message.DeployClass
+}
+
+func (eb *deployBus) Send(ctx context.Context, msg core.Message) (core.Reply, error) {
+	switch m := msg.(type) {
+	case *message.RequestCall:
+		return &reply.ID{ID: core.RecordID{1}}, nil
+	case *message.DeployClass:
+		eb.deployed = m
+		code := core.RandomRef()
+		return &reply.Class{Head: m.Request, Code: &code}, nil
+	}
+	return nil, errors.New("unexpected message")
+}
+
+func TestDeployClassSigned(t *testing.T) {
+	key, _ := ecdsa_helper.GeneratePrivateKey()
+	pub, _ := ecdsa_helper.ExportPublicKey(&key.PublicKey)
+	sm := seedmanager.New()
+	seed := seedmanager.Seed{1, 2, 3}
+	sm.Add(seed)
+
+	msg := &message.DeployClass{
+		MachineType: core.MachineTypeGoPlugin,
+		Code:        []byte("plugin"),
+		PublicKey:   pub,
+		Seed:        seed[:],
+	}
+	signature, _ := ecdsa_helper.Sign(msg.SignedData(), key)
+	params := &Params{
+		Code:      msg.Code,
+		PublicKey: pub,
+		Seed:      base64.StdEncoding.EncodeToString(seed[:]),
+		Signature: ecdsa_helper.ExportSignature(signature),
+	}
+
+	eb := &deployBus{}
+	result, err := NewRequestHandler(context.Background(), params, eb, core.RecordRef{}, sm).ProcessDeployClass()
+	assert.NoError(t, err)
+	var request core.RecordRef
+	request.SetRecord(core.RecordID{1})
+	assert.Equal(t, request, eb.deployed.Request)
+	assert.Equal(t, request.String(), result["class"])
+
+	// signed request can't be replayed
+	eb.deployed = nil
+	_, err = NewRequestHandler(context.Background(), params, eb, core.RecordRef{}, sm).ProcessDeployClass()
+	assert.EqualError(t, err, "[ ProcessDeployClass ]: seed is expired or wasn't issued by the node")
+	assert.Nil(t, eb.deployed)
+
+	// the signature doesn't cover other code
+	sm.Add(seed)
+	params.Code = []byte("other plugin")
+	_, err = NewRequestHandler(context.Background(), params, eb, core.RecordRef{}, sm).ProcessDeployClass()
+	assert.EqualError(t, err, "[ ProcessDeployClass ]: invalid signature")
+
+	params.Code = msg.Code
+	params.Seed = base64.StdEncoding.EncodeToString([]byte("unknown seed of thirty two bytes"))
+	_, err = NewRequestHandler(context.Background(), params, eb, core.RecordRef{}, sm).ProcessDeployClass()
+	assert.EqualError(t, err, "[ ProcessDeployClass ]: seed is expired or wasn't issued by the node")
+}
@@ -16,6 +16,12 @@
 
 package api
 
+import (
+	"encoding/json"
+
+	"github.com/insolar/insolar/core"
+)
+
 // QueryType represents type of query
 type QueryType int
 
@@ -30,6 +36,14 @@ const (
 	RegisterNode
 	IsAuth
 	GetSeed
+	GetEvents
+	SubscribeEvents
+	GetABI
+	DeployClass
+	UpgradeClass
+	DeadLetters
+	ReplayDeadLetter
+	DropDeadLetter
 )
 
 // QTypeFromString converts string representation to enum
@@ -51,6 +65,22 @@ func QTypeFromString(strQType string) QueryType {
 		return IsAuth
 	case "get_seed":
 		return GetSeed
+	case "get_events":
+		return GetEvents
+	case "subscribe_events":
+		return SubscribeEvents
+	case "get_abi":
+		return GetABI
+	case "deploy_class":
+		return DeployClass
+	case "upgrade_class":
+		return UpgradeClass
+	case "dead_letters":
+		return DeadLetters
+	case "replay_dead_letter":
+		return ReplayDeadLetter
+	case "drop_dead_letter":
+		return DropDeadLetter
 	}
 
 	return UNDEFINED
@@ -67,4 +97,18 @@ type Params struct {
 	Amount    uint   `json:"amount"`
 	PublicKey string `json:"public_key"`
 	Role      string `json:"role"`
+	Timeout   uint   `json:"timeout"`
+	Validated bool   `json:"validated"`
+	FromPulse uint32 `json:"from_pulse"`
+	ToPulse   uint32 `json:"to_pulse"`
+
+	Code       []byte          `json:"code"`       // plugin binary, base64 encoded in JSON
+	ABI        json.RawMessage `json:"abi"`        // ABI of the code generated by insgocc
+	Migrations []string        `json:"migrations"` // references to migration code
+	// MachineType is machine type of the code, Go plugin if not set
+	MachineType core.MachineType `json:"machine_type"`
+	Seed        string           `json:"seed"`      // seed from get_seed, base64 encoded
+	Signature   string           `json:"signature"` // owner's signature of the deploy, base64 encoded
+
+	LetterID string `json:"letter_id"` // id of the dead letter to replay or drop
 }
@@ -17,16 +17,21 @@
 package api
 
 import (
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"time"
 
 	"github.com/insolar/insolar/api/seedmanager"
 	"github.com/insolar/insolar/core"
 	"github.com/insolar/insolar/core/message"
 	"github.com/insolar/insolar/core/reply"
+	"github.com/insolar/insolar/cryptohelpers/ecdsa"
+	"github.com/insolar/insolar/tracing"
 	"github.com/pkg/errors"
+	"github.com/ugorji/go/codec"
 )
 
 func extractStringResponse(data []byte) (*string, error) {
@@ -47,6 +52,7 @@ func extractStringResponse(data []byte) (*string, error) {
 
 // RequestHandler encapsulate processing of request
 type RequestHandler struct {
+	ctx                 context.Context
 	qid                 string
 	params              *Params
 	messageBus          core.MessageBus
@@ -55,9 +61,10 @@ type RequestHandler struct {
 	seedGenerator       seedmanager.SeedGenerator
 }
 
-// NewRequestHandler creates new query handler
-func NewRequestHandler(params *Params, messageBus core.MessageBus, rootDomainReference core.RecordRef, smanager *seedmanager.SeedManager) *RequestHandler {
+// NewRequestHandler creates new query handler, all calls made by the handler are bound to the context
+func NewRequestHandler(ctx context.Context, params *Params, messageBus core.MessageBus, rootDomainReference core.RecordRef, smanager *seedmanager.SeedManager) *RequestHandler {
 	return &RequestHandler{
+		ctx:                 ctx,
 		qid:                 params.QID,
 		params:              params,
 		messageBus:          messageBus,
@@ -76,11 +83,28 @@ func (rh *RequestHandler) routeCall(ref core.RecordRef, method string, args core
 		Method:    method,
 		Arguments: args,
 	}
+	if rh.params.Validated {
+		e.ReturnMode = message.ReturnValidated
+	}
+
+	ctx, span := tracing.StartSpan(rh.ctx, "api.routeCall")
+	defer span.End()
+	span.SetAttribute("method", method)
+	span.SetAttribute("object", ref.String())
 
-	res, err := rh.messageBus.Send(e)
+	res, err := rh.messageBus.Send(ctx, e)
 	if err != nil {
+		span.SetError(err)
 		return nil, errors.Wrap(err, "[ RouteCall ] couldn't send message")
 	}
+	if le, ok := res.(*reply.LimitExceeded); ok {
+		span.SetError(&le.LimitExceededError)
+		return nil, errors.Wrap(&le.LimitExceededError, "[ RouteCall ] contract call failed")
+	}
+	if vr, ok := res.(*reply.ValidationRejected); ok {
+		span.SetError(vr)
+		return nil, errors.Wrap(vr, "[ RouteCall ] results weren't validated")
+	}
 
 	return res, nil
 }
@@ -320,3 +344,309 @@ func (rh *RequestHandler) ProcessGetSeed() (map[string]interface{}, error) {
 
 	return result, nil
 }
+
+// eventsPollInterval is how often subscribe_events checks storage for new events
+const eventsPollInterval = time.Second
+
+// ProcessGetEvents processes get_events and subscribe_events query types.
+// Only events of finished pulses are returned, "next_pulse" of the answer should be used as "from_pulse"
+// of the next query. With wait the query doesn't return until there are events or the request times out.
+func (rh *RequestHandler) ProcessGetEvents(wait bool) (map[string]interface{}, error) {
+	if len(rh.params.Reference) == 0 {
+		return nil, errors.New("field 'reference' is required")
+	}
+	obj := core.NewRefFromBase58(rh.params.Reference)
+	from := core.PulseNumber(rh.params.FromPulse)
+	to := core.PulseNumber(rh.params.ToPulse)
+
+	for {
+		events, next, err := rh.getEvents(obj, from, to)
+		if err != nil {
+			return nil, errors.Wrap(err, "[ ProcessGetEvents ]")
+		}
+		if len(events) > 0 || !wait || (to != 0 && next > to) {
+			return map[string]interface{}{"events": events, "next_pulse": next}, nil
+		}
+
+		select {
+		case <-rh.ctx.Done():
+			return map[string]interface{}{"events": events, "next_pulse": next}, nil
+		case <-time.After(eventsPollInterval):
+		}
+	}
+}
+
+// getEvents fetches events of finished pulses and returns them along with the pulse to continue from
+func (rh *RequestHandler) getEvents(obj core.RecordRef, from, to core.PulseNumber) ([]map[string]interface{}, core.PulseNumber, error) {
+	if rh.messageBus == nil {
+		return nil, 0, errors.New("message bus was not set during initialization")
+	}
+	res, err := rh.messageBus.Send(rh.ctx, &message.GetEvents{Object: obj, From: from, To: to})
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "couldn't send message")
+	}
+	rep, ok := res.(*reply.Events)
+	if !ok {
+		return nil, 0, errors.Errorf("unexpected reply %T", res)
+	}
+
+	next := from
+	if rep.Pulse > next {
+		next = rep.Pulse
+	}
+	if to != 0 && to < next {
+		next = to + 1
+	}
+
+	events := []map[string]interface{}{}
+	for _, ev := range rep.Events {
+		if ev.Pulse >= next {
+			continue
+		}
+		events = append(events, map[string]interface{}{
+			"name":    ev.Name,
+			"pulse":   ev.Pulse,
+			"request": ev.Request.String(),
+			"payload": eventPayload(ev.Payload),
+		})
+	}
+	return events, next, nil
+}
+
+// eventPayload decodes payload of the event to be sent as JSON, payload that can't be decoded
+// is sent as is
+func eventPayload(payload []byte) interface{} {
+	ch := new(codec.CborHandle)
+	ch.MapType = reflect.TypeOf(map[string]interface{}(nil))
+	var res interface{}
+	err := codec.NewDecoderBytes(payload, ch).Decode(&res)
+	if err != nil {
+		return payload
+	}
+	return res
+}
+
+// ProcessGetABI processes get_abi query type, it returns ABI of the class by its reference,
+// so clients can encode calls to any contract
+func (rh *RequestHandler) ProcessGetABI() (map[string]interface{}, error) {
+	if len(rh.params.Reference) == 0 {
+		return nil, errors.New("field 'reference' is required")
+	}
+	if rh.messageBus == nil {
+		return nil, errors.New("[ ProcessGetABI ] message bus was not set during initialization")
+	}
+	class := core.NewRefFromBase58(rh.params.Reference)
+
+	res, err := rh.messageBus.Send(rh.ctx, &message.GetClass{Head: class})
+	if err != nil {
+		return nil, errors.Wrap(err, "[ ProcessGetABI ] couldn't fetch class")
+	}
+	classRep, ok := res.(*reply.Class)
+	if !ok {
+		return nil, errors.Errorf("[ ProcessGetABI ] unexpected reply %T", res)
+	}
+	if classRep.TypeRef == nil {
+		return nil, errors.New("[ ProcessGetABI ] class has no ABI")
+	}
+
+	res, err = rh.messageBus.Send(rh.ctx, &message.GetType{TypeRef: *classRep.TypeRef})
+	if err != nil {
+		return nil, errors.Wrap(err, "[ ProcessGetABI ] couldn't fetch type")
+	}
+	typeRep, ok := res.(*reply.TypeDeclaration)
+	if !ok {
+		return nil, errors.Errorf("[ ProcessGetABI ] unexpected reply %T", res)
+	}
+
+	var abi core.ContractABI
+	err = json.Unmarshal(typeRep.TypeDec, &abi)
+	if err != nil {
+		return nil, errors.Wrap(err, "[ ProcessGetABI ] couldn't decode ABI")
+	}
+
+	return map[string]interface{}{"abi": abi, "type": classRep.TypeRef.String()}, nil
+}
+
+// ProcessDeployClass processes deploy_class query type, it deploys the code as new class owned by the key
+// that signed the query. ABI of the code, when provided, is declared as type of the class.
+func (rh *RequestHandler) ProcessDeployClass() (map[string]interface{}, error) {
+	result, err := rh.deployClass(nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "[ ProcessDeployClass ]")
+	}
+	return result, nil
+}
+
+// ProcessUpgradeClass processes upgrade_class query type, it deploys the code and sets it as code
+// of the existing class, objects of the class are migrated with provided migrations. The query must
+// be signed by the owner of the class.
+func (rh *RequestHandler) ProcessUpgradeClass() (map[string]interface{}, error) {
+	if len(rh.params.Reference) == 0 {
+		return nil, errors.New("field 'reference' is required")
+	}
+	class := core.NewRefFromBase58(rh.params.Reference)
+
+	result, err := rh.deployClass(&class)
+	if err != nil {
+		return nil, errors.Wrap(err, "[ ProcessUpgradeClass ]")
+	}
+	return result, nil
+}
+
+// deployClass registers the deploy request and sends it to the ledger, the code is for Go plugins
+// unless machine type is set. Records of the deploy are created together, so failed deploy leaves nothing.
+func (rh *RequestHandler) deployClass(class *core.RecordRef) (map[string]interface{}, error) {
+	msg, err := rh.deployMessage(class)
+	if err != nil {
+		return nil, err
+	}
+	if rh.messageBus == nil {
+		return nil, errors.New("message bus was not set during initialization")
+	}
+
+	res, err := rh.messageBus.Send(rh.ctx, &message.RequestCall{Message: msg})
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't register request")
+	}
+	id, ok := res.(*reply.ID)
+	if !ok {
+		return nil, errors.Errorf("unexpected reply %T", res)
+	}
+	msg.Request.SetRecord(id.ID)
+
+	res, err = rh.messageBus.Send(rh.ctx, msg)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't deploy class")
+	}
+	classRep, ok := res.(*reply.Class)
+	if !ok {
+		return nil, errors.Errorf("unexpected reply %T", res)
+	}
+
+	result := map[string]interface{}{"class": classRep.Head.String(), "code": classRep.Code.String()}
+	if classRep.TypeRef != nil {
+		result["type"] = classRep.TypeRef.String()
+	}
+	return result, nil
+}
+
+// deployMessage builds deploy message from the query and checks its seed and signature, the seed is used up
+func (rh *RequestHandler) deployMessage(class *core.RecordRef) (*message.DeployClass, error) {
+	if len(rh.params.Code) == 0 {
+		return nil, errors.New("field 'code' is required")
+	}
+	if len(rh.params.PublicKey) == 0 {
+		return nil, errors.New("field 'public_key' is required")
+	}
+
+	seed, err := base64.StdEncoding.DecodeString(rh.params.Seed)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't decode seed")
+	}
+	var s seedmanager.Seed
+	if len(seed) != len(s) {
+		return nil, errors.New("field 'seed' is required")
+	}
+	copy(s[:], seed)
+	if !rh.seedManager.Exists(s) {
+		return nil, errors.New("seed is expired or wasn't issued by the node")
+	}
+	signature, err := ecdsa.ImportSignature(rh.params.Signature)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't decode signature")
+	}
+
+	mt := rh.params.MachineType
+	if mt == core.MachineTypeNotExist {
+		mt = core.MachineTypeGoPlugin
+	}
+	migrations := make([]core.RecordRef, 0, len(rh.params.Migrations))
+	for _, m := range rh.params.Migrations {
+		migrations = append(migrations, core.NewRefFromBase58(m))
+	}
+	msg := &message.DeployClass{
+		Domain:      rh.rootDomainReference,
+		Class:       class,
+		MachineType: mt,
+		Code:        rh.params.Code,
+		Migrations:  migrations,
+		TypeDec:     rh.params.ABI,
+		PublicKey:   rh.params.PublicKey,
+		Seed:        seed,
+		Signature:   signature,
+	}
+
+	ok, err := ecdsa.Verify(msg.SignedData(), signature, msg.PublicKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't verify signature")
+	}
+	if !ok {
+		return nil, errors.New("invalid signature")
+	}
+	// seed is consumed only by signed request, concurrent requests with the same seed can't both get it
+	if !rh.seedManager.Pop(s) {
+		return nil, errors.New("seed is expired or wasn't issued by the node")
+	}
+	return msg, nil
+}
+
+// ProcessDeadLetters processes dead_letters query type, it returns asynchronous messages sent by the node
+// that weren't delivered
+func (rh *RequestHandler) ProcessDeadLetters() (map[string]interface{}, error) {
+	q, err := rh.deadLetterQueue()
+	if err != nil {
+		return nil, errors.Wrap(err, "[ ProcessDeadLetters ]")
+	}
+	letters, err := q.DeadLetters()
+	if err != nil {
+		return nil, errors.Wrap(err, "[ ProcessDeadLetters ]")
+	}
+
+	res := make([]map[string]interface{}, 0, len(letters))
+	for _, l := range letters {
+		res = append(res, map[string]interface{}{
+			"id":         l.ID,
+			"type":       l.Message.Type().String(),
+			"target":     l.Message.Target().String(),
+			"attempts":   l.Attempts,
+			"last_error": l.LastError,
+		})
+	}
+	return map[string]interface{}{"letters": res}, nil
+}
+
+// ProcessReplayDeadLetter processes replay_dead_letter query type, the letter is delivered again
+func (rh *RequestHandler) ProcessReplayDeadLetter() (map[string]interface{}, error) {
+	q, err := rh.deadLetterQueue()
+	if err != nil {
+		return nil, errors.Wrap(err, "[ ProcessReplayDeadLetter ]")
+	}
+	if err := q.ReplayDeadLetter(rh.params.LetterID); err != nil {
+		return nil, errors.Wrap(err, "[ ProcessReplayDeadLetter ]")
+	}
+	return map[string]interface{}{"letter_id": rh.params.LetterID}, nil
+}
+
+// ProcessDropDeadLetter processes drop_dead_letter query type, the letter is removed permanently
+func (rh *RequestHandler) ProcessDropDeadLetter() (map[string]interface{}, error) {
+	q, err := rh.deadLetterQueue()
+	if err != nil {
+		return nil, errors.Wrap(err, "[ ProcessDropDeadLetter ]")
+	}
+	if err := q.DropDeadLetter(rh.params.LetterID); err != nil {
+		return nil, errors.Wrap(err, "[ ProcessDropDeadLetter ]")
+	}
+	return map[string]interface{}{"letter_id": rh.params.LetterID}, nil
+}
+
+// deadLetterQueue returns dead letters of the message bus, letter id is required by queries changing them
+func (rh *RequestHandler) deadLetterQueue() (core.DeadLetterQueue, error) {
+	q, ok := rh.messageBus.(core.DeadLetterQueue)
+	if !ok {
+		return nil, errors.New("message bus has no dead letters")
+	}
+	if QTypeFromString(rh.params.QType) != DeadLetters && rh.params.LetterID == "" {
+		return nil, errors.New("field 'letter_id' is required")
+	}
+	return q, nil
+}
@@ -17,6 +17,7 @@
 package bootstrap
 
 import (
+	"context"
 	"io/ioutil"
 	"path/filepath"
 	"runtime"
@@ -26,6 +27,7 @@ import (
 	"github.com/insolar/insolar/genesis/experiment/nodedomain"
 	"github.com/insolar/insolar/genesis/experiment/rootdomain"
 	"github.com/insolar/insolar/log"
+	"github.com/insolar/insolar/logicrunner/builtin"
 	"github.com/insolar/insolar/logicrunner/goplugin/testutil"
 	"github.com/pkg/errors"
 	"github.com/ugorji/go/codec"
@@ -44,7 +46,8 @@ var contractNames = []string{wallet, member, allowance, rootDomain, nodeDomain,
 
 // Bootstrapper is a component for precreation core contracts types and RootDomain instance
 type Bootstrapper struct {
-	rootDomainRef *core.RecordRef
+	rootDomainRef  *core.RecordRef
+	builtinClasses map[string]core.RecordRef
 }
 
 // GetRootDomainRef returns reference to RootDomain instance
@@ -52,6 +55,16 @@ func (b *Bootstrapper) GetRootDomainRef() *core.RecordRef {
 	return b.rootDomainRef
 }
 
+// GetBuiltinClassRef returns reference to class of builtin contract deployed by bootstrap,
+// nil if the contract wasn't deployed by this node
+func (b *Bootstrapper) GetBuiltinClassRef(name string) *core.RecordRef {
+	ref, ok := b.builtinClasses[name]
+	if !ok {
+		return nil
+	}
+	return &ref
+}
+
 // NewBootstrapper creates new Bootstrapper
 func NewBootstrapper(cfg configuration.Configuration) (*Bootstrapper, error) {
 	bootstrapper := &Bootstrapper{}
@@ -111,9 +124,9 @@ func isLightExecutor(c core.Components) (bool, error) {
 	return true, nil
 }
 
-func getRootDomainRef(c core.Components) (*core.RecordRef, error) {
+func getRootDomainRef(ctx context.Context, c core.Components) (*core.RecordRef, error) {
 	am := c.Ledger.GetArtifactManager()
-	rootObj, err := am.GetObject(*am.RootRef(), nil)
+	rootObj, err := am.GetObject(ctx, *am.RootRef(), nil)
 	if err != nil {
 		return nil, errors.Wrap(err, "[ getRootDomainRef ] couldn't get children of RootRef object")
 	}
@@ -162,14 +175,14 @@ func serializeInstance(contractInstance interface{}) ([]byte, error) {
 	return instanceData, nil
 }
 
-func (b *Bootstrapper) activateRootDomain(am core.ArtifactManager, cb *testutil.ContractsBuilder) error {
+func (b *Bootstrapper) activateRootDomain(ctx context.Context, am core.ArtifactManager, cb *testutil.ContractsBuilder) error {
 	instanceData, err := serializeInstance(rootdomain.NewRootDomain())
 	if err != nil {
 		return errors.Wrap(err, "[ ActivateRootDomain ]")
 	}
 
 	contract, err := am.ActivateObject(
-		core.RecordRef{}, core.RandomRef(),
+		ctx, core.RecordRef{}, core.RandomRef(),
 		*cb.Classes[rootDomain],
 		*am.RootRef(),
 		instanceData,
@@ -182,14 +195,14 @@ func (b *Bootstrapper) activateRootDomain(am core.ArtifactManager, cb *testutil.
 	return nil
 }
 
-func (b *Bootstrapper) activateNodeDomain(am core.ArtifactManager, cb *testutil.ContractsBuilder) error {
+func (b *Bootstrapper) activateNodeDomain(ctx context.Context, am core.ArtifactManager, cb *testutil.ContractsBuilder) error {
 	instanceData, err := serializeInstance(nodedomain.NewNodeDomain())
 	if err != nil {
 		return errors.Wrap(err, "[ ActivateNodeDomain ]")
 	}
 
 	contract, err := am.ActivateObject(
-		core.RecordRef{}, core.RandomRef(),
+		ctx, core.RecordRef{}, core.RandomRef(),
 		*cb.Classes[nodeDomain],
 		*b.rootDomainRef,
 		instanceData,
@@ -201,13 +214,13 @@ func (b *Bootstrapper) activateNodeDomain(am core.ArtifactManager, cb *testutil.
 	return nil
 }
 
-func (b *Bootstrapper) activateSmartContracts(am core.ArtifactManager, cb *testutil.ContractsBuilder) error {
-	err := b.activateRootDomain(am, cb)
+func (b *Bootstrapper) activateSmartContracts(ctx context.Context, am core.ArtifactManager, cb *testutil.ContractsBuilder) error {
+	err := b.activateRootDomain(ctx, am, cb)
 	errMsg := "[ ActivateSmartContracts ]"
 	if err != nil {
 		return errors.Wrap(err, errMsg)
 	}
-	err = b.activateNodeDomain(am, cb)
+	err = b.activateNodeDomain(ctx, am, cb)
 	if err != nil {
 		return errors.Wrap(err, errMsg)
 	}
@@ -219,7 +232,8 @@ func (b *Bootstrapper) activateSmartContracts(am core.ArtifactManager, cb *testu
 func (b *Bootstrapper) Start(c core.Components) error {
 	log.Info("[ Bootstrapper ] Starting Bootstrap ...")
 
-	rootDomainRef, err := getRootDomainRef(c)
+	ctx := context.Background()
+	rootDomainRef, err := getRootDomainRef(ctx, c)
 	if err != nil {
 		return errors.Wrap(err, "[ Bootstrapper ] couldn't get ref of rootDomain")
 	}
@@ -238,12 +252,17 @@ func (b *Bootstrapper) Start(c core.Components) error {
 		return nil
 	}
 
+	am := c.Ledger.GetArtifactManager()
+	b.builtinClasses, err = builtin.Deploy(ctx, am, *am.RootRef())
+	if err != nil {
+		return errors.Wrap(err, "[ Bootstrapper ] couldn't deploy builtin contracts")
+	}
+
 	_, insgocc, err := testutil.Build()
 	if err != nil {
 		return errors.Wrap(err, "[ Bootstrapper ] couldn't build insgocc")
 	}
 
-	am := c.Ledger.GetArtifactManager()
 	cb := testutil.NewContractBuilder(am, insgocc)
 	defer cb.Clean()
 
@@ -252,7 +271,7 @@ func (b *Bootstrapper) Start(c core.Components) error {
 		return errors.Wrap(err, "[ Bootstrapper ] couldn't build contracts")
 	}
 
-	err = b.activateSmartContracts(am, cb)
+	err = b.activateSmartContracts(ctx, am, cb)
 	if err != nil {
 		return errors.Wrap(err, "[ Bootstrapper ]")
 	}
@@ -0,0 +1,179 @@
+/*
+ *    Copyright 2018 Insolar
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/insolar/insolar/core"
+	"github.com/insolar/insolar/core/message"
+	ecdsa_helper "github.com/insolar/insolar/cryptohelpers/ecdsa"
+	"github.com/insolar/insolar/logicrunner/goplugin/preprocessor"
+	"github.com/pkg/errors"
+)
+
+// defaultAPI is API address of a node started with default configuration
+const defaultAPI = "http://localhost:19191/api/v1"
+
+// deploy compiles the contract and sends its plugin and ABI to API of a node as new class, or as new code
+// of the class if class is set. The query is signed with the key of the class owner, the node should have
+// deploy allowed in its configuration
+func deploy(
+	api string, parsed *preprocessor.ParsedFile, allowedImports []string, keyFile string, class string, migrations []string,
+) (map[string]interface{}, error) {
+	key, err := readKey(keyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := ioutil.TempDir("", "insgocc-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir) // nolint: errcheck
+
+	err = compile(parsed, dir, allowedImports)
+	if err != nil {
+		return nil, err
+	}
+
+	name := parsed.ContractName()
+	code, err := ioutil.ReadFile(filepath.Join(dir, name+".so"))
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't read plugin")
+	}
+	abi, err := ioutil.ReadFile(filepath.Join(dir, name+".abi.json"))
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't read ABI")
+	}
+	// ABI is signed as the node receives it, JSON encoding compacts it
+	var typeDec bytes.Buffer
+	err = json.Compact(&typeDec, abi)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't read ABI")
+	}
+
+	msg := &message.DeployClass{
+		MachineType: core.MachineTypeGoPlugin,
+		Code:        code,
+		TypeDec:     typeDec.Bytes(),
+	}
+	query := map[string]interface{}{
+		"query_type":   "deploy_class",
+		"code":         code,
+		"abi":          json.RawMessage(typeDec.Bytes()),
+		"machine_type": core.MachineTypeGoPlugin,
+	}
+	if class != "" {
+		ref := core.NewRefFromBase58(class)
+		msg.Class = &ref
+		for _, m := range migrations {
+			msg.Migrations = append(msg.Migrations, core.NewRefFromBase58(m))
+		}
+		query["query_type"] = "upgrade_class"
+		query["reference"] = class
+		query["migrations"] = migrations
+	}
+
+	err = sign(api, key, msg, query)
+	if err != nil {
+		return nil, err
+	}
+	return callAPI(api, query)
+}
+
+// readKey reads PEM encoded private key of the class owner
+func readKey(keyFile string) (*ecdsa.PrivateKey, error) {
+	if keyFile == "" {
+		return nil, errors.New("key of the class owner is required")
+	}
+	pemKey, err := ioutil.ReadFile(keyFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't read key")
+	}
+	return ecdsa_helper.ImportPrivateKey(string(pemKey))
+}
+
+// sign signs the deploy with the key, using fresh seed of the node, and adds the key, the seed
+// and the signature to the query
+func sign(api string, key *ecdsa.PrivateKey, msg *message.DeployClass, query map[string]interface{}) error {
+	answer, err := callAPI(api, map[string]interface{}{"query_type": "get_seed"})
+	if err != nil {
+		return errors.Wrap(err, "couldn't get seed")
+	}
+	seed, ok := answer["seed"].(string)
+	if !ok {
+		return errors.New("node returned no seed")
+	}
+	msg.Seed, err = base64.StdEncoding.DecodeString(seed)
+	if err != nil {
+		return errors.Wrap(err, "couldn't decode seed")
+	}
+	msg.PublicKey, err = ecdsa_helper.ExportPublicKey(&key.PublicKey)
+	if err != nil {
+		return err
+	}
+
+	signature, err := ecdsa_helper.Sign(msg.SignedData(), key)
+	if err != nil {
+		return err
+	}
+	query["public_key"] = msg.PublicKey
+	query["seed"] = seed
+	query["signature"] = ecdsa_helper.ExportSignature(signature)
+	return nil
+}
+
+// callAPI sends the query to API of a node, error reported by the node is returned as error
+func callAPI(api string, query map[string]interface{}) (map[string]interface{}, error) {
+	body, err := json.Marshal(query)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.Post(api, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't send query")
+	}
+	defer resp.Body.Close()
+
+	var answer map[string]interface{}
+	err = json.NewDecoder(resp.Body).Decode(&answer)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't decode answer")
+	}
+	if e, ok := answer["error"].(map[string]interface{}); ok {
+		return nil, errors.Errorf("node failed to process query: %v", e["message"])
+	}
+	return answer, nil
+}
+
+// printRefs prints references returned by deploy_class and upgrade_class queries
+func printRefs(answer map[string]interface{}) {
+	for _, key := range []string{"class", "code", "type"} {
+		if ref, ok := answer[key]; ok {
+			fmt.Printf("%s: %v\n", key, ref)
+		}
+	}
+}
@@ -63,23 +63,95 @@ func (r *outputFlag) Type() string {
 	return "file"
 }
 
+// parse parses a contract from a single file or from all files of a package in the directory
+func parse(path string) (*preprocessor.ParsedFile, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return preprocessor.ParsePackage(path)
+	}
+	return preprocessor.ParseFile(path)
+}
+
+// compile checks the contract and builds its plugin, plugin and ABI of the contract are written
+// into the output directory as <name>.so and <name>.abi.json
+func compile(parsed *preprocessor.ParsedFile, outdir string, allowedImports []string) error {
+	err := parsed.CheckSafety(allowedImports)
+	if err != nil {
+		return err
+	}
+
+	outdir, err = filepath.Abs(outdir)
+	if err != nil {
+		return err
+	}
+
+	// make temporary dir
+	tmpDir, err := ioutil.TempDir("", "test-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir) // nolint: errcheck
+
+	name := parsed.ContractName()
+
+	abi, err := os.Create(path.Join(outdir, name+".abi.json"))
+	if err != nil {
+		return err
+	}
+	defer abi.Close()
+
+	err = parsed.WriteABI(abi)
+	if err != nil {
+		return err
+	}
+
+	parsed.ChangePackageToMain()
+	err = parsed.WriteFiles(tmpDir)
+	if err != nil {
+		return err
+	}
+
+	wrapper, err := os.Create(filepath.Join(tmpDir, name+".wrapper.go"))
+	if err != nil {
+		return err
+	}
+	defer wrapper.Close()
+
+	err = parsed.WriteWrapper(wrapper)
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.Command("go", "build", "-buildmode=plugin", "-o", path.Join(outdir, name+".so"))
+	cmd.Dir = tmpDir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return errors.Wrap(err, "can't build contract: "+string(out))
+	}
+	return nil
+}
+
 func main() {
 
-	var reference, outdir string
+	var reference, outdir, api, keyFile string
+	var allowedImports, migrations []string
 	output := newOutputFlag("-")
 	proxyOut := newOutputFlag("")
 
 	var cmdProxy = &cobra.Command{
-		Use:   "proxy [flags] <file name to process>",
+		Use:   "proxy [flags] <file or package directory to process>",
 		Short: "Generate contract's proxy",
 		Run: func(cmd *cobra.Command, args []string) {
 
 			if len(args) != 1 {
-				fmt.Println("proxy command should be followed by exactly one file name to process")
+				fmt.Println("proxy command should be followed by exactly one file or directory name to process")
 				os.Exit(1)
 			}
 
-			parsed, err := preprocessor.ParseFile(args[0])
+			parsed, err := parse(args[0])
 			if err != nil {
 				fmt.Println(errors.Wrap(err, "couldn't parse"))
 				os.Exit(1)
@@ -125,19 +197,25 @@ func main() {
 	cmdProxy.Flags().VarP(proxyOut, "output", "o", "output file (use - for STDOUT)")
 
 	var cmdWrapper = &cobra.Command{
-		Use:   "wrapper [flags] <file name to process>",
+		Use:   "wrapper [flags] <file or package directory to process>",
 		Short: "Generate contract's wrapper",
 		Run: func(cmd *cobra.Command, args []string) {
 			if len(args) != 1 {
-				fmt.Println("wrapper command should be followed by exactly one file name to process")
+				fmt.Println("wrapper command should be followed by exactly one file or directory name to process")
 				os.Exit(1)
 			}
-			parsed, err := preprocessor.ParseFile(args[0])
+			parsed, err := parse(args[0])
 			if err != nil {
 				fmt.Println(errors.Wrap(err, "couldn't parse"))
 				os.Exit(1)
 			}
 
+			err = parsed.CheckSafety(allowedImports)
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+
 			err = parsed.WriteWrapper(output.writer)
 			if err != nil {
 				fmt.Println(err)
@@ -146,22 +224,32 @@ func main() {
 		},
 	}
 	cmdWrapper.Flags().VarP(output, "output", "o", "output file (use - for STDOUT)")
+	cmdWrapper.Flags().StringSliceVar(&allowedImports, "allow-import", nil, "additionally allowed import path (use path/... for subpackages)")
 
+	var importsDir string
 	var cmdImports = &cobra.Command{
-		Use:   "imports [flags] <file name to process>",
+		Use:   "imports [flags] <file or package directory to process>",
 		Short: "Rewrite imports in contract file",
 		Run: func(cmd *cobra.Command, args []string) {
 			if len(args) != 1 {
-				fmt.Println("imports command should be followed by exactly one file name to process")
+				fmt.Println("imports command should be followed by exactly one file or directory name to process")
 				os.Exit(1)
 			}
-			parsed, err := preprocessor.ParseFile(args[0])
+			parsed, err := parse(args[0])
 			if err != nil {
 				fmt.Println(errors.Wrap(err, "couldn't parse"))
 				os.Exit(1)
 			}
 
-			err = parsed.Write(output.writer)
+			if info, _ := os.Stat(args[0]); info != nil && info.IsDir() {
+				if importsDir == "" {
+					fmt.Println("imports of a package directory need --output-dir, its files can't be written into one output")
+					os.Exit(1)
+				}
+				err = parsed.WriteFiles(importsDir)
+			} else {
+				err = parsed.Write(output.writer)
+			}
 			if err != nil {
 				fmt.Println(err)
 				os.Exit(1)
@@ -169,80 +257,110 @@ func main() {
 		},
 	}
 	cmdImports.Flags().VarP(output, "output", "o", "output file (use - for STDOUT)")
+	cmdImports.Flags().StringVarP(&importsDir, "output-dir", "d", "", "output dir for files of a package directory")
 
-	var cmdCompile = &cobra.Command{
-		Use:   "compile [flags] <file name to compile>",
-		Short: "Compile contract",
+	var cmdABI = &cobra.Command{
+		Use:   "abi [flags] <file or package directory to process>",
+		Short: "Generate contract's ABI",
 		Run: func(cmd *cobra.Command, args []string) {
-			dir, err := os.Getwd()
-			if err != nil {
-				fmt.Println(err)
-				os.Exit(1)
-			}
 			if len(args) != 1 {
-				fmt.Println("compile command should be followed by exactly one file name to compile")
+				fmt.Println("abi command should be followed by exactly one file or directory name to process")
 				os.Exit(1)
 			}
-			parsed, err := preprocessor.ParseFile(args[0])
+			parsed, err := parse(args[0])
 			if err != nil {
-				fmt.Println(err)
+				fmt.Println(errors.Wrap(err, "couldn't parse"))
 				os.Exit(1)
 			}
 
-			// make temporary dir
-			tmpDir, err := ioutil.TempDir("", "test-")
+			err = parsed.WriteABI(output.writer)
 			if err != nil {
 				fmt.Println(err)
 				os.Exit(1)
 			}
-			defer os.RemoveAll(tmpDir) // nolint: errcheck
-
-			name := parsed.ContractName()
+		},
+	}
+	cmdABI.Flags().VarP(output, "output", "o", "output file (use - for STDOUT)")
 
-			contract, err := os.Create(filepath.Join(tmpDir, name+".go"))
+	var cmdCompile = &cobra.Command{
+		Use:   "compile [flags] <file or package directory to compile>",
+		Short: "Compile contract",
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) != 1 {
+				fmt.Println("compile command should be followed by exactly one file or directory name to compile")
+				os.Exit(1)
+			}
+			parsed, err := parse(args[0])
 			if err != nil {
 				fmt.Println(err)
 				os.Exit(1)
 			}
-			defer contract.Close()
 
-			parsed.ChangePackageToMain()
-			err = parsed.Write(contract)
+			err = compile(parsed, outdir, allowedImports)
 			if err != nil {
 				fmt.Println(err)
 				os.Exit(1)
 			}
+		},
+	}
+	cmdCompile.Flags().StringVarP(&outdir, "output-dir", "o", ".", "output dir (default .)")
+	cmdCompile.Flags().StringSliceVar(&allowedImports, "allow-import", nil, "additionally allowed import path (use path/... for subpackages)")
 
-			wrapper, err := os.Create(filepath.Join(tmpDir, name+".wrapper.go"))
+	var cmdDeploy = &cobra.Command{
+		Use:   "deploy [flags] <file or package directory to deploy>",
+		Short: "Compile contract and deploy it as new class through API of a node",
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) != 1 {
+				fmt.Println("deploy command should be followed by exactly one file or directory name to deploy")
+				os.Exit(1)
+			}
+			parsed, err := parse(args[0])
 			if err != nil {
 				fmt.Println(err)
 				os.Exit(1)
 			}
-			defer wrapper.Close()
 
-			err = parsed.WriteWrapper(wrapper)
+			answer, err := deploy(api, parsed, allowedImports, keyFile, "", nil)
 			if err != nil {
-				fmt.Println(err)
+				fmt.Println(errors.Wrap(err, "couldn't deploy"))
 				os.Exit(1)
 			}
+			printRefs(answer)
+		},
+	}
+	cmdDeploy.Flags().StringVar(&api, "api", defaultAPI, "API address of the node")
+	cmdDeploy.Flags().StringSliceVar(&allowedImports, "allow-import", nil, "additionally allowed import path (use path/... for subpackages)")
+	cmdDeploy.Flags().StringVar(&keyFile, "key", "", "file with PEM encoded private key, the key owns the new class")
 
-			err = os.Chdir(tmpDir)
+	var cmdUpgrade = &cobra.Command{
+		Use:   "upgrade [flags] <class reference> <file or package directory to deploy>",
+		Short: "Compile contract and deploy it as new code of existing class through API of a node",
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) != 2 {
+				fmt.Println("upgrade command should be followed by class reference and exactly one file or directory name to deploy")
+				os.Exit(1)
+			}
+			parsed, err := parse(args[1])
 			if err != nil {
 				fmt.Println(err)
 				os.Exit(1)
 			}
 
-			out, err := exec.Command("go", "build", "-buildmode=plugin", "-o", path.Join(dir, outdir, name+".so")).CombinedOutput()
+			answer, err := deploy(api, parsed, allowedImports, keyFile, args[0], migrations)
 			if err != nil {
-				fmt.Println(errors.Wrap(err, "can't build contract: "+string(out)))
+				fmt.Println(errors.Wrap(err, "couldn't upgrade"))
 				os.Exit(1)
 			}
+			printRefs(answer)
 		},
 	}
-	cmdCompile.Flags().StringVarP(&outdir, "output-dir", "o", ".", "output dir (default .)")
+	cmdUpgrade.Flags().StringVar(&api, "api", defaultAPI, "API address of the node")
+	cmdUpgrade.Flags().StringSliceVar(&allowedImports, "allow-import", nil, "additionally allowed import path (use path/... for subpackages)")
+	cmdUpgrade.Flags().StringVar(&keyFile, "key", "", "file with PEM encoded private key of the class owner")
+	cmdUpgrade.Flags().StringSliceVar(&migrations, "migration", nil, "reference to migration code, migrations are applied in provided order")
 
 	var rootCmd = &cobra.Command{Use: "insgocc"}
-	rootCmd.AddCommand(cmdProxy, cmdWrapper, cmdImports, cmdCompile)
+	rootCmd.AddCommand(cmdProxy, cmdWrapper, cmdImports, cmdABI, cmdCompile, cmdDeploy, cmdUpgrade)
 	err := rootCmd.Execute()
 	if err != nil {
 		fmt.Println(err)
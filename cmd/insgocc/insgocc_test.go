@@ -17,8 +17,34 @@
 package main
 
 import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
 )
 
 func TestStub(t *testing.T) {
 }
+
+func TestCallAPI(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var query map[string]interface{}
+		err := json.NewDecoder(r.Body).Decode(&query)
+		assert.NoError(t, err)
+		if query["reference"] == "bad" {
+			w.Write([]byte(`{"error": {"message": "Handler error: bad class", "code": -1}}`))
+			return
+		}
+		w.Write([]byte(`{"class": "1111", "code": "2222"}`))
+	}))
+	defer srv.Close()
+
+	answer, err := callAPI(srv.URL, map[string]interface{}{"query_type": "upgrade_class", "reference": "good"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"class": "1111", "code": "2222"}, answer)
+
+	_, err = callAPI(srv.URL, map[string]interface{}{"query_type": "upgrade_class", "reference": "bad"})
+	assert.EqualError(t, err, "node failed to process query: Handler error: bad class")
+}
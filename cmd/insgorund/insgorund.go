@@ -35,6 +35,8 @@ func main() {
 	path := pflag.StringP("directory", "d", "", "directory where to store code of go plugins")
 	rpcAddress := pflag.String("rpc", "localhost:7778", "address and port of RPC API")
 	rpcProtocol := pflag.String("rpc-proto", "tcp", "protocol of RPC API")
+	cacheSize := pflag.Int64("cache-size", 1<<30, "size limit of the code cache in bytes")
+	prewarm := pflag.Int("prewarm", 10, "number of the most used plugins to load from the cache on start")
 	pflag.Parse()
 
 	err := log.SetLevel("Debug")
@@ -52,8 +54,13 @@ func main() {
 		*path = tmpDir
 	}
 
-	insider := ginsider.NewGoInsider(*path, *rpcProtocol, *rpcAddress)
+	insider, err := ginsider.NewGoInsider(*path, *rpcProtocol, *rpcAddress, *cacheSize)
+	if err != nil {
+		log.Fatal("Couldn't open code cache: ", err)
+		os.Exit(1)
+	}
 	proxyctx.Current = insider
+	go insider.Prewarm(*prewarm)
 
 	err = rpc.Register(&ginsider.RPC{GI: insider})
 	if err != nil {
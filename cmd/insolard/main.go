@@ -34,6 +34,7 @@ import (
 	"github.com/insolar/insolar/metrics"
 	"github.com/insolar/insolar/network/servicenetwork"
 	"github.com/insolar/insolar/pulsar"
+	"github.com/insolar/insolar/tracing"
 	"github.com/insolar/insolar/version"
 	"github.com/spf13/cobra"
 	jww "github.com/spf13/jwalterweatherman"
@@ -101,6 +102,11 @@ func main() {
 
 	initLogger(cfgHolder.Configuration.Log)
 
+	err = tracing.Start(cfgHolder.Configuration.Tracing)
+	if err != nil {
+		log.Fatalln("failed to start Tracing: ", err.Error())
+	}
+
 	fmt.Print("Starts with configuration:\n", configuration.ToString(cfgHolder.Configuration))
 
 	cm := componentManager{}
@@ -148,6 +154,7 @@ func main() {
 
 	defer func() {
 		cm.stopAll()
+		tracing.Stop()
 	}()
 
 	var gracefulStop = make(chan os.Signal)
@@ -159,6 +166,7 @@ func main() {
 		log.Debugln("caught sig: ", sig)
 
 		cm.stopAll()
+		tracing.Stop()
 		os.Exit(0)
 	}()
 
@@ -0,0 +1,125 @@
+/*
+ *    Copyright 2018 Insolar
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/insolar/insolar/configuration"
+	"github.com/insolar/insolar/core"
+	"github.com/insolar/insolar/log"
+	"github.com/insolar/insolar/messagebus"
+	"github.com/insolar/insolar/messagebus/replay"
+)
+
+var (
+	configPath    string
+	recordingPath string
+	dataDir       string
+	allTypes      bool
+)
+
+func parseInputParams() {
+	var rootCmd = &cobra.Command{Use: "insreplay"}
+	rootCmd.Flags().StringVarP(&recordingPath, "recording", "r", "", "path to file recorded by MessageBus")
+	rootCmd.Flags().StringVarP(&configPath, "config", "c", "", "path to config file of the node")
+	rootCmd.Flags().StringVarP(&dataDir, "data", "d", "", "ledger data directory, temporary one if not set")
+	rootCmd.Flags().BoolVarP(&allTypes, "all", "a", false, "replay all recorded messages, not only contract calls")
+	err := rootCmd.Execute()
+	if err != nil {
+		log.Fatal("Wrong input params:", err)
+	}
+}
+
+func main() {
+	parseInputParams()
+	if recordingPath == "" {
+		log.Fatal("recording is required")
+	}
+
+	cfgHolder := configuration.NewHolder()
+	if configPath != "" {
+		if err := cfgHolder.LoadFromFile(configPath); err != nil {
+			log.Fatal("failed to load configuration from file: ", err)
+		}
+	}
+	cfg := cfgHolder.Configuration
+
+	if dataDir == "" {
+		tmp, err := ioutil.TempDir("", "insreplay")
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer os.RemoveAll(tmp) // nolint: errcheck
+		dataDir = tmp
+	}
+	cfg.Ledger.Storage.DataDirectory = dataDir
+
+	records, err := messagebus.ReadRecording(recordingPath)
+	if err != nil {
+		log.Fatal("failed to read recording: ", err)
+	}
+
+	h, err := replay.NewHarness(cfg)
+	if err != nil {
+		log.Fatal("failed to start replay harness: ", err)
+	}
+
+	var types []core.MessageType
+	if allTypes {
+		types, err = recordedTypes(records)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	report, err := h.Run(records, types...)
+	if stopErr := h.Stop(); stopErr != nil {
+		log.Errorln("failed to stop replay harness: ", stopErr)
+	}
+	if err != nil {
+		log.Fatal("replay failed: ", err)
+	}
+
+	fmt.Printf("records: %d, replayed: %d, mismatches: %d\n", report.Total, report.Replayed, len(report.Mismatches))
+	for _, m := range report.Mismatches {
+		fmt.Printf("#%d %s\n\texpected: %s\n\tactual:   %s\n", m.Index, m.Type, m.Expected, m.Actual)
+	}
+	if len(report.Mismatches) > 0 {
+		os.Exit(1)
+	}
+}
+
+// recordedTypes returns types of all recorded messages
+func recordedTypes(records []messagebus.Record) ([]core.MessageType, error) {
+	seen := map[core.MessageType]bool{}
+	var types []core.MessageType
+	for i, rec := range records {
+		msg, err := rec.GetMessage()
+		if err != nil {
+			return nil, fmt.Errorf("broken record #%d: %s", i, err)
+		}
+		if !seen[msg.Type()] {
+			seen[msg.Type()] = true
+			types = append(types, msg.Type())
+		}
+	}
+	return types, nil
+}
@@ -0,0 +1,41 @@
+/*
+ *    Copyright 2018 Insolar
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/insolar/insolar/core"
+	"github.com/insolar/insolar/core/message"
+	"github.com/insolar/insolar/messagebus"
+)
+
+func TestRecordedTypes(t *testing.T) {
+	records := []messagebus.Record{
+		{Message: message.MustSerializeBytes(&message.CallMethod{})},
+		{Message: message.MustSerializeBytes(&message.GetCode{})},
+		{Message: message.MustSerializeBytes(&message.CallMethod{})},
+	}
+	types, err := recordedTypes(records)
+	assert.NoError(t, err)
+	assert.Equal(t, []core.MessageType{core.TypeCallMethod, core.TypeGetCode}, types)
+
+	_, err = recordedTypes([]messagebus.Record{{Message: []byte("broken")}})
+	assert.Error(t, err)
+}
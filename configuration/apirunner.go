@@ -24,6 +24,15 @@ import (
 type APIRunner struct {
 	Port     uint
 	Location string
+	// Timeout - default time limit for processing of a request in seconds,
+	// client can set its own limit with "timeout" query param
+	Timeout uint
+	// AllowDeploy enables deploy_class and upgrade_class queries. Code uploaded with them is run
+	// by the network, so they should be enabled only on nodes reachable by trusted clients.
+	AllowDeploy bool
+	// AllowAdmin enables queries managing the node itself, e.g. dead_letters. They should be enabled
+	// only on nodes reachable by operators of the node.
+	AllowAdmin bool
 }
 
 // NewAPIRunner creates new api config
@@ -31,10 +40,11 @@ func NewAPIRunner() APIRunner {
 	return APIRunner{
 		Port:     19191,
 		Location: "/api/v1",
+		Timeout:  30,
 	}
 }
 
 func (ar *APIRunner) String() string {
-	res := fmt.Sprintln("Port ->", ar.Port, ", Location ->", ar.Location)
+	res := fmt.Sprintln("Port ->", ar.Port, ", Location ->", ar.Location, ", Timeout ->", ar.Timeout, ", AllowDeploy ->", ar.AllowDeploy, ", AllowAdmin ->", ar.AllowAdmin)
 	return res
 }
@@ -35,8 +35,10 @@ type Configuration struct {
 	Log         Log
 	Metrics     Metrics
 	LogicRunner LogicRunner
+	MessageBus  MessageBus
 	APIRunner   APIRunner
 	Pulsar      Pulsar
+	Tracing     Tracing
 }
 
 // Holder provides methods to manage configuration
@@ -55,8 +57,10 @@ func NewConfiguration() Configuration {
 		Log:         NewLog(),
 		Metrics:     NewMetrics(),
 		LogicRunner: NewLogicRunner(),
+		MessageBus:  NewMessageBus(),
 		APIRunner:   NewAPIRunner(),
 		Pulsar:      NewPulsar(),
+		Tracing:     NewTracing(),
 	}
 
 	return cfg
@@ -27,6 +27,48 @@ type LogicRunner struct {
 	BuiltIn *BuiltIn
 	// GoPlugin - configuration of executor based on Go plugins
 	GoPlugin *GoPlugin
+	// External - configuration of executor running contracts in external runtime,
+	// nil means contracts of MachineTypeExternal aren't supported
+	External *External
+	// Limits - resource limits of a single contract call
+	Limits CallLimits
+	// CaseBindDirectory - directory to store CaseBind of every finished pulse in,
+	// empty means CaseBinds aren't stored
+	CaseBindDirectory string
+	// PrivateKey - PEM encoded key to sign validation results with, new key is generated on start if it's empty
+	PrivateKey string
+	// Validation - configuration of calls returning validated results
+	Validation Validation
+}
+
+// Validation - configuration of calls that return results only after validators confirmed them
+type Validation struct {
+	// Quorum - number of validators that should confirm results
+	Quorum int
+	// Timeout - time in milliseconds to wait for validators
+	Timeout int
+	// Validators - known keys of virtual validators besides the node itself, results signed by other nodes are rejected
+	Validators []Validator
+}
+
+// Validator - known key of a virtual validator node
+type Validator struct {
+	// Node - reference of the validator node, base58 encoded
+	Node string
+	// PublicKey - PEM encoded public key of the node
+	PublicKey string
+}
+
+// CallLimits - limits of resources a single contract call may consume, zero means no limit
+type CallLimits struct {
+	// Time - execution time of the call in milliseconds, nested calls included
+	Time int
+	// Memory - memory in bytes allocated by the call
+	Memory uint64
+	// NestedCalls - number of calls to other contracts made directly by the call
+	NestedCalls int
+	// StateSize - size in bytes of the object's memory after the call
+	StateSize int
 }
 
 // BuiltIn configuration, no options at the moment
@@ -39,6 +81,28 @@ type GoPlugin struct {
 	// RunnerProtocol - protocol (network) of above address,
 	// e.g. "tcp", "unix"... see `net.Dial`
 	RunnerProtocol string
+	// RunnerPath - path to `insgorund` binary, when set the node starts
+	// and supervises runners itself, otherwise a runner started separately
+	// is expected on RunnerListen
+	RunnerPath string
+	// RunnerCount - number of runner processes in the pool, runner N listens
+	// on RunnerListen with port increased by N (or suffix ".N" for unix sockets)
+	RunnerCount int
+	// HealthCheckInterval - interval between health pings of runners in milliseconds
+	HealthCheckInterval int
+	// CodeCachePath - directory where runners started by the node keep plugins
+	// between restarts (runner N uses subdirectory N)
+	CodeCachePath string
+	// CodeCacheSize - size limit of the code cache of each runner in bytes
+	CodeCacheSize int64
+}
+
+// External configuration, see package logicrunner/external for the protocol
+type External struct {
+	// Command - executable of the runtime, the node starts it and talks to it over stdin and stdout
+	Command string
+	// Args - arguments of the command
+	Args []string
 }
 
 // NewLogicRunner - returns default config of the logic runner
@@ -48,8 +112,23 @@ func NewLogicRunner() LogicRunner {
 		RPCProtocol: "tcp",
 		BuiltIn:     &BuiltIn{},
 		GoPlugin: &GoPlugin{
-			RunnerListen:   "127.0.0.1:7777",
-			RunnerProtocol: "tcp",
+			RunnerListen:        "127.0.0.1:7777",
+			RunnerProtocol:      "tcp",
+			RunnerCount:         1,
+			HealthCheckInterval: 1000,
+			CodeCachePath:       "./data/codecache",
+			CodeCacheSize:       1 << 30,
+		},
+		Limits: CallLimits{
+			Time:        5000,
+			Memory:      256 << 20,
+			NestedCalls: 100,
+			StateSize:   1 << 20,
+		},
+		CaseBindDirectory: "./data/casebind",
+		Validation: Validation{
+			Quorum:  2,
+			Timeout: 10000,
 		},
 	}
 }
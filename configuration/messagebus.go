@@ -0,0 +1,83 @@
+/*
+ *    Copyright 2018 Insolar
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package configuration
+
+// Outbox holds configuration of the MessageBus outbox for asynchronous messages.
+type Outbox struct {
+	// Directory - where undelivered messages are stored, outbox is disabled if empty
+	Directory string
+	// MaxAttempts - how many times delivery is attempted before message goes to dead letters
+	MaxAttempts int
+	// RetryDelay - delay before the first retry in milliseconds, it's doubled on every next retry
+	RetryDelay int
+	// RetryMaxDelay - upper limit of the delay between retries in milliseconds
+	RetryMaxDelay int
+}
+
+// Delivery holds limits of incoming messages handling, they apply to messages received from network.
+type Delivery struct {
+	// Workers - number of messages of the same type handled concurrently, 0 means no limit,
+	// messages of nested contract calls aren't limited
+	Workers int
+	// QueueSize - number of messages of the same type waiting for a worker, others are rejected as busy
+	QueueSize int
+	// SenderRate - messages per second accepted from one host, 0 means no limit
+	SenderRate float64
+	// SenderBurst - number of messages accepted from one host at once regardless of SenderRate
+	SenderBurst int
+	// BusyRetries - how many times sender repeats message rejected as busy before giving up
+	BusyRetries int
+	// BusyRetryDelay - delay in milliseconds before the first repeat of rejected message,
+	// it's doubled on every next repeat
+	BusyRetryDelay int
+}
+
+// MessageBus holds configuration for MessageBus.
+type MessageBus struct {
+	// SerializeLocal - pass messages and replies addressed to the current node
+	// through serialization, so handlers can't mutate sender's state
+	SerializeLocal bool
+	// Outbox - configuration of persistent queue for SendAsync
+	Outbox Outbox
+	// RecordFile - file where messages delivered to the node and their replies are recorded,
+	// messages sent by the node while handling others aren't recorded as replay reproduces them,
+	// recording is disabled if empty, file is overwritten on start
+	RecordFile string
+	// Delivery - limits of incoming messages handling
+	Delivery Delivery
+}
+
+// NewMessageBus creates new default configuration for MessageBus.
+func NewMessageBus() MessageBus {
+	return MessageBus{
+		SerializeLocal: true,
+		Outbox: Outbox{
+			Directory:     "",
+			MaxAttempts:   10,
+			RetryDelay:    500,
+			RetryMaxDelay: 60000,
+		},
+		Delivery: Delivery{
+			Workers:        0,
+			QueueSize:      1024,
+			SenderRate:     500,
+			SenderBurst:    1000,
+			BusyRetries:    3,
+			BusyRetryDelay: 100,
+		},
+	}
+}
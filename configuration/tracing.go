@@ -0,0 +1,41 @@
+/*
+ *    Copyright 2018 Insolar
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package configuration
+
+// Tracing holds configuration for distributed tracing.
+type Tracing struct {
+	// ServiceName - name of the service reported with spans
+	ServiceName string
+	// File - file where finished spans are appended in OTLP JSON format, one export request per line
+	File string
+	// CollectorEndpoint - OTLP/HTTP traces endpoint of the collector, e.g. http://localhost:4318/v1/traces
+	CollectorEndpoint string
+	// BatchSize - max number of spans in one export request
+	BatchSize int
+	// FlushInterval - max time in milliseconds finished span waits for export
+	FlushInterval int
+}
+
+// NewTracing creates new default configuration for distributed tracing.
+// Spans aren't exported by default, but trace ids are still written to logs.
+func NewTracing() Tracing {
+	return Tracing{
+		ServiceName:   "insolard",
+		BatchSize:     512,
+		FlushInterval: 1000,
+	}
+}
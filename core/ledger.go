@@ -16,6 +16,8 @@
 
 package core
 
+import "context"
+
 // JetRole is number representing a node role.
 type JetRole int
 
@@ -63,84 +65,122 @@ type ArtifactManager interface {
 
 	// RegisterRequest creates or check call request record and returns it RecordRef.
 	// (used by VM on executing side)
-	RegisterRequest(message Message) (*RecordRef, error)
+	RegisterRequest(ctx context.Context, message Message) (*RecordRef, error)
 
 	// GetCode returns code from code record by provided reference according to provided machine preference.
 	//
 	// This method is used by VM to fetch code for execution.
-	GetCode(ref RecordRef, machinePref []MachineType) (CodeDescriptor, error)
+	GetCode(ctx context.Context, ref RecordRef, machinePref []MachineType) (CodeDescriptor, error)
 
 	// GetClass returns descriptor for provided state.
 	//
 	// If provided state is nil, the latest state will be returned (with deactivation check). Returned descriptor will
 	// provide methods for fetching all related data.
-	GetClass(head RecordRef, state *RecordRef) (ClassDescriptor, error)
+	GetClass(ctx context.Context, head RecordRef, state *RecordRef) (ClassDescriptor, error)
 
 	// GetObject returns descriptor for provided state.
 	//
 	// If provided state is nil, the latest state will be returned (with deactivation check). Returned descriptor will
 	// provide methods for fetching all related data.
-	GetObject(head RecordRef, state *RecordRef) (ObjectDescriptor, error)
+	GetObject(ctx context.Context, head RecordRef, state *RecordRef) (ObjectDescriptor, error)
 
 	// GetDelegate returns provided object's delegate reference for provided class.
 	//
 	// Object delegate should be previously created for this object. If object delegate does not exist, an error will
 	// be returned.
-	GetDelegate(head, asClass RecordRef) (*RecordRef, error)
+	GetDelegate(ctx context.Context, head, asClass RecordRef) (*RecordRef, error)
 
 	// GetChildren returns children iterator.
 	//
 	// During iteration children refs will be fetched from remote source (parent object).
-	GetChildren(parent RecordRef, pulse *PulseNumber) (RefIterator, error)
+	GetChildren(ctx context.Context, parent RecordRef, pulse *PulseNumber) (RefIterator, error)
 
 	// DeclareType creates new type record in storage.
 	//
 	// Type is a contract interface. It contains one method signature.
-	DeclareType(domain, request RecordRef, typeDec []byte) (*RecordRef, error)
+	DeclareType(ctx context.Context, domain, request RecordRef, typeDec []byte) (*RecordRef, error)
+
+	// DeclareClassType creates new type record in storage and binds it to the class.
+	//
+	// Type of the class is the contract's ABI, clients fetch it by class reference with GetClass and GetType. The class
+	// is amended with its current code and the type, so the ABI belongs to the class state and later states don't
+	// inherit it.
+	DeclareClassType(ctx context.Context, domain, request, class RecordRef, typeDec []byte) (*RecordRef, error)
+
+	// GetType returns type declaration from type record by provided reference.
+	GetType(ctx context.Context, ref RecordRef) ([]byte, error)
 
 	// DeployCode creates new code record in storage.
 	//
 	// Code records are used to activate class or as migration code for an object.
-	DeployCode(domain, request RecordRef, codeMap map[MachineType][]byte) (*RecordRef, error)
+	DeployCode(ctx context.Context, domain, request RecordRef, codeMap map[MachineType][]byte) (*RecordRef, error)
 
 	// ActivateClass creates activate class record in storage. Provided code reference will be used as a class code.
 	//
 	// Activation reference will be this class'es identifier and referred as "class head".
-	ActivateClass(domain, request RecordRef) (*RecordRef, error)
+	ActivateClass(ctx context.Context, domain, request RecordRef) (*RecordRef, error)
 
 	// DeactivateClass creates deactivate record in storage. Provided reference should be a reference to the head of
 	// the class. If class is already deactivated, an error should be returned.
 	//
 	// Deactivated class cannot be changed or instantiate objects.
-	DeactivateClass(domain, request, class RecordRef) (*RecordID, error)
+	DeactivateClass(ctx context.Context, domain, request, class RecordRef) (*RecordID, error)
 
 	// UpdateClass creates amend class record in storage. Provided reference should be a reference to the head of
 	// the class. Migrations are references to code records.
 	//
 	// Returned reference will be the latest class state (exact) reference. Migration code will be executed by VM to
 	// migrate objects memory in the order they appear in provided slice.
-	UpdateClass(domain, request, class, code RecordRef, migrationRefs []RecordRef) (*RecordID, error)
+	UpdateClass(ctx context.Context, domain, request, class, code RecordRef, migrationRefs []RecordRef) (*RecordID, error)
 
 	// ActivateObject creates activate object record in storage. Provided class reference will be used as object's class.
 	// If memory is not provided, the class default memory will be used.
 	//
 	// Activation reference will be this object's identifier and referred as "object head".
-	ActivateObject(domain, request, class, parent RecordRef, memory []byte) (*RecordRef, error)
+	ActivateObject(ctx context.Context, domain, request, class, parent RecordRef, memory []byte) (*RecordRef, error)
 
 	// ActivateObjectDelegate is similar to ActivateObj but it created object will be parent's delegate of provided class.
-	ActivateObjectDelegate(domain, request, class, parent RecordRef, memory []byte) (*RecordRef, error)
+	ActivateObjectDelegate(ctx context.Context, domain, request, class, parent RecordRef, memory []byte) (*RecordRef, error)
 
 	// DeactivateObject creates deactivate object record in storage. Provided reference should be a reference to the head
 	// of the object. If object is already deactivated, an error should be returned.
 	//
 	// Deactivated object cannot be changed.
-	DeactivateObject(domain, request, obj RecordRef) (*RecordID, error)
+	DeactivateObject(ctx context.Context, domain, request, obj RecordRef) (*RecordID, error)
 
 	// UpdateObject creates amend object record in storage. Provided reference should be a reference to the head of the
 	// object. Provided memory well be the new object memory.
 	//
 	// Returned reference will be the latest object state (exact) reference.
-	UpdateObject(domain, request, obj RecordRef, memory []byte) (*RecordID, error)
+	UpdateObject(ctx context.Context, domain, request, obj RecordRef, memory []byte) (*RecordID, error)
+
+	// UpdateObjects activates objects created by a call chain, creates amend object records for objects changed by it
+	// and stores events for provided changes together. If the latest state of a changed object isn't the base state
+	// of its changes, nothing is saved.
+	UpdateObjects(ctx context.Context, writes []ObjectWrite) error
+
+	// GetEvents returns events emitted by the object in provided pulse range (inclusive).
+	//
+	// If "to" is zero, all events starting from "from" pulse will be returned.
+	GetEvents(ctx context.Context, obj RecordRef, from, to PulseNumber) ([]Event, error)
+}
+
+// ObjectWrite is a change of an object made by a contract call. Changes made by calls of a call chain are collected
+// by the top-level call and saved together when it succeeds.
+type ObjectWrite struct {
+	Domain  RecordRef
+	Request RecordRef
+	Object  RecordRef
+	Base    *RecordID // State of the object the chain read from the ledger, changes of other chains since then fail the save.
+	Memory  []byte
+	Events  []Event
+
+	// Created is set for objects created by the chain, they are activated when changes are saved with the memory
+	// of the change as a child or a delegate of Parent. Such changes have no Base.
+	Created  bool
+	Class    RecordRef
+	Parent   RecordRef
+	Delegate bool
 }
 
 // CodeDescriptor represents meta info required to fetch all code data.
@@ -153,6 +193,9 @@ type CodeDescriptor interface {
 
 	// Code returns code for first available machine type for provided machine preference.
 	Code() []byte
+
+	// Hash returns hash of the code, it's calculated when the code is deployed.
+	Hash() []byte
 }
 
 // ClassDescriptor represents meta info required to fetch all object data.
@@ -165,6 +208,9 @@ type ClassDescriptor interface {
 
 	// CodeDescriptor returns descriptor for fetching class's code data.
 	CodeDescriptor(machinePref []MachineType) (CodeDescriptor, error)
+
+	// TypeRef returns reference to the type record with class's ABI, nil if the type wasn't declared.
+	TypeRef() *RecordRef
 }
 
 // ObjectDescriptor represents meta info required to fetch all object data.
@@ -175,6 +221,9 @@ type ObjectDescriptor interface {
 	// StateID returns reference to object state record.
 	StateID() *RecordID
 
+	// Domain returns reference to the domain object belongs to.
+	Domain() *RecordRef
+
 	// Memory fetches object memory from storage.
 	Memory() []byte
 
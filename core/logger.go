@@ -69,4 +69,7 @@ type Logger interface {
 
 	// SetOutput sets the output destination for the logger.
 	SetOutput(w io.Writer)
+
+	// WithFields returns logger that adds fields to every message.
+	WithFields(map[string]interface{}) Logger
 }
@@ -69,6 +69,22 @@ func getEmptyMessage(mt core.MessageType) (core.Message, error) {
 		return &UpdateObject{}, nil
 	case core.TypeRegisterChild:
 		return &RegisterChild{}, nil
+	// Logicrunner validation
+	case core.TypeValidateCaseBind:
+		return &ValidateCaseBind{}, nil
+	case core.TypeValidationResults:
+		return &ValidationResults{}, nil
+	case core.TypeGetEvents:
+		return &GetEvents{}, nil
+	// Contract types
+	case core.TypeGetType:
+		return &GetType{}, nil
+	// Contract transactions
+	case core.TypeUpdateObjects:
+		return &UpdateObjects{}, nil
+	// Contract deploy
+	case core.TypeDeployClass:
+		return &DeployClass{}, nil
 	default:
 		return nil, errors.Errorf("unimplemented message type %d", mt)
 	}
@@ -137,4 +153,15 @@ func init() {
 	gob.Register(&DeactivateObject{})
 	gob.Register(&UpdateObject{})
 	gob.Register(&RegisterChild{})
+	// Logicrunner validation
+	gob.Register(&ValidateCaseBind{})
+	gob.Register(&ValidationResults{})
+	gob.Register(&GetEvents{})
+	gob.Register(&GetType{})
+	gob.Register(&UpdateObjects{})
+	gob.Register(&DeployClass{})
+	// Responses stored in case records
+	gob.Register(core.RecordRef{})
+	gob.Register([]core.RecordRef{})
+	gob.Register(core.Event{})
 }
@@ -0,0 +1,45 @@
+/*
+ *    Copyright 2018 Insolar
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package message
+
+import (
+	"context"
+	"encoding/binary"
+	"time"
+)
+
+// DeadlineBytes encodes deadline of the context for passing it along with a message,
+// returns nil if context has no deadline.
+func DeadlineBytes(ctx context.Context) []byte {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return nil
+	}
+	buff := make([]byte, 8)
+	binary.BigEndian.PutUint64(buff, uint64(deadline.UnixNano()))
+	return buff
+}
+
+// ContextWithDeadlineBytes returns child of the parent context that expires on deadline
+// encoded by DeadlineBytes. Empty or malformed deadline results in cancelable context without deadline.
+func ContextWithDeadlineBytes(parent context.Context, buff []byte) (context.Context, context.CancelFunc) {
+	if len(buff) != 8 {
+		return context.WithCancel(parent)
+	}
+	deadline := time.Unix(0, int64(binary.BigEndian.Uint64(buff)))
+	return context.WithDeadline(parent, deadline)
+}
@@ -17,6 +17,9 @@
 package message
 
 import (
+	"bytes"
+	"encoding/binary"
+
 	"github.com/insolar/insolar/core"
 )
 
@@ -106,6 +109,7 @@ type DeclareType struct {
 	Domain  core.RecordRef
 	Request core.RecordRef
 	TypeDec []byte
+	Class   *core.RecordRef // If set, the class is amended with the type as its ABI.
 }
 
 // Type implementation of Message interface.
@@ -115,6 +119,9 @@ func (e *DeclareType) Type() core.MessageType {
 
 // Target implementation of Message interface.
 func (e *DeclareType) Target() *core.RecordRef {
+	if e.Class != nil {
+		return e.Class
+	}
 	return &e.Request
 }
 
@@ -179,6 +186,7 @@ type UpdateClass struct {
 	Class      core.RecordRef
 	Code       core.RecordRef
 	Migrations []core.RecordRef
+	TypeRef    *core.RecordRef // Type with class'es ABI, the new state has no ABI if nil.
 }
 
 // Type implementation of Message interface.
@@ -191,6 +199,60 @@ func (e *UpdateClass) Target() *core.RecordRef {
 	return &e.Class
 }
 
+// DeployClass deploys code as a new class or as new code of the class, records of the code, the type and
+// the class are created together. Only the owner of the class can upgrade it, the owner of a new class is
+// the key that signed the message.
+type DeployClass struct {
+	ledgerMessage
+	Domain      core.RecordRef
+	Request     core.RecordRef
+	Class       *core.RecordRef // Class to upgrade, new class is activated if nil.
+	MachineType core.MachineType
+	Code        []byte
+	Migrations  []core.RecordRef
+	TypeDec     []byte // ABI of the code, the class has no ABI if empty.
+	PublicKey   string // PEM encoded key of the class owner.
+	Seed        []byte // Seed issued by the API node to the client, the node accepts a seed once.
+	Signature   []byte // Signature of SignedData by the owner's key.
+}
+
+// Type implementation of Message interface.
+func (e *DeployClass) Type() core.MessageType {
+	return core.TypeDeployClass
+}
+
+// Target implementation of Message interface.
+func (e *DeployClass) Target() *core.RecordRef {
+	if e.Class != nil {
+		return e.Class
+	}
+	return &e.Request
+}
+
+// SignedData returns data the owner signs, domain and request are set by the node and aren't signed.
+func (e *DeployClass) SignedData() []byte {
+	var buf bytes.Buffer
+	write := func(b []byte) {
+		binary.Write(&buf, binary.BigEndian, uint64(len(b))) // nolint: errcheck
+		buf.Write(b)
+	}
+	if e.Class != nil {
+		write(e.Class[:])
+	} else {
+		write(nil)
+	}
+	write([]byte{byte(e.MachineType)})
+	write(e.Code)
+	binary.Write(&buf, binary.BigEndian, uint64(len(e.Migrations))) // nolint: errcheck
+	for _, m := range e.Migrations {
+		write(m[:])
+	}
+	write(e.TypeDec)
+	write([]byte(e.PublicKey))
+	write(e.Seed)
+	return buf.Bytes()
+}
+
 // ActivateObject activates object.
 type ActivateObject struct {
 	ledgerMessage
@@ -318,3 +380,57 @@ func (e *GetChildren) Type() core.MessageType {
 func (e *GetChildren) Target() *core.RecordRef {
 	return &e.Parent
 }
+
+// GetEvents retrieves events emitted by object in pulse range.
+type GetEvents struct {
+	ledgerMessage
+	Object core.RecordRef
+	From   core.PulseNumber
+	To     core.PulseNumber // If zero, events up to the latest pulse will be fetched.
+}
+
+// Type implementation of Message interface.
+func (e *GetEvents) Type() core.MessageType {
+	return core.TypeGetEvents
+}
+
+// Target implementation of Message interface.
+func (e *GetEvents) Target() *core.RecordRef {
+	return &e.Object
+}
+
+// GetType retrieves type declaration from storage.
+type GetType struct {
+	ledgerMessage
+	TypeRef core.RecordRef
+}
+
+// Type implementation of Message interface.
+func (e *GetType) Type() core.MessageType {
+	return core.TypeGetType
+}
+
+// Target implementation of Message interface.
+func (e *GetType) Target() *core.RecordRef {
+	return &e.TypeRef
+}
+
+// UpdateObjects amends objects changed by a call and its nested calls in a single transaction.
+type UpdateObjects struct {
+	ledgerMessage
+	Writes []core.ObjectWrite
+}
+
+// Type implementation of Message interface.
+func (e *UpdateObjects) Type() core.MessageType {
+	return core.TypeUpdateObjects
+}
+
+// Target implementation of Message interface, objects changed together are expected
+// to be stored by the executor of the first one.
+func (e *UpdateObjects) Target() *core.RecordRef {
+	if len(e.Writes) == 0 {
+		return &core.RecordRef{}
+	}
+	return &e.Writes[0].Object
+}
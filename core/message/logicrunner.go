@@ -17,6 +17,8 @@
 package message
 
 import (
+	"encoding/binary"
+
 	"github.com/insolar/insolar/core"
 )
 
@@ -28,24 +30,34 @@ const (
 	ReturnResult MethodReturnMode = iota
 	// ReturnNoWait - call method and return without results
 	ReturnNoWait
-	// ReturnValidated (not yet) - return result only when it's validated
-	// ReturnValidated
+	// ReturnValidated - return result only when it's confirmed by validators
+	ReturnValidated
 )
 
 // BaseLogicMessage base of event class family, do not use it standalone
 type BaseLogicMessage struct {
 	Caller core.RecordRef
+	// Chain is the registered request of the top-level call of the call chain, it makes requests of calls
+	// of different chains differ. Empty for top-level calls. Receiver doesn't trust it: calls nested into
+	// a chain are executed by the node of the chain and never come through the bus.
+	Chain core.RecordRef
 }
 
 type IBaseLogicMessage interface {
 	core.Message
 	GetReference() core.RecordRef
+	GetChain() core.RecordRef
 }
 
 func (e *BaseLogicMessage) GetCaller() *core.RecordRef {
 	return &e.Caller
 }
 
+// GetChain returns the registered request of the top-level call of the chain.
+func (e *BaseLogicMessage) GetChain() core.RecordRef {
+	return e.Chain
+}
+
 // TargetRole returns RoleVirtualExecutor as routing target role.
 func (e *BaseLogicMessage) TargetRole() core.JetRole {
 	return core.RoleVirtualExecutor
@@ -108,3 +120,73 @@ func (e *CallConstructor) Target() *core.RecordRef {
 	}
 	return core.GenRequest(e.PulseNum, MustSerializeBytes(e))
 }
+
+// ValidateCaseBind sends records of the object's calls made during the pulse to validators
+type ValidateCaseBind struct {
+	BaseLogicMessage
+	RecordRef   core.RecordRef
+	CaseRecords []core.CaseRecord
+	Pulse       core.Pulse
+	Request     core.RecordRef // request waiting for validation, empty for CaseBind of the pulse
+}
+
+func (e *ValidateCaseBind) GetReference() core.RecordRef {
+	return e.RecordRef
+}
+
+// Type returns TypeValidateCaseBind.
+func (e *ValidateCaseBind) Type() core.MessageType {
+	return core.TypeValidateCaseBind
+}
+
+// Target returns RecordRef as routing target.
+func (e *ValidateCaseBind) Target() *core.RecordRef {
+	return &e.RecordRef
+}
+
+// TargetRole returns RoleVirtualValidator as routing target role.
+func (e *ValidateCaseBind) TargetRole() core.JetRole {
+	return core.RoleVirtualValidator
+}
+
+// ValidationResults is a result of CaseBind validation signed by validator
+type ValidationResults struct {
+	BaseLogicMessage
+	RecordRef        core.RecordRef
+	PulseNumber      core.PulseNumber
+	Request          core.RecordRef
+	PassedStepsCount int
+	ResultHash       []byte // hash of results of validated calls
+	Error            string
+	Validator        core.RecordRef // node that signed the results
+	Signature        []byte
+}
+
+func (e *ValidationResults) GetReference() core.RecordRef {
+	return e.RecordRef
+}
+
+// Type returns TypeValidationResults.
+func (e *ValidationResults) Type() core.MessageType {
+	return core.TypeValidationResults
+}
+
+// Target returns RecordRef as routing target.
+func (e *ValidationResults) Target() *core.RecordRef {
+	return &e.RecordRef
+}
+
+// SignedData returns data of the results that is signed by validator.
+func (e *ValidationResults) SignedData() []byte {
+	steps := make([]byte, 8)
+	binary.BigEndian.PutUint64(steps, uint64(e.PassedStepsCount))
+
+	var data []byte
+	data = append(data, e.RecordRef[:]...)
+	data = append(data, e.PulseNumber.Bytes()...)
+	data = append(data, e.Request[:]...)
+	data = append(data, steps...)
+	data = append(data, e.ResultHash...)
+	data = append(data, e.Validator[:]...)
+	return append(data, e.Error...)
+}
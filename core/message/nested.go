@@ -0,0 +1,34 @@
+/*
+ *    Copyright 2018 Insolar
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package message
+
+import (
+	"context"
+)
+
+type nestedKey struct{}
+
+// NestedContext marks context of messages sent by the current node while handling another message,
+// it's never transferred with the message, so the mark can't be forged by other nodes.
+func NestedContext(parent context.Context) context.Context {
+	return context.WithValue(parent, nestedKey{}, true)
+}
+
+// IsNested reports whether context is marked by NestedContext
+func IsNested(ctx context.Context) bool {
+	return ctx.Value(nestedKey{}) != nil
+}
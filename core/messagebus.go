@@ -16,6 +16,10 @@
 
 package core
 
+import (
+	"context"
+)
+
 // Arguments is a dedicated type for arguments, that represented as bynary cbored blob
 type Arguments []byte
 
@@ -47,7 +51,8 @@ type Reply interface {
 // MessageBus interface
 type MessageBus interface {
 	// Send an `Message` and get a `Reply` or error from remote host.
-	Send(Message) (Reply, error)
+	// Deadline and cancellation of the context are propagated to the handler of the message.
+	Send(context.Context, Message) (Reply, error)
 	// SendAsync sends an `Message` to remote host.
 	SendAsync(Message)
 	// Register saves message handler in the registry. Only one handler can be registered for a message type.
@@ -56,8 +61,28 @@ type MessageBus interface {
 	MustRegister(p MessageType, handler MessageHandler)
 }
 
+// DeadLetter is an asynchronous message that wasn't delivered in configured number of attempts.
+type DeadLetter struct {
+	ID        string
+	Message   Message
+	Attempts  int
+	LastError string
+}
+
+// DeadLetterQueue gives access to asynchronous messages that weren't delivered, MessageBus implements it
+// when its outbox is enabled.
+type DeadLetterQueue interface {
+	// DeadLetters returns asynchronous messages that weren't delivered.
+	DeadLetters() ([]DeadLetter, error)
+	// ReplayDeadLetter puts dead letter back to the outbox for another round of delivery attempts.
+	ReplayDeadLetter(id string) error
+	// DropDeadLetter removes dead letter.
+	DropDeadLetter(id string) error
+}
+
 // MessageHandler is a function for message handling. It should be registered via Register method.
-type MessageHandler func(Message) (Reply, error)
+// Context is canceled when the sender of the message has given up waiting for the reply.
+type MessageHandler func(context.Context, Message) (Reply, error)
 
 //go:generate stringer -type=MessageType
 const (
@@ -102,4 +127,31 @@ const (
 	TypeUpdateObject
 	// TypeRegisterChild registers child on the parent object.
 	TypeRegisterChild
+
+	// Logicrunner validation
+
+	// TypeValidateCaseBind sends CaseBind of the object to validators.
+	TypeValidateCaseBind
+	// TypeValidationResults sends results of the CaseBind validation back to executor.
+	TypeValidationResults
+
+	// Contract events
+
+	// TypeGetEvents retrieves events emitted by contract.
+	TypeGetEvents
+
+	// Contract types
+
+	// TypeGetType retrieves type declaration (contract's ABI) from storage.
+	TypeGetType
+
+	// Contract transactions
+
+	// TypeUpdateObjects amends objects changed by a call and its nested calls together.
+	TypeUpdateObjects
+
+	// Contract deploy
+
+	// TypeDeployClass deploys code as a new class or as new code of a class, signed by the class owner.
+	TypeDeployClass
 )
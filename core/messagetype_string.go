@@ -4,9 +4,9 @@ package core
 
 import "strconv"
 
-const _MessageType_name = "TypeCallMethodTypeCallConstructorTypeRequestCallTypeGetCodeTypeGetClassTypeGetObjectTypeGetDelegateTypeDeclareTypeTypeDeployCodeTypeActivateClassTypeDeactivateClassTypeUpdateClassTypeActivateObjectTypeActivateObjectDelegateTypeDeactivateObjectTypeUpdateObjectTypeRegisterChild"
+const _MessageType_name = "TypeCallMethodTypeCallConstructorTypeRequestCallTypeGetCodeTypeGetClassTypeGetObjectTypeGetDelegateTypeGetChildrenTypeDeclareTypeTypeDeployCodeTypeActivateClassTypeDeactivateClassTypeUpdateClassTypeActivateObjectTypeActivateObjectDelegateTypeDeactivateObjectTypeUpdateObjectTypeRegisterChildTypeValidateCaseBindTypeValidationResultsTypeGetEventsTypeGetTypeTypeUpdateObjectsTypeDeployClass"
 
-var _MessageType_index = [...]uint16{0, 14, 33, 48, 59, 71, 84, 99, 114, 128, 145, 164, 179, 197, 223, 243, 259, 276}
+var _MessageType_index = [...]uint16{0, 14, 33, 48, 59, 71, 84, 99, 114, 129, 143, 160, 179, 194, 212, 238, 258, 274, 291, 311, 332, 345, 356, 373, 388}
 
 func (i MessageType) String() string {
 	if i >= MessageType(len(_MessageType_index)-1) {
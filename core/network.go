@@ -16,6 +16,10 @@
 
 package core
 
+import (
+	"context"
+)
+
 // Cascade contains routing data for cascade sending
 type Cascade struct {
 	// NodeIds contains the slice of node identifiers that will receive the message
@@ -26,13 +30,14 @@ type Cascade struct {
 	ReplicationFactor uint
 }
 
-// RemoteProcedure is remote procedure call function.
-type RemoteProcedure func(args [][]byte) ([]byte, error)
+// RemoteProcedure is remote procedure call function, sender is the id of the host
+// the call is received from as it's known to the network layer.
+type RemoteProcedure func(sender string, args [][]byte) ([]byte, error)
 
 // Network is interface for network modules facade.
 type Network interface {
-	// SendMessage sends a message.
-	SendMessage(nodeID RecordRef, method string, msg Message) ([]byte, error)
+	// SendMessage sends a message, deadline of the context is delivered to the remote node.
+	SendMessage(ctx context.Context, nodeID RecordRef, method string, msg Message) ([]byte, error)
 	// SendCascadeMessage sends a message.
 	SendCascadeMessage(data Cascade, method string, msg Message) error
 	// GetAddress returns an origin address.
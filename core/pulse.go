@@ -85,6 +85,11 @@ func CalculatePulseNumber(now time.Time) PulseNumber {
 	return PulseNumber(now.Unix() - firstPulseDate + FirstPulseNumber)
 }
 
+// Time returns time when the pulse has started, it's the inverse of CalculatePulseNumber
+func (pn PulseNumber) Time() time.Time {
+	return time.Unix(int64(pn)-FirstPulseNumber+firstPulseDate, 0).UTC()
+}
+
 // PulseManager provides Ledger's methods related to Pulse.
 type PulseManager interface {
 	// Current returns current pulse structure.
@@ -50,6 +50,33 @@ const (
 	TypeID
 	// TypeChildren is a reply for fetching objects children in chunks.
 	TypeChildren
+
+	// MessageBus
+
+	// TypeBusy is a reply of the node that can't handle message right now.
+	TypeBusy
+
+	// Logicrunner limits
+
+	// TypeLimitExceeded is a reply of the contract call that exceeded its resource limits.
+	TypeLimitExceeded
+
+	// Logicrunner validation
+
+	// TypeOK is a reply of handlers that have no data to return.
+	TypeOK
+	// TypeValidationRejected is a reply of the call which results weren't confirmed by validators.
+	TypeValidationRejected
+
+	// Contract events
+
+	// TypeEvents is a reply with events emitted by contract.
+	TypeEvents
+
+	// Contract types
+
+	// TypeTypeDeclaration is type declaration (contract's ABI) from storage.
+	TypeTypeDeclaration
 )
 
 func getEmptyReply(t core.ReplyType) (core.Reply, error) {
@@ -72,6 +99,18 @@ func getEmptyReply(t core.ReplyType) (core.Reply, error) {
 		return &ID{}, nil
 	case TypeChildren:
 		return &Children{}, nil
+	case TypeBusy:
+		return &Busy{}, nil
+	case TypeLimitExceeded:
+		return &LimitExceeded{}, nil
+	case TypeOK:
+		return &OK{}, nil
+	case TypeValidationRejected:
+		return &ValidationRejected{}, nil
+	case TypeEvents:
+		return &Events{}, nil
+	case TypeTypeDeclaration:
+		return &TypeDeclaration{}, nil
 	default:
 		return nil, errors.Errorf("unimplemented reply type: '%d'", t)
 	}
@@ -117,4 +156,10 @@ func init() {
 	gob.Register(&Reference{})
 	gob.Register(&ID{})
 	gob.Register(&Children{})
+	gob.Register(&Busy{})
+	gob.Register(&LimitExceeded{})
+	gob.Register(&OK{})
+	gob.Register(&ValidationRejected{})
+	gob.Register(&Events{})
+	gob.Register(&TypeDeclaration{})
 }
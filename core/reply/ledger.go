@@ -20,9 +20,10 @@ import (
 	"github.com/insolar/insolar/core"
 )
 
-// Code is code from storage.
+// Code is code from storage, it's sent as the serialized code record, so receiver checks it against
+// the reference before use.
 type Code struct {
-	Code        []byte
+	Record      []byte
 	MachineType core.MachineType
 }
 
@@ -33,9 +34,10 @@ func (e *Code) Type() core.ReplyType {
 
 // Class is class from storage.
 type Class struct {
-	Head  core.RecordRef
-	State core.RecordID
-	Code  *core.RecordRef // Can be nil.
+	Head    core.RecordRef
+	State   core.RecordID
+	Code    *core.RecordRef // Can be nil.
+	TypeRef *core.RecordRef // Type with class'es ABI, can be nil.
 }
 
 // Type implementation of Reply interface.
@@ -48,6 +50,7 @@ type Object struct {
 	Head   core.RecordRef
 	State  core.RecordID
 	Class  core.RecordRef
+	Domain core.RecordRef
 	Memory []byte
 }
 
@@ -96,3 +99,24 @@ type Children struct {
 func (e *Children) Type() core.ReplyType {
 	return TypeChildren
 }
+
+// Events is a reply with events emitted by object.
+type Events struct {
+	Events []core.Event
+	Pulse  core.PulseNumber // Current pulse of the storage, events of earlier pulses are complete.
+}
+
+// Type implementation of Reply interface.
+func (e *Events) Type() core.ReplyType {
+	return TypeEvents
+}
+
+// TypeDeclaration is type declaration from storage.
+type TypeDeclaration struct {
+	TypeDec []byte
+}
+
+// Type implementation of Reply interface.
+func (e *TypeDeclaration) Type() core.ReplyType {
+	return TypeTypeDeclaration
+}
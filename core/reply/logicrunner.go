@@ -17,6 +17,9 @@
 package reply
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/insolar/insolar/core"
 )
 
@@ -39,3 +42,44 @@ type CallConstructor struct {
 func (r *CallConstructor) Type() core.ReplyType {
 	return TypeCallConstructor
 }
+
+// LimitExceeded - reply of the contract call that was aborted because of resource limits,
+// object's state isn't changed by such call
+type LimitExceeded struct {
+	core.LimitExceededError
+}
+
+// Type returns type of the reply
+func (r *LimitExceeded) Type() core.ReplyType {
+	return TypeLimitExceeded
+}
+
+// OK - reply of the message that was handled successfully but has no data to return
+type OK struct {
+}
+
+// Type returns type of the reply
+func (r *OK) Type() core.ReplyType {
+	return TypeOK
+}
+
+// ValidationRejected - reply of the call in ReturnValidated mode which results weren't confirmed
+// by quorum of validators, object's state isn't changed by such call
+type ValidationRejected struct {
+	Quorum    int
+	Confirmed int
+	Reasons   []string // why validators rejected results
+}
+
+// Type returns type of the reply
+func (r *ValidationRejected) Type() core.ReplyType {
+	return TypeValidationRejected
+}
+
+// Error returns description of the rejection, so the reply can be returned as error
+func (r *ValidationRejected) Error() string {
+	return fmt.Sprintf(
+		"results confirmed by %d of %d required validators: %s",
+		r.Confirmed, r.Quorum, strings.Join(r.Reasons, "; "),
+	)
+}
@@ -0,0 +1,35 @@
+/*
+ *    Copyright 2018 Insolar
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package reply
+
+import (
+	"time"
+
+	"github.com/insolar/insolar/core"
+)
+
+// Busy is a reply of the node that rejected message because of overload or rate limits,
+// sender should repeat the message not earlier than after RetryAfter.
+type Busy struct {
+	RetryAfter time.Duration
+	Reason     string
+}
+
+// Type returns type of the reply
+func (r *Busy) Type() core.ReplyType {
+	return TypeBusy
+}
@@ -17,7 +17,12 @@
 package core
 
 import (
+	"context"
+	"encoding/binary"
+	"fmt"
 	"time"
+
+	"github.com/insolar/insolar/cryptohelpers/hash"
 )
 
 // MachineType is a type of virtual machine
@@ -28,6 +33,7 @@ const (
 	MachineTypeNotExist             = 0
 	MachineTypeBuiltin  MachineType = iota + 1
 	MachineTypeGoPlugin
+	MachineTypeExternal
 
 	MachineTypesLastID
 )
@@ -41,19 +47,111 @@ type MachineLogicExecutor interface {
 
 // LogicRunner is an interface that should satisfy logic executor
 type LogicRunner interface {
-	Execute(Message) (res Reply, err error)
+	Execute(context.Context, Message) (res Reply, err error)
 	Validate(ref RecordRef, p Pulse, cr []CaseRecord) (int, error)
 	OnPulse(Pulse) error
 }
 
 // LogicCallContext is a context of contract execution
 type LogicCallContext struct {
-	Callee *RecordRef // Contract that was called
-	Class  *RecordRef // Class of the callee
-	Parent *RecordRef // Parent of the callee
-	Caller *RecordRef // Contract that made the call
-	Time   time.Time  // Time when call was made
-	Pulse  Pulse      // Number of the pulse
+	Callee  *RecordRef // Contract that was called
+	Class   *RecordRef // Class of the callee
+	Parent  *RecordRef // Parent of the callee
+	Caller  *RecordRef // Contract that made the call
+	Request *RecordRef // Registered request that caused the call
+	Chain   *RecordRef // Registered request of the top-level call of the call chain
+	Time    time.Time  // Time when call was made, derived from the pulse
+	Pulse   Pulse      // Number of the pulse
+
+	ReadOnly bool // Call of read-only method, it must not change state of the callee
+
+	Deadline    time.Time // Time after which caller is not interested in the result, zero if there is no deadline
+	TimeLimited bool      // Deadline is the end of the call's time limit rather than the caller's deadline
+	Trace       []byte    // Span of the call encoded for passing to the contract runner, nil if call isn't traced
+	Limits      CallLimits
+}
+
+// RandomSeed returns seed of random numbers of the call, it's derived from the pulse entropy and the request,
+// so executor and validators of the call get the same numbers
+func (lcc *LogicCallContext) RandomSeed() int64 {
+	data := append([]byte{}, lcc.Pulse.Entropy[:]...)
+	if lcc.Request != nil {
+		data = append(data, lcc.Request[:]...)
+	}
+	return int64(binary.BigEndian.Uint64(hash.SHA3Bytes(data)))
+}
+
+// Context returns context for requests made on behalf of the call, it expires at Deadline
+func (lcc *LogicCallContext) Context() (context.Context, context.CancelFunc) {
+	if lcc.Deadline.IsZero() {
+		return context.WithCancel(context.Background())
+	}
+	return context.WithDeadline(context.Background(), lcc.Deadline)
+}
+
+// TimeoutError returns error of the executor call that didn't finish before Deadline, it's exceeded time limit
+// if Deadline is the end of the limit, otherwise the caller just stopped waiting
+func (lcc *LogicCallContext) TimeoutError(elapsed time.Duration) error {
+	if !lcc.TimeLimited {
+		return context.DeadlineExceeded
+	}
+	return &LimitExceededError{
+		Limit:  CallLimitTime,
+		Limits: lcc.Limits,
+		Usage:  CallUsage{Time: elapsed},
+	}
+}
+
+// Names of contract call limits
+const (
+	CallLimitTime        = "time"
+	CallLimitMemory      = "memory"
+	CallLimitNestedCalls = "nested_calls"
+	CallLimitStateSize   = "state_size"
+)
+
+// CallLimits are limits of resources a contract call may consume, zero value of a limit means no limit.
+//
+// Contracts are executed as native code, so execution is limited by time rather than by instructions.
+type CallLimits struct {
+	Time        time.Duration // Execution time of the call, nested calls included
+	Memory      uint64        // Memory allocated during the call, bytes
+	NestedCalls int           // Calls to other contracts made directly by the call
+	StateSize   int           // Size of the object's memory after the call, bytes
+}
+
+// CallUsage is amount of resources consumed by a contract call
+type CallUsage struct {
+	Time        time.Duration
+	Memory      uint64
+	NestedCalls int
+	StateSize   int
+}
+
+// Exceeded returns name of the first limit exceeded by usage, empty string if usage is within limits
+func (l CallLimits) Exceeded(u CallUsage) string {
+	switch {
+	case l.Time > 0 && u.Time > l.Time:
+		return CallLimitTime
+	case l.Memory > 0 && u.Memory > l.Memory:
+		return CallLimitMemory
+	case l.NestedCalls > 0 && u.NestedCalls > l.NestedCalls:
+		return CallLimitNestedCalls
+	case l.StateSize > 0 && u.StateSize > l.StateSize:
+		return CallLimitStateSize
+	}
+	return ""
+}
+
+// LimitExceededError is returned by MachineLogicExecutor when contract call exceeds one of its limits
+type LimitExceededError struct {
+	Limit  string // Name of the exceeded limit
+	Limits CallLimits
+	Usage  CallUsage
+}
+
+func (e *LimitExceededError) Error() string {
+	return fmt.Sprintf("contract call exceeded %s limit: limits %+v, consumed %+v", e.Limit, e.Limits, e.Usage)
 }
 
 // CaseRecordType is a type of caserecord
@@ -69,6 +167,7 @@ const (
 	CaseRecordTypeGetObjChildren
 	CaseRecordTypeSaveAsDelegate
 	CaseRecordTypeGetDelegate
+	CaseRecordTypeEmitEvent
 )
 
 // CaseRecord is one record of validateable object calling history
@@ -76,6 +175,16 @@ type CaseRecord struct {
 	Type   CaseRecordType
 	ReqSig []byte
 	Resp   interface{}
+	Base   *RecordID // State of the object the call started from, set in start records of method calls
+}
+
+// Event is a typed notification emitted by a contract during a call
+type Event struct {
+	Object  RecordRef   // object that emitted the event
+	Request RecordRef   // request that was executed when the event was emitted
+	Pulse   PulseNumber // pulse of the call
+	Name    string      // type of the event, chosen by contract
+	Payload []byte      // serialized event data
 }
 
 // CaseBinder is a whole result of executor efforts on every object it seen on this pulse
@@ -91,3 +200,63 @@ type CaseBindReplay struct {
 	Step       int
 	Fail       int
 }
+
+// ContractABI is a machine-readable description of contract's interface, it's generated by insgocc,
+// declared on the ledger as the class'es type and is used by clients to encode calls to any contract
+type ContractABI struct {
+	Contract     string      `json:"contract"`
+	Methods      []MethodABI `json:"methods"`
+	Constructors []MethodABI `json:"constructors"`
+}
+
+// MethodABI describes a method or a constructor of the contract
+type MethodABI struct {
+	Name      string        `json:"name"`
+	Arguments []ArgumentABI `json:"arguments"`
+	Results   []ArgumentABI `json:"results"`
+	ReadOnly  bool          `json:"readonly,omitempty"`
+}
+
+// ArgumentABI describes an argument or a result of the method
+type ArgumentABI struct {
+	Name string  `json:"name,omitempty"`
+	Type TypeABI `json:"type"`
+}
+
+// Kinds of types in ABI, they don't depend on the language of the contract
+const (
+	KindBool      = "bool"
+	KindInt       = "int"   // signed integer of Bits size
+	KindUint      = "uint"  // unsigned integer of Bits size
+	KindFloat     = "float" // floating point number of Bits size
+	KindString    = "string"
+	KindBytes     = "bytes"
+	KindList      = "list" // sequence of Elem, of Length size if it's fixed
+	KindMap       = "map"  // map of Key to Elem
+	KindStruct    = "struct"
+	KindOptional  = "optional"  // Elem or nil
+	KindReference = "reference" // reference to a record on the ledger, Name is set for objects of the contract
+	KindError     = "error"     // error message or nil
+	KindAny       = "any"       // any value, its type is known only at runtime
+	KindExternal  = "external"  // type of other package, Name is the only description
+)
+
+// TypeABI is a language-neutral description of a type in the contract's ABI. Declared types have Name,
+// a declared struct that refers to itself is described without Fields inside its own description.
+type TypeABI struct {
+	Kind   string     `json:"kind"`
+	Name   string     `json:"name,omitempty"`
+	Bits   int        `json:"bits,omitempty"`
+	Length int        `json:"length,omitempty"`
+	Key    *TypeABI   `json:"key,omitempty"`
+	Elem   *TypeABI   `json:"elem,omitempty"`
+	Fields []FieldABI `json:"fields,omitempty"`
+}
+
+// FieldABI describes a serialized field of a struct, fields of an embedded struct are serialized as fields
+// of the struct that embeds it
+type FieldABI struct {
+	Name     string  `json:"name"`
+	Type     TypeABI `json:"type"`
+	Embedded bool    `json:"embedded,omitempty"`
+}
@@ -0,0 +1,67 @@
+/*
+ *    Copyright 2018 Insolar
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCallLimits_Exceeded(t *testing.T) {
+	limits := CallLimits{Time: time.Second, Memory: 100, NestedCalls: 2, StateSize: 10}
+
+	assert.Equal(t, "", limits.Exceeded(CallUsage{Time: time.Second, Memory: 100, NestedCalls: 2, StateSize: 10}))
+	assert.Equal(t, CallLimitTime, limits.Exceeded(CallUsage{Time: 2 * time.Second}))
+	assert.Equal(t, CallLimitMemory, limits.Exceeded(CallUsage{Memory: 101}))
+	assert.Equal(t, CallLimitNestedCalls, limits.Exceeded(CallUsage{NestedCalls: 3}))
+	assert.Equal(t, CallLimitStateSize, limits.Exceeded(CallUsage{StateSize: 11}))
+
+	assert.Equal(t, "", CallLimits{}.Exceeded(CallUsage{Time: time.Hour, Memory: 1 << 40, NestedCalls: 1000, StateSize: 1 << 30}))
+}
+
+func TestLogicCallContext_RandomSeed(t *testing.T) {
+	request := NewRefFromBase58("request")
+	ctx := LogicCallContext{Pulse: Pulse{Entropy: Entropy{1, 2, 3}}, Request: &request}
+	same := LogicCallContext{Pulse: Pulse{Entropy: Entropy{1, 2, 3}}, Request: &request}
+	assert.Equal(t, ctx.RandomSeed(), same.RandomSeed())
+
+	otherRequest := NewRefFromBase58("other")
+	assert.NotEqual(t, ctx.RandomSeed(), (&LogicCallContext{Pulse: ctx.Pulse, Request: &otherRequest}).RandomSeed())
+	assert.NotEqual(t, ctx.RandomSeed(), (&LogicCallContext{Pulse: Pulse{Entropy: Entropy{3, 2, 1}}, Request: &request}).RandomSeed())
+}
+
+func TestLogicCallContext_Context(t *testing.T) {
+	ctx, cancel := (&LogicCallContext{}).Context()
+	_, ok := ctx.Deadline()
+	assert.False(t, ok)
+	cancel()
+	assert.Error(t, ctx.Err())
+
+	deadline := time.Now().Add(time.Hour)
+	ctx, cancel = (&LogicCallContext{Deadline: deadline}).Context()
+	defer cancel()
+	actual, ok := ctx.Deadline()
+	assert.True(t, ok)
+	assert.Equal(t, deadline, actual)
+}
+
+func TestPulseNumber_Time(t *testing.T) {
+	now := time.Now()
+	assert.Equal(t, now.Unix(), CalculatePulseNumber(now).Time().Unix())
+}
@@ -30,8 +30,9 @@ type Allowance struct {
 	ExpireTime int64
 }
 
+//ins:readonly
 func (a *Allowance) IsExpired() bool {
-	return a.GetContext().Time.After(time.Unix(a.ExpireTime, 0))
+	return foundation.Now().After(time.Unix(a.ExpireTime, 0))
 }
 
 func (a *Allowance) TakeAmount() uint {
@@ -45,6 +46,7 @@ func (a *Allowance) TakeAmount() uint {
 	return 0
 }
 
+//ins:readonly
 func (a *Allowance) GetBalanceForOwner() uint {
 	if !a.IsExpired() {
 		return a.Amount
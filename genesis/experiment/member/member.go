@@ -32,9 +32,12 @@ type Member struct {
 	PublicKey string
 }
 
+//ins:readonly
 func (m *Member) GetName() string {
 	return m.Name
 }
+
+//ins:readonly
 func (m *Member) GetPublicKey() string {
 	return m.PublicKey
 }
@@ -41,6 +41,8 @@ func (nd *NodeDomain) RegisterNode(pk string, role string) core.RecordRef {
 }
 
 // GetNodeRecord get node record by ref
+//
+//ins:readonly
 func (nd *NodeDomain) GetNodeRecord(ref core.RecordRef) *noderecord.NodeRecord {
 	return noderecord.GetObject(ref)
 }
@@ -52,6 +54,8 @@ func (nd *NodeDomain) RemoveNode(nodeRef core.RecordRef) {
 }
 
 // IsAuthorized checks is signature correct
+//
+//ins:readonly
 func (nd *NodeDomain) IsAuthorized(nodeRef core.RecordRef, seed []byte, signatureRaw []byte) bool {
 	nodeR := nd.GetNodeRecord(nodeRef)
 	ok, err := ecdsa.Verify(seed, signatureRaw, nodeR.GetPublicKey())
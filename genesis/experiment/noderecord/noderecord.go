@@ -65,10 +65,12 @@ func NewNodeRecord(pk string, roleS string) *NodeRecord {
 	}
 }
 
+//ins:readonly
 func (nr *NodeRecord) GetPublicKey() string {
 	return nr.PublicKey
 }
 
+//ins:readonly
 func (nr *NodeRecord) GetRole() NodeRole {
 	return nr.Role
 }
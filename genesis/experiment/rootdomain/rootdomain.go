@@ -17,7 +17,6 @@
 package rootdomain
 
 import (
-	"crypto/rand"
 	"encoding/json"
 
 	ecdsa_helper "github.com/insolar/insolar/cryptohelpers/ecdsa"
@@ -52,7 +51,7 @@ func (rd *RootDomain) RegisterNode(publicKey string, role string) string {
 
 func makeSeed() []byte {
 	seed := make([]byte, 32)
-	_, err := rand.Read(seed)
+	_, err := foundation.GetRandom().Read(seed)
 	if err != nil {
 		panic(err)
 	}
@@ -104,6 +103,8 @@ func (rd *RootDomain) CreateMember(name string, key string) string {
 }
 
 // GetBalance processes get balance request
+//
+//ins:readonly
 func (rd *RootDomain) GetBalance(reference string) uint {
 	w := wallet.GetImplementationFrom(core.NewRefFromBase58(reference))
 	return w.GetTotalBalance()
@@ -127,6 +128,8 @@ func (rd *RootDomain) getUserInfoMap(m *member.Member) map[string]interface{} {
 }
 
 // DumpUserInfo processes dump user info request
+//
+//ins:readonly
 func (rd *RootDomain) DumpUserInfo(reference string) []byte {
 	m := member.GetObject(core.NewRefFromBase58(reference))
 	res := rd.getUserInfoMap(m)
@@ -135,6 +138,8 @@ func (rd *RootDomain) DumpUserInfo(reference string) []byte {
 }
 
 // DumpAllUsers processes dump all users request
+//
+//ins:readonly
 func (rd *RootDomain) DumpAllUsers() []byte {
 	res := []map[string]interface{}{}
 	crefs, err := rd.GetChildrenTyped(member.ClassReference)
@@ -58,12 +58,24 @@ func (w *Wallet) Transfer(amount uint, to *core.RecordRef) {
 
 	r := a.GetReference()
 	toWallet.Accept(&r)
+
+	err := w.EmitEvent("transfer", map[string]interface{}{"to": toWalletRef.String(), "amount": amount})
+	if err != nil {
+		panic(err)
+	}
 }
 
 func (w *Wallet) Accept(aRef *core.RecordRef) {
-	w.Balance += allowance.GetObject(*aRef).TakeAmount()
+	amount := allowance.GetObject(*aRef).TakeAmount()
+	w.Balance += amount
+
+	err := w.EmitEvent("accept", map[string]interface{}{"allowance": aRef.String(), "amount": amount})
+	if err != nil {
+		panic(err)
+	}
 }
 
+//ins:readonly
 func (w *Wallet) GetTotalBalance() uint {
 	var totalAllowanced uint
 	crefs, err := w.GetChildrenTyped(allowance.GetClass())
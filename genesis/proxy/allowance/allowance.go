@@ -97,16 +97,15 @@ func (r *Allowance) IsExpired(  ) ( bool ) {
    		panic(err)
 	}
 
-	resList := [1]interface{}{}
 	var a0 bool
-	resList[0] = a0
+	resList := []interface{}{&a0}
 
 	err = proxyctx.Current.Deserialize(res, &resList)
 	if err != nil {
 		panic(err)
 	}
 
-	return resList[0].(bool)
+	return a0
 }
 
 func (r *Allowance) IsExpiredNoWait(  ) {
@@ -140,16 +139,15 @@ func (r *Allowance) TakeAmount(  ) ( uint ) {
    		panic(err)
 	}
 
-	resList := [1]interface{}{}
 	var a0 uint
-	resList[0] = a0
+	resList := []interface{}{&a0}
 
 	err = proxyctx.Current.Deserialize(res, &resList)
 	if err != nil {
 		panic(err)
 	}
 
-	return resList[0].(uint)
+	return a0
 }
 
 func (r *Allowance) TakeAmountNoWait(  ) {
@@ -183,16 +181,15 @@ func (r *Allowance) GetBalanceForOwner(  ) ( uint ) {
    		panic(err)
 	}
 
-	resList := [1]interface{}{}
 	var a0 uint
-	resList[0] = a0
+	resList := []interface{}{&a0}
 
 	err = proxyctx.Current.Deserialize(res, &resList)
 	if err != nil {
 		panic(err)
 	}
 
-	return resList[0].(uint)
+	return a0
 }
 
 func (r *Allowance) GetBalanceForOwnerNoWait(  ) {
@@ -226,16 +223,15 @@ func (r *Allowance) DeleteExpiredAllowance(  ) ( uint ) {
    		panic(err)
 	}
 
-	resList := [1]interface{}{}
 	var a0 uint
-	resList[0] = a0
+	resList := []interface{}{&a0}
 
 	err = proxyctx.Current.Deserialize(res, &resList)
 	if err != nil {
 		panic(err)
 	}
 
-	return resList[0].(uint)
+	return a0
 }
 
 func (r *Allowance) DeleteExpiredAllowanceNoWait(  ) {
@@ -97,16 +97,15 @@ func (r *Member) GetName(  ) ( string ) {
    		panic(err)
 	}
 
-	resList := [1]interface{}{}
 	var a0 string
-	resList[0] = a0
+	resList := []interface{}{&a0}
 
 	err = proxyctx.Current.Deserialize(res, &resList)
 	if err != nil {
 		panic(err)
 	}
 
-	return resList[0].(string)
+	return a0
 }
 
 func (r *Member) GetNameNoWait(  ) {
@@ -140,16 +139,15 @@ func (r *Member) GetPublicKey(  ) ( string ) {
    		panic(err)
 	}
 
-	resList := [1]interface{}{}
 	var a0 string
-	resList[0] = a0
+	resList := []interface{}{&a0}
 
 	err = proxyctx.Current.Deserialize(res, &resList)
 	if err != nil {
 		panic(err)
 	}
 
-	return resList[0].(string)
+	return a0
 }
 
 func (r *Member) GetPublicKeyNoWait(  ) {
@@ -188,18 +186,16 @@ func (r *Member) AuthorizedCall( ref string, method string, params []interface{}
    		panic(err)
 	}
 
-	resList := [2]interface{}{}
 	var a0 []interface{}
-	resList[0] = a0
 	var a1 *foundation.Error
-	resList[1] = a1
+	resList := []interface{}{&a0, &a1}
 
 	err = proxyctx.Current.Deserialize(res, &resList)
 	if err != nil {
 		panic(err)
 	}
 
-	return resList[0].([]interface{}), resList[1].(*foundation.Error)
+	return a0, a1
 }
 
 func (r *Member) AuthorizedCallNoWait( ref string, method string, params []interface{}, seed []byte, sign []byte ) {
@@ -97,16 +97,15 @@ func (r *NodeDomain) RegisterNode( pk string, role string ) ( core.RecordRef ) {
    		panic(err)
 	}
 
-	resList := [1]interface{}{}
 	var a0 core.RecordRef
-	resList[0] = a0
+	resList := []interface{}{&a0}
 
 	err = proxyctx.Current.Deserialize(res, &resList)
 	if err != nil {
 		panic(err)
 	}
 
-	return resList[0].(core.RecordRef)
+	return a0
 }
 
 func (r *NodeDomain) RegisterNodeNoWait( pk string, role string ) {
@@ -143,16 +142,15 @@ func (r *NodeDomain) GetNodeRecord( ref core.RecordRef ) ( *noderecord.NodeRecor
    		panic(err)
 	}
 
-	resList := [1]interface{}{}
 	var a0 *noderecord.NodeRecord
-	resList[0] = a0
+	resList := []interface{}{&a0}
 
 	err = proxyctx.Current.Deserialize(res, &resList)
 	if err != nil {
 		panic(err)
 	}
 
-	return resList[0].(*noderecord.NodeRecord)
+	return a0
 }
 
 func (r *NodeDomain) GetNodeRecordNoWait( ref core.RecordRef ) {
@@ -188,7 +186,7 @@ func (r *NodeDomain) RemoveNode( nodeRef core.RecordRef ) (  ) {
    		panic(err)
 	}
 
-	resList := [0]interface{}{}
+	resList := []interface{}{}
 
 	err = proxyctx.Current.Deserialize(res, &resList)
 	if err != nil {
@@ -233,16 +231,15 @@ func (r *NodeDomain) IsAuthorized( nodeRef core.RecordRef, seed []byte, signatur
    		panic(err)
 	}
 
-	resList := [1]interface{}{}
 	var a0 bool
-	resList[0] = a0
+	resList := []interface{}{&a0}
 
 	err = proxyctx.Current.Deserialize(res, &resList)
 	if err != nil {
 		panic(err)
 	}
 
-	return resList[0].(bool)
+	return a0
 }
 
 func (r *NodeDomain) IsAuthorizedNoWait( nodeRef core.RecordRef, seed []byte, signatureRaw []byte ) {
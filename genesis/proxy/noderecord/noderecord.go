@@ -97,16 +97,15 @@ func (r *NodeRecord) GetPublicKey(  ) ( string ) {
    		panic(err)
 	}
 
-	resList := [1]interface{}{}
 	var a0 string
-	resList[0] = a0
+	resList := []interface{}{&a0}
 
 	err = proxyctx.Current.Deserialize(res, &resList)
 	if err != nil {
 		panic(err)
 	}
 
-	return resList[0].(string)
+	return a0
 }
 
 func (r *NodeRecord) GetPublicKeyNoWait(  ) {
@@ -140,16 +139,15 @@ func (r *NodeRecord) GetRole(  ) ( NodeRole ) {
    		panic(err)
 	}
 
-	resList := [1]interface{}{}
 	var a0 NodeRole
-	resList[0] = a0
+	resList := []interface{}{&a0}
 
 	err = proxyctx.Current.Deserialize(res, &resList)
 	if err != nil {
 		panic(err)
 	}
 
-	return resList[0].(NodeRole)
+	return a0
 }
 
 func (r *NodeRecord) GetRoleNoWait(  ) {
@@ -183,7 +181,7 @@ func (r *NodeRecord) Destroy(  ) (  ) {
    		panic(err)
 	}
 
-	resList := [0]interface{}{}
+	resList := []interface{}{}
 
 	err = proxyctx.Current.Deserialize(res, &resList)
 	if err != nil {
@@ -97,16 +97,15 @@ func (r *RootDomain) RegisterNode( publicKey string, role string ) ( string ) {
    		panic(err)
 	}
 
-	resList := [1]interface{}{}
 	var a0 string
-	resList[0] = a0
+	resList := []interface{}{&a0}
 
 	err = proxyctx.Current.Deserialize(res, &resList)
 	if err != nil {
 		panic(err)
 	}
 
-	return resList[0].(string)
+	return a0
 }
 
 func (r *RootDomain) RegisterNodeNoWait( publicKey string, role string ) {
@@ -142,16 +141,15 @@ func (r *RootDomain) IsAuthorized(  ) ( bool ) {
    		panic(err)
 	}
 
-	resList := [1]interface{}{}
 	var a0 bool
-	resList[0] = a0
+	resList := []interface{}{&a0}
 
 	err = proxyctx.Current.Deserialize(res, &resList)
 	if err != nil {
 		panic(err)
 	}
 
-	return resList[0].(bool)
+	return a0
 }
 
 func (r *RootDomain) IsAuthorizedNoWait(  ) {
@@ -187,16 +185,15 @@ func (r *RootDomain) CreateMember( name string, key string ) ( string ) {
    		panic(err)
 	}
 
-	resList := [1]interface{}{}
 	var a0 string
-	resList[0] = a0
+	resList := []interface{}{&a0}
 
 	err = proxyctx.Current.Deserialize(res, &resList)
 	if err != nil {
 		panic(err)
 	}
 
-	return resList[0].(string)
+	return a0
 }
 
 func (r *RootDomain) CreateMemberNoWait( name string, key string ) {
@@ -233,16 +230,15 @@ func (r *RootDomain) GetBalance( reference string ) ( uint ) {
    		panic(err)
 	}
 
-	resList := [1]interface{}{}
 	var a0 uint
-	resList[0] = a0
+	resList := []interface{}{&a0}
 
 	err = proxyctx.Current.Deserialize(res, &resList)
 	if err != nil {
 		panic(err)
 	}
 
-	return resList[0].(uint)
+	return a0
 }
 
 func (r *RootDomain) GetBalanceNoWait( reference string ) {
@@ -280,16 +276,15 @@ func (r *RootDomain) SendMoney( from string, to string, amount uint ) ( bool ) {
    		panic(err)
 	}
 
-	resList := [1]interface{}{}
 	var a0 bool
-	resList[0] = a0
+	resList := []interface{}{&a0}
 
 	err = proxyctx.Current.Deserialize(res, &resList)
 	if err != nil {
 		panic(err)
 	}
 
-	return resList[0].(bool)
+	return a0
 }
 
 func (r *RootDomain) SendMoneyNoWait( from string, to string, amount uint ) {
@@ -327,16 +322,15 @@ func (r *RootDomain) DumpUserInfo( reference string ) ( []byte ) {
    		panic(err)
 	}
 
-	resList := [1]interface{}{}
 	var a0 []byte
-	resList[0] = a0
+	resList := []interface{}{&a0}
 
 	err = proxyctx.Current.Deserialize(res, &resList)
 	if err != nil {
 		panic(err)
 	}
 
-	return resList[0].([]byte)
+	return a0
 }
 
 func (r *RootDomain) DumpUserInfoNoWait( reference string ) {
@@ -371,16 +365,15 @@ func (r *RootDomain) DumpAllUsers(  ) ( []byte ) {
    		panic(err)
 	}
 
-	resList := [1]interface{}{}
 	var a0 []byte
-	resList[0] = a0
+	resList := []interface{}{&a0}
 
 	err = proxyctx.Current.Deserialize(res, &resList)
 	if err != nil {
 		panic(err)
 	}
 
-	return resList[0].([]byte)
+	return a0
 }
 
 func (r *RootDomain) DumpAllUsersNoWait(  ) {
@@ -415,18 +408,16 @@ func (r *RootDomain) SetRoot( adminKey string ) ( string, *foundation.Error ) {
    		panic(err)
 	}
 
-	resList := [2]interface{}{}
 	var a0 string
-	resList[0] = a0
 	var a1 *foundation.Error
-	resList[1] = a1
+	resList := []interface{}{&a0, &a1}
 
 	err = proxyctx.Current.Deserialize(res, &resList)
 	if err != nil {
 		panic(err)
 	}
 
-	return resList[0].(string), resList[1].(*foundation.Error)
+	return a0, a1
 }
 
 func (r *RootDomain) SetRootNoWait( adminKey string ) {
@@ -97,16 +97,15 @@ func (r *Wallet) Allocate( amount uint, to *core.RecordRef ) ( core.RecordRef )
    		panic(err)
 	}
 
-	resList := [1]interface{}{}
 	var a0 core.RecordRef
-	resList[0] = a0
+	resList := []interface{}{&a0}
 
 	err = proxyctx.Current.Deserialize(res, &resList)
 	if err != nil {
 		panic(err)
 	}
 
-	return resList[0].(core.RecordRef)
+	return a0
 }
 
 func (r *Wallet) AllocateNoWait( amount uint, to *core.RecordRef ) {
@@ -144,7 +143,7 @@ func (r *Wallet) Receive( amount uint, from *core.RecordRef ) (  ) {
    		panic(err)
 	}
 
-	resList := [0]interface{}{}
+	resList := []interface{}{}
 
 	err = proxyctx.Current.Deserialize(res, &resList)
 	if err != nil {
@@ -189,7 +188,7 @@ func (r *Wallet) Transfer( amount uint, to *core.RecordRef ) (  ) {
    		panic(err)
 	}
 
-	resList := [0]interface{}{}
+	resList := []interface{}{}
 
 	err = proxyctx.Current.Deserialize(res, &resList)
 	if err != nil {
@@ -233,7 +232,7 @@ func (r *Wallet) Accept( aRef *core.RecordRef ) (  ) {
    		panic(err)
 	}
 
-	resList := [0]interface{}{}
+	resList := []interface{}{}
 
 	err = proxyctx.Current.Deserialize(res, &resList)
 	if err != nil {
@@ -275,16 +274,15 @@ func (r *Wallet) GetTotalBalance(  ) ( uint ) {
    		panic(err)
 	}
 
-	resList := [1]interface{}{}
 	var a0 uint
-	resList[0] = a0
+	resList := []interface{}{&a0}
 
 	err = proxyctx.Current.Deserialize(res, &resList)
 	if err != nil {
 		panic(err)
 	}
 
-	return resList[0].(uint)
+	return a0
 }
 
 func (r *Wallet) GetTotalBalanceNoWait(  ) {
@@ -318,7 +316,7 @@ func (r *Wallet) ReturnAndDeleteExpiriedAllowances(  ) (  ) {
    		panic(err)
 	}
 
-	resList := [0]interface{}{}
+	resList := []interface{}{}
 
 	err = proxyctx.Current.Deserialize(res, &resList)
 	if err != nil {
@@ -17,9 +17,16 @@
 package artifactmanager
 
 import (
+	"bytes"
+	"context"
+
+	"github.com/pkg/errors"
+
 	"github.com/insolar/insolar/core"
 	"github.com/insolar/insolar/core/message"
 	"github.com/insolar/insolar/core/reply"
+	"github.com/insolar/insolar/cryptohelpers/hash"
+	"github.com/insolar/insolar/ledger/record"
 	"github.com/insolar/insolar/ledger/storage"
 )
 
@@ -57,9 +64,9 @@ func (m *LedgerArtifactManager) RootRef() *core.RecordRef {
 // RegisterRequest sends message for request registration,
 // returns request record Ref if request successfuly created or already exists.
 func (m *LedgerArtifactManager) RegisterRequest(
-	msg core.Message,
+	ctx context.Context, msg core.Message,
 ) (*core.RecordRef, error) {
-	id, err := m.fetchID(&message.RequestCall{Message: msg})
+	id, err := m.fetchID(ctx, &message.RequestCall{Message: msg})
 	if err != nil {
 		return nil, err
 	}
@@ -72,9 +79,9 @@ func (m *LedgerArtifactManager) RegisterRequest(
 //
 // This method is used by VM to fetch code for execution.
 func (m *LedgerArtifactManager) GetCode(
-	code core.RecordRef, machinePref []core.MachineType,
+	ctx context.Context, code core.RecordRef, machinePref []core.MachineType,
 ) (core.CodeDescriptor, error) {
-	genericReact, err := m.messageBus.Send(&message.GetCode{
+	genericReact, err := m.messageBus.Send(ctx, &message.GetCode{
 		Code:        code,
 		MachinePref: machinePref,
 	})
@@ -87,23 +94,56 @@ func (m *LedgerArtifactManager) GetCode(
 	if !ok {
 		return nil, ErrUnexpectedReply
 	}
+	codeBytes, codeHash, err := verifyCode(code, react)
+	if err != nil {
+		return nil, err
+	}
 	desc := CodeDescriptor{
 		machinePref: machinePref,
 		ref:         code,
 
 		machineType: react.MachineType,
-		code:        react.Code,
+		code:        codeBytes,
+		hash:        codeHash,
 	}
 
 	return &desc, nil
 }
 
+// verifyCode checks the code record from reply against the hash in its reference and the code against the hash
+// stored in the record on deploy. Nothing in the reply is trusted before it's checked.
+func verifyCode(ref core.RecordRef, react *reply.Code) ([]byte, []byte, error) {
+	raw, err := record.DecodeToRaw(react.Record)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to decode code record")
+	}
+	if !bytes.Equal(raw.Hash(), record.Core2Reference(ref).Record.Hash) {
+		return nil, nil, ErrCodeMismatch
+	}
+	codeRec, ok := raw.ToRecord().(*record.CodeRecord)
+	if !ok {
+		return nil, nil, errors.Wrap(ErrInvalidRef, "failed to retrieve code record")
+	}
+	code, ok := codeRec.TargetedCode[react.MachineType]
+	if !ok {
+		return nil, nil, errors.New("code for provided machine type not found")
+	}
+	codeHash, ok := codeRec.CodeHashes[react.MachineType]
+	if !ok {
+		return nil, nil, ErrNoCodeHash
+	}
+	if !bytes.Equal(hash.SHA3Bytes(code), codeHash) {
+		return nil, nil, ErrCodeMismatch
+	}
+	return code, codeHash, nil
+}
+
 // GetClass returns descriptor for provided state.
 //
 // If provided state is nil, the latest state will be returned (with deactivation check). Returned descriptor will
 // provide methods for fetching all related data.
-func (m *LedgerArtifactManager) GetClass(head core.RecordRef, state *core.RecordRef) (core.ClassDescriptor, error) {
-	genericReact, err := m.messageBus.Send(&message.GetClass{
+func (m *LedgerArtifactManager) GetClass(ctx context.Context, head core.RecordRef, state *core.RecordRef) (core.ClassDescriptor, error) {
+	genericReact, err := m.messageBus.Send(ctx, &message.GetClass{
 		Head:  head,
 		State: state,
 	})
@@ -117,10 +157,12 @@ func (m *LedgerArtifactManager) GetClass(head core.RecordRef, state *core.Record
 		return nil, ErrUnexpectedReply
 	}
 	desc := ClassDescriptor{
-		am:    m,
-		head:  react.Head,
-		state: react.State,
-		code:  react.Code,
+		am:      m,
+		ctx:     ctx,
+		head:    react.Head,
+		state:   react.State,
+		code:    react.Code,
+		typeRef: react.TypeRef,
 	}
 	return &desc, nil
 }
@@ -129,8 +171,8 @@ func (m *LedgerArtifactManager) GetClass(head core.RecordRef, state *core.Record
 //
 // If provided state is nil, the latest state will be returned (with deactivation check). Returned descriptor will
 // provide methods for fetching all related data.
-func (m *LedgerArtifactManager) GetObject(head core.RecordRef, state *core.RecordRef) (core.ObjectDescriptor, error) {
-	genericReact, err := m.messageBus.Send(&message.GetObject{
+func (m *LedgerArtifactManager) GetObject(ctx context.Context, head core.RecordRef, state *core.RecordRef) (core.ObjectDescriptor, error) {
+	genericReact, err := m.messageBus.Send(ctx, &message.GetObject{
 		Head:  head,
 		State: state,
 	})
@@ -145,9 +187,11 @@ func (m *LedgerArtifactManager) GetObject(head core.RecordRef, state *core.Recor
 	}
 	desc := ObjectDescriptor{
 		am:     m,
+		ctx:    ctx,
 		head:   react.Head,
 		state:  react.State,
 		class:  react.Class,
+		domain: react.Domain,
 		memory: react.Memory,
 	}
 	return &desc, nil
@@ -157,8 +201,8 @@ func (m *LedgerArtifactManager) GetObject(head core.RecordRef, state *core.Recor
 //
 // Object delegate should be previously created for this object. If object delegate does not exist, an error will
 // be returned.
-func (m *LedgerArtifactManager) GetDelegate(head, asClass core.RecordRef) (*core.RecordRef, error) {
-	genericReact, err := m.messageBus.Send(&message.GetDelegate{
+func (m *LedgerArtifactManager) GetDelegate(ctx context.Context, head, asClass core.RecordRef) (*core.RecordRef, error) {
+	genericReact, err := m.messageBus.Send(ctx, &message.GetDelegate{
 		Head:    head,
 		AsClass: asClass,
 	})
@@ -177,30 +221,60 @@ func (m *LedgerArtifactManager) GetDelegate(head, asClass core.RecordRef) (*core
 // GetChildren returns children iterator.
 //
 // During iteration children refs will be fetched from remote source (parent object).
-func (m *LedgerArtifactManager) GetChildren(parent core.RecordRef, pulse *core.PulseNumber) (core.RefIterator, error) {
-	return NewChildIterator(m.messageBus, parent, pulse, m.getChildrenChunkSize)
+func (m *LedgerArtifactManager) GetChildren(ctx context.Context, parent core.RecordRef, pulse *core.PulseNumber) (core.RefIterator, error) {
+	return NewChildIterator(ctx, m.messageBus, parent, pulse, m.getChildrenChunkSize)
 }
 
 // DeclareType creates new type record in storage.
 //
 // Type is a contract interface. It contains one method signature.
 func (m *LedgerArtifactManager) DeclareType(
-	domain, request core.RecordRef, typeDec []byte,
+	ctx context.Context, domain, request core.RecordRef, typeDec []byte,
 ) (*core.RecordRef, error) {
-	return m.fetchReference(&message.DeclareType{
+	return m.fetchReference(ctx, &message.DeclareType{
 		Domain:  domain,
 		Request: request,
 		TypeDec: typeDec,
 	})
 }
 
+// DeclareClassType creates new type record in storage and binds it to the class.
+//
+// Type of the class is the contract's ABI, clients fetch it by class reference with GetClass and GetType. The class
+// is amended with its current code and the type, so the ABI belongs to the class state and later states don't
+// inherit it.
+func (m *LedgerArtifactManager) DeclareClassType(
+	ctx context.Context, domain, request, class core.RecordRef, typeDec []byte,
+) (*core.RecordRef, error) {
+	return m.fetchReference(ctx, &message.DeclareType{
+		Domain:  domain,
+		Request: request,
+		TypeDec: typeDec,
+		Class:   &class,
+	})
+}
+
+// GetType returns type declaration from type record by provided reference.
+func (m *LedgerArtifactManager) GetType(ctx context.Context, ref core.RecordRef) ([]byte, error) {
+	genericReact, err := m.messageBus.Send(ctx, &message.GetType{TypeRef: ref})
+	if err != nil {
+		return nil, err
+	}
+
+	react, ok := genericReact.(*reply.TypeDeclaration)
+	if !ok {
+		return nil, ErrUnexpectedReply
+	}
+	return react.TypeDec, nil
+}
+
 // DeployCode creates new code record in storage.
 //
 // Code records are used to activate class or as migration code for an object.
 func (m *LedgerArtifactManager) DeployCode(
-	domain, request core.RecordRef, codeMap map[core.MachineType][]byte,
+	ctx context.Context, domain, request core.RecordRef, codeMap map[core.MachineType][]byte,
 ) (*core.RecordRef, error) {
-	return m.fetchReference(&message.DeployCode{
+	return m.fetchReference(ctx, &message.DeployCode{
 		Domain:  domain,
 		Request: request,
 		CodeMap: codeMap,
@@ -211,9 +285,9 @@ func (m *LedgerArtifactManager) DeployCode(
 //
 // Activation reference will be this class'es identifier and referred as "class head".
 func (m *LedgerArtifactManager) ActivateClass(
-	domain, request core.RecordRef,
+	ctx context.Context, domain, request core.RecordRef,
 ) (*core.RecordRef, error) {
-	return m.fetchReference(&message.ActivateClass{
+	return m.fetchReference(ctx, &message.ActivateClass{
 		Domain:  domain,
 		Request: request,
 	})
@@ -224,9 +298,9 @@ func (m *LedgerArtifactManager) ActivateClass(
 //
 // Deactivated class cannot be changed or instantiate objects.
 func (m *LedgerArtifactManager) DeactivateClass(
-	domain, request, class core.RecordRef,
+	ctx context.Context, domain, request, class core.RecordRef,
 ) (*core.RecordID, error) {
-	return m.fetchID(&message.DeactivateClass{
+	return m.fetchID(ctx, &message.DeactivateClass{
 		Domain:  domain,
 		Request: request,
 		Class:   class,
@@ -239,9 +313,9 @@ func (m *LedgerArtifactManager) DeactivateClass(
 // Returned reference will be the latest class state (exact) reference. Migration code will be executed by VM to
 // migrate objects memory in the order they appear in provided slice.
 func (m *LedgerArtifactManager) UpdateClass(
-	domain, request, class, code core.RecordRef, migrations []core.RecordRef,
+	ctx context.Context, domain, request, class, code core.RecordRef, migrations []core.RecordRef,
 ) (*core.RecordID, error) {
-	return m.fetchID(&message.UpdateClass{
+	return m.fetchID(ctx, &message.UpdateClass{
 		Domain:     domain,
 		Request:    request,
 		Class:      class,
@@ -255,9 +329,9 @@ func (m *LedgerArtifactManager) UpdateClass(
 //
 // Activation reference will be this object's identifier and referred as "object head".
 func (m *LedgerArtifactManager) ActivateObject(
-	domain, request, class, parent core.RecordRef, memory []byte,
+	ctx context.Context, domain, request, class, parent core.RecordRef, memory []byte,
 ) (*core.RecordRef, error) {
-	objRef, err := m.fetchReference(&message.ActivateObject{
+	objRef, err := m.fetchReference(ctx, &message.ActivateObject{
 		Domain:  domain,
 		Request: request,
 		Class:   class,
@@ -269,7 +343,7 @@ func (m *LedgerArtifactManager) ActivateObject(
 		return nil, err
 	}
 
-	_, err = m.fetchID(&message.RegisterChild{
+	_, err = m.fetchID(ctx, &message.RegisterChild{
 		Parent: parent,
 		Child:  *objRef,
 	})
@@ -282,9 +356,9 @@ func (m *LedgerArtifactManager) ActivateObject(
 
 // ActivateObjectDelegate is similar to ActivateObj but it created object will be parent's delegate of provided class.
 func (m *LedgerArtifactManager) ActivateObjectDelegate(
-	domain, request, class, parent core.RecordRef, memory []byte,
+	ctx context.Context, domain, request, class, parent core.RecordRef, memory []byte,
 ) (*core.RecordRef, error) {
-	return m.fetchReference(&message.ActivateObjectDelegate{
+	return m.fetchReference(ctx, &message.ActivateObjectDelegate{
 		Domain:  domain,
 		Request: request,
 		Class:   class,
@@ -298,9 +372,9 @@ func (m *LedgerArtifactManager) ActivateObjectDelegate(
 //
 // Deactivated object cannot be changed.
 func (m *LedgerArtifactManager) DeactivateObject(
-	domain, request, object core.RecordRef,
+	ctx context.Context, domain, request, object core.RecordRef,
 ) (*core.RecordID, error) {
-	return m.fetchID(&message.DeactivateObject{
+	return m.fetchID(ctx, &message.DeactivateObject{
 		Domain:  domain,
 		Request: request,
 		Object:  object,
@@ -312,9 +386,9 @@ func (m *LedgerArtifactManager) DeactivateObject(
 //
 // Returned reference will be the latest object state (exact) reference.
 func (m *LedgerArtifactManager) UpdateObject(
-	domain, request, object core.RecordRef, memory []byte,
+	ctx context.Context, domain, request, object core.RecordRef, memory []byte,
 ) (*core.RecordID, error) {
-	return m.fetchID(&message.UpdateObject{
+	return m.fetchID(ctx, &message.UpdateObject{
 		Domain:  domain,
 		Request: request,
 		Object:  object,
@@ -322,8 +396,45 @@ func (m *LedgerArtifactManager) UpdateObject(
 	})
 }
 
-func (m *LedgerArtifactManager) fetchReference(ev core.Message) (*core.RecordRef, error) {
-	genericReact, err := m.messageBus.Send(ev)
+// UpdateObjects activates objects created by a call chain, creates amend object records for objects changed by it
+// and stores events for provided changes together. If the latest state of a changed object isn't the base state
+// of its changes, nothing is saved.
+func (m *LedgerArtifactManager) UpdateObjects(ctx context.Context, writes []core.ObjectWrite) error {
+	genericReact, err := m.messageBus.Send(ctx, &message.UpdateObjects{Writes: writes})
+	if err != nil {
+		return err
+	}
+	if _, ok := genericReact.(*reply.OK); !ok {
+		return ErrUnexpectedReply
+	}
+	return nil
+}
+
+// GetEvents returns events emitted by the object in provided pulse range (inclusive).
+//
+// If "to" is zero, all events starting from "from" pulse will be returned.
+func (m *LedgerArtifactManager) GetEvents(
+	ctx context.Context, object core.RecordRef, from, to core.PulseNumber,
+) ([]core.Event, error) {
+	genericReact, err := m.messageBus.Send(ctx, &message.GetEvents{
+		Object: object,
+		From:   from,
+		To:     to,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	react, ok := genericReact.(*reply.Events)
+	if !ok {
+		return nil, ErrUnexpectedReply
+	}
+	return react.Events, nil
+}
+
+func (m *LedgerArtifactManager) fetchReference(ctx context.Context, ev core.Message) (*core.RecordRef, error) {
+	genericReact, err := m.messageBus.Send(ctx, ev)
 
 	if err != nil {
 		return nil, err
@@ -336,8 +447,8 @@ func (m *LedgerArtifactManager) fetchReference(ev core.Message) (*core.RecordRef
 	return &react.Ref, nil
 }
 
-func (m *LedgerArtifactManager) fetchID(msg core.Message) (*core.RecordID, error) {
-	genericReact, err := m.messageBus.Send(msg)
+func (m *LedgerArtifactManager) fetchID(ctx context.Context, msg core.Message) (*core.RecordID, error) {
+	genericReact, err := m.messageBus.Send(ctx, msg)
 
 	if err != nil {
 		return nil, err
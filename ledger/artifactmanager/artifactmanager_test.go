@@ -17,15 +17,20 @@
 package artifactmanager
 
 import (
+	"context"
+	"crypto/ecdsa"
 	"fmt"
 	"math/rand"
 	"testing"
 
+	ecdsa_helper "github.com/insolar/insolar/cryptohelpers/ecdsa"
+	"github.com/insolar/insolar/cryptohelpers/hash"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/insolar/insolar/core"
 	"github.com/insolar/insolar/core/message"
+	"github.com/insolar/insolar/core/reply"
 	"github.com/insolar/insolar/ledger/index"
 	"github.com/insolar/insolar/ledger/record"
 	"github.com/insolar/insolar/ledger/storage"
@@ -101,13 +106,13 @@ func (mb *messageBusMock) Stop() error {
 	panic("implement me")
 }
 
-func (mb *messageBusMock) Send(m core.Message) (core.Reply, error) {
+func (mb *messageBusMock) Send(ctx context.Context, m core.Message) (core.Reply, error) {
 	typ := m.Type()
 	handler, ok := mb.handlers[typ]
 	if !ok {
 		return nil, errors.New(fmt.Sprintf("no handler for this message type %s", typ))
 	}
-	return handler(m)
+	return handler(ctx, m)
 }
 
 func (mb *messageBusMock) SendAsync(m core.Message) {
@@ -139,7 +144,7 @@ func TestLedgerArtifactManager_RegisterRequest_ConstructorCall(t *testing.T) {
 	defer cleaner()
 
 	msg := &message.CallConstructor{}
-	reqCoreRef1, err := td.manager.RegisterRequest(msg)
+	reqCoreRef1, err := td.manager.RegisterRequest(context.Background(), msg)
 	assert.NoError(t, err)
 	reqCoreID := reqCoreRef1.GetRecordID()
 
@@ -153,7 +158,7 @@ func TestLedgerArtifactManager_RegisterRequest_ConstructorCall(t *testing.T) {
 	assert.Equal(t, rec, req)
 
 	// RegisterRequest should be idempotent.
-	reqCoreRef2, err := td.manager.RegisterRequest(msg)
+	reqCoreRef2, err := td.manager.RegisterRequest(context.Background(), msg)
 	assert.NoError(t, err)
 
 	reqCoreID2 := reqCoreRef2.GetRecordID()
@@ -167,7 +172,7 @@ func TestLedgerArtifactManager_RegisterRequest_MethodCall(t *testing.T) {
 	defer cleaner()
 
 	msg := &message.CallMethod{}
-	reqCoreRef1, err := td.manager.RegisterRequest(msg)
+	reqCoreRef1, err := td.manager.RegisterRequest(context.Background(), msg)
 	assert.NoError(t, err)
 	reqCoreID := reqCoreRef1.GetRecordID()
 
@@ -181,7 +186,7 @@ func TestLedgerArtifactManager_RegisterRequest_MethodCall(t *testing.T) {
 	assert.Equal(t, rec, req)
 
 	// RegisterRequest should be idempotent.
-	reqCoreRef2, err := td.manager.RegisterRequest(msg)
+	reqCoreRef2, err := td.manager.RegisterRequest(context.Background(), msg)
 	assert.NoError(t, err)
 
 	reqCoreID2 := reqCoreRef2.GetRecordID()
@@ -195,7 +200,7 @@ func TestLedgerArtifactManager_DeclareType(t *testing.T) {
 	defer cleaner()
 
 	typeDec := []byte{1, 2, 3}
-	coreRef, err := td.manager.DeclareType(*domainRef.CoreRef(), *td.requestRef.CoreRef(), typeDec)
+	coreRef, err := td.manager.DeclareType(context.Background(), *domainRef.CoreRef(), *td.requestRef.CoreRef(), typeDec)
 	assert.NoError(t, err)
 	ref := record.Core2Reference(*coreRef)
 	typeRec, err := td.db.GetRecord(&ref.Record)
@@ -213,6 +218,134 @@ func TestLedgerArtifactManager_DeclareType(t *testing.T) {
 	}, typeRec)
 }
 
+func TestLedgerArtifactManager_DeclareClassType(t *testing.T) {
+	t.Parallel()
+	td, cleaner := prepareAMTestData(t)
+	defer cleaner()
+
+	ctx := context.Background()
+
+	classID, _ := td.db.SetRecord(&record.ClassActivateRecord{
+		ActivationRecord: record.ActivationRecord{
+			StatefulResult: record.StatefulResult{
+				ResultRecord: record.ResultRecord{
+					DomainRecord: domainRef,
+				},
+			},
+		},
+	})
+	td.db.SetClassIndex(classID, &index.ClassLifeline{LatestState: *classID})
+	classRef := genRefWithID(classID)
+
+	typeDec := []byte(`{"contract": "Wallet"}`)
+	_, err := td.manager.DeclareClassType(ctx, *domainRef.CoreRef(), *td.requestRef.CoreRef(), *classRef, typeDec)
+	assert.Error(t, err, "class without code")
+
+	codeRef, err := td.manager.DeployCode(ctx, *domainRef.CoreRef(), *td.requestRef.CoreRef(), map[core.MachineType][]byte{
+		core.MachineTypeBuiltin: {1},
+	})
+	assert.NoError(t, err)
+	_, err = td.manager.UpdateClass(ctx, *domainRef.CoreRef(), *td.requestRef.CoreRef(), *classRef, *codeRef, nil)
+	assert.NoError(t, err)
+
+	typeRef, err := td.manager.DeclareClassType(ctx, *domainRef.CoreRef(), *td.requestRef.CoreRef(), *classRef, typeDec)
+	assert.NoError(t, err)
+
+	classDesc, err := td.manager.GetClass(ctx, *classRef, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, typeRef, classDesc.TypeRef())
+	typedState := classDesc.StateID()
+
+	dec, err := td.manager.GetType(ctx, *classDesc.TypeRef())
+	assert.NoError(t, err)
+	assert.Equal(t, typeDec, dec)
+
+	// upgrade without ABI leaves the new state without type, the old state keeps it
+	_, err = td.manager.UpdateClass(ctx, *domainRef.CoreRef(), *td.requestRef.CoreRef(), *classRef, *codeRef, nil)
+	assert.NoError(t, err)
+	classDesc, err = td.manager.GetClass(ctx, *classRef, nil)
+	assert.NoError(t, err)
+	assert.Nil(t, classDesc.TypeRef())
+
+	var stateRef core.RecordRef
+	stateRef.SetRecord(*typedState)
+	classDesc, err = td.manager.GetClass(ctx, *classRef, &stateRef)
+	assert.NoError(t, err)
+	assert.Equal(t, typeRef, classDesc.TypeRef())
+
+	_, err = td.manager.DeclareClassType(
+		context.Background(), *domainRef.CoreRef(), *td.requestRef.CoreRef(), *genRandomRef(0).CoreRef(), typeDec,
+	)
+	assert.Error(t, err)
+}
+
+func TestLedgerArtifactManager_DeployClass(t *testing.T) {
+	t.Parallel()
+	td, cleaner := prepareAMTestData(t)
+	defer cleaner()
+
+	ctx := context.Background()
+	owner, _ := ecdsa_helper.GeneratePrivateKey()
+	stranger, _ := ecdsa_helper.GeneratePrivateKey()
+	send := func(class *core.RecordRef, code []byte, key *ecdsa.PrivateKey) (*reply.Class, error) {
+		pub, _ := ecdsa_helper.ExportPublicKey(&key.PublicKey)
+		msg := &message.DeployClass{
+			Domain:      *domainRef.CoreRef(),
+			Request:     *genRandomRef(0).CoreRef(),
+			Class:       class,
+			MachineType: core.MachineTypeBuiltin,
+			Code:        code,
+			TypeDec:     []byte(`{"contract": "Wallet"}`),
+			PublicKey:   pub,
+			Seed:        []byte{1, 2, 3},
+		}
+		msg.Signature, _ = ecdsa_helper.Sign(msg.SignedData(), key)
+		rep, err := td.manager.messageBus.Send(ctx, msg)
+		if err != nil {
+			return nil, err
+		}
+		return rep.(*reply.Class), nil
+	}
+
+	deployed, err := send(nil, []byte{1}, owner)
+	assert.NoError(t, err)
+	classDesc, err := td.manager.GetClass(ctx, deployed.Head, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, deployed.Code, classDesc.(*ClassDescriptor).code)
+	assert.Equal(t, deployed.TypeRef, classDesc.TypeRef())
+
+	_, err = send(&deployed.Head, []byte{2}, stranger)
+	assert.Equal(t, ErrNotClassOwner, errors.Cause(err))
+	classDesc, err = td.manager.GetClass(ctx, deployed.Head, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, deployed.State, *classDesc.StateID())
+
+	upgraded, err := send(&deployed.Head, []byte{2}, owner)
+	assert.NoError(t, err)
+	assert.Equal(t, deployed.Head, upgraded.Head)
+	classDesc, err = td.manager.GetClass(ctx, deployed.Head, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, upgraded.Code, classDesc.(*ClassDescriptor).code)
+
+	// signature doesn't match the message
+	pub, _ := ecdsa_helper.ExportPublicKey(&owner.PublicKey)
+	_, err = td.manager.messageBus.Send(ctx, &message.DeployClass{
+		Domain:    *domainRef.CoreRef(),
+		Request:   *genRandomRef(0).CoreRef(),
+		Class:     &deployed.Head,
+		Code:      []byte{3},
+		PublicKey: pub,
+		Signature: upgraded.Code[:],
+	})
+	assert.Error(t, err)
+
+	// classes activated without owner can't be upgraded
+	noOwner, err := td.manager.ActivateClass(ctx, *domainRef.CoreRef(), *td.requestRef.CoreRef())
+	assert.NoError(t, err)
+	_, err = send(noOwner, []byte{3}, owner)
+	assert.Equal(t, ErrNotClassOwner, errors.Cause(err))
+}
+
 func TestLedgerArtifactManager_DeployCode_CreatesCorrectRecord(t *testing.T) {
 	t.Parallel()
 	td, cleaner := prepareAMTestData(t)
@@ -220,7 +353,7 @@ func TestLedgerArtifactManager_DeployCode_CreatesCorrectRecord(t *testing.T) {
 
 	codeMap := map[core.MachineType][]byte{1: {1}}
 	coreRef, err := td.manager.DeployCode(
-		*domainRef.CoreRef(), *td.requestRef.CoreRef(), codeMap,
+		context.Background(), *domainRef.CoreRef(), *td.requestRef.CoreRef(), codeMap,
 	)
 	assert.NoError(t, err)
 	ref := record.Core2Reference(*coreRef)
@@ -236,16 +369,55 @@ func TestLedgerArtifactManager_DeployCode_CreatesCorrectRecord(t *testing.T) {
 			},
 		},
 		TargetedCode: codeMap,
+		CodeHashes:   map[core.MachineType][]byte{1: hash.SHA3Bytes([]byte{1})},
 	})
 }
 
+func TestLedgerArtifactManager_GetCode_VerifiesRecord(t *testing.T) {
+	t.Parallel()
+	td, cleaner := prepareAMTestData(t)
+	defer cleaner()
+	ctx := context.Background()
+
+	codeRef, err := td.manager.DeployCode(ctx, *domainRef.CoreRef(), *td.requestRef.CoreRef(), map[core.MachineType][]byte{
+		core.MachineTypeBuiltin: {1, 2, 3},
+	})
+	assert.NoError(t, err)
+	desc, err := td.manager.GetCode(ctx, *codeRef, []core.MachineType{core.MachineTypeBuiltin})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{1, 2, 3}, desc.Code())
+
+	// Reference with a different record hash must not accept the record.
+	wrongRef := record.Core2Reference(*codeRef)
+	wrongRef.Record.Hash = hash.SHA3Bytes([]byte("wrong"))[:len(wrongRef.Record.Hash)]
+	_, err = td.manager.GetCode(ctx, *wrongRef.CoreRef(), []core.MachineType{core.MachineTypeBuiltin})
+	assert.Error(t, err)
+
+	// Records stored without hashes of the code are rejected.
+	unhashedID, err := td.db.SetRecord(&record.CodeRecord{
+		TargetedCode: map[core.MachineType][]byte{core.MachineTypeBuiltin: {4, 5, 6}},
+	})
+	assert.NoError(t, err)
+	_, err = td.manager.GetCode(ctx, *genRefWithID(unhashedID), []core.MachineType{core.MachineTypeBuiltin})
+	assert.Equal(t, ErrNoCodeHash, err)
+
+	// Code that doesn't match the hash stored on deploy is rejected.
+	forgedID, err := td.db.SetRecord(&record.CodeRecord{
+		TargetedCode: map[core.MachineType][]byte{core.MachineTypeBuiltin: {4, 5, 6}},
+		CodeHashes:   map[core.MachineType][]byte{core.MachineTypeBuiltin: hash.SHA3Bytes([]byte{1, 2, 3})},
+	})
+	assert.NoError(t, err)
+	_, err = td.manager.GetCode(ctx, *genRefWithID(forgedID), []core.MachineType{core.MachineTypeBuiltin})
+	assert.Equal(t, ErrCodeMismatch, err)
+}
+
 func TestLedgerArtifactManager_ActivateClass_CreatesCorrectRecord(t *testing.T) {
 	t.Parallel()
 	td, cleaner := prepareAMTestData(t)
 	defer cleaner()
 
 	activateCoreRef, err := td.manager.ActivateClass(
-		*domainRef.CoreRef(), *td.requestRef.CoreRef(),
+		context.Background(), *domainRef.CoreRef(), *td.requestRef.CoreRef(),
 	)
 	activateRef := record.Core2Reference(*activateCoreRef)
 	assert.Nil(t, err)
@@ -269,12 +441,12 @@ func TestLedgerArtifactManager_DeactivateClass_VerifiesRecord(t *testing.T) {
 	defer cleaner()
 
 	_, err := td.manager.DeactivateClass(
-		*domainRef.CoreRef(), *td.requestRef.CoreRef(), *genRandomRef(0).CoreRef(),
+		context.Background(), *domainRef.CoreRef(), *td.requestRef.CoreRef(), *genRandomRef(0).CoreRef(),
 	)
 	assert.NotNil(t, err)
 
 	notClassID, _ := td.db.SetRecord(&record.CodeRecord{})
-	_, err = td.manager.DeactivateClass(*domainRef.CoreRef(), *td.requestRef.CoreRef(), *genRefWithID(notClassID))
+	_, err = td.manager.DeactivateClass(context.Background(), *domainRef.CoreRef(), *td.requestRef.CoreRef(), *genRefWithID(notClassID))
 	assert.NotNil(t, err)
 }
 
@@ -288,7 +460,7 @@ func TestLedgerArtifactManager_DeactivateClass_VerifiesClassIsActive(t *testing.
 	td.db.SetClassIndex(classID, &index.ClassLifeline{
 		LatestState: *deactivateRef,
 	})
-	_, err := td.manager.DeactivateClass(*domainRef.CoreRef(), *td.requestRef.CoreRef(), *genRefWithID(classID))
+	_, err := td.manager.DeactivateClass(context.Background(), *domainRef.CoreRef(), *td.requestRef.CoreRef(), *genRefWithID(classID))
 	assert.NotNil(t, err)
 }
 
@@ -311,7 +483,7 @@ func TestLedgerArtifactManager_DeactivateClass_CreatesCorrectRecord(t *testing.T
 	})
 
 	deactivateCoreID, err := td.manager.DeactivateClass(
-		*domainRef.CoreRef(), *td.requestRef.CoreRef(), *genRefWithID(classID),
+		context.Background(), *domainRef.CoreRef(), *td.requestRef.CoreRef(), *genRefWithID(classID),
 	)
 	assert.NoError(t, err)
 	deactivateID := record.Bytes2ID(deactivateCoreID[:])
@@ -336,12 +508,12 @@ func TestLedgerArtifactManager_UpdateClass_VerifiesRecord(t *testing.T) {
 	defer cleaner()
 
 	_, err := td.manager.UpdateClass(
-		*domainRef.CoreRef(), *td.requestRef.CoreRef(), *genRandomRef(0).CoreRef(), *genRandomRef(0).CoreRef(), nil,
+		context.Background(), *domainRef.CoreRef(), *td.requestRef.CoreRef(), *genRandomRef(0).CoreRef(), *genRandomRef(0).CoreRef(), nil,
 	)
 	assert.NotNil(t, err)
 	notClassID, _ := td.db.SetRecord(&record.CodeRecord{})
 	_, err = td.manager.UpdateClass(
-		*domainRef.CoreRef(), *td.requestRef.CoreRef(), *genRefWithID(notClassID), *genRandomRef(0).CoreRef(), nil,
+		context.Background(), *domainRef.CoreRef(), *td.requestRef.CoreRef(), *genRefWithID(notClassID), *genRandomRef(0).CoreRef(), nil,
 	)
 	assert.NotNil(t, err)
 }
@@ -358,7 +530,7 @@ func TestLedgerArtifactManager_UpdateClass_VerifiesClassIsActive(t *testing.T) {
 		LatestState: *deactivateID,
 	})
 	_, err := td.manager.UpdateClass(
-		*domainRef.CoreRef(), *td.requestRef.CoreRef(), *genRefWithID(classID), *genRefWithID(codeRef), nil)
+		context.Background(), *domainRef.CoreRef(), *td.requestRef.CoreRef(), *genRefWithID(classID), *genRefWithID(codeRef), nil)
 	assert.NotNil(t, err)
 }
 
@@ -402,7 +574,7 @@ func TestLedgerArtifactManager_UpdateClass_CreatesCorrectRecord(t *testing.T) {
 	migrationRefs := []record.Reference{{Domain: domainID, Record: *migrationID}}
 	migrationCoreRefs := []core.RecordRef{*migrationRefs[0].CoreRef()}
 	updateCoreID, err := td.manager.UpdateClass(
-		*domainRef.CoreRef(), *td.requestRef.CoreRef(), *genRefWithID(classID), *genRefWithID(codeID),
+		context.Background(), *domainRef.CoreRef(), *td.requestRef.CoreRef(), *genRefWithID(classID), *genRefWithID(codeID),
 		migrationCoreRefs,
 	)
 	assert.NoError(t, err)
@@ -430,13 +602,13 @@ func TestLedgerArtifactManager_ActivateObject_VerifiesRecord(t *testing.T) {
 	defer cleaner()
 
 	_, err := td.manager.ActivateObject(
-		*domainRef.CoreRef(), *td.requestRef.CoreRef(), *genRandomRef(0).CoreRef(), *genRandomRef(0).CoreRef(),
+		context.Background(), *domainRef.CoreRef(), *td.requestRef.CoreRef(), *genRandomRef(0).CoreRef(), *genRandomRef(0).CoreRef(),
 		[]byte{},
 	)
 	assert.NotNil(t, err)
 	notClassID, _ := td.db.SetRecord(&record.ObjectActivateRecord{})
 	_, err = td.manager.ActivateObject(
-		*domainRef.CoreRef(), *td.requestRef.CoreRef(), *genRefWithID(notClassID), *genRandomRef(0).CoreRef(), []byte{},
+		context.Background(), *domainRef.CoreRef(), *td.requestRef.CoreRef(), *genRefWithID(notClassID), *genRandomRef(0).CoreRef(), []byte{},
 	)
 	assert.NotNil(t, err)
 }
@@ -474,7 +646,7 @@ func TestLedgerArtifactManager_ActivateObject_CreatesCorrectRecord(t *testing.T)
 	})
 
 	activateCoreRef, err := td.manager.ActivateObject(
-		*domainRef.CoreRef(), *td.requestRef.CoreRef(), *genRefWithID(classID), *genRefWithID(parentID), memory,
+		context.Background(), *domainRef.CoreRef(), *td.requestRef.CoreRef(), *genRefWithID(classID), *genRefWithID(parentID), memory,
 	)
 	assert.Nil(t, err)
 	activateRef := record.Core2Reference(*activateCoreRef)
@@ -508,7 +680,7 @@ func TestLedgerArtifactManager_ActivateObjectDelegate_VerifiesRecord(t *testing.
 	defer cleaner()
 
 	_, err := td.manager.ActivateObjectDelegate(
-		*domainRef.CoreRef(), *td.requestRef.CoreRef(), *genRandomRef(0).CoreRef(), *genRandomRef(0).CoreRef(),
+		context.Background(), *domainRef.CoreRef(), *td.requestRef.CoreRef(), *genRandomRef(0).CoreRef(), *genRandomRef(0).CoreRef(),
 		[]byte{},
 	)
 	assert.NotNil(t, err)
@@ -522,7 +694,7 @@ func TestLedgerArtifactManager_ActivateObjectDelegate_VerifiesRecord(t *testing.
 		},
 	})
 	_, err = td.manager.ActivateObjectDelegate(
-		*domainRef.CoreRef(), *td.requestRef.CoreRef(), *genRefWithID(notClassID), *genRefWithID(notClassID),
+		context.Background(), *domainRef.CoreRef(), *td.requestRef.CoreRef(), *genRefWithID(notClassID), *genRefWithID(notClassID),
 		[]byte{},
 	)
 	assert.NotNil(t, err)
@@ -561,7 +733,7 @@ func TestLedgerArtifactManager_ActivateObjectDelegate_CreatesCorrectRecord(t *te
 	})
 
 	activateCoreRef, err := td.manager.ActivateObjectDelegate(
-		*domainRef.CoreRef(), *td.requestRef.CoreRef(), *genRefWithID(classID), *genRefWithID(parentID), memory,
+		context.Background(), *domainRef.CoreRef(), *td.requestRef.CoreRef(), *genRefWithID(classID), *genRefWithID(parentID), memory,
 	)
 	assert.Nil(t, err)
 	activateRef := record.Core2Reference(*activateCoreRef)
@@ -582,7 +754,7 @@ func TestLedgerArtifactManager_ActivateObjectDelegate_CreatesCorrectRecord(t *te
 		Delegate:            true,
 	})
 
-	delegate, err := td.manager.GetDelegate(*genRefWithID(parentID), *genRefWithID(classID))
+	delegate, err := td.manager.GetDelegate(context.Background(), *genRefWithID(parentID), *genRefWithID(classID))
 	assert.NoError(t, err)
 	assert.Equal(t, activateCoreRef, delegate)
 }
@@ -593,10 +765,10 @@ func TestLedgerArtifactManager_DeActivateObject_VerifiesRecord(t *testing.T) {
 	defer cleaner()
 
 	_, err := td.manager.DeactivateClass(
-		*domainRef.CoreRef(), *td.requestRef.CoreRef(), *genRandomRef(0).CoreRef())
+		context.Background(), *domainRef.CoreRef(), *td.requestRef.CoreRef(), *genRandomRef(0).CoreRef())
 	assert.NotNil(t, err)
 	notObjID, _ := td.db.SetRecord(&record.ClassActivateRecord{})
-	_, err = td.manager.DeactivateClass(*domainRef.CoreRef(), *td.requestRef.CoreRef(), *genRefWithID(notObjID))
+	_, err = td.manager.DeactivateClass(context.Background(), *domainRef.CoreRef(), *td.requestRef.CoreRef(), *genRefWithID(notObjID))
 	assert.NotNil(t, err)
 }
 
@@ -610,7 +782,7 @@ func TestLedgerArtifactManager_DeActivateObject_VerifiesObjectIsActive(t *testin
 	td.db.SetObjectIndex(objRef, &index.ObjectLifeline{
 		LatestState: *deactivateID,
 	})
-	_, err := td.manager.DeactivateObject(*domainRef.CoreRef(), *td.requestRef.CoreRef(), *genRefWithID(deactivateID))
+	_, err := td.manager.DeactivateObject(context.Background(), *domainRef.CoreRef(), *td.requestRef.CoreRef(), *genRefWithID(deactivateID))
 	assert.NotNil(t, err)
 }
 
@@ -632,7 +804,7 @@ func TestLedgerArtifactManager_DeactivateObject_CreatesCorrectRecord(t *testing.
 		LatestState: *objID,
 	})
 	deactivateCoreID, err := td.manager.DeactivateObject(
-		*domainRef.CoreRef(), *td.requestRef.CoreRef(), *genRefWithID(objID),
+		context.Background(), *domainRef.CoreRef(), *td.requestRef.CoreRef(), *genRefWithID(objID),
 	)
 	assert.Nil(t, err)
 	deactivateID := record.Bytes2ID(deactivateCoreID[:])
@@ -657,11 +829,11 @@ func TestLedgerArtifactManager_UpdateObject_VerifiesRecord(t *testing.T) {
 	defer cleaner()
 
 	_, err := td.manager.UpdateObject(
-		*domainRef.CoreRef(), *td.requestRef.CoreRef(), *genRandomRef(0).CoreRef(), nil)
+		context.Background(), *domainRef.CoreRef(), *td.requestRef.CoreRef(), *genRandomRef(0).CoreRef(), nil)
 	assert.NotNil(t, err)
 	notObjID, _ := td.db.SetRecord(&record.CodeRecord{})
 	_, err = td.manager.UpdateObject(
-		*domainRef.CoreRef(), *td.requestRef.CoreRef(), *genRefWithID(notObjID), nil,
+		context.Background(), *domainRef.CoreRef(), *td.requestRef.CoreRef(), *genRefWithID(notObjID), nil,
 	)
 	assert.NotNil(t, err)
 }
@@ -677,7 +849,7 @@ func TestLedgerArtifactManager_UpdateObject_VerifiesObjectIsActive(t *testing.T)
 		LatestState: *deactivateID,
 	})
 	_, err := td.manager.UpdateObject(
-		*domainRef.CoreRef(), *td.requestRef.CoreRef(), *genRefWithID(deactivateID), nil,
+		context.Background(), *domainRef.CoreRef(), *td.requestRef.CoreRef(), *genRefWithID(deactivateID), nil,
 	)
 	assert.NotNil(t, err)
 }
@@ -701,7 +873,7 @@ func TestLedgerArtifactManager_UpdateObject_CreatesCorrectRecord(t *testing.T) {
 	})
 	memory := []byte{1, 2, 3}
 	updateCoreID, err := td.manager.UpdateObject(
-		*domainRef.CoreRef(), *td.requestRef.CoreRef(), *genRefWithID(objID), memory)
+		context.Background(), *domainRef.CoreRef(), *td.requestRef.CoreRef(), *genRefWithID(objID), memory)
 	assert.Nil(t, err)
 	updateID := record.Bytes2ID(updateCoreID[:])
 	updateRec, err := td.db.GetRecord(&updateID)
@@ -720,6 +892,149 @@ func TestLedgerArtifactManager_UpdateObject_CreatesCorrectRecord(t *testing.T) {
 	})
 }
 
+func TestLedgerArtifactManager_UpdateObjects(t *testing.T) {
+	t.Parallel()
+	td, cleaner := prepareAMTestData(t)
+	defer cleaner()
+
+	newObject := func() *record.ID {
+		objID, _ := td.db.SetRecord(&record.ObjectActivateRecord{
+			ActivationRecord: record.ActivationRecord{
+				StatefulResult: record.StatefulResult{
+					ResultRecord: record.ResultRecord{
+						DomainRecord: *genRandomRef(0),
+					},
+				},
+			},
+		})
+		td.db.SetObjectIndex(objID, &index.ObjectLifeline{
+			LatestState: *objID,
+		})
+		return objID
+	}
+	memory := func(objID *record.ID) []byte {
+		idx, err := td.db.GetObjectIndex(objID)
+		assert.NoError(t, err)
+		rec, err := td.db.GetRecord(&idx.LatestState)
+		assert.NoError(t, err)
+		switch r := rec.(type) {
+		case *record.ObjectAmendRecord:
+			return r.NewMemory
+		case *record.ObjectActivateRecord:
+			return r.Memory
+		}
+		return nil
+	}
+
+	a, b := newObject(), newObject()
+	write := func(objID *record.ID, base *core.RecordID, mem []byte) core.ObjectWrite {
+		return core.ObjectWrite{
+			Domain:  *domainRef.CoreRef(),
+			Request: *td.requestRef.CoreRef(),
+			Object:  *genRefWithID(objID),
+			Base:    base,
+			Memory:  mem,
+			Events:  []core.Event{{Name: "changed"}},
+		}
+	}
+
+	// state of b isn't the state the change is based on, a isn't changed too
+	err := td.manager.UpdateObjects(context.Background(), []core.ObjectWrite{
+		write(a, a.CoreID(), []byte{1}),
+		write(b, a.CoreID(), []byte{2}),
+	})
+	assert.Error(t, err)
+	assert.Nil(t, memory(a))
+	assert.Nil(t, memory(b))
+	events, err := td.db.GetEvents(*genRefWithID(a), 0, 0)
+	assert.NoError(t, err)
+	assert.Empty(t, events)
+
+	// all changes of an object are based on its state before them
+	err = td.manager.UpdateObjects(context.Background(), []core.ObjectWrite{
+		write(a, a.CoreID(), []byte{1}),
+		write(b, b.CoreID(), []byte{2}),
+		write(a, a.CoreID(), []byte{3}),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{3}, memory(a))
+	assert.Equal(t, []byte{2}, memory(b))
+	events, err = td.db.GetEvents(*genRefWithID(b), 0, 0)
+	assert.NoError(t, err)
+	if assert.Len(t, events, 1) {
+		assert.Equal(t, *genRefWithID(b), events[0].Object)
+		assert.Equal(t, *td.requestRef.CoreRef(), events[0].Request)
+		assert.Equal(t, "changed", events[0].Name)
+	}
+
+	// change of an existing object must be based on some state
+	err = td.manager.UpdateObjects(context.Background(), []core.ObjectWrite{
+		write(b, nil, []byte{4}),
+	})
+	assert.Error(t, err)
+	assert.Equal(t, []byte{2}, memory(b))
+
+	classID, _ := td.db.SetRecord(&record.ClassActivateRecord{
+		ActivationRecord: record.ActivationRecord{
+			StatefulResult: record.StatefulResult{
+				ResultRecord: record.ResultRecord{
+					DomainRecord: *genRandomRef(0),
+				},
+			},
+		},
+	})
+	td.db.SetClassIndex(classID, &index.ClassLifeline{
+		LatestState: *classID,
+	})
+	create := func(obj, parent core.RecordRef, mem []byte) core.ObjectWrite {
+		return core.ObjectWrite{
+			Domain:  *domainRef.CoreRef(),
+			Request: *td.requestRef.CoreRef(),
+			Object:  obj,
+			Memory:  mem,
+			Events:  []core.Event{{Name: "created"}},
+			Created: true,
+			Class:   *genRefWithID(classID),
+			Parent:  parent,
+		}
+	}
+	child, grandchild := *genRandomRef(0).CoreRef(), *genRandomRef(0).CoreRef()
+	childID, grandchildID := record.Core2Reference(child).Record, record.Core2Reference(grandchild).Record
+
+	// nothing is activated if a change fails
+	err = td.manager.UpdateObjects(context.Background(), []core.ObjectWrite{
+		create(child, *genRefWithID(a), []byte{5}),
+		write(b, b.CoreID(), []byte{6}),
+	})
+	assert.Error(t, err)
+	_, err = td.db.GetObjectIndex(&childID)
+	assert.Error(t, err)
+
+	// created objects are activated under their references, parent may be created by the same changes
+	err = td.manager.UpdateObjects(context.Background(), []core.ObjectWrite{
+		create(child, *genRefWithID(a), []byte{5}),
+		create(grandchild, child, []byte{6}),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{5}, memory(&childID))
+	assert.Equal(t, []byte{6}, memory(&grandchildID))
+	idx, err := td.db.GetObjectIndex(a)
+	assert.NoError(t, err)
+	childRec, err := td.db.GetRecord(idx.LatestChild)
+	assert.NoError(t, err)
+	assert.Equal(t, record.Core2Reference(child), childRec.(*record.ChildRecord).Ref)
+	events, err = td.db.GetEvents(grandchild, 0, 0)
+	assert.NoError(t, err)
+	assert.Len(t, events, 1)
+
+	// object can't be activated twice
+	err = td.manager.UpdateObjects(context.Background(), []core.ObjectWrite{
+		create(child, *genRefWithID(a), []byte{7}),
+	})
+	assert.Error(t, err)
+	assert.Equal(t, []byte{5}, memory(&childID))
+}
+
 func TestLedgerArtifactManager_GetClass_ReturnsCorrectDescriptors(t *testing.T) {
 	t.Parallel()
 	td, cleaner := prepareAMTestData(t)
@@ -752,10 +1067,11 @@ func TestLedgerArtifactManager_GetClass_ReturnsCorrectDescriptors(t *testing.T)
 	td.db.SetClassIndex(classID, &classIndex)
 
 	classRef := genRefWithID(classID)
-	classDesc, err := td.manager.GetClass(*classRef, nil)
+	classDesc, err := td.manager.GetClass(context.Background(), *classRef, nil)
 	assert.NoError(t, err)
 	expectedClassDesc := &ClassDescriptor{
 		am:    td.manager,
+		ctx:   context.Background(),
 		head:  *classRef,
 		state: *classAmendID.CoreID(),
 		code:  codeRef.CoreRef(),
@@ -769,7 +1085,7 @@ func TestLedgerArtifactManager_GetObject_VerifiesRecords(t *testing.T) {
 	td, cleaner := prepareAMTestData(t)
 	defer cleaner()
 
-	_, err := td.manager.GetObject(*genRandomRef(0).CoreRef(), nil)
+	_, err := td.manager.GetObject(context.Background(), *genRandomRef(0).CoreRef(), nil)
 	assert.NotNil(t, err)
 }
 
@@ -828,14 +1144,16 @@ func TestLedgerArtifactManager_GetLatestObj_ReturnsCorrectDescriptors(t *testing
 	}
 	td.db.SetObjectIndex(objectID, &objectIndex)
 
-	objDesc, err := td.manager.GetObject(*genRefWithID(objectID), nil)
+	objDesc, err := td.manager.GetObject(context.Background(), *genRefWithID(objectID), nil)
 	assert.NoError(t, err)
 	expectedObjDesc := &ObjectDescriptor{
-		am: td.manager,
+		am:  td.manager,
+		ctx: context.Background(),
 
 		head:   *getReference(td.requestRef.CoreRef(), objectID),
 		state:  *objectAmendID.CoreID(),
 		class:  *getReference(td.requestRef.CoreRef(), classID),
+		domain: *domainRef.CoreRef(),
 		memory: []byte{4},
 	}
 
@@ -880,7 +1198,7 @@ func TestLedgerArtifactManager_GetChildren(t *testing.T) {
 	td.db.SetObjectIndex(parentID, &parentIndex)
 
 	t.Run("returns correct children without pulse", func(t *testing.T) {
-		i, err := td.manager.GetChildren(*genRefWithID(parentID), nil)
+		i, err := td.manager.GetChildren(context.Background(), *genRefWithID(parentID), nil)
 		assert.NoError(t, err)
 		child, err := i.Next()
 		assert.NoError(t, err)
@@ -899,7 +1217,7 @@ func TestLedgerArtifactManager_GetChildren(t *testing.T) {
 
 	t.Run("returns correct children with pulse", func(t *testing.T) {
 		pn := core.PulseNumber(1)
-		i, err := td.manager.GetChildren(*genRefWithID(parentID), &pn)
+		i, err := td.manager.GetChildren(context.Background(), *genRefWithID(parentID), &pn)
 		assert.NoError(t, err)
 		child, err := i.Next()
 		assert.NoError(t, err)
@@ -916,7 +1234,7 @@ func TestLedgerArtifactManager_GetChildren(t *testing.T) {
 
 	t.Run("returns correct children in many chunks", func(t *testing.T) {
 		td.manager.getChildrenChunkSize = 1
-		i, err := td.manager.GetChildren(*genRefWithID(parentID), nil)
+		i, err := td.manager.GetChildren(context.Background(), *genRefWithID(parentID), nil)
 		assert.NoError(t, err)
 		child, err := i.Next()
 		assert.NoError(t, err)
@@ -937,7 +1255,7 @@ func TestLedgerArtifactManager_GetChildren(t *testing.T) {
 	t.Run("doesn't fail when has no children to return", func(t *testing.T) {
 		td.manager.getChildrenChunkSize = 1
 		pn := core.PulseNumber(3)
-		i, err := td.manager.GetChildren(*genRefWithID(parentID), &pn)
+		i, err := td.manager.GetChildren(context.Background(), *genRefWithID(parentID), &pn)
 		assert.NoError(t, err)
 		child, err := i.Next()
 		assert.NoError(t, err)
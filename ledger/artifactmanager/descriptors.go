@@ -17,6 +17,8 @@
 package artifactmanager
 
 import (
+	"context"
+
 	"github.com/insolar/insolar/core"
 	"github.com/insolar/insolar/core/message"
 	"github.com/insolar/insolar/core/reply"
@@ -27,6 +29,7 @@ import (
 type CodeDescriptor struct {
 	machineType core.MachineType
 	code        []byte
+	hash        []byte
 	ref         core.RecordRef
 	machinePref []core.MachineType
 }
@@ -46,17 +49,24 @@ func (d *CodeDescriptor) Code() []byte {
 	return d.code
 }
 
+// Hash returns hash of the code calculated when it was deployed.
+func (d *CodeDescriptor) Hash() []byte {
+	return d.hash
+}
+
 // ClassDescriptor represents meta info required to fetch all class data.
 type ClassDescriptor struct {
 	cache struct {
 		codeDescriptor core.CodeDescriptor
 	}
 
-	am core.ArtifactManager
+	am  core.ArtifactManager
+	ctx context.Context // context the descriptor was fetched with, its deadline applies to related data
 
-	head  core.RecordRef
-	state core.RecordID
-	code  *core.RecordRef // Can be nil.
+	head    core.RecordRef
+	state   core.RecordID
+	code    *core.RecordRef // Can be nil.
+	typeRef *core.RecordRef // Can be nil.
 }
 
 // HeadRef returns head reference to represented class record.
@@ -79,7 +89,12 @@ func (d *ClassDescriptor) CodeDescriptor(machinePref []core.MachineType) (core.C
 		return nil, errors.New("class has no code")
 	}
 
-	return d.am.GetCode(*d.code, machinePref)
+	return d.am.GetCode(d.ctx, *d.code, machinePref)
+}
+
+// TypeRef returns reference to the type record with class's ABI, nil if the type wasn't declared.
+func (d *ClassDescriptor) TypeRef() *core.RecordRef {
+	return d.typeRef
 }
 
 // ObjectDescriptor represents meta info required to fetch all object data.
@@ -87,11 +102,13 @@ type ObjectDescriptor struct {
 	cache struct {
 		classDescriptor core.ClassDescriptor
 	}
-	am *LedgerArtifactManager
+	am  *LedgerArtifactManager
+	ctx context.Context // context the descriptor was fetched with, its deadline applies to related data
 
 	head     core.RecordRef
 	state    core.RecordID
 	class    core.RecordRef
+	domain   core.RecordRef
 	memory   []byte
 	children []core.RecordRef
 }
@@ -106,6 +123,11 @@ func (d *ObjectDescriptor) StateID() *core.RecordID {
 	return &d.state
 }
 
+// Domain returns reference to the domain object belongs to.
+func (d *ObjectDescriptor) Domain() *core.RecordRef {
+	return &d.domain
+}
+
 // Memory fetches latest memory of the object known to storage.
 func (d *ObjectDescriptor) Memory() []byte {
 	return d.memory
@@ -113,7 +135,7 @@ func (d *ObjectDescriptor) Memory() []byte {
 
 // Children returns object's children references.
 func (d *ObjectDescriptor) Children(pulse *core.PulseNumber) (core.RefIterator, error) {
-	return d.am.GetChildren(d.head, pulse)
+	return d.am.GetChildren(d.ctx, d.head, pulse)
 }
 
 // ClassDescriptor returns descriptor for fetching object's class data.
@@ -122,13 +144,14 @@ func (d *ObjectDescriptor) ClassDescriptor(state *core.RecordRef) (core.ClassDes
 		return d.cache.classDescriptor, nil
 	}
 
-	return d.am.GetClass(d.class, state)
+	return d.am.GetClass(d.ctx, d.class, state)
 }
 
 // ChildIterator is used to iterate over objects children.
 //
 // During iteration children refs will be fetched from remote source (parent object).
 type ChildIterator struct {
+	ctx        context.Context
 	messageBus core.MessageBus
 	parent     core.RecordRef
 	chunkSize  int
@@ -141,9 +164,10 @@ type ChildIterator struct {
 
 // NewChildIterator creates new child iterator.
 func NewChildIterator(
-	mb core.MessageBus, parent core.RecordRef, fromPulse *core.PulseNumber, chunkSize int,
+	ctx context.Context, mb core.MessageBus, parent core.RecordRef, fromPulse *core.PulseNumber, chunkSize int,
 ) (*ChildIterator, error) {
 	iter := ChildIterator{
+		ctx:        ctx,
 		messageBus: mb,
 		parent:     parent,
 		fromPulse:  fromPulse,
@@ -193,7 +217,7 @@ func (i *ChildIterator) fetch() error {
 	if !i.canFetch {
 		return errors.New("failed to fetch record")
 	}
-	genericReply, err := i.messageBus.Send(&message.GetChildren{
+	genericReply, err := i.messageBus.Send(i.ctx, &message.GetChildren{
 		Parent:    i.parent,
 		FromPulse: i.fromPulse,
 		FromChild: i.fromChild,
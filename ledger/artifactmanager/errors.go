@@ -31,4 +31,11 @@ var (
 	ErrWrongObject                = errors.New("provided object is not and instance of provided class")
 	ErrNotFound                   = errors.New("object not found")
 	ErrUnexpectedReply            = errors.New("unexpected reply")
+	ErrStateChanged               = errors.New("object was changed since the state the change is based on")
+	ErrCodeMismatch               = errors.New("code record doesn't match its reference")
+	ErrNoCodeHash                 = errors.New("code record has no hash of the code, it must be redeployed")
+	ErrInvalidSignature           = errors.New("invalid signature")
+	ErrNotClassOwner              = errors.New("request isn't signed by the class owner")
+	ErrNoBaseState                = errors.New("change of an object has no base state")
+	ErrObjectExists               = errors.New("object already exists")
 )
@@ -17,6 +17,10 @@
 package artifactmanager
 
 import (
+	"context"
+
+	"github.com/insolar/insolar/cryptohelpers/ecdsa"
+	"github.com/insolar/insolar/cryptohelpers/hash"
 	"github.com/insolar/insolar/ledger/index"
 	"github.com/pkg/errors"
 
@@ -25,6 +29,7 @@ import (
 	"github.com/insolar/insolar/core/reply"
 	"github.com/insolar/insolar/ledger/record"
 	"github.com/insolar/insolar/ledger/storage"
+	"github.com/insolar/insolar/tracing"
 )
 
 // MessageHandler processes messages for local storage interaction.
@@ -57,12 +62,16 @@ func (h *MessageHandler) Link(components core.Components) error {
 	bus.MustRegister(core.TypeUpdateObject, h.handleUpdateObject)
 	bus.MustRegister(core.TypeRegisterChild, h.handleRegisterChild)
 	bus.MustRegister(core.TypeRequestCall, h.handleRegisterRequest)
+	bus.MustRegister(core.TypeGetEvents, h.handleGetEvents)
+	bus.MustRegister(core.TypeGetType, h.handleGetType)
+	bus.MustRegister(core.TypeUpdateObjects, h.handleUpdateObjects)
+	bus.MustRegister(core.TypeDeployClass, h.handleDeployClass)
 
 	return nil
 }
 
 func (h *MessageHandler) handleRegisterRequest(
-	genericMsg core.Message,
+	ctx context.Context, genericMsg core.Message,
 ) (core.Reply, error) {
 	msg := genericMsg.(*message.RequestCall)
 	requestRec := &record.CallRequest{
@@ -72,34 +81,35 @@ func (h *MessageHandler) handleRegisterRequest(
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to set request record")
 	}
+	tracing.Logger(ctx).Debugf("request of %s is registered", msg.Message.Type())
 	return &reply.ID{ID: *id.CoreID()}, nil
 }
 
-func (h *MessageHandler) handleGetCode(genericMsg core.Message) (core.Reply, error) {
+func (h *MessageHandler) handleGetCode(ctx context.Context, genericMsg core.Message) (core.Reply, error) {
 	msg := genericMsg.(*message.GetCode)
 	codeRef := record.Core2Reference(msg.Code)
-	rec, err := h.db.GetRecord(&codeRef.Record)
+	raw, err := h.db.GetRawRecord(&codeRef.Record)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to retrieve code record")
 	}
-	codeRec, ok := rec.(*record.CodeRecord)
+	codeRec, ok := raw.ToRecord().(*record.CodeRecord)
 	if !ok {
 		return nil, errors.Wrap(ErrInvalidRef, "failed to retrieve code record")
 	}
-	code, mt, err := codeRec.GetCode(msg.MachinePref)
+	_, mt, err := codeRec.GetCode(msg.MachinePref)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to retrieve code from record")
 	}
 
 	rep := reply.Code{
-		Code:        code,
+		Record:      record.MustEncodeRaw(raw),
 		MachineType: mt,
 	}
 
 	return &rep, nil
 }
 
-func (h *MessageHandler) handleGetClass(genericMsg core.Message) (core.Reply, error) {
+func (h *MessageHandler) handleGetClass(ctx context.Context, genericMsg core.Message) (core.Reply, error) {
 	msg := genericMsg.(*message.GetClass)
 	headRef := record.Core2Reference(msg.Head)
 
@@ -115,16 +125,22 @@ func (h *MessageHandler) handleGetClass(genericMsg core.Message) (core.Reply, er
 		code = state.GetCode().CoreRef()
 	}
 
+	var typeRef *core.RecordRef
+	if state.GetType() != nil {
+		typeRef = state.GetType().CoreRef()
+	}
+
 	rep := reply.Class{
-		Head:  msg.Head,
-		State: *stateID,
-		Code:  code,
+		Head:    msg.Head,
+		State:   *stateID,
+		Code:    code,
+		TypeRef: typeRef,
 	}
 
 	return &rep, nil
 }
 
-func (h *MessageHandler) handleGetObject(genericMsg core.Message) (core.Reply, error) {
+func (h *MessageHandler) handleGetObject(ctx context.Context, genericMsg core.Message) (core.Reply, error) {
 	msg := genericMsg.(*message.GetObject)
 	headRef := record.Core2Reference(msg.Head)
 
@@ -132,18 +148,27 @@ func (h *MessageHandler) handleGetObject(genericMsg core.Message) (core.Reply, e
 	if err != nil {
 		return nil, err
 	}
+	rec, err := h.db.GetRecord(&headRef.Record)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve object activation record")
+	}
+	activation, ok := rec.(*record.ObjectActivateRecord)
+	if !ok {
+		return nil, errors.Wrap(ErrInvalidRef, "failed to retrieve object activation record")
+	}
 
 	rep := reply.Object{
 		Head:   msg.Head,
 		State:  *stateID,
 		Class:  *idx.ClassRef.CoreRef(),
+		Domain: *activation.DomainRecord.CoreRef(),
 		Memory: state.GetMemory(),
 	}
 
 	return &rep, nil
 }
 
-func (h *MessageHandler) handleGetDelegate(genericMsg core.Message) (core.Reply, error) {
+func (h *MessageHandler) handleGetDelegate(ctx context.Context, genericMsg core.Message) (core.Reply, error) {
 	msg := genericMsg.(*message.GetDelegate)
 	headRef := record.Core2Reference(msg.Head)
 
@@ -164,7 +189,7 @@ func (h *MessageHandler) handleGetDelegate(genericMsg core.Message) (core.Reply,
 	return &rep, nil
 }
 
-func (h *MessageHandler) handleGetChildren(genericMsg core.Message) (core.Reply, error) {
+func (h *MessageHandler) handleGetChildren(ctx context.Context, genericMsg core.Message) (core.Reply, error) {
 	msg := genericMsg.(*message.GetChildren)
 	parentRef := record.Core2Reference(msg.Parent)
 
@@ -216,7 +241,7 @@ func (h *MessageHandler) handleGetChildren(genericMsg core.Message) (core.Reply,
 	return &reply.Children{Refs: refs, NextFrom: nil}, nil
 }
 
-func (h *MessageHandler) handleDeclareType(genericMsg core.Message) (core.Reply, error) {
+func (h *MessageHandler) handleDeclareType(ctx context.Context, genericMsg core.Message) (core.Reply, error) {
 	msg := genericMsg.(*message.DeclareType)
 
 	domainRef := record.Core2Reference(msg.Domain)
@@ -233,20 +258,76 @@ func (h *MessageHandler) handleDeclareType(genericMsg core.Message) (core.Reply,
 		},
 		TypeDeclaration: msg.TypeDec,
 	}
-	typeID, err := h.db.SetRecord(&rec)
+
+	if msg.Class == nil {
+		typeID, err := h.db.SetRecord(&rec)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to store record")
+		}
+		return &reply.Reference{Ref: *getReference(&msg.Request, typeID)}, nil
+	}
+
+	classRef := record.Core2Reference(*msg.Class)
+	var typeRef *core.RecordRef
+	err := h.db.Update(func(tx *storage.TransactionManager) error {
+		idx, _, state, err := getClass(tx, &classRef.Record, nil)
+		if err != nil {
+			return err
+		}
+		if state.GetCode() == nil {
+			return errors.New("class has no code to declare the type for")
+		}
+
+		typeID, err := tx.SetRecord(&rec)
+		if err != nil {
+			return errors.Wrap(err, "failed to store record")
+		}
+		typeRef = getReference(&msg.Request, typeID)
+		ref := record.Core2Reference(*typeRef)
+
+		// the type is bound to the class state, so the class is amended with the same code
+		amend := record.ClassAmendRecord{
+			AmendRecord: record.AmendRecord{
+				StatefulResult: record.StatefulResult{
+					ResultRecord: record.ResultRecord{
+						DomainRecord:  domainRef,
+						RequestRecord: requestRef,
+					},
+				},
+				AmendedRecord: idx.LatestState,
+			},
+			NewCode: *state.GetCode(),
+			Type:    &ref,
+		}
+		amendID, err := tx.SetRecord(&amend)
+		if err != nil {
+			return errors.Wrap(err, "failed to store record")
+		}
+		idx.LatestState = *amendID
+		idx.AmendRefs = append(idx.AmendRefs, *amendID)
+		err = tx.SetClassIndex(&classRef.Record, idx)
+		if err != nil {
+			return errors.Wrap(err, "failed to store lifeline index")
+		}
+		return nil
+	})
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to store record")
+		return nil, err
 	}
 
-	return &reply.Reference{Ref: *getReference(&msg.Request, typeID)}, nil
+	return &reply.Reference{Ref: *typeRef}, nil
 }
 
-func (h *MessageHandler) handleDeployCode(genericMsg core.Message) (core.Reply, error) {
+func (h *MessageHandler) handleDeployCode(ctx context.Context, genericMsg core.Message) (core.Reply, error) {
 	msg := genericMsg.(*message.DeployCode)
 
 	domainRef := record.Core2Reference(msg.Domain)
 	requestRef := record.Core2Reference(msg.Request)
 
+	hashes := make(map[core.MachineType][]byte, len(msg.CodeMap))
+	for mt, code := range msg.CodeMap {
+		hashes[mt] = hash.SHA3Bytes(code)
+	}
 	rec := record.CodeRecord{
 		StorageRecord: record.StorageRecord{
 			StatefulResult: record.StatefulResult{
@@ -257,6 +338,7 @@ func (h *MessageHandler) handleDeployCode(genericMsg core.Message) (core.Reply,
 			},
 		},
 		TargetedCode: msg.CodeMap,
+		CodeHashes:   hashes,
 	}
 	codeID, err := h.db.SetRecord(&rec)
 	if err != nil {
@@ -265,7 +347,7 @@ func (h *MessageHandler) handleDeployCode(genericMsg core.Message) (core.Reply,
 	return &reply.Reference{Ref: *getReference(&msg.Request, codeID)}, nil
 }
 
-func (h *MessageHandler) handleActivateClass(genericMsg core.Message) (core.Reply, error) {
+func (h *MessageHandler) handleActivateClass(ctx context.Context, genericMsg core.Message) (core.Reply, error) {
 	msg := genericMsg.(*message.ActivateClass)
 
 	domainRef := record.Core2Reference(msg.Domain)
@@ -306,7 +388,7 @@ func (h *MessageHandler) handleActivateClass(genericMsg core.Message) (core.Repl
 	return &reply.Reference{Ref: *getReference(&msg.Request, classID)}, nil
 }
 
-func (h *MessageHandler) handleDeactivateClass(genericMsg core.Message) (core.Reply, error) {
+func (h *MessageHandler) handleDeactivateClass(ctx context.Context, genericMsg core.Message) (core.Reply, error) {
 	msg := genericMsg.(*message.DeactivateClass)
 
 	domainRef := record.Core2Reference(msg.Domain)
@@ -354,7 +436,7 @@ func (h *MessageHandler) handleDeactivateClass(genericMsg core.Message) (core.Re
 	return &reply.ID{ID: *deactivationID.CoreID()}, nil
 }
 
-func (h *MessageHandler) handleUpdateClass(genericMsg core.Message) (core.Reply, error) {
+func (h *MessageHandler) handleUpdateClass(ctx context.Context, genericMsg core.Message) (core.Reply, error) {
 	msg := genericMsg.(*message.UpdateClass)
 
 	domainRef := record.Core2Reference(msg.Domain)
@@ -376,6 +458,15 @@ func (h *MessageHandler) handleUpdateClass(genericMsg core.Message) (core.Reply,
 			return nil, err
 		}
 	}
+	var typeRef *record.Reference
+	if msg.TypeRef != nil {
+		err = validateType(h.db, msg.TypeRef)
+		if err != nil {
+			return nil, err
+		}
+		ref := record.Core2Reference(*msg.TypeRef)
+		typeRef = &ref
+	}
 
 	var amendID *record.ID
 	err = h.db.Update(func(tx *storage.TransactionManager) error {
@@ -396,6 +487,7 @@ func (h *MessageHandler) handleUpdateClass(genericMsg core.Message) (core.Reply,
 			},
 			NewCode:    record.Core2Reference(msg.Code),
 			Migrations: migrationRefs,
+			Type:       typeRef,
 		}
 
 		amendID, err = tx.SetRecord(&rec)
@@ -418,7 +510,125 @@ func (h *MessageHandler) handleUpdateClass(genericMsg core.Message) (core.Reply,
 	return &reply.ID{ID: *amendID.CoreID()}, nil
 }
 
-func (h *MessageHandler) handleActivateObject(genericMsg core.Message) (core.Reply, error) {
+func (h *MessageHandler) handleDeployClass(ctx context.Context, genericMsg core.Message) (core.Reply, error) {
+	msg := genericMsg.(*message.DeployClass)
+
+	ok, err := ecdsa.Verify(msg.SignedData(), msg.Signature, msg.PublicKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to verify signature")
+	}
+	if !ok {
+		return nil, ErrInvalidSignature
+	}
+
+	domainRef := record.Core2Reference(msg.Domain)
+	requestRef := record.Core2Reference(msg.Request)
+	result := record.StatefulResult{
+		ResultRecord: record.ResultRecord{
+			DomainRecord:  domainRef,
+			RequestRecord: requestRef,
+		},
+	}
+	migrationRefs := make([]record.Reference, 0, len(msg.Migrations))
+	for _, migration := range msg.Migrations {
+		err = validateCode(h.db, &migration)
+		if err != nil {
+			return nil, err
+		}
+		migrationRefs = append(migrationRefs, record.Core2Reference(migration))
+	}
+
+	var rep reply.Class
+	err = h.db.Update(func(tx *storage.TransactionManager) error {
+		var (
+			classID *record.ID
+			idx     *index.ClassLifeline
+			err     error
+		)
+		if msg.Class == nil {
+			classID, err = tx.SetRecord(&record.ClassActivateRecord{
+				ActivationRecord: record.ActivationRecord{StatefulResult: result},
+				Owner:            msg.PublicKey,
+			})
+			if err != nil {
+				return errors.Wrap(err, "failed to store record")
+			}
+			idx = &index.ClassLifeline{LatestState: *classID}
+		} else {
+			classRef := record.Core2Reference(*msg.Class)
+			classID = &classRef.Record
+			idx, _, _, err = getClass(tx, classID, nil)
+			if err != nil {
+				return err
+			}
+			err = checkClassOwner(tx, classID, msg.PublicKey)
+			if err != nil {
+				return err
+			}
+		}
+
+		codeID, err := tx.SetRecord(&record.CodeRecord{
+			StorageRecord: record.StorageRecord{StatefulResult: result},
+			TargetedCode:  map[core.MachineType][]byte{msg.MachineType: msg.Code},
+			CodeHashes:    map[core.MachineType][]byte{msg.MachineType: hash.SHA3Bytes(msg.Code)},
+		})
+		if err != nil {
+			return errors.Wrap(err, "failed to store record")
+		}
+		var typeRef *record.Reference
+		if len(msg.TypeDec) > 0 {
+			typeID, err := tx.SetRecord(&record.TypeRecord{
+				StorageRecord:   record.StorageRecord{StatefulResult: result},
+				TypeDeclaration: msg.TypeDec,
+			})
+			if err != nil {
+				return errors.Wrap(err, "failed to store record")
+			}
+			ref := record.Core2Reference(*getReference(&msg.Request, typeID))
+			typeRef = &ref
+		}
+
+		amendID, err := tx.SetRecord(&record.ClassAmendRecord{
+			AmendRecord: record.AmendRecord{
+				StatefulResult: result,
+				AmendedRecord:  idx.LatestState,
+			},
+			NewCode:    record.Core2Reference(*getReference(&msg.Request, codeID)),
+			Migrations: migrationRefs,
+			Type:       typeRef,
+		})
+		if err != nil {
+			return errors.Wrap(err, "failed to store record")
+		}
+		idx.LatestState = *amendID
+		idx.AmendRefs = append(idx.AmendRefs, *amendID)
+		err = tx.SetClassIndex(classID, idx)
+		if err != nil {
+			return errors.Wrap(err, "failed to store lifeline index")
+		}
+
+		head := msg.Class
+		if head == nil {
+			head = getReference(&msg.Request, classID)
+		}
+		rep = reply.Class{
+			Head:  *head,
+			State: *amendID.CoreID(),
+			Code:  getReference(&msg.Request, codeID),
+		}
+		if typeRef != nil {
+			rep.TypeRef = typeRef.CoreRef()
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &rep, nil
+}
+
+func (h *MessageHandler) handleActivateObject(ctx context.Context, genericMsg core.Message) (core.Reply, error) {
 	msg := genericMsg.(*message.ActivateObject)
 
 	domainRef := record.Core2Reference(msg.Domain)
@@ -490,7 +700,7 @@ func (h *MessageHandler) handleActivateObject(genericMsg core.Message) (core.Rep
 	return &reply.Reference{Ref: *getReference(&msg.Request, objID)}, nil
 }
 
-func (h *MessageHandler) handleActivateObjectDelegate(genericMsg core.Message) (core.Reply, error) {
+func (h *MessageHandler) handleActivateObjectDelegate(ctx context.Context, genericMsg core.Message) (core.Reply, error) {
 	msg := genericMsg.(*message.ActivateObjectDelegate)
 
 	domainRef := record.Core2Reference(msg.Domain)
@@ -566,7 +776,7 @@ func (h *MessageHandler) handleActivateObjectDelegate(genericMsg core.Message) (
 	return &reply.Reference{Ref: *getReference(&msg.Request, objID)}, nil
 }
 
-func (h *MessageHandler) handleDeactivateObject(genericMsg core.Message) (core.Reply, error) {
+func (h *MessageHandler) handleDeactivateObject(ctx context.Context, genericMsg core.Message) (core.Reply, error) {
 	msg := genericMsg.(*message.DeactivateObject)
 
 	domainRef := record.Core2Reference(msg.Domain)
@@ -614,7 +824,7 @@ func (h *MessageHandler) handleDeactivateObject(genericMsg core.Message) (core.R
 	return &reply.ID{ID: *deactivationID.CoreID()}, nil
 }
 
-func (h *MessageHandler) handleUpdateObject(genericMsg core.Message) (core.Reply, error) {
+func (h *MessageHandler) handleUpdateObject(ctx context.Context, genericMsg core.Message) (core.Reply, error) {
 	msg := genericMsg.(*message.UpdateObject)
 
 	domainRef := record.Core2Reference(msg.Domain)
@@ -664,7 +874,156 @@ func (h *MessageHandler) handleUpdateObject(genericMsg core.Message) (core.Reply
 	return &reply.ID{ID: *amendID.CoreID()}, nil
 }
 
-func (h *MessageHandler) handleRegisterChild(genericMsg core.Message) (core.Reply, error) {
+func (h *MessageHandler) handleUpdateObjects(ctx context.Context, genericMsg core.Message) (core.Reply, error) {
+	msg := genericMsg.(*message.UpdateObjects)
+
+	err := h.db.Update(func(tx *storage.TransactionManager) error {
+		// all changes of an object are based on its state in storage before the transaction
+		bases := map[core.RecordRef]record.ID{}
+		for _, w := range msg.Writes {
+			if w.Created {
+				err := activateObject(tx, w)
+				if err != nil {
+					return err
+				}
+				continue
+			}
+
+			objRef := record.Core2Reference(w.Object)
+			idx, _, _, err := getObject(tx, &objRef.Record, nil)
+			if err != nil {
+				return err
+			}
+			if w.Base == nil {
+				return ErrNoBaseState
+			}
+			base, ok := bases[w.Object]
+			if !ok {
+				base = idx.LatestState
+				bases[w.Object] = base
+			}
+			if *base.CoreID() != *w.Base {
+				return ErrStateChanged
+			}
+
+			rec := record.ObjectAmendRecord{
+				AmendRecord: record.AmendRecord{
+					StatefulResult: record.StatefulResult{
+						ResultRecord: record.ResultRecord{
+							DomainRecord:  record.Core2Reference(w.Domain),
+							RequestRecord: record.Core2Reference(w.Request),
+						},
+					},
+					AmendedRecord: idx.LatestState,
+				},
+				NewMemory: w.Memory,
+			}
+			amendID, err := tx.SetRecord(&rec)
+			if err != nil {
+				return errors.Wrap(err, "failed to store record")
+			}
+			idx.LatestState = *amendID
+			err = tx.SetObjectIndex(&objRef.Record, idx)
+			if err != nil {
+				return errors.Wrap(err, "failed to store lifeline index")
+			}
+
+			err = tx.SetEvents(linkEvents(w.Object, w.Request, w.Events))
+			if err != nil {
+				return errors.Wrap(err, "failed to store events")
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		tracing.Logger(ctx).Debugf("update of %d objects is rejected: %s", len(msg.Writes), err)
+		return nil, err
+	}
+
+	return &reply.OK{}, nil
+}
+
+// activateObject activates object created by a call chain under the reference the chain gave it,
+// as a child or a delegate of the parent. The parent may be created earlier by the same chain.
+func activateObject(tx *storage.TransactionManager, w core.ObjectWrite) error {
+	objRef := record.Core2Reference(w.Object)
+	classRef := record.Core2Reference(w.Class)
+	parentRef := record.Core2Reference(w.Parent)
+
+	_, err := tx.GetObjectIndex(&objRef.Record)
+	if err == nil {
+		return ErrObjectExists
+	}
+	if err != storage.ErrNotFound {
+		return errors.Wrap(err, "inconsistent index")
+	}
+	_, _, _, err = getClass(tx, &classRef.Record, nil)
+	if err != nil {
+		return err
+	}
+	parentIdx, _, _, err := getObject(tx, &parentRef.Record, nil)
+	if err != nil {
+		return err
+	}
+
+	rec := record.ObjectActivateRecord{
+		ActivationRecord: record.ActivationRecord{
+			StatefulResult: record.StatefulResult{
+				ResultRecord: record.ResultRecord{
+					DomainRecord:  record.Core2Reference(w.Domain),
+					RequestRecord: record.Core2Reference(w.Request),
+				},
+			},
+		},
+		ClassActivateRecord: classRef,
+		Memory:              w.Memory,
+		Parent:              parentRef,
+		Delegate:            w.Delegate,
+	}
+	stateID, err := tx.SetRecord(&rec)
+	if err != nil {
+		return errors.Wrap(err, "failed to store record")
+	}
+	err = tx.SetObjectIndex(&objRef.Record, &index.ObjectLifeline{
+		ClassRef:    classRef,
+		LatestState: *stateID,
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to store lifeline index")
+	}
+
+	// append new object to parent's delegates or children
+	if w.Delegate {
+		if _, ok := parentIdx.Delegates[w.Class]; ok {
+			return ErrClassDelegateAlreadyExists
+		}
+		if parentIdx.Delegates == nil {
+			parentIdx.Delegates = map[core.RecordRef]record.Reference{}
+		}
+		parentIdx.Delegates[w.Class] = objRef
+	} else {
+		child, err := tx.SetRecord(&record.ChildRecord{
+			PrevChild: parentIdx.LatestChild,
+			Ref:       objRef,
+		})
+		if err != nil {
+			return errors.Wrap(err, "failed to store child record")
+		}
+		parentIdx.LatestChild = child
+	}
+	err = tx.SetObjectIndex(&parentRef.Record, parentIdx)
+	if err != nil {
+		return errors.Wrap(err, "failed to store lifeline index")
+	}
+
+	err = tx.SetEvents(linkEvents(w.Object, w.Request, w.Events))
+	if err != nil {
+		return errors.Wrap(err, "failed to store events")
+	}
+	return nil
+}
+
+func (h *MessageHandler) handleRegisterChild(ctx context.Context, genericMsg core.Message) (core.Reply, error) {
 	msg := genericMsg.(*message.RegisterChild)
 	parentRef := record.Core2Reference(msg.Parent)
 
@@ -767,6 +1126,22 @@ func getObject(
 	return idx, stateID.CoreID(), stateRec, nil
 }
 
+// checkClassOwner checks that the key is the owner key stored in activation record of the class
+func checkClassOwner(s storage.Store, head *record.ID, key string) error {
+	rec, err := s.GetRecord(head)
+	if err != nil {
+		return err
+	}
+	activation, ok := rec.(*record.ClassActivateRecord)
+	if !ok {
+		return errors.New("invalid class record")
+	}
+	if activation.Owner == "" || activation.Owner != key {
+		return ErrNotClassOwner
+	}
+	return nil
+}
+
 func validateCode(s storage.Store, ref *core.RecordRef) error {
 	codeRef := record.Core2Reference(*ref)
 	rec, err := s.GetRecord(&codeRef.Record)
@@ -780,3 +1155,55 @@ func validateCode(s storage.Store, ref *core.RecordRef) error {
 
 	return nil
 }
+
+func validateType(s storage.Store, ref *core.RecordRef) error {
+	typeRef := record.Core2Reference(*ref)
+	rec, err := s.GetRecord(&typeRef.Record)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := rec.(*record.TypeRecord); !ok {
+		return errors.New("invalid type reference")
+	}
+
+	return nil
+}
+
+// linkEvents links events to the object and the request no matter what sender put into them.
+func linkEvents(obj, request core.RecordRef, events []core.Event) []core.Event {
+	linked := make([]core.Event, 0, len(events))
+	for _, ev := range events {
+		ev.Object = obj
+		ev.Request = request
+		linked = append(linked, ev)
+	}
+	return linked
+}
+
+func (h *MessageHandler) handleGetEvents(ctx context.Context, genericMsg core.Message) (core.Reply, error) {
+	msg := genericMsg.(*message.GetEvents)
+
+	events, err := h.db.GetEvents(msg.Object, msg.From, msg.To)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve events")
+	}
+
+	return &reply.Events{Events: events, Pulse: h.db.GetCurrentPulse()}, nil
+}
+
+func (h *MessageHandler) handleGetType(ctx context.Context, genericMsg core.Message) (core.Reply, error) {
+	msg := genericMsg.(*message.GetType)
+
+	typeRef := record.Core2Reference(msg.TypeRef)
+	rec, err := h.db.GetRecord(&typeRef.Record)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve type record")
+	}
+	typeRec, ok := rec.(*record.TypeRecord)
+	if !ok {
+		return nil, errors.Wrap(ErrInvalidRef, "failed to retrieve type record")
+	}
+
+	return &reply.TypeDeclaration{TypeDec: typeRec.TypeDeclaration}, nil
+}
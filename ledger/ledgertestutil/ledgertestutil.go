@@ -17,6 +17,7 @@
 package ledgertestutil
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"testing"
@@ -65,14 +66,14 @@ func (mb *messageBusMock) Stop() error {
 	panic("implement me")
 }
 
-func (mb *messageBusMock) Send(m core.Message) (core.Reply, error) {
+func (mb *messageBusMock) Send(ctx context.Context, m core.Message) (core.Reply, error) {
 	t := m.Type()
 	handler, ok := mb.handlers[t]
 	if !ok {
 		return nil, errors.New(fmt.Sprint("no handler for message type:", t.String()))
 	}
 
-	return handler(m)
+	return handler(ctx, m)
 }
 
 func (mb *messageBusMock) SendAsync(m core.Message) {
@@ -82,6 +83,12 @@ func (mb *messageBusMock) SendAsync(m core.Message) {
 // TmpLedger crteates ledger on top of temporary database.
 // Returns *ledger.Ledger andh cleanup function.
 func TmpLedger(t testing.TB, dir string) (*ledger.Ledger, func()) {
+	return TmpLedgerOnBus(t, dir, newMessageBusMock())
+}
+
+// TmpLedgerOnBus creates ledger on top of temporary database, ledger's handlers are registered
+// on provided message bus. Returns *ledger.Ledger and cleanup function.
+func TmpLedgerOnBus(t testing.TB, dir string, mb core.MessageBus) (*ledger.Ledger, func()) {
 	var err error
 	// Init subcomponents.
 	conf := configuration.NewLedger()
@@ -100,7 +107,6 @@ func TmpLedger(t testing.TB, dir string) (*ledger.Ledger, func()) {
 	assert.NoError(t, err)
 
 	// Init components.
-	mb := newMessageBusMock()
 	components := core.Components{MessageBus: mb}
 
 	// Create ledger.
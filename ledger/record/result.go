@@ -29,6 +29,8 @@ type ClassState interface {
 	IsAmend() bool
 	// GetCode returns state code.
 	GetCode() *Reference
+	// GetType returns type with class ABI of the state.
+	GetType() *Reference
 }
 
 // ObjectState is common object state record.
@@ -139,6 +141,7 @@ type ClassActivateRecord struct {
 	ActivationRecord
 
 	DefaultMemory Memory
+	Owner         string // PEM encoded key allowed to upgrade the class, the class can't be upgraded if empty.
 }
 
 // IsDeactivation determines if current state is deactivation.
@@ -156,6 +159,11 @@ func (r *ClassActivateRecord) GetCode() *Reference {
 	return nil
 }
 
+// GetType returns type with class ABI of the state.
+func (r *ClassActivateRecord) GetType() *Reference {
+	return nil
+}
+
 // ObjectActivateRecord is produced when we instantiate new object from an available class.
 type ObjectActivateRecord struct {
 	ActivationRecord
@@ -191,7 +199,9 @@ type CodeRecord struct {
 	StorageRecord
 
 	TargetedCode map[core.MachineType][]byte
-	SourceCode   string
+	// CodeHashes are hashes of TargetedCode calculated on deploy, executors verify fetched code against them.
+	CodeHashes map[core.MachineType][]byte
+	SourceCode string
 }
 
 // TypeRecord is a code interface declaration.
@@ -226,6 +236,7 @@ type ClassAmendRecord struct {
 
 	NewCode    Reference   // CodeRecord
 	Migrations []Reference // CodeRecord
+	Type       *Reference  // TypeRecord with class ABI, nil if the state has no ABI
 }
 
 // IsDeactivation determines if current state is deactivation.
@@ -243,6 +254,11 @@ func (r *ClassAmendRecord) GetCode() *Reference {
 	return &r.NewCode
 }
 
+// GetType returns type with class ABI of the state.
+func (r *ClassAmendRecord) GetType() *Reference {
+	return r.Type
+}
+
 // DeactivationRecord marks targeted object as disabled.
 type DeactivationRecord struct {
 	AmendRecord
@@ -268,6 +284,11 @@ func (*DeactivationRecord) GetCode() *Reference {
 	return nil
 }
 
+// GetType returns type with class ABI of the state.
+func (*DeactivationRecord) GetType() *Reference {
+	return nil
+}
+
 // ObjectAmendRecord is an amendment record for objects.
 type ObjectAmendRecord struct {
 	AmendRecord
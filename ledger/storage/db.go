@@ -37,6 +37,7 @@ const (
 	scopeIDRecord   byte = 2
 	scopeIDJetDrop  byte = 3
 	scopeIDEntropy  byte = 4
+	scopeIDEvent    byte = 5
 
 	rootKey = "0"
 )
@@ -207,6 +208,13 @@ func (db *DB) SetRequest(req record.Request) (*record.ID, error) {
 	return id, nil
 }
 
+// GetRawRecord wraps matching transaction manager method.
+func (db *DB) GetRawRecord(id *record.ID) (*record.Raw, error) {
+	tx := db.BeginTransaction(false)
+	defer tx.Discard()
+	return tx.GetRawRecord(id)
+}
+
 // GetRecord wraps matching transaction manager method.
 func (db *DB) GetRecord(id *record.ID) (record.Record, error) {
 	tx := db.BeginTransaction(false)
@@ -349,6 +357,20 @@ func (db *DB) SetEntropy(pulse core.PulseNumber, entropy core.Entropy) error {
 	})
 }
 
+// GetEvents wraps matching transaction manager method.
+func (db *DB) GetEvents(obj core.RecordRef, from, to core.PulseNumber) ([]core.Event, error) {
+	tx := db.BeginTransaction(false)
+	defer tx.Discard()
+	return tx.GetEvents(obj, from, to)
+}
+
+// SetEvents wraps matching transaction manager method.
+func (db *DB) SetEvents(events []core.Event) error {
+	return db.Update(func(tx *TransactionManager) error {
+		return tx.SetEvents(events)
+	})
+}
+
 // SetCurrentPulse sets current pulse number.
 func (db *DB) SetCurrentPulse(pulse core.PulseNumber) {
 	db.currentPulse = pulse
@@ -168,3 +168,37 @@ func TestStore_SetEntropy(t *testing.T) {
 	_, err = db.GetEntropy(1)
 	assert.Error(t, err)
 }
+
+func TestStore_SetEvents(t *testing.T) {
+	t.Parallel()
+	db, cleaner := storagetest.TmpDB(t, "")
+	defer cleaner()
+
+	obj := core.RandomRef()
+	other := core.RandomRef()
+	req := core.RandomRef()
+	events := []core.Event{
+		{Object: obj, Request: req, Pulse: 10, Name: "second", Payload: []byte{2}},
+		{Object: obj, Request: req, Pulse: 10, Name: "first", Payload: []byte{1}},
+		{Object: obj, Request: req, Pulse: 20, Name: "third", Payload: []byte{3}},
+		{Object: other, Request: req, Pulse: 10, Name: "other", Payload: []byte{4}},
+	}
+	err := db.SetEvents(events)
+	assert.NoError(t, err)
+
+	got, err := db.GetEvents(obj, 0, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, events[:3], got)
+
+	got, err = db.GetEvents(obj, 10, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, events[:2], got)
+
+	got, err = db.GetEvents(obj, 11, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, events[2:3], got)
+
+	got, err = db.GetEvents(obj, 21, 0)
+	assert.NoError(t, err)
+	assert.Empty(t, got)
+}
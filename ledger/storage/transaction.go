@@ -17,7 +17,10 @@
 package storage
 
 import (
+	"encoding/binary"
+
 	"github.com/dgraph-io/badger"
+	"github.com/ugorji/go/codec"
 
 	"github.com/insolar/insolar/core"
 	"github.com/insolar/insolar/cryptohelpers/hash"
@@ -82,8 +85,20 @@ func (m *TransactionManager) SetRequest(req record.Request) (*record.ID, error)
 //
 // It returns ErrNotFound if the DB does not contain the key.
 func (m *TransactionManager) GetRecord(id *record.ID) (record.Record, error) {
+	raw, err := m.GetRawRecord(id)
+	if err != nil {
+		return nil, err
+	}
+	return raw.ToRecord(), nil
+}
+
+// GetRawRecord returns record from BadgerDB in the serialized form it was stored, so its hash can be checked
+// against the ID.
+//
+// It returns ErrNotFound if the DB does not contain the key.
+func (m *TransactionManager) GetRawRecord(id *record.ID) (*record.Raw, error) {
 	k := prefixkey(scopeIDRecord, record.ID2Bytes(*id))
-	log.Debugf("GetRecord by id %+v (key=%x)", id, k)
+	log.Debugf("GetRawRecord by id %+v (key=%x)", id, k)
 	item, err := m.txn.Get(k)
 	if err != nil {
 		if err == badger.ErrKeyNotFound {
@@ -95,11 +110,7 @@ func (m *TransactionManager) GetRecord(id *record.ID) (record.Record, error) {
 	if err != nil {
 		return nil, err
 	}
-	raw, err := record.DecodeToRaw(buf)
-	if err != nil {
-		return nil, err
-	}
-	return raw.ToRecord(), nil
+	return record.DecodeToRaw(buf)
 }
 
 // SetRecord stores record in BadgerDB and returns *record.ID of new record.
@@ -218,6 +229,66 @@ func (m *TransactionManager) SetEntropy(pulse core.PulseNumber, entropy core.Ent
 	return m.txn.Set(k, entropy[:])
 }
 
+// eventkey returns key of the event, events are ordered by object, pulse, request
+// and position of the event in the request.
+func eventkey(ev *core.Event, pos uint32) []byte {
+	k := make([]byte, 0, 1+2*core.RecordRefSize+core.PulseNumberSize+4)
+	k = append(k, scopeIDEvent)
+	k = append(k, ev.Object[:]...)
+	k = append(k, ev.Pulse.Bytes()...)
+	k = append(k, ev.Request[:]...)
+	var p [4]byte
+	binary.BigEndian.PutUint32(p[:], pos)
+	return append(k, p[:]...)
+}
+
+// GetEvents returns events emitted by object in pulse range, both bounds are inclusive.
+//
+// If "to" is zero, events of all pulses starting from "from" are returned.
+func (m *TransactionManager) GetEvents(obj core.RecordRef, from, to core.PulseNumber) ([]core.Event, error) {
+	prefix := append([]byte{scopeIDEvent}, obj[:]...)
+	start := append(append([]byte{}, prefix...), from.Bytes()...)
+
+	it := m.txn.NewIterator(badger.DefaultIteratorOptions)
+	defer it.Close()
+
+	var events []core.Event
+	for it.Seek(start); it.ValidForPrefix(prefix); it.Next() {
+		item := it.Item()
+		pulse := core.Bytes2PulseNumber(item.Key()[len(prefix) : len(prefix)+core.PulseNumberSize])
+		if to != 0 && pulse > to {
+			break
+		}
+		buf, err := item.Value()
+		if err != nil {
+			return nil, err
+		}
+		var ev core.Event
+		err = codec.NewDecoderBytes(buf, &codec.CborHandle{}).Decode(&ev)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, ev)
+	}
+	return events, nil
+}
+
+// SetEvents stores events in order they were emitted.
+func (m *TransactionManager) SetEvents(events []core.Event) error {
+	for i := range events {
+		var buf []byte
+		err := codec.NewEncoderBytes(&buf, &codec.CborHandle{}).Encode(events[i])
+		if err != nil {
+			return err
+		}
+		err = m.txn.Set(eventkey(&events[i], uint32(i)), buf)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // Get returns value by key.
 func (m *TransactionManager) Get(key []byte) ([]byte, error) {
 	// var buf []byte
@@ -160,3 +160,8 @@ func Panicf(format string, args ...interface{}) {
 func SetOutput(w io.Writer) {
 	globalLogger.SetOutput(w)
 }
+
+// WithFields returns logger based on the global logger that adds fields to every message.
+func WithFields(fields map[string]interface{}) core.Logger {
+	return globalLogger.WithFields(fields)
+}
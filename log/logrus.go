@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"io"
 
+	"github.com/insolar/insolar/core"
 	"github.com/sirupsen/logrus"
 )
 
@@ -151,3 +152,8 @@ func (l logrusAdapter) GetLevel() string {
 func (l logrusAdapter) SetOutput(w io.Writer) {
 	l.entry.Logger.SetOutput(w)
 }
+
+// WithFields returns logger that adds fields to every message.
+func (l logrusAdapter) WithFields(fields map[string]interface{}) core.Logger {
+	return logrusAdapter{entry: l.entry.WithFields(fields), skipCallNumber: defaultSkipCallNumber}
+}
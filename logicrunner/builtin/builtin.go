@@ -18,10 +18,13 @@
 package builtin
 
 import (
+	"context"
+	"fmt"
 	"reflect"
+	"sort"
+	"sync"
 
 	"github.com/insolar/insolar/core"
-	"github.com/insolar/insolar/logicrunner/builtin/helloworld"
 	"github.com/pkg/errors"
 	"github.com/ugorji/go/codec"
 )
@@ -30,11 +33,125 @@ import (
 type Contract interface {
 }
 
+// Constructors maps names of contract's constructors to functions creating the contract
+type Constructors map[string]interface{}
+
+// Registration is a builtin contract registered with Register
+type Registration struct {
+	Contract     Contract // instance of the contract, its type is used to decode object's state
+	Constructors Constructors
+}
+
+// Registry keeps contracts by names, name of a contract is its code
+type Registry struct {
+	mu        sync.RWMutex
+	contracts map[string]Registration
+}
+
+// NewRegistry creates empty registry
+func NewRegistry() *Registry {
+	return &Registry{contracts: make(map[string]Registration)}
+}
+
+// Register adds contract to the registry, it panics if the name is already taken, the contract
+// isn't a pointer to struct or a constructor isn't a function returning the contract.
+func (r *Registry) Register(name string, c Contract, constructors Constructors) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.contracts[name]; ok {
+		panic("contract " + name + " is already registered")
+	}
+	ct := reflect.TypeOf(c)
+	if ct == nil || ct.Kind() != reflect.Ptr || ct.Elem().Kind() != reflect.Struct {
+		panic(fmt.Sprintf("contract %s must be a pointer to struct, got %T", name, c))
+	}
+	for cname, f := range constructors {
+		ft := reflect.TypeOf(f)
+		if ft == nil || ft.Kind() != reflect.Func || ft.NumOut() != 1 || ft.Out(0) != ct {
+			panic(fmt.Sprintf("constructor %s of contract %s must be a function returning %s", cname, name, ct))
+		}
+	}
+	r.contracts[name] = Registration{Contract: c, Constructors: constructors}
+}
+
+// Get returns contract by name
+func (r *Registry) Get(name string) (Registration, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	c, ok := r.contracts[name]
+	return c, ok
+}
+
+// Registered returns copy of the registry's contracts by names
+func (r *Registry) Registered() map[string]Registration {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	res := make(map[string]Registration, len(r.contracts))
+	for name, c := range r.contracts {
+		res[name] = c
+	}
+	return res
+}
+
+// registry keeps builtin contracts
+var registry = NewRegistry()
+
+// Register makes builtin contract available to executors, name of the contract is its code.
+// It's supposed to be called from init of the package implementing the contract:
+//
+//	func init() {
+//		builtin.Register("helloworld", &HelloWorld{}, builtin.Constructors{"NewHelloWorld": NewHelloWorld})
+//	}
+//
+// Register panics if the name is already taken or a constructor isn't a function returning the contract.
+func Register(name string, c Contract, constructors Constructors) {
+	registry.Register(name, c, constructors)
+}
+
+// Registered returns registered builtin contracts by names
+func Registered() map[string]Registration {
+	return registry.Registered()
+}
+
+// Deploy creates code records and classes of all registered builtin contracts, it's used by bootstrap
+// to make builtin contracts available for instantiation. Returns class references by contract names.
+func Deploy(ctx context.Context, am core.ArtifactManager, domain core.RecordRef) (map[string]core.RecordRef, error) {
+	registered := Registered()
+	names := make([]string, 0, len(registered))
+	for name := range registered {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	classes := make(map[string]core.RecordRef, len(names))
+	for _, name := range names {
+		// every contract needs its own request, otherwise records of classes are the same
+		request := *core.GenRequest(0, []byte(name))
+
+		codeRef, err := am.DeployCode(ctx, domain, request, map[core.MachineType][]byte{core.MachineTypeBuiltin: []byte(name)})
+		if err != nil {
+			return nil, errors.Wrapf(err, "couldn't deploy code of builtin contract %s", name)
+		}
+		classRef, err := am.ActivateClass(ctx, domain, request)
+		if err != nil {
+			return nil, errors.Wrapf(err, "couldn't activate class of builtin contract %s", name)
+		}
+		_, err = am.UpdateClass(ctx, domain, request, *classRef, *codeRef, nil)
+		if err != nil {
+			return nil, errors.Wrapf(err, "couldn't set code of builtin contract %s", name)
+		}
+		classes[name] = *classRef
+	}
+	return classes, nil
+}
+
 // BuiltIn is a contract runner engine
 type BuiltIn struct {
 	AM       core.ArtifactManager
 	EB       core.MessageBus
-	Registry map[string]Contract
+	Registry map[string]Registration
 }
 
 // NewBuiltIn is an constructor
@@ -42,67 +159,115 @@ func NewBuiltIn(eb core.MessageBus, am core.ArtifactManager) *BuiltIn {
 	bi := BuiltIn{
 		AM:       am,
 		EB:       eb,
-		Registry: make(map[string]Contract),
+		Registry: Registered(),
 	}
 
-	bi.Registry["helloworld"] = helloworld.NewHelloWorld()
-
 	return &bi
 }
 
-func (bi *BuiltIn) CallConstructor(ctx *core.LogicCallContext, code core.RecordRef, name string, args core.Arguments) (objectState []byte, err error) {
-	panic("implement me")
-}
-
 func (bi *BuiltIn) Stop() error {
 	return nil
 }
 
-// CallMethod runs a method on contract
-func (bi *BuiltIn) CallMethod(ctx *core.LogicCallContext, codeRef core.RecordRef, data []byte, method string, args core.Arguments) (newObjectState []byte, methodResults core.Arguments, err error) {
-	am := bi.AM
-	codeDescriptor, err := am.GetCode(codeRef, []core.MachineType{core.MachineTypeBuiltin})
+// registration returns builtin contract by reference to its code
+func (bi *BuiltIn) registration(ctx context.Context, codeRef core.RecordRef) (*Registration, error) {
+	codeDescriptor, err := bi.AM.GetCode(ctx, codeRef, []core.MachineType{core.MachineTypeBuiltin})
 	if err != nil {
-		return nil, nil, errors.Wrap(err, "Can't find code")
+		return nil, errors.Wrap(err, "Can't find code")
 	}
-	code := codeDescriptor.Code()
-	c, ok := bi.Registry[string(code)]
+	r, ok := bi.Registry[string(codeDescriptor.Code())]
 	if !ok {
-		return nil, nil, errors.New("Wrong reference for builtin contract")
+		return nil, errors.New("Wrong reference for builtin contract")
 	}
+	return &r, nil
+}
 
-	zv := reflect.New(reflect.TypeOf(c).Elem()).Interface()
-	ch := new(codec.CborHandle)
+// Call calls function with CBOR encoded arguments, arguments are decoded into values of the function's
+// parameter types, so wrong arguments are reported as an error instead of a panic
+func Call(f reflect.Value, args core.Arguments) ([]reflect.Value, error) {
+	inLen := f.Type().NumIn()
 
-	err = codec.NewDecoderBytes(data, ch).Decode(zv)
+	ch := new(codec.CborHandle)
+	var raw []interface{}
+	err := codec.NewDecoderBytes(args, ch).Decode(&raw)
 	if err != nil {
-		return nil, nil, errors.Wrapf(err, "couldn't decode data into %T", zv)
+		return nil, errors.Wrap(err, "couldn't unmarshal CBOR for arguments")
 	}
-
-	m := reflect.ValueOf(zv).MethodByName(method)
-	if !m.IsValid() {
-		return nil, nil, errors.New("no method " + method + " in the contract")
+	if len(raw) != inLen {
+		return nil, errors.Errorf("function takes %d arguments, %d given", inLen, len(raw))
 	}
 
-	inLen := m.Type().NumIn()
-
 	mask := make([]interface{}, inLen)
 	for i := 0; i < inLen; i++ {
-		argType := m.Type().In(i)
-		mask[i] = reflect.Zero(argType).Interface()
+		mask[i] = reflect.New(f.Type().In(i)).Interface()
 	}
-
 	err = codec.NewDecoderBytes(args, ch).Decode(&mask)
 	if err != nil {
-		return nil, nil, errors.Wrap(err, "couldn't unmarshal CBOR for arguments of the method")
+		return nil, errors.Wrap(err, "couldn't unmarshal CBOR for arguments")
 	}
 
 	in := make([]reflect.Value, inLen)
 	for i := 0; i < inLen; i++ {
-		in[i] = reflect.ValueOf(mask[i])
+		in[i] = reflect.ValueOf(mask[i]).Elem()
 	}
 
-	resValues := m.Call(in)
+	return f.Call(in), nil
+}
+
+// CallConstructor runs a constructor of contract and returns state of the created object
+func (bi *BuiltIn) CallConstructor(ctx *core.LogicCallContext, codeRef core.RecordRef, name string, args core.Arguments) (objectState []byte, err error) {
+	cctx, cancel := ctx.Context()
+	defer cancel()
+	r, err := bi.registration(cctx, codeRef)
+	if err != nil {
+		return nil, err
+	}
+
+	f, ok := r.Constructors[name]
+	if !ok {
+		return nil, errors.New("no constructor " + name + " in the contract")
+	}
+
+	resValues, err := Call(reflect.ValueOf(f), args)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't call constructor "+name)
+	}
+
+	ch := new(codec.CborHandle)
+	err = codec.NewEncoderBytes(&objectState, ch).Encode(resValues[0].Interface())
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't marshal new object data into cbor")
+	}
+
+	return objectState, nil
+}
+
+// CallMethod runs a method on contract
+func (bi *BuiltIn) CallMethod(ctx *core.LogicCallContext, codeRef core.RecordRef, data []byte, method string, args core.Arguments) (newObjectState []byte, methodResults core.Arguments, err error) {
+	cctx, cancel := ctx.Context()
+	defer cancel()
+	r, err := bi.registration(cctx, codeRef)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	zv := reflect.New(reflect.TypeOf(r.Contract).Elem()).Interface()
+	ch := new(codec.CborHandle)
+
+	err = codec.NewDecoderBytes(data, ch).Decode(zv)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "couldn't decode data into %T", zv)
+	}
+
+	m := reflect.ValueOf(zv).MethodByName(method)
+	if !m.IsValid() {
+		return nil, nil, errors.New("no method " + method + " in the contract")
+	}
+
+	resValues, err := Call(m, args)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "couldn't call method "+method)
+	}
 
 	err = codec.NewEncoderBytes(&newObjectState, ch).Encode(zv)
 	if err != nil {
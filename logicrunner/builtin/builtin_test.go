@@ -0,0 +1,53 @@
+/*
+ *    Copyright 2018 Insolar
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package builtin
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/ugorji/go/codec"
+
+	"github.com/insolar/insolar/core"
+)
+
+func cborArgs(t *testing.T, args ...interface{}) core.Arguments {
+	var buf []byte
+	err := codec.NewEncoderBytes(&buf, new(codec.CborHandle)).Encode(args)
+	assert.NoError(t, err)
+	return buf
+}
+
+func TestCall(t *testing.T) {
+	f := reflect.ValueOf(func(n uint32, name string, refs []core.RecordRef) string {
+		return name + string(refs[n][:1])
+	})
+
+	res, err := Call(f, cborArgs(t, 1, "ref", []core.RecordRef{{'a'}, {'b'}}))
+	assert.NoError(t, err)
+	assert.Equal(t, "refb", res[0].Interface())
+
+	_, err = Call(f, cborArgs(t, 1, "ref"))
+	assert.Error(t, err, "too few arguments")
+
+	_, err = Call(f, cborArgs(t, 1, "ref", nil, 4))
+	assert.Error(t, err, "too many arguments")
+
+	_, err = Call(f, cborArgs(t, "one", "ref", nil))
+	assert.Error(t, err, "argument of wrong type")
+}
@@ -16,7 +16,14 @@
 
 package helloworld
 
-import "github.com/insolar/insolar/core"
+import (
+	"github.com/insolar/insolar/core"
+	"github.com/insolar/insolar/logicrunner/builtin"
+)
+
+func init() {
+	builtin.Register("helloworld", &HelloWorld{}, builtin.Constructors{"NewHelloWorld": NewHelloWorld})
+}
 
 // HelloWorld contract
 type HelloWorld struct {
@@ -17,8 +17,10 @@
 package logicrunner
 
 import (
+	"context"
 	"testing"
 
+	"github.com/insolar/insolar/logicrunner/builtin"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/insolar/insolar/configuration"
@@ -26,6 +28,7 @@ import (
 	"github.com/insolar/insolar/core/message"
 	"github.com/insolar/insolar/core/reply"
 	"github.com/insolar/insolar/logicrunner/builtin/helloworld"
+	"github.com/insolar/insolar/testutils"
 
 	"github.com/insolar/insolar/ledger/ledgertestutil"
 	"github.com/insolar/insolar/logicrunner/goplugin/testutil"
@@ -44,7 +47,8 @@ func TestBareHelloworld(t *testing.T) {
 
 	am := l.GetArtifactManager()
 	lr, err := NewLogicRunner(&configuration.LogicRunner{
-		BuiltIn: &configuration.BuiltIn{},
+		PrivateKey: testutils.PrivateKey(),
+		BuiltIn:    &configuration.BuiltIn{},
 	})
 	assert.NoError(t, err, "Initialize runner")
 
@@ -63,12 +67,12 @@ func TestBareHelloworld(t *testing.T) {
 	_, _, classRef, err := testutil.AMPublishCode(t, am, domain, request, core.MachineTypeBuiltin, []byte("helloworld"))
 	assert.NoError(t, err)
 
-	contract, err := am.ActivateObject(request, domain, *classRef, *am.RootRef(), testutil.CBORMarshal(t, hw))
+	contract, err := am.ActivateObject(context.Background(), request, domain, *classRef, *am.RootRef(), testutil.CBORMarshal(t, hw))
 	assert.NoError(t, err)
 	assert.Equal(t, true, contract != nil, "contract created")
 
 	// #1
-	resp, err := lr.Execute(&message.CallMethod{
+	resp, err := lr.Execute(context.Background(), &message.CallMethod{
 		ObjectRef: *contract,
 		Method:    "Greet",
 		Arguments: testutil.CBORMarshal(t, []interface{}{"Vany"}),
@@ -81,7 +85,7 @@ func TestBareHelloworld(t *testing.T) {
 	assert.Equal(t, map[interface{}]interface{}(map[interface{}]interface{}{"Greeted": uint64(1)}), d)
 
 	// #2
-	resp, err = lr.Execute(&message.CallMethod{
+	resp, err = lr.Execute(context.Background(), &message.CallMethod{
 		ObjectRef: *contract,
 		Method:    "Greet",
 		Arguments: testutil.CBORMarshal(t, []interface{}{"Ruz"}),
@@ -93,3 +97,55 @@ func TestBareHelloworld(t *testing.T) {
 	assert.Equal(t, []interface{}([]interface{}{"Hello Ruz's world"}), r)
 	assert.Equal(t, map[interface{}]interface{}(map[interface{}]interface{}{"Greeted": uint64(2)}), d)
 }
+
+func TestBuiltinConstructor(t *testing.T) {
+	l, cleaner := ledgertestutil.TmpLedger(t, "")
+	defer cleaner()
+
+	am := l.GetArtifactManager()
+	lr, err := NewLogicRunner(&configuration.LogicRunner{
+		PrivateKey: testutils.PrivateKey(),
+		BuiltIn:    &configuration.BuiltIn{},
+	})
+	assert.NoError(t, err, "Initialize runner")
+
+	eb := &testMessageBus{lr}
+
+	assert.NoError(t, lr.Start(core.Components{
+		Ledger:     l,
+		MessageBus: eb,
+	}), "starting logicrunner")
+	lr.OnPulse(*pulsar.NewPulse(configuration.NewPulsar().NumberDelta, 0, &pulsar.StandardEntropyGenerator{}))
+
+	classes, err := builtin.Deploy(context.Background(), am, *am.RootRef())
+	assert.NoError(t, err)
+	classRef, ok := classes["helloworld"]
+	assert.True(t, ok, "registered contract is deployed")
+
+	resp, err := lr.Execute(context.Background(), &message.CallConstructor{
+		ClassRef:  classRef,
+		ParentRef: *am.RootRef(),
+		Name:      "NewHelloWorld",
+		Arguments: testutil.CBORMarshal(t, []interface{}{}),
+	})
+	assert.NoError(t, err, "constructor call")
+	contract := resp.(*reply.CallConstructor).Object
+	assert.NotNil(t, contract)
+
+	resp, err = lr.Execute(context.Background(), &message.CallMethod{
+		ObjectRef: *contract,
+		Method:    "Greet",
+		Arguments: testutil.CBORMarshal(t, []interface{}{"Vany"}),
+	})
+	assert.NoError(t, err, "contract call")
+	r := testutil.CBORUnMarshal(t, resp.(*reply.CallMethod).Result)
+	assert.Equal(t, []interface{}{"Hello Vany's world"}, r)
+
+	_, err = lr.Execute(context.Background(), &message.CallConstructor{
+		ClassRef:  classRef,
+		ParentRef: *am.RootRef(),
+		Name:      "NoSuchConstructor",
+		Arguments: testutil.CBORMarshal(t, []interface{}{}),
+	})
+	assert.Error(t, err)
+}
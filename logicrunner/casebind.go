@@ -19,6 +19,8 @@
 package logicrunner
 
 import (
+	"context"
+
 	"github.com/insolar/insolar/core"
 	"github.com/pkg/errors"
 	"github.com/ugorji/go/codec"
@@ -36,10 +38,122 @@ func HashInterface(in interface{}) []byte {
 	return sh.Sum(s)
 }
 
+// resultsHash returns hash of results of validated calls
+func resultsHash(replies []core.Reply) []byte {
+	s := []byte{}
+	ch := new(codec.CborHandle)
+	err := codec.NewEncoderBytes(&s, ch).Encode(replies)
+	if err != nil {
+		panic("Can't marshal: " + err.Error())
+	}
+	h := sha3.Sum224(s)
+	return h[:]
+}
+
+// startRecord returns case record of the call start, its ReqSig is the reference of the registered request
+// and Base is the state of the object the call is executed on, nil for constructors
+func startRecord(lctx core.LogicCallContext, msg core.Message, base *core.RecordID) core.CaseRecord {
+	cr := core.CaseRecord{
+		Type: core.CaseRecordTypeStart,
+		Resp: msg,
+		Base: base,
+	}
+	if lctx.Request != nil {
+		cr.ReqSig = lctx.Request[:]
+	}
+	return cr
+}
+
+// startRequest returns reference of the request stored in the start record, nil if there is no such
+func startRequest(cr core.CaseRecord) *core.RecordRef {
+	if cr.Type != core.CaseRecordTypeStart || len(cr.ReqSig) != core.RecordRefSize {
+		return nil
+	}
+	var request core.RecordRef
+	copy(request[:], cr.ReqSig)
+	return &request
+}
+
 func (lr *LogicRunner) addObjectCaseRecord(ref core.RecordRef, cr core.CaseRecord) {
 	lr.caseBindMutex.Lock()
 	lr.caseBind.Records[ref] = append(lr.caseBind.Records[ref], cr)
+	for _, c := range lr.caseCaptures[ref] {
+		*c = append(*c, cr)
+	}
+	lr.caseBindMutex.Unlock()
+}
+
+// dropCaseRecords removes records of the failed call starting with its start record, failed calls change nothing
+// so there is nothing to validate. Records are kept if the CaseBind of the call's pulse is already finished.
+func (lr *LogicRunner) dropCaseRecords(ref core.RecordRef, start core.CaseRecord) {
+	lr.caseBindMutex.Lock()
+	defer lr.caseBindMutex.Unlock()
+	lr.caseBind.Records[ref] = truncateAtStart(lr.caseBind.Records[ref], start)
+	if len(lr.caseBind.Records[ref]) == 0 {
+		delete(lr.caseBind.Records, ref)
+	}
+	for _, c := range lr.caseCaptures[ref] {
+		*c = truncateAtStart(*c, start)
+	}
+}
+
+// truncateAtStart cuts records at the start record of the call, records are returned as is if there is no such
+func truncateAtStart(records []core.CaseRecord, start core.CaseRecord) []core.CaseRecord {
+	for i := len(records) - 1; i >= 0; i-- {
+		if records[i].Type == core.CaseRecordTypeStart && records[i].Resp == start.Resp {
+			return records[:i]
+		}
+	}
+	return records
+}
+
+// captureCaseRecords starts collecting case records of the object apart from the CaseBind,
+// returned function stops collecting and returns records collected so far
+func (lr *LogicRunner) captureCaseRecords(ref core.RecordRef) func() []core.CaseRecord {
+	records := &[]core.CaseRecord{}
+	lr.caseBindMutex.Lock()
+	lr.caseCaptures[ref] = append(lr.caseCaptures[ref], records)
 	lr.caseBindMutex.Unlock()
+
+	return func() []core.CaseRecord {
+		lr.caseBindMutex.Lock()
+		defer lr.caseBindMutex.Unlock()
+		captures := lr.caseCaptures[ref]
+		for i, c := range captures {
+			if c == records {
+				captures = append(captures[:i], captures[i+1:]...)
+				break
+			}
+		}
+		if len(captures) == 0 {
+			delete(lr.caseCaptures, ref)
+		} else {
+			lr.caseCaptures[ref] = captures
+		}
+		return *records
+	}
+}
+
+// emittedEvents returns events emitted by the object in the call in order of emission. Records of concurrent
+// constructors of a class are mixed, so only events emitted in the chain of the call are returned.
+func emittedEvents(records []core.CaseRecord, obj core.RecordRef, lctx core.LogicCallContext) []core.Event {
+	var events []core.Event
+	for _, cr := range records {
+		if cr.Type != core.CaseRecordTypeEmitEvent {
+			continue
+		}
+		ev := cr.Resp.(core.Event)
+		if lctx.Chain != nil && ev.Request != *lctx.Chain {
+			continue
+		}
+		ev.Object = obj
+		ev.Pulse = lctx.Pulse.PulseNumber
+		if lctx.Request != nil {
+			ev.Request = *lctx.Request
+		}
+		events = append(events, ev)
+	}
+	return events
 }
 
 func (lr *LogicRunner) getNextValidationStep(ref core.RecordRef) (*core.CaseRecord, int) {
@@ -58,8 +172,14 @@ func (lr *LogicRunner) getNextValidationStep(ref core.RecordRef) (*core.CaseReco
 }
 
 func (lr *LogicRunner) Validate(ref core.RecordRef, p core.Pulse, cr []core.CaseRecord) (int, error) {
+	step, _, err := lr.validate(context.Background(), ref, p, cr)
+	return step, err
+}
+
+// validate replays case records of the object and returns number of passed steps and results of replayed calls
+func (lr *LogicRunner) validate(ctx context.Context, ref core.RecordRef, p core.Pulse, cr []core.CaseRecord) (int, []core.Reply, error) {
 	if len(cr) < 1 {
-		return 0, errors.New("casebind is empty")
+		return 0, nil, errors.New("casebind is empty")
 	}
 
 	err := func() error {
@@ -77,7 +197,7 @@ func (lr *LogicRunner) Validate(ref core.RecordRef, p core.Pulse, cr []core.Case
 		return nil
 	}()
 	if err != nil {
-		return 0, err
+		return 0, nil, err
 	}
 
 	defer func() {
@@ -86,26 +206,29 @@ func (lr *LogicRunner) Validate(ref core.RecordRef, p core.Pulse, cr []core.Case
 		delete(lr.caseBindReplays, ref)
 	}()
 
+	var results []core.Reply
 	for {
 		start, step := lr.getNextValidationStep(ref)
 		if step < 0 {
-			return step, errors.New("no validation data")
+			return step, results, errors.New("no validation data")
 		} else if start == nil { // finish
-			return step, nil
+			return step, results, nil
 		}
 		if start.Type != core.CaseRecordTypeStart {
-			return step, errors.New("step between two shores")
+			return step, results, errors.New("step between two shores")
 		}
 
 		msg := start.Resp.(core.Message)
-		if _, err := lr.Execute(msg); err != nil {
-			return 0, errors.Wrap(err, "validation step failed")
+		re, err := lr.Execute(ctx, msg)
+		if err != nil {
+			return 0, results, errors.Wrap(err, "validation step failed")
 		}
+		results = append(results, re)
 
 		if stop, step := lr.getNextValidationStep(ref); step < 0 {
-			return 0, errors.New("validation container broken")
+			return 0, results, errors.New("validation container broken")
 		} else if stop.Type != core.CaseRecordTypeResult {
-			return step, errors.New("Validation stoped not on result")
+			return step, results, errors.New("Validation stoped not on result")
 		}
 	}
 }
@@ -114,7 +237,11 @@ func (lr *LogicRunner) Validate(ref core.RecordRef, p core.Pulse, cr []core.Case
 type ValidationBehaviour interface {
 	Begin(refs core.RecordRef, record core.CaseRecord)
 	End(refs core.RecordRef, record core.CaseRecord)
+	// Drop forgets records of the call that failed before End
+	Drop(refs core.RecordRef, start core.CaseRecord)
 	ModifyContext(ctx *core.LogicCallContext)
+	// BaseState returns state of the object the call must start from, nil means the latest one
+	BaseState() *core.RecordID
 	NeedSave() bool
 }
 
@@ -138,6 +265,14 @@ func (vb ValidationSaver) End(refs core.RecordRef, record core.CaseRecord) {
 	vb.lr.addObjectCaseRecord(refs, record)
 }
 
+func (vb ValidationSaver) Drop(refs core.RecordRef, start core.CaseRecord) {
+	vb.lr.dropCaseRecords(refs, start)
+}
+
+func (vb ValidationSaver) BaseState() *core.RecordID {
+	return nil
+}
+
 type ValidationChecker struct {
 	lr *LogicRunner
 	cb core.CaseBindReplay
@@ -149,6 +284,10 @@ func (vb ValidationChecker) NeedSave() bool {
 
 func (vb ValidationChecker) ModifyContext(ctx *core.LogicCallContext) {
 	ctx.Pulse = vb.cb.Pulse
+	ctx.Time = vb.cb.Pulse.PulseNumber.Time()
+	if vb.cb.Step > 0 {
+		ctx.Request = startRequest(vb.cb.Records[vb.cb.Step-1])
+	}
 }
 
 func (vb ValidationChecker) Begin(refs core.RecordRef, record core.CaseRecord) {
@@ -158,3 +297,16 @@ func (vb ValidationChecker) Begin(refs core.RecordRef, record core.CaseRecord) {
 func (vb ValidationChecker) End(refs core.RecordRef, record core.CaseRecord) {
 	// do nothing, everything done in lr.Validate
 }
+
+func (vb ValidationChecker) Drop(refs core.RecordRef, start core.CaseRecord) {
+	// do nothing, failure is reported by lr.Validate
+}
+
+// BaseState returns state recorded by the executor, so the call is replayed on the same state
+// regardless of changes made after it
+func (vb ValidationChecker) BaseState() *core.RecordID {
+	if vb.cb.Step > 0 {
+		return vb.cb.Records[vb.cb.Step-1].Base
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+/*
+ *    Copyright 2018 Insolar
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package contracttest
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/insolar/insolar/core"
+)
+
+// bus is a message bus of a single node, messages are passed to handlers without serialization
+type bus struct {
+	mu       sync.RWMutex
+	handlers map[core.MessageType]core.MessageHandler
+}
+
+func newBus() *bus {
+	return &bus{handlers: make(map[core.MessageType]core.MessageHandler)}
+}
+
+// Send passes message to its handler and returns the reply
+func (b *bus) Send(ctx context.Context, msg core.Message) (core.Reply, error) {
+	b.mu.RLock()
+	handler, ok := b.handlers[msg.Type()]
+	b.mu.RUnlock()
+	if !ok {
+		return nil, errors.Errorf("no handler for message type %s", msg.Type())
+	}
+	return handler(ctx, msg)
+}
+
+// SendAsync passes calls nobody waits for to their handler in background, other asynchronous messages
+// are sent to validators and there are none, so they are dropped
+func (b *bus) SendAsync(msg core.Message) {
+	if msg.Type() != core.TypeCallMethod {
+		return
+	}
+	b.mu.RLock()
+	handler, ok := b.handlers[msg.Type()]
+	b.mu.RUnlock()
+	if !ok {
+		return
+	}
+	go handler(context.Background(), msg) // nolint: errcheck
+}
+
+// Register saves message handler in the registry
+func (b *bus) Register(p core.MessageType, handler core.MessageHandler) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.handlers[p]; ok {
+		return errors.Errorf("handler for message type %s already exists", p)
+	}
+	b.handlers[p] = handler
+	return nil
+}
+
+// MustRegister is a Register wrapper that panics if an error was returned
+func (b *bus) MustRegister(p core.MessageType, handler core.MessageHandler) {
+	if err := b.Register(p, handler); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,288 @@
+/*
+ *    Copyright 2018 Insolar
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+// Package contracttest runs contracts in-process for their unit tests. Contracts are compiled
+// into the test binary, so no plugins are built and no runners are started, and their calls are
+// executed by LogicRunner on top of ledger in temporary directory:
+//
+//	func TestWallet(t *testing.T) {
+//		h := contracttest.New(t)
+//		defer h.Stop()
+//
+//		class := h.Deploy("wallet", &Wallet{}, contracttest.Constructors{"New": New})
+//		w := h.New(class, "New", uint(1000))
+//
+//		var balance uint
+//		h.Call(w, "GetBalance").Scan(&balance)
+//		h.AssertState(w, &Wallet{Balance: 1000})
+//		h.NextPulse()
+//	}
+//
+// Contracts call each other through proxies as usual, ClassReference of the proxy should be set
+// to the class deployed in the harness. Proxies reach the harness through global proxyctx.Current,
+// so calls of harnesses of parallel tests take turns.
+package contracttest
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/insolar/insolar/configuration"
+	"github.com/insolar/insolar/core"
+	"github.com/insolar/insolar/core/message"
+	"github.com/insolar/insolar/core/reply"
+	"github.com/insolar/insolar/ledger/ledgertestutil"
+	"github.com/insolar/insolar/logicrunner"
+	"github.com/insolar/insolar/logicrunner/goplugin/ginsider"
+	"github.com/insolar/insolar/logicrunner/goplugin/proxyctx"
+	"github.com/insolar/insolar/pulsar"
+)
+
+// callMutex is held while harness makes a call, proxyctx.Current is set to the harness for the call
+var callMutex sync.Mutex
+
+// Harness deploys contracts and calls them in-process
+type Harness struct {
+	t testing.TB
+
+	Ledger          core.Ledger
+	ArtifactManager core.ArtifactManager
+	LogicRunner     *logicrunner.LogicRunner
+
+	bus      *bus
+	helper   *helper
+	executor *executor
+	pulse    core.Pulse
+	cleaner  func()
+	stopped  bool
+}
+
+// New creates harness and starts the first pulse, database of the ledger is kept
+// in memory (tmpfs) when the system has one
+func New(t testing.TB) *Harness {
+	h := &Harness{t: t, bus: newBus()}
+	l, cleaner := ledgertestutil.TmpLedgerOnBus(t, memDir(), h.bus)
+	h.Ledger = l
+	h.ArtifactManager = l.GetArtifactManager()
+	h.cleaner = cleaner
+
+	lr, err := logicrunner.NewLogicRunner(&configuration.LogicRunner{})
+	h.check(err)
+	h.check(lr.Start(core.Components{Ledger: l, MessageBus: h.bus}))
+	h.LogicRunner = lr
+
+	h.helper = &helper{gi: &ginsider.GoInsider{}, rpc: logicrunner.NewRPC(lr)}
+	h.executor = newExecutor(h.ArtifactManager, h.helper)
+	h.check(lr.RegisterExecutor(core.MachineTypeGoPlugin, h.executor))
+
+	pulse, err := l.GetPulseManager().Current()
+	h.check(err)
+	h.pulse = *pulse
+	h.NextPulse()
+
+	return h
+}
+
+// Stop stops logic runner and removes the ledger
+func (h *Harness) Stop() {
+	if h.stopped {
+		return
+	}
+	h.stopped = true
+
+	if h.LogicRunner != nil {
+		if err := h.LogicRunner.Stop(); err != nil {
+			h.t.Error(err)
+		}
+	}
+	h.cleaner()
+}
+
+// check stops the harness and fails the test on error
+func (h *Harness) check(err error) {
+	if err == nil {
+		return
+	}
+	h.Stop()
+	h.t.Fatal(err)
+}
+
+// Pulse returns current pulse
+func (h *Harness) Pulse() core.Pulse {
+	return h.pulse
+}
+
+// NextPulse starts new pulse in the ledger and the logic runner, calls of the finished pulse
+// are sent to validation
+func (h *Harness) NextPulse() core.Pulse {
+	p := pulsar.NewPulse(1, h.pulse.PulseNumber, &pulsar.StandardEntropyGenerator{})
+	h.check(h.Ledger.GetPulseManager().Set(*p))
+	h.check(h.LogicRunner.OnPulse(*p))
+	h.pulse = *p
+	return *p
+}
+
+// Deploy makes contract available for instantiation and returns reference to its class.
+// Contract is a pointer to the contract's struct, constructors are functions returning such pointer.
+func (h *Harness) Deploy(name string, contract interface{}, constructors Constructors) core.RecordRef {
+	h.executor.register(name, contract, constructors)
+
+	ctx := context.Background()
+	domain := *h.ArtifactManager.RootRef()
+	request := *core.GenRequest(h.pulse.PulseNumber, []byte(name))
+
+	code, err := h.ArtifactManager.DeployCode(ctx, domain, request, map[core.MachineType][]byte{core.MachineTypeGoPlugin: []byte(name)})
+	h.check(err)
+	class, err := h.ArtifactManager.ActivateClass(ctx, domain, request)
+	h.check(err)
+	_, err = h.ArtifactManager.UpdateClass(ctx, domain, request, *class, *code, nil)
+	h.check(err)
+	return *class
+}
+
+// New creates object of the class with the constructor as a child of the root object
+func (h *Harness) New(class core.RecordRef, constructor string, args ...interface{}) core.RecordRef {
+	res, err := h.send(&message.CallConstructor{
+		ParentRef: *h.ArtifactManager.RootRef(),
+		SaveAs:    message.Child,
+		ClassRef:  class,
+		Name:      constructor,
+		Arguments: h.serialize(args),
+		PulseNum:  h.pulse.PulseNumber,
+	})
+	h.check(err)
+	return *res.(*reply.CallConstructor).Object
+}
+
+// Result is a result of the call made by harness
+type Result struct {
+	h    *Harness
+	Data []byte // serialized list of values returned by the method
+	Err  error  // error of the call itself, e.g. panic of the contract or exceeded limit
+}
+
+// Call calls method of the object
+func (h *Harness) Call(obj core.RecordRef, method string, args ...interface{}) *Result {
+	res, err := h.send(&message.CallMethod{
+		ReturnMode: message.ReturnResult,
+		ObjectRef:  obj,
+		Method:     method,
+		Arguments:  h.serialize(args),
+	})
+	if err != nil {
+		return &Result{h: h, Err: err}
+	}
+	return &Result{h: h, Data: res.(*reply.CallMethod).Result}
+}
+
+// Scan decodes returned values into provided pointers, errors are decoded into *foundation.Error.
+// It fails the test if the call failed.
+func (r *Result) Scan(into ...interface{}) {
+	if r.Err != nil {
+		r.h.t.Fatalf("call failed: %v", r.Err)
+	}
+	err := r.h.helper.Deserialize(r.Data, &into)
+	if err != nil {
+		r.h.t.Fatalf("couldn't decode results: %v", err)
+	}
+}
+
+// State decodes the latest memory of the object into the value
+func (h *Harness) State(obj core.RecordRef, into interface{}) {
+	desc, err := h.ArtifactManager.GetObject(context.Background(), obj, nil)
+	h.check(err)
+	h.check(h.helper.Deserialize(desc.Memory(), into))
+}
+
+// AssertState checks that the latest memory of the object equals to expected,
+// expected is a pointer to the contract's struct
+func (h *Harness) AssertState(obj core.RecordRef, expected interface{}) bool {
+	actual := reflect.New(reflect.TypeOf(expected).Elem()).Interface()
+	h.State(obj, actual)
+	return assert.Equal(h.t, expected, actual)
+}
+
+// Children returns references to children of the object
+func (h *Harness) Children(obj core.RecordRef) []core.RecordRef {
+	it, err := h.ArtifactManager.GetChildren(context.Background(), obj, nil)
+	h.check(err)
+
+	var res []core.RecordRef
+	for it.HasNext() {
+		ref, err := it.Next()
+		h.check(err)
+		res = append(res, *ref)
+	}
+	return res
+}
+
+// Events returns events emitted by the object
+func (h *Harness) Events(obj core.RecordRef) []core.Event {
+	events, err := h.ArtifactManager.GetEvents(context.Background(), obj, 0, 0)
+	h.check(err)
+	return events
+}
+
+// AssertEmitted checks that the object emitted event with the name and the payload
+func (h *Harness) AssertEmitted(obj core.RecordRef, name string, payload interface{}) bool {
+	data := h.serialize(payload)
+	for _, ev := range h.Events(obj) {
+		if ev.Name == name && bytes.Equal(ev.Payload, data) {
+			return true
+		}
+	}
+	return assert.Fail(h.t, fmt.Sprintf("object %s didn't emit event %s with payload %+v", obj, name, payload))
+}
+
+func (h *Harness) send(msg core.Message) (core.Reply, error) {
+	callMutex.Lock()
+	proxyctx.Current = h.helper
+	defer func() {
+		proxyctx.Current = nil
+		callMutex.Unlock()
+	}()
+
+	res, err := h.bus.Send(context.Background(), msg)
+	if err != nil {
+		return nil, err
+	}
+	if le, ok := res.(*reply.LimitExceeded); ok {
+		return nil, &le.LimitExceededError
+	}
+	return res, nil
+}
+
+func (h *Harness) serialize(v interface{}) []byte {
+	var data []byte
+	h.check(h.helper.Serialize(v, &data))
+	return data
+}
+
+// memDir returns directory of in-memory file system, empty string
+// (default directory for temporary files) if there is none
+func memDir() string {
+	if info, err := os.Stat("/dev/shm"); err == nil && info.IsDir() {
+		return "/dev/shm"
+	}
+	return ""
+}
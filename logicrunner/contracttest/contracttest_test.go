@@ -0,0 +1,258 @@
+/*
+ *    Copyright 2018 Insolar
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package contracttest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/insolar/insolar/core"
+	"github.com/insolar/insolar/logicrunner/goplugin/foundation"
+	"github.com/insolar/insolar/logicrunner/goplugin/proxyctx"
+)
+
+type Counter struct {
+	foundation.BaseContract
+	Value int
+}
+
+func NewCounter(start int) *Counter {
+	return &Counter{Value: start}
+}
+
+func (c *Counter) Inc(by int) (int, error) {
+	if by <= 0 {
+		return c.Value, errors.New("counter can only grow")
+	}
+	c.Value += by
+	return c.Value, c.EmitEvent("inc", by)
+}
+
+func (c *Counter) Get() int {
+	return c.Value
+}
+
+func (c *Counter) Crash() {
+	panic("crash")
+}
+
+func TestHarness(t *testing.T) {
+	h := New(t)
+	defer h.Stop()
+
+	class := h.Deploy("counter", &Counter{}, Constructors{"NewCounter": NewCounter})
+	obj := h.New(class, "NewCounter", 10)
+	assert.Contains(t, h.Children(*h.ArtifactManager.RootRef()), obj)
+
+	var value int
+	var ferr *foundation.Error
+	h.Call(obj, "Inc", 5).Scan(&value, &ferr)
+	assert.Equal(t, 15, value)
+	assert.Nil(t, ferr)
+	h.AssertState(obj, &Counter{Value: 15})
+	h.AssertEmitted(obj, "inc", 5)
+
+	h.NextPulse()
+	h.Call(obj, "Inc", -1).Scan(&value, &ferr)
+	assert.Equal(t, 15, value)
+	assert.EqualError(t, ferr, "counter can only grow")
+
+	h.Call(obj, "Get").Scan(&value)
+	assert.Equal(t, 15, value)
+
+	res := h.Call(obj, "Crash")
+	assert.Error(t, res.Err)
+	h.AssertState(obj, &Counter{Value: 15})
+}
+
+// Pair changes two counters in one call, the way generated proxies call other objects
+type Pair struct {
+	foundation.BaseContract
+	First, Second core.RecordRef
+}
+
+func NewPair(first, second core.RecordRef) *Pair {
+	return &Pair{First: first, Second: second}
+}
+
+func (p *Pair) Call(firstMethod, secondMethod string) error {
+	for _, c := range []struct {
+		obj    core.RecordRef
+		method string
+	}{{p.First, firstMethod}, {p.Second, secondMethod}} {
+		var args []byte
+		err := proxyctx.Current.Serialize([]interface{}{1}, &args)
+		if err != nil {
+			return err
+		}
+		_, err = proxyctx.Current.RouteCall(c.obj, true, c.method, args)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// meanwhile is called by Pair.CallMeanwhile after the nested calls, tests use it to change objects
+// concurrently with the call chain
+var meanwhile func()
+
+func (p *Pair) CallMeanwhile(firstMethod, secondMethod string) error {
+	err := p.Call(firstMethod, secondMethod)
+	if err != nil {
+		return err
+	}
+	meanwhile()
+	return nil
+}
+
+func TestNestedCallsAreAtomic(t *testing.T) {
+	h := New(t)
+	defer h.Stop()
+
+	counter := h.Deploy("counter", &Counter{}, Constructors{"NewCounter": NewCounter})
+	first := h.New(counter, "NewCounter", 10)
+	second := h.New(counter, "NewCounter", 20)
+	pair := h.New(h.Deploy("pair", &Pair{}, Constructors{"NewPair": NewPair}), "NewPair", first, second)
+
+	// the second call fails after the first one changed its counter, so nothing is changed
+	res := h.Call(pair, "Call", "Inc", "Crash")
+	assert.Error(t, res.Err)
+	h.AssertState(first, &Counter{Value: 10})
+	h.AssertState(second, &Counter{Value: 20})
+	assert.Empty(t, h.Events(first))
+
+	var ferr *foundation.Error
+	h.Call(pair, "Call", "Inc", "Inc").Scan(&ferr)
+	assert.Nil(t, ferr)
+	h.AssertState(first, &Counter{Value: 11})
+	h.AssertState(second, &Counter{Value: 21})
+	h.AssertEmitted(second, "inc", 1)
+}
+
+func TestConcurrentChangeFailsChain(t *testing.T) {
+	h := New(t)
+	defer h.Stop()
+
+	counter := h.Deploy("counter", &Counter{}, Constructors{"NewCounter": NewCounter})
+	first := h.New(counter, "NewCounter", 10)
+	second := h.New(counter, "NewCounter", 20)
+	pair := h.New(h.Deploy("pair", &Pair{}, Constructors{"NewPair": NewPair}), "NewPair", first, second)
+
+	// the first counter is changed by other chain after the chain read it, so changes of the chain are rejected
+	meanwhile = func() {
+		request := *core.GenRequest(h.Pulse().PulseNumber, []byte("meanwhile"))
+		_, err := h.ArtifactManager.UpdateObject(
+			context.Background(), *h.ArtifactManager.RootRef(), request, first, h.serialize(&Counter{Value: 100}),
+		)
+		assert.NoError(t, err)
+	}
+	defer func() { meanwhile = nil }()
+
+	res := h.Call(pair, "CallMeanwhile", "Inc", "Inc")
+	assert.Error(t, res.Err)
+	h.AssertState(first, &Counter{Value: 100})
+	h.AssertState(second, &Counter{Value: 20})
+	assert.Empty(t, h.Events(second))
+}
+
+// Factory creates counters as its children and changes them in the same call
+type Factory struct {
+	foundation.BaseContract
+	Class core.RecordRef
+}
+
+func NewFactory(class core.RecordRef) *Factory {
+	return &Factory{Class: class}
+}
+
+// Make creates a counter and increments it, the counter panics after that if crash is set.
+// It returns the number of counters of the factory the call sees.
+func (f *Factory) Make(crash bool) (int, error) {
+	var args []byte
+	err := proxyctx.Current.Serialize([]interface{}{10}, &args)
+	if err != nil {
+		return 0, err
+	}
+	counter, err := proxyctx.Current.SaveAsChild(f.GetReference(), f.Class, "NewCounter", args)
+	if err != nil {
+		return 0, err
+	}
+
+	err = proxyctx.Current.Serialize([]interface{}{1}, &args)
+	if err != nil {
+		return 0, err
+	}
+	_, err = proxyctx.Current.RouteCall(counter, true, "Inc", args)
+	if err != nil {
+		return 0, err
+	}
+	if crash {
+		err = proxyctx.Current.Serialize([]interface{}{}, &args)
+		if err != nil {
+			return 0, err
+		}
+		_, err = proxyctx.Current.RouteCall(counter, true, "Crash", args)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	counters, err := f.GetChildrenTyped(f.Class)
+	return len(counters), err
+}
+
+func TestNestedCreationIsAtomic(t *testing.T) {
+	h := New(t)
+	defer h.Stop()
+
+	counter := h.Deploy("counter", &Counter{}, Constructors{"NewCounter": NewCounter})
+	factory := h.New(h.Deploy("factory", &Factory{}, Constructors{"NewFactory": NewFactory}), "NewFactory", counter)
+
+	// the counter is created and changed, then the chain fails, so the counter is never activated
+	res := h.Call(factory, "Make", true)
+	assert.Error(t, res.Err)
+	assert.Empty(t, h.Children(factory))
+
+	var n int
+	var ferr *foundation.Error
+	h.Call(factory, "Make", false).Scan(&n, &ferr)
+	assert.Nil(t, ferr)
+	assert.Equal(t, 1, n) // the chain sees the counter it created
+	counters := h.Children(factory)
+	if assert.Len(t, counters, 1) {
+		h.AssertState(counters[0], &Counter{Value: 11})
+		h.AssertEmitted(counters[0], "inc", 1)
+	}
+}
+
+func TestHarnessesDontBlockEachOther(t *testing.T) {
+	// a harness that isn't stopped (e.g. the test failed before deferred Stop) doesn't block others
+	first := New(t)
+	second := New(t)
+	defer second.Stop()
+
+	obj := second.New(second.Deploy("counter", &Counter{}, Constructors{"NewCounter": NewCounter}), "NewCounter", 1)
+	var value int
+	second.Call(obj, "Get").Scan(&value)
+	assert.Equal(t, 1, value)
+
+	first.Stop()
+}
@@ -0,0 +1,167 @@
+/*
+ *    Copyright 2018 Insolar
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package contracttest
+
+import (
+	"reflect"
+
+	"github.com/pkg/errors"
+	"github.com/tylerb/gls"
+
+	"github.com/insolar/insolar/core"
+	"github.com/insolar/insolar/logicrunner/builtin"
+)
+
+// Constructors maps names of contract's constructors to functions creating the contract
+type Constructors map[string]interface{}
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// executor runs contracts compiled into the test binary the way wrappers generated by insgocc do,
+// code of the contract in the ledger is the name it's registered with
+type executor struct {
+	am       core.ArtifactManager
+	helper   *helper
+	registry *builtin.Registry
+}
+
+func newExecutor(am core.ArtifactManager, h *helper) *executor {
+	return &executor{
+		am:       am,
+		helper:   h,
+		registry: builtin.NewRegistry(),
+	}
+}
+
+// register adds contract to the executor, it panics if the name is taken or a constructor
+// isn't a function returning the contract
+func (e *executor) register(name string, c interface{}, constructors Constructors) {
+	e.registry.Register(name, c, builtin.Constructors(constructors))
+}
+
+func (e *executor) contract(ctx *core.LogicCallContext, code core.RecordRef) (*builtin.Registration, error) {
+	cctx, cancel := ctx.Context()
+	defer cancel()
+	desc, err := e.am.GetCode(cctx, code, []core.MachineType{core.MachineTypeGoPlugin})
+	if err != nil {
+		return nil, errors.Wrap(err, "can't find code")
+	}
+
+	c, ok := e.registry.Get(string(desc.Code()))
+	if !ok {
+		return nil, errors.Errorf("contract %q isn't deployed in the harness", desc.Code())
+	}
+	return &c, nil
+}
+
+// CallMethod runs a method on contract
+func (e *executor) CallMethod(ctx *core.LogicCallContext, code core.RecordRef, data []byte, method string, args core.Arguments) ([]byte, core.Arguments, error) {
+	c, err := e.contract(ctx, code)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	typ := reflect.TypeOf(c.Contract)
+	self := reflect.New(typ.Elem())
+	err = e.helper.Deserialize(data, self.Interface())
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "couldn't decode data into %s", typ)
+	}
+
+	m := self.MethodByName(method)
+	if !m.IsValid() {
+		return nil, nil, errors.Errorf("no method %s in %s", method, typ)
+	}
+
+	var state, result []byte
+	err = run(ctx, func() error {
+		out, err := builtin.Call(m, args)
+		if err != nil {
+			return err
+		}
+
+		res := make([]interface{}, len(out))
+		for i, v := range out {
+			res[i] = v.Interface()
+			if m.Type().Out(i) == errorType && !v.IsNil() {
+				res[i] = e.helper.MakeErrorSerializable(v.Interface().(error))
+			}
+		}
+
+		err = e.helper.Serialize(self.Interface(), &state)
+		if err != nil {
+			return errors.Wrap(err, "couldn't serialize state")
+		}
+		return e.helper.Serialize(res, &result)
+	})
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "call of %s.%s failed", typ, method)
+	}
+
+	return state, result, nil
+}
+
+// CallConstructor runs a constructor of contract and returns state of the created object
+func (e *executor) CallConstructor(ctx *core.LogicCallContext, code core.RecordRef, name string, args core.Arguments) ([]byte, error) {
+	c, err := e.contract(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+
+	typ := reflect.TypeOf(c.Contract)
+	f, ok := c.Constructors[name]
+	if !ok {
+		return nil, errors.Errorf("no constructor %s of %s", name, typ)
+	}
+
+	var state []byte
+	err = run(ctx, func() error {
+		out, err := builtin.Call(reflect.ValueOf(f), args)
+		if err != nil {
+			return err
+		}
+		return e.helper.Serialize(out[0].Interface(), &state)
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "constructor %s of %s failed", name, typ)
+	}
+
+	return state, nil
+}
+
+// Stop implementation of core.MachineLogicExecutor
+func (e *executor) Stop() error {
+	return nil
+}
+
+// run runs f in its own goroutine with the call context set, the way runner does it, so calls
+// to other contracts made by f don't replace context of the caller. Panic of the contract is
+// returned as error.
+func run(ctx *core.LogicCallContext, f func() error) error {
+	done := make(chan error, 1)
+	go func() {
+		defer gls.Cleanup()
+		defer func() {
+			if r := recover(); r != nil {
+				done <- errors.Errorf("contract panicked: %v", r)
+			}
+		}()
+		gls.Set("ctx", ctx)
+		done <- f()
+	}()
+	return <-done
+}
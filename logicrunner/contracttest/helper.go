@@ -0,0 +1,137 @@
+/*
+ *    Copyright 2018 Insolar
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package contracttest
+
+import (
+	"github.com/insolar/insolar/core"
+	"github.com/insolar/insolar/logicrunner"
+	"github.com/insolar/insolar/logicrunner/goplugin/ginsider"
+	"github.com/insolar/insolar/logicrunner/goplugin/rpctypes"
+)
+
+// helper gives contracts access to the logic runner like GoInsider of the runner does,
+// but calls methods of the logic runner's RPC service directly
+type helper struct {
+	gi  *ginsider.GoInsider
+	rpc *logicrunner.RPC
+}
+
+// RouteCall calls method of other object
+func (h *helper) RouteCall(ref core.RecordRef, wait bool, method string, args []byte) ([]byte, error) {
+	req := rpctypes.UpRouteReq{
+		UpBaseReq: ginsider.MakeUpBaseReq(),
+		Wait:      wait,
+		Object:    ref,
+		Method:    method,
+		Arguments: args,
+	}
+	res := rpctypes.UpRouteResp{}
+	err := h.rpc.RouteCall(req, &res)
+	if err != nil {
+		return nil, err
+	}
+	return res.Result, nil
+}
+
+// SaveAsChild creates object as a child of the parent
+func (h *helper) SaveAsChild(parentRef, classRef core.RecordRef, constructorName string, argsSerialized []byte) (core.RecordRef, error) {
+	req := rpctypes.UpSaveAsChildReq{
+		UpBaseReq:       ginsider.MakeUpBaseReq(),
+		Parent:          parentRef,
+		Class:           classRef,
+		ConstructorName: constructorName,
+		ArgsSerialized:  argsSerialized,
+	}
+	res := rpctypes.UpSaveAsChildResp{}
+	err := h.rpc.SaveAsChild(req, &res)
+	if err != nil {
+		return core.RecordRef{}, err
+	}
+	return *res.Reference, nil
+}
+
+// GetObjChildren returns children of the object of the class
+func (h *helper) GetObjChildren(head core.RecordRef, class core.RecordRef) ([]core.RecordRef, error) {
+	req := rpctypes.UpGetObjChildrenReq{
+		UpBaseReq: ginsider.MakeUpBaseReq(),
+		Obj:       head,
+		Class:     class,
+	}
+	res := rpctypes.UpGetObjChildrenResp{}
+	err := h.rpc.GetObjChildren(req, &res)
+	if err != nil {
+		return nil, err
+	}
+	return res.Children, nil
+}
+
+// SaveAsDelegate creates object as a delegate of the parent
+func (h *helper) SaveAsDelegate(parentRef, classRef core.RecordRef, constructorName string, argsSerialized []byte) (core.RecordRef, error) {
+	req := rpctypes.UpSaveAsDelegateReq{
+		UpBaseReq:       ginsider.MakeUpBaseReq(),
+		Into:            parentRef,
+		Class:           classRef,
+		ConstructorName: constructorName,
+		ArgsSerialized:  argsSerialized,
+	}
+	res := rpctypes.UpSaveAsDelegateResp{}
+	err := h.rpc.SaveAsDelegate(req, &res)
+	if err != nil {
+		return core.RecordRef{}, err
+	}
+	return *res.Reference, nil
+}
+
+// GetDelegate returns delegate of the object of the class
+func (h *helper) GetDelegate(object, ofType core.RecordRef) (core.RecordRef, error) {
+	req := rpctypes.UpGetDelegateReq{
+		UpBaseReq: ginsider.MakeUpBaseReq(),
+		Object:    object,
+		OfType:    ofType,
+	}
+	res := rpctypes.UpGetDelegateResp{}
+	err := h.rpc.GetDelegate(req, &res)
+	if err != nil {
+		return core.RecordRef{}, err
+	}
+	return res.Object, nil
+}
+
+// EmitEvent emits event of the calling object
+func (h *helper) EmitEvent(name string, payload []byte) error {
+	req := rpctypes.UpEmitEventReq{
+		UpBaseReq: ginsider.MakeUpBaseReq(),
+		Name:      name,
+		Payload:   payload,
+	}
+	return h.rpc.EmitEvent(req, &rpctypes.UpEmitEventResp{})
+}
+
+// Serialize serializes the value the same way the runner does
+func (h *helper) Serialize(what interface{}, to *[]byte) error {
+	return h.gi.Serialize(what, to)
+}
+
+// Deserialize deserializes the value the same way the runner does
+func (h *helper) Deserialize(from []byte, into interface{}) error {
+	return h.gi.Deserialize(from, into)
+}
+
+// MakeErrorSerializable converts error into foundation.Error
+func (h *helper) MakeErrorSerializable(e error) error {
+	return h.gi.MakeErrorSerializable(e)
+}
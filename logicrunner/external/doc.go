@@ -0,0 +1,78 @@
+/*
+ *    Copyright 2018 Insolar
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+/*
+Package external runs contracts of MachineTypeExternal in a runtime written in any language.
+
+The node starts the runtime as a child process and talks to it over its stdin and stdout, stderr of the
+runtime goes to stderr of the node. The runtime should exit when its stdin is closed. The runtime is
+started on the first call and restarted on the next call after it exits.
+
+Every message is a frame: 4 bytes of big-endian length followed by JSON object of that length.
+Frames larger than 64MiB are rejected. Requests and responses look like this:
+
+	{"id": 1, "method": "CallMethod", "params": {...}}
+	{"id": 1, "result": {...}}
+	{"id": 1, "error": "description of the failure"}
+
+Both sides send requests: the node sends down-calls, the runtime sends up-calls while it executes a down-call.
+Each side numbers its own requests, a response has the id of the request it answers. Many requests may be
+in progress at the same time, e.g. a contract calls another contract of the same runtime, so the runtime
+must keep reading while a call is executed and responses may come in any order.
+
+References are base58 strings, binary data (code, memory of objects, arguments, results and payloads)
+is base64 strings. Arguments and results are CBOR arrays the same way as for contracts in Go, memory
+of objects is opaque to the node.
+
+Down-calls are made by the node. CallMethod runs method of an object, the result is new memory of the object and serialized results:
+
+	params: {"context": <context>, "code": ref, "data": bytes, "method": string, "arguments": bytes}
+	result: {"data": bytes, "result": bytes}
+
+CallConstructor creates memory of a new object:
+
+	params: {"context": <context>, "code": ref, "name": string, "arguments": bytes}
+	result: {"data": bytes}
+
+Cancel is sent when a call times out. It's a notification: its id is 0 and it's not answered.
+The runtime should abort the call and answer it with an error, the answer is ignored. The runtime
+is killed when the call isn't answered in 5 seconds, it's restarted on the next call:
+
+	params: {"call": id of the down-call}
+
+Context describes the call. Contracts must use its "time" and "seed" instead of the clock and random
+numbers of the runtime, otherwise validators won't get the same results:
+
+	{"callee": ref, "class": ref, "parent": ref, "caller": ref, "request": ref,
+	 "time": RFC 3339 time, "pulse": number, "seed": number, "read_only": bool}
+
+Up-calls are made by the runtime, every up-call has "call" param, it's id of the down-call the up-call is made for:
+
+	GetCode         {"call", "code": ref}                             -> {"code": bytes}
+	RouteCall       {"call", "object": ref, "method", "arguments": bytes,
+	                 "wait": bool}                                    -> {"result": bytes}
+	SaveAsChild     {"call", "parent": ref, "class": ref,
+	                 "constructor", "arguments": bytes}               -> {"reference": ref}
+	SaveAsDelegate  {"call", "into": ref, "class": ref,
+	                 "constructor", "arguments": bytes}               -> {"reference": ref}
+	GetObjChildren  {"call", "object": ref, "class": ref}             -> {"children": [ref]}
+	GetDelegate     {"call", "object": ref, "of_type": ref}           -> {"object": ref}
+	EmitEvent       {"call", "name", "payload": bytes}                -> {}
+
+Code deployed for MachineTypeExternal is fetched with GetCode, the runtime decides what the code is,
+e.g. source of a script or a module of the runtime.
+*/
+package external
@@ -0,0 +1,376 @@
+/*
+ *    Copyright 2018 Insolar
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package external
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/insolar/insolar/configuration"
+	"github.com/insolar/insolar/core"
+	"github.com/insolar/insolar/log"
+	"github.com/insolar/insolar/logicrunner/goplugin/rpctypes"
+	"github.com/pkg/errors"
+)
+
+// Upstream serves up-calls of contracts, it's implemented by logicrunner.RPC
+type Upstream interface {
+	GetCode(rpctypes.UpGetCodeReq, *rpctypes.UpGetCodeResp) error
+	RouteCall(rpctypes.UpRouteReq, *rpctypes.UpRouteResp) error
+	SaveAsChild(rpctypes.UpSaveAsChildReq, *rpctypes.UpSaveAsChildResp) error
+	SaveAsDelegate(rpctypes.UpSaveAsDelegateReq, *rpctypes.UpSaveAsDelegateResp) error
+	GetObjChildren(rpctypes.UpGetObjChildrenReq, *rpctypes.UpGetObjChildrenResp) error
+	GetDelegate(rpctypes.UpGetDelegateReq, *rpctypes.UpGetDelegateResp) error
+	EmitEvent(rpctypes.UpEmitEventReq, *rpctypes.UpEmitEventResp) error
+}
+
+const (
+	// timeout of a call without deadline
+	timeout = time.Second * 60
+	// stopTimeout is time the runtime is given to exit after its stdin is closed
+	stopTimeout = time.Second * 5
+)
+
+// cancelTimeout is time the runtime is given to abort a cancelled call, it's killed if the call isn't answered in time
+var cancelTimeout = time.Second * 5
+
+// Executor runs contracts in external runtime, it implements core.MachineLogicExecutor
+type Executor struct {
+	cfg      *configuration.External
+	upstream Upstream
+
+	mu      sync.Mutex
+	proc    *process
+	stopped bool
+}
+
+// NewExecutor creates executor, the runtime is started on the first call
+func NewExecutor(cfg *configuration.External, upstream Upstream) (*Executor, error) {
+	if cfg == nil || cfg.Command == "" {
+		return nil, errors.New("command of external runtime isn't configured")
+	}
+	return &Executor{cfg: cfg, upstream: upstream}, nil
+}
+
+// process returns running process of the runtime, starts it if needed
+func (e *Executor) process() (*process, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.stopped {
+		return nil, errors.New("executor is stopped")
+	}
+	if e.proc != nil && e.proc.running() {
+		return e.proc, nil
+	}
+
+	cmd := exec.Command(e.cfg.Command, e.cfg.Args...)
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, errors.Wrapf(err, "couldn't start runtime '%s'", e.cfg.Command)
+	}
+
+	p := &process{
+		upstream: e.upstream,
+		cmd:      cmd,
+		stdin:    stdin,
+		pending:  make(map[uint64]chan *frame),
+		calls:    make(map[uint64]*call),
+		exited:   make(chan struct{}),
+	}
+	go p.serve(stdout)
+	e.proc = p
+	return p, nil
+}
+
+// CallMethod runs a method on an object in the runtime
+func (e *Executor) CallMethod(ctx *core.LogicCallContext, code core.RecordRef, data []byte, method string, args core.Arguments) ([]byte, core.Arguments, error) {
+	p, err := e.process()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var res callMethodResult
+	err = p.call(ctx, methodCallMethod, &callMethodParams{
+		Context:   newCallContext(ctx),
+		Code:      code.String(),
+		Data:      data,
+		Method:    method,
+		Arguments: args,
+	}, &res, res.size)
+	if err != nil {
+		return nil, nil, err
+	}
+	return res.Data, res.Result, nil
+}
+
+// CallConstructor runs a constructor of a contract in the runtime
+func (e *Executor) CallConstructor(ctx *core.LogicCallContext, code core.RecordRef, name string, args core.Arguments) ([]byte, error) {
+	p, err := e.process()
+	if err != nil {
+		return nil, err
+	}
+
+	var res callConstructorResult
+	err = p.call(ctx, methodCallConstructor, &callConstructorParams{
+		Context:   newCallContext(ctx),
+		Code:      code.String(),
+		Name:      name,
+		Arguments: args,
+	}, &res, res.size)
+	if err != nil {
+		return nil, err
+	}
+	return res.Data, nil
+}
+
+// Stop closes stdin of the runtime and waits for it to exit, the runtime is killed if it doesn't exit in time
+func (e *Executor) Stop() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.stopped = true
+	if e.proc == nil {
+		return nil
+	}
+	return e.proc.stop()
+}
+
+// call is a down-call in progress
+type call struct {
+	ctx      *core.LogicCallContext
+	nested   int   // calls to other contracts made by the call
+	exceeded error // limit of the call exceeded by its up-calls
+}
+
+// process is a started runtime
+type process struct {
+	upstream Upstream
+	cmd      *exec.Cmd
+
+	writeMu sync.Mutex
+	stdin   io.WriteCloser
+
+	mu      sync.Mutex
+	nextID  uint64
+	pending map[uint64]chan *frame // responses awaited by down-calls
+	calls   map[uint64]*call
+	err     error // reason the runtime stopped serving
+	exited  chan struct{}
+}
+
+// running checks that the runtime didn't exit
+func (p *process) running() bool {
+	select {
+	case <-p.exited:
+		return false
+	default:
+		return true
+	}
+}
+
+// serve reads frames until the runtime exits or breaks the protocol, requests are served concurrently
+func (p *process) serve(stdout io.Reader) {
+	r := bufio.NewReader(stdout)
+	var err error
+	for {
+		var f *frame
+		f, err = readFrame(r)
+		if err != nil {
+			break
+		}
+		if f.Method != "" {
+			go p.upcall(f)
+			continue
+		}
+
+		p.mu.Lock()
+		ch, ok := p.pending[f.ID]
+		delete(p.pending, f.ID)
+		p.mu.Unlock()
+		if !ok {
+			log.Warnf("external runtime answered to unknown request %d", f.ID)
+			continue
+		}
+		ch <- f
+	}
+
+	if err != io.EOF {
+		log.Errorf("external runtime broke the protocol: %s", err)
+		if err := p.cmd.Process.Kill(); err != nil {
+			log.Errorf("couldn't kill external runtime: %s", err)
+		}
+	}
+	err = p.cmd.Wait()
+	log.Infof("external runtime %s exited: %v", p.cmd.Path, err)
+
+	p.mu.Lock()
+	p.err = errors.Errorf("external runtime exited: %v", err)
+	p.mu.Unlock()
+	close(p.exited)
+}
+
+// send writes frame to the runtime, frames are written whole
+func (p *process) send(f *frame) error {
+	p.writeMu.Lock()
+	defer p.writeMu.Unlock()
+	return writeFrame(p.stdin, f)
+}
+
+// callTimeout returns time left for the call, it's never greater than the default timeout
+func callTimeout(ctx *core.LogicCallContext) time.Duration {
+	if ctx == nil || ctx.Deadline.IsZero() {
+		return timeout
+	}
+	left := time.Until(ctx.Deadline)
+	if left > timeout {
+		return timeout
+	}
+	return left
+}
+
+// call makes a down-call and decodes its result, size returns state size of the decoded result
+// to check it against limits of the call
+func (p *process) call(ctx *core.LogicCallContext, method string, params interface{}, result interface{}, size func() int) error {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return errors.Wrap(err, "couldn't encode params")
+	}
+
+	ch := make(chan *frame, 1)
+	c := &call{ctx: ctx}
+	p.mu.Lock()
+	p.nextID++
+	id := p.nextID
+	p.pending[id] = ch
+	p.calls[id] = c
+	p.mu.Unlock()
+	cancelled := false
+	defer func() {
+		p.mu.Lock()
+		// answer of the cancelled call is awaited by cancel
+		if !cancelled {
+			delete(p.pending, id)
+		}
+		delete(p.calls, id)
+		p.mu.Unlock()
+	}()
+
+	start := time.Now()
+	err = p.send(&frame{ID: id, Method: method, Params: data})
+	if err != nil {
+		return errors.Wrap(err, "couldn't send call to external runtime")
+	}
+
+	var res *frame
+	select {
+	case res = <-ch:
+	case <-p.exited:
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		return p.err
+	case <-time.After(callTimeout(ctx)):
+		cancelled = true
+		p.cancel(id, ch)
+		if ctx == nil || ctx.Deadline.IsZero() {
+			return errors.New("timeout")
+		}
+		return ctx.TimeoutError(time.Since(start))
+	}
+
+	p.mu.Lock()
+	exceeded := c.exceeded
+	p.mu.Unlock()
+	if exceeded != nil {
+		return exceeded
+	}
+	if res.Error != "" {
+		return errors.New(res.Error)
+	}
+	err = json.Unmarshal(res.Result, result)
+	if err != nil {
+		return errors.Wrap(err, "couldn't decode result")
+	}
+
+	if ctx != nil {
+		usage := core.CallUsage{Time: time.Since(start), StateSize: size()}
+		if limit := ctx.Limits.Exceeded(usage); limit != "" {
+			return &core.LimitExceededError{Limit: limit, Limits: ctx.Limits, Usage: usage}
+		}
+	}
+	return nil
+}
+
+// cancel asks the runtime to abort the down-call, the runtime is killed if it doesn't answer the call in time,
+// so a stuck call doesn't keep running, and it's restarted on the next call
+func (p *process) cancel(id uint64, ch chan *frame) {
+	data, err := json.Marshal(&cancelParams{Call: id})
+	if err == nil {
+		err = p.send(&frame{Method: methodCancel, Params: data})
+	}
+	if err != nil {
+		log.Warnf("couldn't cancel call %d of external runtime: %s", id, err)
+	}
+
+	go func() {
+		select {
+		case <-ch:
+			return
+		case <-p.exited:
+			return
+		case <-time.After(cancelTimeout):
+		}
+		p.mu.Lock()
+		delete(p.pending, id)
+		p.mu.Unlock()
+		log.Errorf("external runtime didn't abort call %d in %s, killing it", id, cancelTimeout)
+		if err := p.cmd.Process.Kill(); err != nil {
+			log.Errorf("couldn't kill external runtime: %s", err)
+		}
+	}()
+}
+
+// stop closes stdin of the runtime and waits for it to exit
+func (p *process) stop() error {
+	if !p.running() {
+		return nil
+	}
+	if err := p.stdin.Close(); err != nil {
+		log.Warnf("couldn't close stdin of external runtime: %s", err)
+	}
+	select {
+	case <-p.exited:
+		return nil
+	case <-time.After(stopTimeout):
+	}
+	if err := p.cmd.Process.Kill(); err != nil {
+		return errors.Wrap(err, "couldn't kill external runtime")
+	}
+	<-p.exited
+	return nil
+}
@@ -0,0 +1,265 @@
+/*
+ *    Copyright 2018 Insolar
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package external
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/insolar/insolar/configuration"
+	"github.com/insolar/insolar/core"
+	"github.com/insolar/insolar/logicrunner/goplugin/rpctypes"
+)
+
+// runtimeArg makes the test binary act as external runtime instead of running tests
+const runtimeArg = "external-runtime"
+
+func TestMain(m *testing.M) {
+	if len(os.Args) > 1 && os.Args[1] == runtimeArg {
+		runtime()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// runtime serves down-calls until stdin is closed. Method "Echo" routes its arguments
+// to the callee as many times as there are dots in the arguments, "Fail" fails,
+// "Crash" stops the runtime, "Hang" waits until it's cancelled, "Stuck" never returns,
+// constructors return their arguments as memory.
+func runtime() {
+	var mu sync.Mutex
+	var nextID uint64
+	pending := map[uint64]chan *frame{}
+	cancels := map[uint64]chan struct{}{}
+	send := func(f *frame) {
+		mu.Lock()
+		defer mu.Unlock()
+		if err := writeFrame(os.Stdout, f); err != nil {
+			panic(err)
+		}
+	}
+	upcall := func(method string, params interface{}) *frame {
+		data, _ := json.Marshal(params)
+		ch := make(chan *frame, 1)
+		mu.Lock()
+		nextID++
+		id := nextID
+		pending[id] = ch
+		mu.Unlock()
+		send(&frame{ID: id, Method: method, Params: data})
+		return <-ch
+	}
+	respond := func(id uint64, res interface{}, err error) {
+		if err != nil {
+			send(&frame{ID: id, Error: err.Error()})
+			return
+		}
+		data, _ := json.Marshal(res)
+		send(&frame{ID: id, Result: data})
+	}
+
+	for {
+		f, err := readFrame(os.Stdin)
+		if err != nil {
+			return
+		}
+		if f.Method == "" {
+			mu.Lock()
+			ch := pending[f.ID]
+			delete(pending, f.ID)
+			mu.Unlock()
+			ch <- f
+			continue
+		}
+		cancel := make(chan struct{})
+		mu.Lock()
+		if f.Method == methodCancel {
+			var req cancelParams
+			_ = json.Unmarshal(f.Params, &req)
+			if c, ok := cancels[req.Call]; ok {
+				close(c)
+				delete(cancels, req.Call)
+			}
+			mu.Unlock()
+			continue
+		}
+		cancels[f.ID] = cancel
+		mu.Unlock()
+
+		go func(f *frame) {
+			if f.Method == methodCallConstructor {
+				var req callConstructorParams
+				_ = json.Unmarshal(f.Params, &req)
+				respond(f.ID, &callConstructorResult{Data: req.Arguments}, nil)
+				return
+			}
+
+			var req callMethodParams
+			_ = json.Unmarshal(f.Params, &req)
+			switch req.Method {
+			case "Fail":
+				respond(f.ID, nil, errors.New("contract failed"))
+				return
+			case "Crash":
+				os.Exit(1)
+			case "Hang":
+				<-cancel
+				respond(f.ID, nil, errors.New("cancelled"))
+				return
+			case "Stuck":
+				select {}
+			}
+
+			var result []byte
+			for i := 0; i < bytes.Count(req.Arguments, []byte(".")); i++ {
+				res := upcall(methodRouteCall, &routeCallParams{
+					upParams:  upParams{Call: f.ID},
+					Object:    req.Context.Callee,
+					Method:    "Get",
+					Arguments: req.Arguments,
+					Wait:      true,
+				})
+				if res.Error != "" {
+					respond(f.ID, nil, errors.New(res.Error))
+					return
+				}
+				var rc routeCallResult
+				_ = json.Unmarshal(res.Result, &rc)
+				result = append(result, rc.Result...)
+			}
+			respond(f.ID, &callMethodResult{Data: req.Data, Result: result}, nil)
+		}(f)
+	}
+}
+
+type testUpstream struct {
+	Upstream // up-calls other than RouteCall aren't implemented
+
+	mu     sync.Mutex
+	routed []rpctypes.UpRouteReq
+}
+
+func (u *testUpstream) RouteCall(req rpctypes.UpRouteReq, rep *rpctypes.UpRouteResp) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.routed = append(u.routed, req)
+	rep.Result = []byte(strings.ToUpper(string(req.Arguments)))
+	return nil
+}
+
+func newTestExecutor(t *testing.T, up Upstream) *Executor {
+	e, err := NewExecutor(&configuration.External{
+		Command: os.Args[0],
+		Args:    []string{runtimeArg},
+	}, up)
+	assert.NoError(t, err)
+	return e
+}
+
+func TestExecutor(t *testing.T) {
+	up := &testUpstream{}
+	e := newTestExecutor(t, up)
+	defer e.Stop()
+
+	obj := core.RandomRef()
+	code := core.RandomRef()
+	ctx := &core.LogicCallContext{Callee: &obj}
+
+	state, err := e.CallConstructor(ctx, code, "New", []byte("memory"))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("memory"), state)
+
+	state, res, err := e.CallMethod(ctx, code, []byte("memory"), "Echo", []byte("a.b."))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("memory"), state)
+	assert.Equal(t, []byte("A.B.A.B."), []byte(res))
+	assert.Len(t, up.routed, 2)
+	assert.Equal(t, obj, up.routed[0].Me)
+	assert.Equal(t, obj, up.routed[0].Object)
+
+	_, _, err = e.CallMethod(ctx, code, nil, "Fail", nil)
+	assert.EqualError(t, err, "contract failed")
+
+	limited := &core.LogicCallContext{Callee: &obj, Limits: core.CallLimits{NestedCalls: 1}}
+	_, _, err = e.CallMethod(limited, code, nil, "Echo", []byte("a.b."))
+	assert.IsType(t, &core.LimitExceededError{}, err)
+
+	_, _, err = e.CallMethod(ctx, code, nil, "Crash", nil)
+	assert.EqualError(t, err, "external runtime exited: exit status 1")
+	_, res, err = e.CallMethod(ctx, code, nil, "Echo", []byte("."))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("."), []byte(res))
+
+	assert.NoError(t, e.Stop())
+	_, _, err = e.CallMethod(ctx, code, nil, "Echo", nil)
+	assert.Error(t, err)
+}
+
+func TestExecutor_CancelsTimedOutCalls(t *testing.T) {
+	cancelTimeout = time.Millisecond * 200
+	e := newTestExecutor(t, &testUpstream{})
+	defer e.Stop()
+
+	obj := core.RandomRef()
+	code := core.RandomRef()
+	deadline := func() *core.LogicCallContext {
+		return &core.LogicCallContext{Callee: &obj, Deadline: time.Now().Add(time.Millisecond * 100)}
+	}
+
+	// the runtime aborts cancelled call and keeps running
+	_, _, err := e.CallMethod(deadline(), code, nil, "Hang", nil)
+	assert.Error(t, err)
+	proc := e.proc
+	time.Sleep(cancelTimeout * 2)
+	assert.True(t, proc.running())
+
+	// the runtime that doesn't abort the call is killed and started again
+	_, _, err = e.CallMethod(deadline(), code, nil, "Stuck", nil)
+	assert.Error(t, err)
+	time.Sleep(cancelTimeout * 2)
+	assert.False(t, proc.running())
+	_, res, err := e.CallMethod(deadline(), code, nil, "Echo", []byte("."))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("."), []byte(res))
+	assert.NotEqual(t, proc, e.proc)
+}
+
+func TestFrames(t *testing.T) {
+	var buf bytes.Buffer
+	err := writeFrame(&buf, &frame{ID: 1, Method: methodCallMethod, Params: json.RawMessage(`{"method":"Get"}`)})
+	assert.NoError(t, err)
+	err = writeFrame(&buf, &frame{ID: 1, Error: "failed"})
+	assert.NoError(t, err)
+
+	f, err := readFrame(&buf)
+	assert.NoError(t, err)
+	assert.Equal(t, &frame{ID: 1, Method: methodCallMethod, Params: json.RawMessage(`{"method":"Get"}`)}, f)
+	f, err = readFrame(&buf)
+	assert.NoError(t, err)
+	assert.Equal(t, &frame{ID: 1, Error: "failed"}, f)
+
+	_, err = readFrame(bytes.NewReader([]byte{0xff, 0xff, 0xff, 0xff}))
+	assert.EqualError(t, err, "frame of 4294967295 bytes exceeds limit of 67108864 bytes")
+}
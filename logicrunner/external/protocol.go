@@ -0,0 +1,241 @@
+/*
+ *    Copyright 2018 Insolar
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package external
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/insolar/insolar/core"
+	"github.com/pkg/errors"
+)
+
+// maxFrameSize limits size of frames read from the runtime
+const maxFrameSize = 64 << 20
+
+// frame is a request when Method is set, otherwise it's a response to request with the same ID
+type frame struct {
+	ID     uint64          `json:"id"`
+	Method string          `json:"method,omitempty"`
+	Params json.RawMessage `json:"params,omitempty"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  string          `json:"error,omitempty"`
+}
+
+// writeFrame writes length of the encoded frame and the frame itself with a single write
+func writeFrame(w io.Writer, f *frame) error {
+	data, err := json.Marshal(f)
+	if err != nil {
+		return errors.Wrap(err, "couldn't encode frame")
+	}
+	buf := make([]byte, 4+len(data))
+	binary.BigEndian.PutUint32(buf, uint32(len(data)))
+	copy(buf[4:], data)
+	_, err = w.Write(buf)
+	return err
+}
+
+// readFrame reads a frame, io.EOF is returned as is when there are no more frames
+func readFrame(r io.Reader) (*frame, error) {
+	var size [4]byte
+	_, err := io.ReadFull(r, size[:])
+	if err != nil {
+		return nil, err
+	}
+	n := binary.BigEndian.Uint32(size[:])
+	if n > maxFrameSize {
+		return nil, errors.Errorf("frame of %d bytes exceeds limit of %d bytes", n, maxFrameSize)
+	}
+
+	data := make([]byte, n)
+	_, err = io.ReadFull(r, data)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't read frame")
+	}
+	var f frame
+	err = json.Unmarshal(data, &f)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't decode frame")
+	}
+	return &f, nil
+}
+
+// Names of down-calls
+const (
+	methodCallMethod      = "CallMethod"
+	methodCallConstructor = "CallConstructor"
+	methodCancel          = "Cancel"
+)
+
+// callContext is core.LogicCallContext as seen by the runtime
+type callContext struct {
+	Callee   string           `json:"callee,omitempty"`
+	Class    string           `json:"class,omitempty"`
+	Parent   string           `json:"parent,omitempty"`
+	Caller   string           `json:"caller,omitempty"`
+	Request  string           `json:"request,omitempty"`
+	Time     time.Time        `json:"time"`
+	Pulse    core.PulseNumber `json:"pulse"`
+	Seed     int64            `json:"seed"`
+	ReadOnly bool             `json:"read_only"`
+}
+
+func newCallContext(ctx *core.LogicCallContext) callContext {
+	return callContext{
+		Callee:   refString(ctx.Callee),
+		Class:    refString(ctx.Class),
+		Parent:   refString(ctx.Parent),
+		Caller:   refString(ctx.Caller),
+		Request:  refString(ctx.Request),
+		Time:     ctx.Time,
+		Pulse:    ctx.Pulse.PulseNumber,
+		Seed:     ctx.RandomSeed(),
+		ReadOnly: ctx.ReadOnly,
+	}
+}
+
+// refString returns base58 form of the reference, empty string for nil
+func refString(ref *core.RecordRef) string {
+	if ref == nil {
+		return ""
+	}
+	return ref.String()
+}
+
+type callMethodParams struct {
+	Context   callContext `json:"context"`
+	Code      string      `json:"code"`
+	Data      []byte      `json:"data"`
+	Method    string      `json:"method"`
+	Arguments []byte      `json:"arguments"`
+}
+
+type callMethodResult struct {
+	Data   []byte `json:"data"`
+	Result []byte `json:"result"`
+}
+
+func (r *callMethodResult) size() int {
+	return len(r.Data)
+}
+
+type callConstructorParams struct {
+	Context   callContext `json:"context"`
+	Code      string      `json:"code"`
+	Name      string      `json:"name"`
+	Arguments []byte      `json:"arguments"`
+}
+
+// cancelParams are params of the Cancel notification
+type cancelParams struct {
+	Call uint64 `json:"call"` // id of the cancelled down-call
+}
+
+type callConstructorResult struct {
+	Data []byte `json:"data"`
+}
+
+func (r *callConstructorResult) size() int {
+	return len(r.Data)
+}
+
+// Names of up-calls
+const (
+	methodGetCode        = "GetCode"
+	methodRouteCall      = "RouteCall"
+	methodSaveAsChild    = "SaveAsChild"
+	methodSaveAsDelegate = "SaveAsDelegate"
+	methodGetObjChildren = "GetObjChildren"
+	methodGetDelegate    = "GetDelegate"
+	methodEmitEvent      = "EmitEvent"
+)
+
+// upParams are params common for all up-calls
+type upParams struct {
+	Call uint64 `json:"call"` // id of the down-call
+}
+
+type getCodeParams struct {
+	upParams
+	Code string `json:"code"`
+}
+
+type getCodeResult struct {
+	Code []byte `json:"code"`
+}
+
+type routeCallParams struct {
+	upParams
+	Object    string `json:"object"`
+	Method    string `json:"method"`
+	Arguments []byte `json:"arguments"`
+	Wait      bool   `json:"wait"`
+}
+
+type routeCallResult struct {
+	Result []byte `json:"result"`
+}
+
+type saveAsChildParams struct {
+	upParams
+	Parent      string `json:"parent"`
+	Class       string `json:"class"`
+	Constructor string `json:"constructor"`
+	Arguments   []byte `json:"arguments"`
+}
+
+type saveAsDelegateParams struct {
+	upParams
+	Into        string `json:"into"`
+	Class       string `json:"class"`
+	Constructor string `json:"constructor"`
+	Arguments   []byte `json:"arguments"`
+}
+
+type referenceResult struct {
+	Reference string `json:"reference"`
+}
+
+type getObjChildrenParams struct {
+	upParams
+	Object string `json:"object"`
+	Class  string `json:"class"`
+}
+
+type getObjChildrenResult struct {
+	Children []string `json:"children"`
+}
+
+type getDelegateParams struct {
+	upParams
+	Object string `json:"object"`
+	OfType string `json:"of_type"`
+}
+
+type getDelegateResult struct {
+	Object string `json:"object"`
+}
+
+type emitEventParams struct {
+	upParams
+	Name    string `json:"name"`
+	Payload []byte `json:"payload"`
+}
+
+type emitEventResult struct{}
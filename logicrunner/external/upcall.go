@@ -0,0 +1,235 @@
+/*
+ *    Copyright 2018 Insolar
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package external
+
+import (
+	"encoding/json"
+
+	"github.com/insolar/insolar/core"
+	"github.com/insolar/insolar/log"
+	"github.com/insolar/insolar/logicrunner/goplugin/rpctypes"
+	"github.com/pkg/errors"
+)
+
+// upcall serves request of the runtime and sends response to it
+func (p *process) upcall(f *frame) {
+	res, err := p.handle(f.Method, f.Params)
+	resp := &frame{ID: f.ID}
+	if err == nil {
+		resp.Result, err = json.Marshal(res)
+	}
+	if err != nil {
+		resp.Error = err.Error()
+	}
+	if err := p.send(resp); err != nil {
+		log.Warnf("couldn't answer %s of external runtime: %s", f.Method, err)
+	}
+}
+
+// request decodes params of up-call and makes base of request to upstream from context of the down-call
+func (p *process) request(params json.RawMessage, v interface{}, up *upParams) (rpctypes.UpBaseReq, error) {
+	err := json.Unmarshal(params, v)
+	if err != nil {
+		return rpctypes.UpBaseReq{}, errors.Wrap(err, "couldn't decode params")
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	c, ok := p.calls[up.Call]
+	if !ok {
+		return rpctypes.UpBaseReq{}, errors.Errorf("call %d isn't in progress", up.Call)
+	}
+	if c.ctx == nil {
+		return rpctypes.UpBaseReq{}, nil
+	}
+	base := rpctypes.UpBaseReq{
+		Deadline: c.ctx.Deadline,
+		Trace:    c.ctx.Trace,
+		ReadOnly: c.ctx.ReadOnly,
+	}
+	// constructors aren't called on an object yet, they make requests on behalf of the class
+	if c.ctx.Callee != nil {
+		base.Me = *c.ctx.Callee
+	} else if c.ctx.Class != nil {
+		base.Me = *c.ctx.Class
+	}
+	if c.ctx.Chain != nil {
+		base.Chain = *c.ctx.Chain
+	}
+	return base, nil
+}
+
+// countNestedCall counts call to another contract made by the down-call, the down-call fails
+// when it makes more calls than its limit allows
+func (p *process) countNestedCall(up *upParams) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	c, ok := p.calls[up.Call]
+	if !ok || c.ctx == nil {
+		return nil
+	}
+	c.nested++
+	usage := core.CallUsage{NestedCalls: c.nested}
+	if c.ctx.Limits.Exceeded(usage) == core.CallLimitNestedCalls {
+		c.exceeded = &core.LimitExceededError{Limit: core.CallLimitNestedCalls, Limits: c.ctx.Limits, Usage: usage}
+		return c.exceeded
+	}
+	return nil
+}
+
+// handle serves up-call with upstream
+func (p *process) handle(method string, params json.RawMessage) (interface{}, error) {
+	switch method {
+	case methodGetCode:
+		var req getCodeParams
+		base, err := p.request(params, &req, &req.upParams)
+		if err != nil {
+			return nil, err
+		}
+		var rep rpctypes.UpGetCodeResp
+		err = p.upstream.GetCode(rpctypes.UpGetCodeReq{
+			UpBaseReq: base,
+			MType:     core.MachineTypeExternal,
+			Code:      core.NewRefFromBase58(req.Code),
+		}, &rep)
+		if err != nil {
+			return nil, err
+		}
+		return &getCodeResult{Code: rep.Code}, nil
+
+	case methodRouteCall:
+		var req routeCallParams
+		base, err := p.request(params, &req, &req.upParams)
+		if err != nil {
+			return nil, err
+		}
+		if err := p.countNestedCall(&req.upParams); err != nil {
+			return nil, err
+		}
+		var rep rpctypes.UpRouteResp
+		err = p.upstream.RouteCall(rpctypes.UpRouteReq{
+			UpBaseReq: base,
+			Wait:      req.Wait,
+			Object:    core.NewRefFromBase58(req.Object),
+			Method:    req.Method,
+			Arguments: req.Arguments,
+		}, &rep)
+		if err != nil {
+			return nil, err
+		}
+		return &routeCallResult{Result: rep.Result}, nil
+
+	case methodSaveAsChild:
+		var req saveAsChildParams
+		base, err := p.request(params, &req, &req.upParams)
+		if err != nil {
+			return nil, err
+		}
+		if err := p.countNestedCall(&req.upParams); err != nil {
+			return nil, err
+		}
+		var rep rpctypes.UpSaveAsChildResp
+		err = p.upstream.SaveAsChild(rpctypes.UpSaveAsChildReq{
+			UpBaseReq:       base,
+			Parent:          core.NewRefFromBase58(req.Parent),
+			Class:           core.NewRefFromBase58(req.Class),
+			ConstructorName: req.Constructor,
+			ArgsSerialized:  req.Arguments,
+		}, &rep)
+		if err != nil {
+			return nil, err
+		}
+		return &referenceResult{Reference: refString(rep.Reference)}, nil
+
+	case methodSaveAsDelegate:
+		var req saveAsDelegateParams
+		base, err := p.request(params, &req, &req.upParams)
+		if err != nil {
+			return nil, err
+		}
+		if err := p.countNestedCall(&req.upParams); err != nil {
+			return nil, err
+		}
+		var rep rpctypes.UpSaveAsDelegateResp
+		err = p.upstream.SaveAsDelegate(rpctypes.UpSaveAsDelegateReq{
+			UpBaseReq:       base,
+			Into:            core.NewRefFromBase58(req.Into),
+			Class:           core.NewRefFromBase58(req.Class),
+			ConstructorName: req.Constructor,
+			ArgsSerialized:  req.Arguments,
+		}, &rep)
+		if err != nil {
+			return nil, err
+		}
+		return &referenceResult{Reference: refString(rep.Reference)}, nil
+
+	case methodGetObjChildren:
+		var req getObjChildrenParams
+		base, err := p.request(params, &req, &req.upParams)
+		if err != nil {
+			return nil, err
+		}
+		var rep rpctypes.UpGetObjChildrenResp
+		err = p.upstream.GetObjChildren(rpctypes.UpGetObjChildrenReq{
+			UpBaseReq: base,
+			Obj:       core.NewRefFromBase58(req.Object),
+			Class:     core.NewRefFromBase58(req.Class),
+		}, &rep)
+		if err != nil {
+			return nil, err
+		}
+		children := make([]string, len(rep.Children))
+		for i, ref := range rep.Children {
+			children[i] = ref.String()
+		}
+		return &getObjChildrenResult{Children: children}, nil
+
+	case methodGetDelegate:
+		var req getDelegateParams
+		base, err := p.request(params, &req, &req.upParams)
+		if err != nil {
+			return nil, err
+		}
+		var rep rpctypes.UpGetDelegateResp
+		err = p.upstream.GetDelegate(rpctypes.UpGetDelegateReq{
+			UpBaseReq: base,
+			Object:    core.NewRefFromBase58(req.Object),
+			OfType:    core.NewRefFromBase58(req.OfType),
+		}, &rep)
+		if err != nil {
+			return nil, err
+		}
+		return &getDelegateResult{Object: rep.Object.String()}, nil
+
+	case methodEmitEvent:
+		var req emitEventParams
+		base, err := p.request(params, &req, &req.upParams)
+		if err != nil {
+			return nil, err
+		}
+		err = p.upstream.EmitEvent(rpctypes.UpEmitEventReq{
+			UpBaseReq: base,
+			Name:      req.Name,
+			Payload:   req.Payload,
+		}, &rpctypes.UpEmitEventResp{})
+		if err != nil {
+			return nil, err
+		}
+		return &emitEventResult{}, nil
+	}
+	return nil, errors.Errorf("unknown method %q", method)
+}
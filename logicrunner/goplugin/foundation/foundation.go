@@ -18,6 +18,9 @@
 package foundation
 
 import (
+	"math/rand"
+	"time"
+
 	"github.com/insolar/insolar/core"
 	"github.com/insolar/insolar/logicrunner/goplugin/proxyctx"
 	"github.com/tylerb/gls"
@@ -70,6 +73,23 @@ func GetContext() *core.LogicCallContext {
 	}
 }
 
+// Now returns time of the current call, it's derived from the pulse
+// so validators replaying the call get the same time, use it instead of time.Now
+func Now() time.Time {
+	return GetContext().Time
+}
+
+// GetRandom returns source of random numbers of the current call, it's seeded with the pulse entropy
+// and the request so validators replaying the call get the same numbers, use it instead of math/rand
+func GetRandom() *rand.Rand {
+	if r, ok := gls.Get("rand").(*rand.Rand); ok {
+		return r
+	}
+	r := rand.New(rand.NewSource(GetContext().RandomSeed()))
+	gls.Set("rand", r)
+	return r
+}
+
 // GetImplementationFor finds delegate typed r in object and returns it
 func GetImplementationFor(object, ofType core.RecordRef) (core.RecordRef, error) {
 	return proxyctx.Current.GetDelegate(object, ofType)
@@ -80,6 +100,17 @@ func (bc *BaseContract) GetChildrenTyped(r core.RecordRef) ([]core.RecordRef, er
 	return proxyctx.Current.GetObjChildren(bc.GetReference(), r)
 }
 
+// EmitEvent emits event of the contract, events are stored in ledger along with the request
+// when the call succeeds and can be fetched or awaited by clients
+func (bc *BaseContract) EmitEvent(name string, payload interface{}) error {
+	var data []byte
+	err := proxyctx.Current.Serialize(payload, &data)
+	if err != nil {
+		return err
+	}
+	return proxyctx.Current.EmitEvent(name, data)
+}
+
 // GetObject create proxy by address
 // unimplemented
 func GetObject(ref core.RecordRef) ProxyInterface {
@@ -100,3 +131,12 @@ type Error struct {
 func (e *Error) Error() string {
 	return e.S
 }
+
+// ErrorOrNil returns error deserialized from a call of other contract's method as error,
+// a nil *Error turns into nil error, so callers can compare the error with nil
+func ErrorOrNil(e *Error) error {
+	if e == nil {
+		return nil
+	}
+	return e
+}
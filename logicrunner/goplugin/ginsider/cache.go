@@ -0,0 +1,283 @@
+/*
+ *    Copyright 2018 Insolar
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package ginsider
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/insolar/insolar/core"
+	"github.com/insolar/insolar/cryptohelpers/hash"
+	"github.com/insolar/insolar/log"
+)
+
+// cacheIndexFile is the file in the cache directory that keeps the index between restarts
+const cacheIndexFile = "index.json"
+
+// cacheSaveInterval is the minimal interval between saves of usage statistics collected on hits
+const cacheSaveInterval = time.Minute
+
+// cacheEntry describes a plugin in the cache
+type cacheEntry struct {
+	Size     int64
+	Hits     uint64
+	LastUsed time.Time
+	Refs     []string // code records with this content
+}
+
+// codeCache is a persistent cache of plugins, files are named by hash of their content,
+// the content is verified when it's put to the cache and when the cache is opened. Size
+// of the cache is bounded, the least recently used plugins are evicted first.
+type codeCache struct {
+	dir   string
+	limit int64
+
+	mu       sync.Mutex
+	entries  map[string]*cacheEntry // by hex of the hash
+	refs     map[string]string      // hash by code reference
+	saved    time.Time              // when the index was saved last time
+	saving   bool                   // flush is scheduled
+	modified bool                   // statistics of hits changed since the last save
+}
+
+// newCodeCache opens cache in the directory, creates the directory if needed
+func newCodeCache(dir string, limit int64) (*codeCache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, errors.Wrap(err, "couldn't create directory for code cache")
+	}
+	c := &codeCache{
+		dir:     dir,
+		limit:   limit,
+		entries: make(map[string]*cacheEntry),
+		refs:    make(map[string]string),
+		saved:   time.Now(),
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, cacheIndexFile))
+	if os.IsNotExist(err) {
+		return c, nil
+	}
+	if err == nil {
+		err = json.Unmarshal(data, &c.entries)
+	}
+	if err != nil {
+		log.Warnf("couldn't read index of code cache, starting with empty cache: %s", err)
+		c.entries = make(map[string]*cacheEntry)
+		return c, nil
+	}
+
+	for h, e := range c.entries {
+		code, err := ioutil.ReadFile(c.path(h))
+		if err != nil || hex.EncodeToString(hash.SHA3Bytes(code)) != h {
+			log.Warnf("plugin %s in code cache is missing or damaged, dropping it", h)
+			c.remove(h)
+			continue
+		}
+		for _, ref := range e.Refs {
+			c.refs[ref] = h
+		}
+	}
+	return c, nil
+}
+
+// path returns path to the plugin file with the hash
+func (c *codeCache) path(h string) string {
+	return filepath.Join(c.dir, h+".so")
+}
+
+// get returns path to plugin of the code record if it's in the cache, usage statistics are
+// updated in memory and saved in background at most once per cacheSaveInterval
+func (c *codeCache) get(ref core.RecordRef) (string, bool) {
+	c.mu.Lock()
+	h, ok := c.refs[ref.String()]
+	if !ok {
+		c.mu.Unlock()
+		return "", false
+	}
+	e := c.entries[h]
+	e.Hits++
+	e.LastUsed = time.Now()
+	c.modified = true
+	flush := !c.saving && time.Since(c.saved) >= cacheSaveInterval
+	if flush {
+		c.saving = true
+	}
+	c.mu.Unlock()
+
+	if flush {
+		go c.flush()
+	}
+	return c.path(h), true
+}
+
+// flush saves statistics collected on hits since the last save
+func (c *codeCache) flush() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.modified {
+		c.save()
+	}
+	c.saving = false
+}
+
+// put verifies the code against its hash from the ledger, stores it and returns path to the plugin
+func (c *codeCache) put(ref core.RecordRef, code []byte, codeHash []byte) (string, error) {
+	if len(codeHash) == 0 {
+		return "", errors.Errorf("no hash for code %s", ref)
+	}
+	if !bytes.Equal(hash.SHA3Bytes(code), codeHash) {
+		return "", errors.Errorf("code %s doesn't match its hash", ref)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	h := hex.EncodeToString(codeHash)
+	path := c.path(h)
+	e, ok := c.entries[h]
+	if !ok {
+		// write to a temporary file first, so a partially written plugin never gets a valid name
+		tmp, err := ioutil.TempFile(c.dir, h+".tmp")
+		if err != nil {
+			return "", errors.Wrap(err, "couldn't create file in code cache")
+		}
+		_, err = tmp.Write(code)
+		if cerr := tmp.Close(); err == nil {
+			err = cerr
+		}
+		if err == nil {
+			err = os.Rename(tmp.Name(), path)
+		}
+		if err != nil {
+			os.Remove(tmp.Name()) // nolint: errcheck
+			return "", errors.Wrap(err, "couldn't write code to cache")
+		}
+		e = &cacheEntry{Size: int64(len(code))}
+		c.entries[h] = e
+	}
+	if _, ok := c.refs[ref.String()]; !ok {
+		e.Refs = append(e.Refs, ref.String())
+		c.refs[ref.String()] = h
+	}
+	e.Hits++
+	e.LastUsed = time.Now()
+
+	c.evict(h)
+	c.save()
+	return path, nil
+}
+
+// popular returns references to the most used code records, at most n
+func (c *codeCache) popular(n int) []core.RecordRef {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	hashes := make([]string, 0, len(c.entries))
+	for h := range c.entries {
+		hashes = append(hashes, h)
+	}
+	sort.Slice(hashes, func(i, j int) bool {
+		return c.entries[hashes[i]].Hits > c.entries[hashes[j]].Hits
+	})
+	if len(hashes) > n {
+		hashes = hashes[:n]
+	}
+
+	var res []core.RecordRef
+	for _, h := range hashes {
+		for _, ref := range c.entries[h].Refs {
+			res = append(res, core.NewRefFromBase58(ref))
+		}
+	}
+	return res
+}
+
+// evict removes the least recently used plugins until the cache fits the limit, keep is never removed,
+// c.mu must be held
+func (c *codeCache) evict(keep string) {
+	var size int64
+	hashes := make([]string, 0, len(c.entries))
+	for h, e := range c.entries {
+		size += e.Size
+		if h != keep {
+			hashes = append(hashes, h)
+		}
+	}
+	sort.Slice(hashes, func(i, j int) bool {
+		return c.entries[hashes[i]].LastUsed.Before(c.entries[hashes[j]].LastUsed)
+	})
+	for _, h := range hashes {
+		if size <= c.limit {
+			return
+		}
+		size -= c.entries[h].Size
+		c.remove(h)
+	}
+}
+
+// remove drops the plugin from the cache, c.mu must be held
+func (c *codeCache) remove(h string) {
+	if e, ok := c.entries[h]; ok {
+		for _, ref := range e.Refs {
+			delete(c.refs, ref)
+		}
+		delete(c.entries, h)
+	}
+	if err := os.Remove(c.path(h)); err != nil && !os.IsNotExist(err) {
+		log.Warnf("couldn't remove plugin %s from code cache: %s", h, err)
+	}
+}
+
+// save writes the index of the cache, c.mu must be held
+func (c *codeCache) save() {
+	c.saved = time.Now()
+	c.modified = false
+	data, err := json.Marshal(c.entries)
+	if err == nil {
+		err = c.writeIndex(data)
+	}
+	if err != nil {
+		log.Warnf("couldn't save index of code cache: %s", err)
+	}
+}
+
+// writeIndex replaces the index file, the new index is written to a temporary file first,
+// so a crash never leaves a partially written index
+func (c *codeCache) writeIndex(data []byte) error {
+	tmp, err := ioutil.TempFile(c.dir, cacheIndexFile+".tmp")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), filepath.Join(c.dir, cacheIndexFile))
+	}
+	if err != nil {
+		os.Remove(tmp.Name()) // nolint: errcheck
+	}
+	return err
+}
@@ -0,0 +1,91 @@
+/*
+ *    Copyright 2018 Insolar
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package ginsider
+
+import (
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/insolar/insolar/core"
+	"github.com/insolar/insolar/cryptohelpers/hash"
+)
+
+func TestCodeCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "codecache-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir) // nolint: errcheck
+
+	c, err := newCodeCache(dir, 10)
+	assert.NoError(t, err)
+
+	ref := core.RandomRef()
+	code := []byte("plugin")
+
+	_, err = c.put(ref, code, hash.SHA3Bytes([]byte("other")))
+	assert.Error(t, err, "code doesn't match hash")
+	_, ok := c.get(ref)
+	assert.False(t, ok)
+
+	path, err := c.put(ref, code, hash.SHA3Bytes(code))
+	assert.NoError(t, err)
+	data, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, code, data)
+
+	// index survives restart
+	c, err = newCodeCache(dir, 10)
+	assert.NoError(t, err)
+	index, err := ioutil.ReadFile(filepath.Join(dir, cacheIndexFile))
+	assert.NoError(t, err)
+	cached, ok := c.get(ref)
+	assert.True(t, ok)
+	assert.Equal(t, path, cached)
+	assert.Equal(t, []core.RecordRef{ref}, c.popular(10))
+
+	// hits don't touch the disk until the statistics are flushed
+	after, err := ioutil.ReadFile(filepath.Join(dir, cacheIndexFile))
+	assert.NoError(t, err)
+	assert.Equal(t, index, after)
+	c.flush()
+	after, err = ioutil.ReadFile(filepath.Join(dir, cacheIndexFile))
+	assert.NoError(t, err)
+	assert.NotEqual(t, index, after)
+
+	// the least recently used plugin is evicted
+	ref2 := core.RandomRef()
+	_, err = c.put(ref2, []byte("plugin2"), hash.SHA3Bytes([]byte("plugin2")))
+	assert.NoError(t, err)
+	_, ok = c.get(ref)
+	assert.False(t, ok)
+	_, ok = c.get(ref2)
+	assert.True(t, ok)
+
+	// damaged plugin is dropped when the cache is opened
+	h := hex.EncodeToString(hash.SHA3Bytes([]byte("plugin2")))
+	assert.NoError(t, ioutil.WriteFile(c.path(h), []byte("damaged"), 0644))
+	c, err = newCodeCache(dir, 10)
+	assert.NoError(t, err)
+	_, ok = c.get(ref2)
+	assert.False(t, ok)
+	_, err = os.Stat(c.path(h))
+	assert.True(t, os.IsNotExist(err))
+}
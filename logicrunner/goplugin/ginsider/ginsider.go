@@ -17,10 +17,7 @@
 package ginsider
 
 import (
-	"io/ioutil"
 	"net/rpc"
-	"os"
-	"path/filepath"
 	"plugin"
 	"sync"
 
@@ -36,7 +33,7 @@ import (
 
 // GoInsider is an RPC interface to run code of plugins
 type GoInsider struct {
-	dir              string
+	cache            *codeCache
 	UpstreamProtocol string
 	UpstreamAddress  string
 	UpstreamClient   *rpc.Client
@@ -44,12 +41,16 @@ type GoInsider struct {
 	pluginsMutex     sync.Mutex
 }
 
-// NewGoInsider creates a new GoInsider instance validating arguments
-func NewGoInsider(path, network, address string) *GoInsider {
-	//TODO: check that path exist, it's a directory and writable
-	res := GoInsider{dir: path, UpstreamProtocol: network, UpstreamAddress: address}
+// NewGoInsider creates a new GoInsider instance, plugins are cached in `path`
+// that is bounded by `cacheSize` bytes
+func NewGoInsider(path, network, address string, cacheSize int64) (*GoInsider, error) {
+	cache, err := newCodeCache(path, cacheSize)
+	if err != nil {
+		return nil, err
+	}
+	res := GoInsider{cache: cache, UpstreamProtocol: network, UpstreamAddress: address}
 	res.plugins = make(map[string]*plugin.Plugin)
-	return &res
+	return &res, nil
 }
 
 // RPC struct with methods representing RPC interface of this code runner
@@ -79,11 +80,20 @@ func (t *RPC) CallMethod(args rpctypes.DownCallMethodReq, reply *rpctypes.DownCa
 		return errors.New("Wrapper with wrong signature")
 	}
 
+	m := newMeter(args.Context.Limits)
 	gls.Set("ctx", args.Context)
+	gls.Set("meter", m)
 	state, result, err := wrapper(args.Data, args.Arguments) // may be entire args???
+	m.stop()
 	gls.Cleanup()
 
+	reply.Usage = m.usage()
+	reply.Usage.StateSize = len(state)
 	if err != nil {
+		if m.exceeded(reply.Usage) != nil {
+			// call failed because of limits, caller gets the consumed amounts instead of the error
+			return nil
+		}
 		return errors.Wrapf(err, "Method call returned error")
 	}
 	reply.Data = state
@@ -109,8 +119,23 @@ func (t *RPC) CallConstructor(args rpctypes.DownCallConstructorReq, reply *rpcty
 		return errors.New("Wrapper with wrong signature")
 	}
 
+	var limits core.CallLimits
+	if args.Context != nil {
+		limits = args.Context.Limits
+		gls.Set("ctx", args.Context)
+	}
+	m := newMeter(limits)
+	gls.Set("meter", m)
 	resValues, err := f(args.Arguments)
+	m.stop()
+	gls.Cleanup()
+
+	reply.Usage = m.usage()
+	reply.Usage.StateSize = len(resValues)
 	if err != nil {
+		if m.exceeded(reply.Usage) != nil {
+			return nil
+		}
 		return errors.Wrapf(err, "Can't call constructor %s", args.Name)
 	}
 
@@ -119,6 +144,11 @@ func (t *RPC) CallConstructor(args rpctypes.DownCallConstructorReq, reply *rpcty
 	return nil
 }
 
+// Ping is an RPC that GoPlugin uses to check health of the runner
+func (t *RPC) Ping(args rpctypes.DownPingReq, reply *rpctypes.DownPingResp) error {
+	return nil
+}
+
 // Upstream returns RPC client connected to upstream server (goplugin)
 func (gi *GoInsider) Upstream() (*rpc.Client, error) {
 	if gi.UpstreamClient != nil {
@@ -135,15 +165,10 @@ func (gi *GoInsider) Upstream() (*rpc.Client, error) {
 }
 
 // ObtainCode returns path on the file system to the plugin, fetches it from a provider
-// if it's not in the storage
+// if it's not in the cache. Code is verified against its hash from the ledger.
 func (gi *GoInsider) ObtainCode(ref core.RecordRef) (string, error) {
-	path := filepath.Join(gi.dir, ref.String())
-	_, err := os.Stat(path)
-
-	if err == nil {
+	if path, ok := gi.cache.get(ref); ok {
 		return path, nil
-	} else if !os.IsNotExist(err) {
-		return "", errors.Wrap(err, "file !notexists()")
 	}
 
 	client, err := gi.Upstream()
@@ -158,14 +183,24 @@ func (gi *GoInsider) ObtainCode(ref core.RecordRef) (string, error) {
 		return "", errors.Wrap(err, "on calling main API")
 	}
 
-	err = ioutil.WriteFile(path, res.Code, 0666)
+	path, err := gi.cache.put(ref, res.Code, res.Hash)
 	if err != nil {
-		return "", errors.Wrap(err, "on writing file down")
+		return "", errors.Wrap(err, "on storing code")
 	}
 
 	return path, nil
 }
 
+// Prewarm loads up to n most used plugins from the cache, so first calls
+// after a restart don't wait for them
+func (gi *GoInsider) Prewarm(n int) {
+	for _, ref := range gi.cache.popular(n) {
+		if _, err := gi.Plugin(ref); err != nil {
+			log.Warnf("couldn't prewarm plugin %s: %s", ref, err)
+		}
+	}
+}
+
 // Plugin loads Go plugin by reference and returns `*plugin.Plugin`
 // ready to lookup symbols
 func (gi *GoInsider) Plugin(ref core.RecordRef) (*plugin.Plugin, error) {
@@ -191,18 +226,33 @@ func (gi *GoInsider) Plugin(ref core.RecordRef) (*plugin.Plugin, error) {
 	return p, nil
 }
 
-// MakeUpBaseReq makes base of request from current CallContext
+// MakeUpBaseReq makes base of request from current CallContext,
+// constructor makes requests on behalf of the class as the object doesn't exist yet
 func MakeUpBaseReq() rpctypes.UpBaseReq {
-	if ctx, ok := gls.Get("ctx").(*core.LogicCallContext); ok {
-		return rpctypes.UpBaseReq{
-			Me: *ctx.Callee,
+	if ctx, ok := gls.Get("ctx").(*core.LogicCallContext); ok && (ctx.Callee != nil || ctx.Class != nil) {
+		me := ctx.Callee
+		if me == nil {
+			me = ctx.Class
+		}
+		req := rpctypes.UpBaseReq{
+			Me:       *me,
+			Deadline: ctx.Deadline,
+			Trace:    ctx.Trace,
+			ReadOnly: ctx.ReadOnly,
 		}
+		if ctx.Chain != nil {
+			req.Chain = *ctx.Chain
+		}
+		return req
 	}
 	panic("Wrong or unexistent context")
 }
 
 // RouteCall ...
 func (gi *GoInsider) RouteCall(ref core.RecordRef, wait bool, method string, args []byte) ([]byte, error) {
+	if err := countNestedCall(); err != nil {
+		return nil, err
+	}
 	client, err := gi.Upstream()
 	if err != nil {
 		return nil, err
@@ -216,7 +266,7 @@ func (gi *GoInsider) RouteCall(ref core.RecordRef, wait bool, method string, arg
 	}
 
 	res := rpctypes.UpRouteResp{}
-	err = client.Call("RPC.RouteCall", req, &res)
+	err = callUpstream(client, "RPC.RouteCall", req, &res)
 	if err != nil {
 		return nil, errors.Wrap(err, "on calling main API")
 	}
@@ -226,6 +276,9 @@ func (gi *GoInsider) RouteCall(ref core.RecordRef, wait bool, method string, arg
 
 // SaveAsChild ...
 func (gi *GoInsider) SaveAsChild(parentRef, classRef core.RecordRef, constructorName string, argsSerialized []byte) (core.RecordRef, error) {
+	if err := countNestedCall(); err != nil {
+		return core.NewRefFromBase58(""), err
+	}
 	client, err := gi.Upstream()
 	if err != nil {
 		return core.NewRefFromBase58(""), err
@@ -240,7 +293,7 @@ func (gi *GoInsider) SaveAsChild(parentRef, classRef core.RecordRef, constructor
 	}
 
 	res := rpctypes.UpSaveAsChildResp{}
-	err = client.Call("RPC.SaveAsChild", req, &res)
+	err = callUpstream(client, "RPC.SaveAsChild", req, &res)
 	if err != nil {
 		return core.NewRefFromBase58(""), errors.Wrap(err, "on calling main API")
 	}
@@ -261,7 +314,7 @@ func (gi *GoInsider) GetObjChildren(obj core.RecordRef, class core.RecordRef) ([
 		Obj:       obj,
 		Class:     class,
 	}
-	err = client.Call("RPC.GetObjChildren", req, &res)
+	err = callUpstream(client, "RPC.GetObjChildren", req, &res)
 	if err != nil {
 		return nil, errors.Wrap(err, "on calling main API RPC.GetObjChildren")
 	}
@@ -271,6 +324,9 @@ func (gi *GoInsider) GetObjChildren(obj core.RecordRef, class core.RecordRef) ([
 
 // SaveAsDelegate ...
 func (gi *GoInsider) SaveAsDelegate(intoRef, classRef core.RecordRef, constructorName string, argsSerialized []byte) (core.RecordRef, error) {
+	if err := countNestedCall(); err != nil {
+		return core.NewRefFromBase58(""), err
+	}
 	client, err := gi.Upstream()
 	if err != nil {
 		return core.NewRefFromBase58(""), err
@@ -285,7 +341,7 @@ func (gi *GoInsider) SaveAsDelegate(intoRef, classRef core.RecordRef, constructo
 	}
 
 	res := rpctypes.UpSaveAsDelegateResp{}
-	err = client.Call("RPC.SaveAsDelegate", req, &res)
+	err = callUpstream(client, "RPC.SaveAsDelegate", req, &res)
 	if err != nil {
 		return core.NewRefFromBase58(""), errors.Wrap(err, "on calling main API")
 	}
@@ -307,7 +363,7 @@ func (gi *GoInsider) GetDelegate(object, ofType core.RecordRef) (core.RecordRef,
 	}
 
 	res := rpctypes.UpGetDelegateResp{}
-	err = client.Call("RPC.GetDelegate", req, &res)
+	err = callUpstream(client, "RPC.GetDelegate", req, &res)
 	if err != nil {
 		return core.NewRefFromBase58(""), errors.Wrap(err, "on calling main API")
 	}
@@ -315,18 +371,38 @@ func (gi *GoInsider) GetDelegate(object, ofType core.RecordRef) (core.RecordRef,
 	return res.Object, nil
 }
 
+// EmitEvent ...
+func (gi *GoInsider) EmitEvent(name string, payload []byte) error {
+	client, err := gi.Upstream()
+	if err != nil {
+		return err
+	}
+
+	req := rpctypes.UpEmitEventReq{
+		UpBaseReq: MakeUpBaseReq(),
+		Name:      name,
+		Payload:   payload,
+	}
+
+	res := rpctypes.UpEmitEventResp{}
+	err = callUpstream(client, "RPC.EmitEvent", req, &res)
+	if err != nil {
+		return errors.Wrap(err, "on calling main API")
+	}
+
+	return nil
+}
+
 // Serialize - CBOR serializer wrapper: `what` -> `to`
 func (gi *GoInsider) Serialize(what interface{}, to *[]byte) error {
-	ch := new(codec.CborHandle)
 	log.Debugf("serializing %+v", what)
-	return codec.NewEncoderBytes(to, ch).Encode(what)
+	return codec.NewEncoderBytes(to, cborHandle()).Encode(what)
 }
 
 // Deserialize - CBOR de-serializer wrapper: `from` -> `into`
 func (gi *GoInsider) Deserialize(from []byte, into interface{}) error {
-	ch := new(codec.CborHandle)
 	log.Debugf("de-serializing %+v", from)
-	return codec.NewDecoderBytes(from, ch).Decode(into)
+	return codec.NewDecoderBytes(from, cborHandle()).Decode(into)
 }
 
 // MakeErrorSerializable converts errors satisfying error interface to foundation.Error
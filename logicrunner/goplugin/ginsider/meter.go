@@ -0,0 +1,124 @@
+/*
+ *    Copyright 2018 Insolar
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package ginsider
+
+import (
+	"net/rpc"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/tylerb/gls"
+
+	"github.com/insolar/insolar/core"
+)
+
+// metering is held by the call while its contract code runs, so memory allocated by the runner meanwhile
+// is allocated by the call. Contracts can't start goroutines, the call releases it only to wait for upstream,
+// so nested calls to the same runner can run. Contract code of the runner is executed one call at a time,
+// pool of runners executes calls in parallel.
+var metering sync.Mutex
+
+// meter measures resources consumed by a contract call
+type meter struct {
+	limits      core.CallLimits
+	start       time.Time
+	nestedCalls int32
+
+	running bool   // call holds metering
+	memory  uint64 // allocated by the call before the last pause
+	resumed uint64 // allocated by the runner when the call resumed
+}
+
+// newMeter starts metering of the call executed in the current goroutine, it waits for other calls to pause
+func newMeter(limits core.CallLimits) *meter {
+	m := &meter{limits: limits}
+	m.resume()
+	m.start = time.Now()
+	return m
+}
+
+func totalAlloc() uint64 {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	return ms.TotalAlloc
+}
+
+// resume continues metering after pause
+func (m *meter) resume() {
+	metering.Lock()
+	m.running = true
+	m.resumed = totalAlloc()
+}
+
+// pause accounts memory allocated so far and lets other calls run
+func (m *meter) pause() {
+	m.memory += totalAlloc() - m.resumed
+	m.running = false
+	metering.Unlock()
+}
+
+// stop finishes metering of the call
+func (m *meter) stop() {
+	m.pause()
+}
+
+// usage returns resources consumed so far
+func (m *meter) usage() core.CallUsage {
+	memory := m.memory
+	if m.running {
+		memory += totalAlloc() - m.resumed
+	}
+	return core.CallUsage{
+		Time:        time.Since(m.start),
+		Memory:      memory,
+		NestedCalls: int(atomic.LoadInt32(&m.nestedCalls)),
+	}
+}
+
+// exceeded returns error if usage is over limits
+func (m *meter) exceeded(u core.CallUsage) error {
+	if limit := m.limits.Exceeded(u); limit != "" {
+		return &core.LimitExceededError{Limit: limit, Limits: m.limits, Usage: u}
+	}
+	return nil
+}
+
+// nestedCall accounts a call to other contract, the call isn't allowed if limits are already exceeded
+func (m *meter) nestedCall() error {
+	atomic.AddInt32(&m.nestedCalls, 1)
+	return m.exceeded(m.usage())
+}
+
+// countNestedCall accounts a call to other contract made by the call executed in the current goroutine
+func countNestedCall() error {
+	if m, ok := gls.Get("meter").(*meter); ok {
+		return m.nestedCall()
+	}
+	return nil
+}
+
+// callUpstream makes a request to the node on behalf of the call executed in the current goroutine,
+// metering of the call is paused till the node replies
+func callUpstream(client *rpc.Client, method string, req interface{}, res interface{}) error {
+	if m, ok := gls.Get("meter").(*meter); ok {
+		m.pause()
+		defer m.resume()
+	}
+	return client.Call(method, req, res)
+}
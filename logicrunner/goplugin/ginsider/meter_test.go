@@ -0,0 +1,66 @@
+/*
+ *    Copyright 2018 Insolar
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package ginsider
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/insolar/insolar/core"
+)
+
+var sink []byte
+
+func TestMeter_Memory(t *testing.T) {
+	m := newMeter(core.CallLimits{Memory: 1 << 20})
+	sink = make([]byte, 2<<20)
+	m.pause()
+	// memory allocated while the call waits for upstream isn't the call's
+	sink = make([]byte, 8<<20)
+	m.resume()
+	m.stop()
+	sink = nil
+
+	u := m.usage()
+	assert.True(t, u.Memory >= 2<<20, "allocated %d", u.Memory)
+	assert.True(t, u.Memory < 8<<20, "allocated %d", u.Memory)
+	assert.Equal(t, core.CallLimitMemory, m.exceeded(u).(*core.LimitExceededError).Limit)
+}
+
+func TestMeter_OneCallAtATime(t *testing.T) {
+	m := newMeter(core.CallLimits{})
+	started := make(chan struct{})
+	go func() {
+		other := newMeter(core.CallLimits{})
+		close(started)
+		other.stop()
+	}()
+
+	select {
+	case <-started:
+		t.Fatal("other call started while the call is running")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// call waits for upstream, other calls may run
+	m.pause()
+	<-started
+	m.resume()
+	m.stop()
+}
@@ -0,0 +1,70 @@
+/*
+ *    Copyright 2018 Insolar
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package ginsider
+
+import (
+	"math/big"
+	"reflect"
+
+	"github.com/pkg/errors"
+	"github.com/ugorji/go/codec"
+)
+
+// bigIntTag is CBOR tag of big.Int values, they are serialized as decimal strings
+const bigIntTag = 0xB16
+
+// cborHandle returns handle for contracts' data, it's canonical, so the same state
+// is always serialized the same way, and knows how to serialize big.Int
+func cborHandle() *codec.CborHandle {
+	ch := new(codec.CborHandle)
+	ch.Canonical = true
+	if err := ch.SetInterfaceExt(reflect.TypeOf(big.Int{}), bigIntTag, bigIntExt{}); err != nil {
+		panic(err)
+	}
+	return ch
+}
+
+// bigIntExt is CBOR extension for big.Int
+type bigIntExt struct{}
+
+// ConvertExt converts big.Int into its decimal representation
+func (bigIntExt) ConvertExt(v interface{}) interface{} {
+	if i, ok := v.(big.Int); ok {
+		v = &i
+	}
+	i, ok := v.(*big.Int)
+	if !ok {
+		panic(errors.Errorf("can't serialize %T as big.Int", v))
+	}
+	return i.String()
+}
+
+// UpdateExt sets big.Int from its decimal representation
+func (bigIntExt) UpdateExt(dst interface{}, src interface{}) {
+	var s string
+	switch v := src.(type) {
+	case string:
+		s = v
+	case []byte:
+		s = string(v)
+	default:
+		panic(errors.Errorf("can't deserialize big.Int from %T", src))
+	}
+	if _, ok := dst.(*big.Int).SetString(s, 10); !ok {
+		panic(errors.Errorf("can't deserialize big.Int from %q", s))
+	}
+}
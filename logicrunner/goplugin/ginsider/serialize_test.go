@@ -0,0 +1,66 @@
+/*
+ *    Copyright 2018 Insolar
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package ginsider
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/insolar/insolar/core"
+)
+
+func TestSerializeRichTypes(t *testing.T) {
+	type item struct {
+		Owner   core.RecordRef
+		Amount  *big.Int
+		Tags    map[string][]byte
+		Parents []core.RecordRef
+	}
+
+	gi := &GoInsider{}
+	in := item{
+		Owner:   core.RandomRef(),
+		Amount:  new(big.Int).Lsh(big.NewInt(1), 100),
+		Tags:    map[string][]byte{"a": []byte("b")},
+		Parents: []core.RecordRef{core.RandomRef(), core.RandomRef()},
+	}
+
+	var data []byte
+	err := gi.Serialize(in, &data)
+	assert.NoError(t, err)
+
+	var out item
+	err = gi.Deserialize(data, &out)
+	assert.NoError(t, err)
+	assert.Equal(t, in.Owner, out.Owner)
+	assert.Equal(t, 0, in.Amount.Cmp(out.Amount))
+	assert.Equal(t, in.Tags, out.Tags)
+	assert.Equal(t, in.Parents, out.Parents)
+
+	// values are deserialized right into typed variables as proxies do
+	var a0 *big.Int
+	var a1 core.RecordRef
+	err = gi.Serialize([]interface{}{in.Amount, in.Owner}, &data)
+	assert.NoError(t, err)
+	resList := []interface{}{&a0, &a1}
+	err = gi.Deserialize(data, &resList)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, in.Amount.Cmp(a0))
+	assert.Equal(t, in.Owner, a1)
+}
@@ -19,7 +19,6 @@ package goplugin
 
 import (
 	"net/rpc"
-	"os/exec"
 	"time"
 
 	"github.com/insolar/insolar/configuration"
@@ -47,16 +46,24 @@ type GoPlugin struct {
 	Cfg             *configuration.LogicRunner
 	MessageBus      core.MessageBus
 	ArtifactManager core.ArtifactManager
-	runner          *exec.Cmd
-	client          *rpc.Client
+	pool            *pool
 }
 
 // NewGoPlugin returns a new started GoPlugin
 func NewGoPlugin(conf *configuration.LogicRunner, eb core.MessageBus, am core.ArtifactManager) (*GoPlugin, error) {
+	p, err := newPool(conf)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't configure runners")
+	}
+	if err := p.start(); err != nil {
+		return nil, errors.Wrap(err, "couldn't start runners")
+	}
+
 	gp := GoPlugin{
 		Cfg:             conf,
 		MessageBus:      eb,
 		ArtifactManager: am,
+		pool:            p,
 	}
 
 	return &gp, nil
@@ -64,36 +71,83 @@ func NewGoPlugin(conf *configuration.LogicRunner, eb core.MessageBus, am core.Ar
 
 // Stop stops runner(s) and RPC service
 func (gp *GoPlugin) Stop() error {
-	return nil
+	return gp.pool.stop()
 }
 
-// Downstream returns a connection to `ginsider`
+// Downstream returns a connection to `ginsider`, runners of the pool take turns
 func (gp *GoPlugin) Downstream() (*rpc.Client, error) {
-	if gp.client != nil {
-		return gp.client, nil
+	_, client, err := gp.pool.get()
+	return client, err
+}
+
+const timeout = time.Second * 60
+
+// callTimeout returns time left for the call, it's never greater than the default timeout
+func callTimeout(ctx *core.LogicCallContext) time.Duration {
+	if ctx == nil || ctx.Deadline.IsZero() {
+		return timeout
 	}
+	left := time.Until(ctx.Deadline)
+	if left > timeout {
+		return timeout
+	}
+	return left
+}
 
-	client, err := rpc.Dial(gp.Cfg.GoPlugin.RunnerProtocol, gp.Cfg.GoPlugin.RunnerListen)
+// timeoutError returns error of the call that didn't finish in time
+func timeoutError(ctx *core.LogicCallContext, start time.Time) error {
+	if ctx == nil || ctx.Deadline.IsZero() {
+		return errors.New("timeout")
+	}
+	return ctx.TimeoutError(time.Since(start))
+}
+
+// checkUsage checks resources consumed by the call as reported by the runner and the actual state size
+func checkUsage(ctx *core.LogicCallContext, usage core.CallUsage, state []byte) error {
+	if ctx == nil {
+		return nil
+	}
+	if len(state) > usage.StateSize {
+		usage.StateSize = len(state)
+	}
+	if limit := ctx.Limits.Exceeded(usage); limit != "" {
+		return &core.LimitExceededError{Limit: limit, Limits: ctx.Limits, Usage: usage}
+	}
+	return nil
+}
+
+// call makes an RPC call to one of runners within the time limit of the call
+func (gp *GoPlugin) call(ctx *core.LogicCallContext, method string, req interface{}, res interface{}) error {
+	r, client, err := gp.pool.get()
 	if err != nil {
-		return nil, errors.Wrapf(
-			err, "couldn't dial '%s' over %s",
-			gp.Cfg.GoPlugin.RunnerListen, gp.Cfg.GoPlugin.RunnerProtocol,
-		)
+		return errors.Wrap(err, "problem with rpc connection")
 	}
 
-	gp.client = client
-	return gp.client, nil
+	start := time.Now()
+	select {
+	case call := <-client.Go(method, req, res, nil).Done:
+		if call.Error != nil {
+			gp.pool.failed(r, client, call.Error)
+			return errors.Wrap(call.Error, "problem with API call")
+		}
+	case <-time.After(callTimeout(ctx)):
+		if exhausted(ctx) {
+			gp.pool.abandoned(r, client)
+		}
+		// otherwise the caller gave up, the call is dropped and its result is discarded when it comes
+		return timeoutError(ctx, start)
+	}
+	return nil
 }
 
-const timeout = time.Second * 60
+// exhausted checks that the call ran out of its own time limit, then the contract is a runaway
+// and its runner has to be restarted. Contract is left running when only the caller gave up waiting.
+func exhausted(ctx *core.LogicCallContext) bool {
+	return ctx != nil && ctx.TimeLimited && !time.Now().Before(ctx.Deadline)
+}
 
 // CallMethod runs a method on an object in controlled environment
 func (gp *GoPlugin) CallMethod(ctx *core.LogicCallContext, code core.RecordRef, data []byte, method string, args core.Arguments) ([]byte, core.Arguments, error) {
-	client, err := gp.Downstream()
-	if err != nil {
-		return nil, nil, errors.Wrap(err, "problem with rpc connection")
-	}
-
 	res := rpctypes.DownCallMethodResp{}
 	req := rpctypes.DownCallMethodReq{
 		Context:   ctx,
@@ -103,34 +157,25 @@ func (gp *GoPlugin) CallMethod(ctx *core.LogicCallContext, code core.RecordRef,
 		Arguments: args,
 	}
 
-	select {
-	case call := <-client.Go("RPC.CallMethod", req, &res, nil).Done:
-		if call.Error != nil {
-			return nil, nil, errors.Wrap(call.Error, "problem with API call")
-		}
-	case <-time.After(timeout):
-		return nil, nil, errors.New("timeout")
+	if err := gp.call(ctx, "RPC.CallMethod", req, &res); err != nil {
+		return nil, nil, err
+	}
+	if err := checkUsage(ctx, res.Usage, res.Data); err != nil {
+		return nil, nil, err
 	}
 	return res.Data, res.Ret, nil
 }
 
 // CallConstructor runs a constructor of a contract in controlled environment
 func (gp *GoPlugin) CallConstructor(ctx *core.LogicCallContext, code core.RecordRef, name string, args core.Arguments) ([]byte, error) {
-	client, err := gp.Downstream()
-	if err != nil {
-		return nil, errors.Wrap(err, "problem with rpc connection")
-	}
-
 	res := rpctypes.DownCallConstructorResp{}
-	req := rpctypes.DownCallConstructorReq{Code: code, Name: name, Arguments: args}
+	req := rpctypes.DownCallConstructorReq{Context: ctx, Code: code, Name: name, Arguments: args}
 
-	select {
-	case call := <-client.Go("RPC.CallConstructor", req, &res, nil).Done:
-		if call.Error != nil {
-			return nil, errors.Wrap(call.Error, "problem with API call")
-		}
-	case <-time.After(timeout):
-		return nil, errors.New("timeout")
+	if err := gp.call(ctx, "RPC.CallConstructor", req, &res); err != nil {
+		return nil, err
+	}
+	if err := checkUsage(ctx, res.Usage, res.Ret); err != nil {
+		return nil, err
 	}
 	return res.Ret, nil
 }
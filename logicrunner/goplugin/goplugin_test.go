@@ -17,7 +17,13 @@
 package goplugin
 
 import (
+	"context"
+	"net"
+	"net/rpc"
 	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
 
 	"github.com/insolar/insolar/core"
 )
@@ -25,3 +31,123 @@ import (
 func TestTypeCompatibility(t *testing.T) {
 	var _ core.MachineLogicExecutor = (*GoPlugin)(nil)
 }
+
+func TestCheckUsage(t *testing.T) {
+	ctx := &core.LogicCallContext{
+		Limits: core.CallLimits{NestedCalls: 1, StateSize: 3},
+	}
+
+	assert.NoError(t, checkUsage(nil, core.CallUsage{NestedCalls: 10}, []byte("long state")))
+	assert.NoError(t, checkUsage(ctx, core.CallUsage{NestedCalls: 1}, []byte("abc")))
+
+	err := checkUsage(ctx, core.CallUsage{NestedCalls: 2}, []byte("abc"))
+	assert.Equal(t, &core.LimitExceededError{
+		Limit:  core.CallLimitNestedCalls,
+		Limits: ctx.Limits,
+		Usage:  core.CallUsage{NestedCalls: 2, StateSize: 3},
+	}, err)
+
+	err = checkUsage(ctx, core.CallUsage{}, []byte("abcd"))
+	assert.Equal(t, core.CallLimitStateSize, err.(*core.LimitExceededError).Limit)
+}
+
+func TestTimeoutError(t *testing.T) {
+	_, ok := timeoutError(nil, time.Now()).(*core.LimitExceededError)
+	assert.False(t, ok)
+
+	ctx := &core.LogicCallContext{
+		Deadline:    time.Now(),
+		TimeLimited: true,
+		Limits:      core.CallLimits{Time: time.Second},
+	}
+	err, ok := timeoutError(ctx, time.Now().Add(-time.Second)).(*core.LimitExceededError)
+	assert.True(t, ok)
+	assert.Equal(t, core.CallLimitTime, err.Limit)
+	assert.True(t, err.Usage.Time >= time.Second)
+
+	// the caller's deadline came before the end of the time limit
+	ctx.TimeLimited = false
+	assert.Equal(t, context.DeadlineExceeded, timeoutError(ctx, time.Now().Add(-time.Second)))
+}
+
+func TestRunnerAddress(t *testing.T) {
+	addr, err := runnerAddress("tcp", "127.0.0.1:7777", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "127.0.0.1:7777", addr)
+
+	addr, err = runnerAddress("tcp", "127.0.0.1:7777", 2)
+	assert.NoError(t, err)
+	assert.Equal(t, "127.0.0.1:7779", addr)
+
+	addr, err = runnerAddress("unix", "/tmp/rund.sock", 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "/tmp/rund.sock.1", addr)
+
+	_, err = runnerAddress("tcp", "localhost", 1)
+	assert.Error(t, err)
+}
+
+func TestPool_abandoned(t *testing.T) {
+	r := &runner{path: "sleep", args: []string{"60"}, protocol: "tcp", address: "127.0.0.1:0"}
+	p := &pool{runners: []*runner{r}, check: make(chan struct{}, 1)}
+	assert.NoError(t, r.start())
+	defer r.stop()
+	hung := r.cmd.Process.Pid
+
+	// call to the runner timed out, it's skipped until the supervisor restarts it
+	p.abandoned(r, nil)
+	assert.False(t, r.alive())
+	assert.Len(t, p.check, 1)
+
+	p.heal(r)
+	assert.True(t, r.alive())
+	assert.NotEqual(t, hung, r.cmd.Process.Pid)
+
+	// runners started by someone else aren't restarted
+	external := &runner{protocol: "tcp", address: "127.0.0.1:0"}
+	p.abandoned(external, nil)
+	assert.True(t, external.alive())
+}
+
+type slowService struct{}
+
+func (*slowService) Wait(d time.Duration, res *bool) error {
+	time.Sleep(d)
+	*res = true
+	return nil
+}
+
+func TestGoPlugin_callTimeout(t *testing.T) {
+	server := rpc.NewServer()
+	assert.NoError(t, server.RegisterName("Slow", &slowService{}))
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer l.Close()
+	go server.Accept(l)
+
+	r := &runner{path: "sleep", args: []string{"60"}, protocol: "tcp", address: l.Addr().String()}
+	p := &pool{runners: []*runner{r}, check: make(chan struct{}, 1)}
+	assert.NoError(t, r.start())
+	defer r.stop()
+	gp := &GoPlugin{pool: p}
+
+	// caller gave up, the call is dropped and the runner keeps working
+	var res bool
+	ctx := &core.LogicCallContext{Deadline: time.Now().Add(10 * time.Millisecond)}
+	err = gp.call(ctx, "Slow.Wait", time.Second, &res)
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.False(t, r.isHung())
+	assert.Len(t, p.check, 0)
+	assert.NoError(t, gp.call(nil, "Slow.Wait", time.Duration(0), &res))
+
+	// call ran out of its time limit, the runner is restarted
+	ctx = &core.LogicCallContext{
+		Deadline:    time.Now().Add(10 * time.Millisecond),
+		TimeLimited: true,
+		Limits:      core.CallLimits{Time: 10 * time.Millisecond},
+	}
+	err = gp.call(ctx, "Slow.Wait", time.Second, &res)
+	assert.Equal(t, core.CallLimitTime, err.(*core.LimitExceededError).Limit)
+	assert.True(t, r.isHung())
+	assert.Len(t, p.check, 1)
+}
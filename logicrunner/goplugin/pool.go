@@ -0,0 +1,431 @@
+/*
+ *    Copyright 2018 Insolar
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package goplugin
+
+import (
+	"io"
+	"net"
+	"net/rpc"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/insolar/insolar/configuration"
+	"github.com/insolar/insolar/log"
+	"github.com/insolar/insolar/logicrunner/goplugin/rpctypes"
+	"github.com/pkg/errors"
+)
+
+const (
+	// dialTimeout is time a just started runner has to start listening
+	dialTimeout = time.Second * 5
+	// dialRetry is delay between attempts to dial a starting runner
+	dialRetry = time.Millisecond * 50
+	// stopTimeout is time a runner has to exit after SIGINT before it's killed
+	stopTimeout = time.Second * 5
+	// healthCheckInterval is used when the interval isn't configured
+	healthCheckInterval = time.Second
+)
+
+// runnerAddress returns address the n-th runner of the pool listens on
+func runnerAddress(protocol, listen string, n int) (string, error) {
+	if n == 0 {
+		return listen, nil
+	}
+	if protocol == "unix" {
+		return listen + "." + strconv.Itoa(n), nil
+	}
+	host, port, err := net.SplitHostPort(listen)
+	if err != nil {
+		return "", errors.Wrapf(err, "couldn't derive address of runner %d from '%s'", n, listen)
+	}
+	p, err := strconv.Atoi(port)
+	if err != nil {
+		return "", errors.Wrapf(err, "couldn't derive address of runner %d from '%s'", n, listen)
+	}
+	return net.JoinHostPort(host, strconv.Itoa(p+n)), nil
+}
+
+// runner is a process of `insgorund` and a connection to it
+type runner struct {
+	// path is a binary of the runner, empty when the runner is started by someone else
+	path     string
+	args     []string
+	protocol string
+	address  string
+
+	mu     sync.Mutex
+	cmd    *exec.Cmd
+	exited chan struct{}
+	client *rpc.Client
+	hung   bool // runner is executing a call nobody waits for, it must be restarted
+}
+
+// start spawns the process of the runner, it's no-op for runners started by someone else
+func (r *runner) start() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.path == "" {
+		return nil
+	}
+
+	r.removeSocket()
+	cmd := exec.Command(r.path, r.args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Start(); err != nil {
+		return errors.Wrapf(err, "couldn't start runner '%s'", r.path)
+	}
+
+	exited := make(chan struct{})
+	go func() {
+		err := cmd.Wait()
+		log.Infof("runner %s exited: %v", r.address, err)
+		close(exited)
+	}()
+	r.cmd = cmd
+	r.exited = exited
+	r.hung = false
+	return nil
+}
+
+// removeSocket removes unix socket left by a crashed runner, it prevents a new one from listening
+func (r *runner) removeSocket() {
+	if r.protocol != "unix" {
+		return
+	}
+	if err := os.Remove(r.address); err != nil && !os.IsNotExist(err) {
+		log.Warnf("couldn't remove socket of runner %s: %s", r.address, err)
+	}
+}
+
+// alive checks that the process of the runner is running and isn't waiting for restart
+func (r *runner) alive() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.path == "" {
+		return true
+	}
+	return r.running() && !r.hung
+}
+
+// isHung checks that the runner is waiting for restart
+func (r *runner) isHung() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.hung
+}
+
+// running checks the process, r.mu must be held
+func (r *runner) running() bool {
+	if r.exited == nil {
+		return false
+	}
+	select {
+	case <-r.exited:
+		return false
+	default:
+		return true
+	}
+}
+
+// connection returns a client connected to the runner, dials the runner if needed
+func (r *runner) connection() (*rpc.Client, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.client != nil {
+		return r.client, nil
+	}
+
+	deadline := time.Now().Add(dialTimeout)
+	for {
+		client, err := rpc.Dial(r.protocol, r.address)
+		if err == nil {
+			r.client = client
+			return client, nil
+		}
+		// runners we started may need some time to start listening
+		if r.path == "" || !r.running() || time.Now().After(deadline) {
+			return nil, errors.Wrapf(err, "couldn't dial '%s' over %s", r.address, r.protocol)
+		}
+		time.Sleep(dialRetry)
+	}
+}
+
+// disconnect drops the client if it's still the current one, next call dials the runner again
+func (r *runner) disconnect(client *rpc.Client) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.client == nil || r.client != client {
+		return
+	}
+	if err := r.client.Close(); err != nil && err != rpc.ErrShutdown {
+		log.Warnf("couldn't close connection to runner %s: %s", r.address, err)
+	}
+	r.client = nil
+}
+
+// ping checks that the runner responds in time
+func (r *runner) ping(timeout time.Duration) error {
+	client, err := r.connection()
+	if err != nil {
+		return err
+	}
+
+	select {
+	case call := <-client.Go("RPC.Ping", rpctypes.DownPingReq{}, &rpctypes.DownPingResp{}, nil).Done:
+		if call.Error != nil {
+			r.disconnect(client)
+			return call.Error
+		}
+	case <-time.After(timeout):
+		r.disconnect(client)
+		return errors.New("timeout")
+	}
+	return nil
+}
+
+// stop closes connection to the runner and stops its process
+func (r *runner) stop() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.client != nil {
+		if err := r.client.Close(); err != nil && err != rpc.ErrShutdown {
+			log.Warnf("couldn't close connection to runner %s: %s", r.address, err)
+		}
+		r.client = nil
+	}
+	if !r.running() {
+		return nil
+	}
+
+	if err := r.cmd.Process.Signal(syscall.SIGINT); err != nil {
+		return errors.Wrapf(err, "couldn't stop runner %s", r.address)
+	}
+	select {
+	case <-r.exited:
+	case <-time.After(stopTimeout):
+		if err := r.cmd.Process.Kill(); err != nil {
+			return errors.Wrapf(err, "couldn't kill runner %s", r.address)
+		}
+		<-r.exited
+	}
+	r.removeSocket()
+	return nil
+}
+
+// pool is a set of runners calls are balanced between, runners started
+// by the pool are supervised and restarted when they crash or hang
+type pool struct {
+	runners  []*runner
+	next     uint32
+	interval time.Duration
+	check    chan struct{}
+	done     chan struct{}
+	wg       sync.WaitGroup
+}
+
+// newPool creates a pool of runners described by the configuration
+func newPool(cfg *configuration.LogicRunner) (*pool, error) {
+	gpc := cfg.GoPlugin
+	count := gpc.RunnerCount
+	if count < 1 || gpc.RunnerPath == "" {
+		count = 1
+	}
+	interval := time.Duration(gpc.HealthCheckInterval) * time.Millisecond
+	if interval <= 0 {
+		interval = healthCheckInterval
+	}
+
+	p := &pool{
+		interval: interval,
+		check:    make(chan struct{}, 1),
+		done:     make(chan struct{}),
+	}
+	for n := 0; n < count; n++ {
+		address, err := runnerAddress(gpc.RunnerProtocol, gpc.RunnerListen, n)
+		if err != nil {
+			return nil, err
+		}
+		args := []string{
+			"--listen", address, "--proto", gpc.RunnerProtocol,
+			"--rpc", cfg.RPCListen, "--rpc-proto", cfg.RPCProtocol,
+		}
+		if gpc.CodeCachePath != "" {
+			args = append(args, "--directory", filepath.Join(gpc.CodeCachePath, strconv.Itoa(n)))
+		}
+		if gpc.CodeCacheSize > 0 {
+			args = append(args, "--cache-size", strconv.FormatInt(gpc.CodeCacheSize, 10))
+		}
+		p.runners = append(p.runners, &runner{
+			path:     gpc.RunnerPath,
+			args:     args,
+			protocol: gpc.RunnerProtocol,
+			address:  address,
+		})
+	}
+	return p, nil
+}
+
+// start spawns runners and starts supervising them
+func (p *pool) start() error {
+	for i, r := range p.runners {
+		if err := r.start(); err != nil {
+			for _, started := range p.runners[:i] {
+				if err := started.stop(); err != nil {
+					log.Errorf("couldn't stop runner %s: %s", started.address, err)
+				}
+			}
+			return err
+		}
+	}
+
+	p.wg.Add(1)
+	go p.supervise()
+	return nil
+}
+
+// stop stops supervising and runners of the pool
+func (p *pool) stop() error {
+	close(p.done)
+	p.wg.Wait()
+
+	var reterr error
+	for _, r := range p.runners {
+		if err := r.stop(); err != nil {
+			reterr = err
+			log.Errorf("couldn't stop runner %s: %s", r.address, err)
+		}
+	}
+	return reterr
+}
+
+// get returns the next runner in round robin order and a connection to it,
+// runners that are down are skipped
+func (p *pool) get() (*runner, *rpc.Client, error) {
+	err := errors.New("no runners")
+	for range p.runners {
+		n := atomic.AddUint32(&p.next, 1)
+		r := p.runners[int(n%uint32(len(p.runners)))]
+		if !r.alive() {
+			err = errors.Errorf("runner %s is down", r.address)
+			p.wake()
+			continue
+		}
+		var client *rpc.Client
+		client, err = r.connection()
+		if err == nil {
+			return r, client, nil
+		}
+		p.wake()
+	}
+	return nil, nil, errors.Wrap(err, "no runner available")
+}
+
+// failed handles an error of a call to the runner, a broken connection is dropped
+// and the runner is checked by the supervisor without waiting for the next tick
+func (p *pool) failed(r *runner, client *rpc.Client, err error) {
+	if err != rpc.ErrShutdown && err != io.ErrUnexpectedEOF {
+		return
+	}
+	r.disconnect(client)
+	p.wake()
+}
+
+// abandoned handles a call that ran out of its time limit. Go can't interrupt the contract running in the runner,
+// so the runner is restarted by the supervisor, calls executed by the runner concurrently fail.
+// Runners started by someone else can only be disconnected, the contract keeps running there.
+func (p *pool) abandoned(r *runner, client *rpc.Client) {
+	r.disconnect(client)
+	if r.path == "" {
+		log.Warnf("call to runner %s timed out, the runner isn't managed by the pool and isn't restarted", r.address)
+		return
+	}
+	r.mu.Lock()
+	r.hung = true
+	r.mu.Unlock()
+	p.wake()
+}
+
+// wake triggers a health check of runners
+func (p *pool) wake() {
+	select {
+	case p.check <- struct{}{}:
+	default:
+	}
+}
+
+// supervise periodically checks runners of the pool
+func (p *pool) supervise() {
+	defer p.wg.Done()
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.done:
+			return
+		case <-ticker.C:
+		case <-p.check:
+		}
+		for _, r := range p.runners {
+			p.heal(r)
+		}
+	}
+}
+
+// heal restarts a runner that crashed, doesn't respond or executes an abandoned call,
+// runners started by someone else only get reconnected
+func (p *pool) heal(r *runner) {
+	if r.isHung() {
+		log.Warnf("runner %s executes abandoned call, restarting", r.address)
+		p.restart(r)
+		return
+	}
+	if !r.alive() {
+		log.Warnf("runner %s is down, restarting", r.address)
+		if err := r.start(); err != nil {
+			log.Errorf("couldn't restart runner %s: %s", r.address, err)
+		}
+		return
+	}
+
+	err := r.ping(p.interval)
+	if err == nil {
+		return
+	}
+	log.Warnf("runner %s doesn't respond: %s", r.address, err)
+	if r.path == "" {
+		return
+	}
+	p.restart(r)
+}
+
+// restart stops the runner and starts it again
+func (p *pool) restart(r *runner) {
+	if err := r.stop(); err != nil {
+		log.Errorf("couldn't stop runner %s: %s", r.address, err)
+		return
+	}
+	if err := r.start(); err != nil {
+		log.Errorf("couldn't restart runner %s: %s", r.address, err)
+	}
+}
@@ -0,0 +1,200 @@
+/*
+ *    Copyright 2018 Insolar
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package preprocessor
+
+import (
+	"encoding/json"
+	"go/ast"
+	"go/token"
+	"io"
+	"strconv"
+
+	"github.com/insolar/insolar/core"
+)
+
+// ABI returns description of contract's methods and constructors
+func (pf *ParsedFile) ABI() *core.ContractABI {
+	abi := &core.ContractABI{
+		Contract:     pf.contract,
+		Methods:      []core.MethodABI{},
+		Constructors: []core.MethodABI{},
+	}
+	for _, fd := range pf.methods[pf.contract] {
+		abi.Methods = append(abi.Methods, pf.methodABI(fd))
+	}
+	for _, fd := range pf.constructors[pf.contract] {
+		abi.Constructors = append(abi.Constructors, pf.methodABI(fd))
+	}
+	return abi
+}
+
+// WriteABI writes into `out` contract's ABI as JSON
+func (pf *ParsedFile) WriteABI(out io.Writer) error {
+	data, err := json.MarshalIndent(pf.ABI(), "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = out.Write(append(data, '\n'))
+	return err
+}
+
+func (pf *ParsedFile) methodABI(fd *ast.FuncDecl) core.MethodABI {
+	return core.MethodABI{
+		Name:      fd.Name.Name,
+		Arguments: pf.argumentsABI(fd.Type.Params),
+		Results:   pf.argumentsABI(fd.Type.Results),
+		ReadOnly:  isReadOnly(fd),
+	}
+}
+
+func (pf *ParsedFile) argumentsABI(list *ast.FieldList) []core.ArgumentABI {
+	res := []core.ArgumentABI{}
+	if list == nil {
+		return res
+	}
+	for _, f := range list.List {
+		t := pf.typeABI(f.Type, make(map[string]bool))
+		if len(f.Names) == 0 {
+			res = append(res, core.ArgumentABI{Type: t})
+			continue
+		}
+		for _, name := range f.Names {
+			res = append(res, core.ArgumentABI{Name: name.Name, Type: t})
+		}
+	}
+	return res
+}
+
+// basicTypesABI describes builtin types of Go, sizes of int and uint are ones of 64-bit platforms
+var basicTypesABI = map[string]core.TypeABI{
+	"bool":    {Kind: core.KindBool},
+	"string":  {Kind: core.KindString},
+	"int":     {Kind: core.KindInt, Bits: 64},
+	"int8":    {Kind: core.KindInt, Bits: 8},
+	"int16":   {Kind: core.KindInt, Bits: 16},
+	"int32":   {Kind: core.KindInt, Bits: 32},
+	"rune":    {Kind: core.KindInt, Bits: 32},
+	"int64":   {Kind: core.KindInt, Bits: 64},
+	"uint":    {Kind: core.KindUint, Bits: 64},
+	"uint8":   {Kind: core.KindUint, Bits: 8},
+	"byte":    {Kind: core.KindUint, Bits: 8},
+	"uint16":  {Kind: core.KindUint, Bits: 16},
+	"uint32":  {Kind: core.KindUint, Bits: 32},
+	"uint64":  {Kind: core.KindUint, Bits: 64},
+	"float32": {Kind: core.KindFloat, Bits: 32},
+	"float64": {Kind: core.KindFloat, Bits: 64},
+	"error":   {Kind: core.KindError},
+}
+
+// typeABI describes the type as it's serialized, types declared in the contract's package
+// are expanded, `seen` holds declared types that are being expanded
+func (pf *ParsedFile) typeABI(t ast.Expr, seen map[string]bool) core.TypeABI {
+	switch t := t.(type) {
+	case *ast.Ident:
+		if t.Name == pf.contract {
+			// state of the contract isn't a part of its interface, callers get reference to the object
+			return core.TypeABI{Kind: core.KindReference, Name: t.Name}
+		}
+		spec, ok := pf.types[t.Name]
+		if !ok {
+			if basic, ok := basicTypesABI[t.Name]; ok {
+				return basic
+			}
+			return core.TypeABI{Kind: core.KindExternal, Name: t.Name}
+		}
+		if seen[t.Name] {
+			if _, ok := spec.Type.(*ast.StructType); ok {
+				return core.TypeABI{Kind: core.KindStruct, Name: t.Name}
+			}
+			return core.TypeABI{Kind: core.KindAny, Name: t.Name}
+		}
+		seen[t.Name] = true
+		res := pf.typeABI(spec.Type, seen)
+		delete(seen, t.Name)
+		res.Name = t.Name
+		return res
+	case *ast.StarExpr:
+		elem := pf.typeABI(t.X, seen)
+		return core.TypeABI{Kind: core.KindOptional, Elem: &elem}
+	case *ast.ArrayType:
+		if t.Len == nil {
+			if elt, ok := t.Elt.(*ast.Ident); ok && (elt.Name == "byte" || elt.Name == "uint8") {
+				return core.TypeABI{Kind: core.KindBytes}
+			}
+		}
+		elem := pf.typeABI(t.Elt, seen)
+		res := core.TypeABI{Kind: core.KindList, Elem: &elem}
+		if l, ok := t.Len.(*ast.BasicLit); ok && l.Kind == token.INT {
+			res.Length, _ = strconv.Atoi(l.Value)
+		}
+		return res
+	case *ast.Ellipsis:
+		elem := pf.typeABI(t.Elt, seen)
+		return core.TypeABI{Kind: core.KindList, Elem: &elem}
+	case *ast.MapType:
+		key := pf.typeABI(t.Key, seen)
+		elem := pf.typeABI(t.Value, seen)
+		return core.TypeABI{Kind: core.KindMap, Key: &key, Elem: &elem}
+	case *ast.StructType:
+		return core.TypeABI{Kind: core.KindStruct, Fields: pf.fieldsABI(t.Fields, seen)}
+	case *ast.InterfaceType:
+		return core.TypeABI{Kind: core.KindAny}
+	case *ast.SelectorExpr:
+		name := pf.codeOfNode(t)
+		if name == "core.RecordRef" {
+			return core.TypeABI{Kind: core.KindReference}
+		}
+		return core.TypeABI{Kind: core.KindExternal, Name: name}
+	}
+	return core.TypeABI{Kind: core.KindExternal, Name: pf.codeOfNode(t)}
+}
+
+// fieldsABI describes exported fields of a struct, unexported fields aren't serialized
+// and foundation.BaseContract is a part of every contract that has no data
+func (pf *ParsedFile) fieldsABI(list *ast.FieldList, seen map[string]bool) []core.FieldABI {
+	var res []core.FieldABI
+	for _, f := range list.List {
+		if len(f.Names) == 0 {
+			name := embeddedName(f.Type)
+			if !ast.IsExported(name) || pf.codeOfNode(f.Type) == "foundation.BaseContract" {
+				continue
+			}
+			res = append(res, core.FieldABI{Name: name, Type: pf.typeABI(f.Type, seen), Embedded: true})
+			continue
+		}
+		for _, name := range f.Names {
+			if name.IsExported() {
+				res = append(res, core.FieldABI{Name: name.Name, Type: pf.typeABI(f.Type, seen)})
+			}
+		}
+	}
+	return res
+}
+
+// embeddedName returns name of the field of embedded type
+func embeddedName(t ast.Expr) string {
+	if star, ok := t.(*ast.StarExpr); ok {
+		t = star.X
+	}
+	switch t := t.(type) {
+	case *ast.Ident:
+		return t.Name
+	case *ast.SelectorExpr:
+		return t.Sel.Name
+	}
+	return ""
+}
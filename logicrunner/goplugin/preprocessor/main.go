@@ -42,68 +42,204 @@ var foundationPath = "github.com/insolar/insolar/logicrunner/goplugin/foundation
 var proxyctxPath = "github.com/insolar/insolar/logicrunner/goplugin/proxyctx"
 var corePath = "github.com/insolar/insolar/core"
 
-// ParsedFile struct with prepared info we extract from source code
+// generatedCode matches comment that marks generated files, see `go generate`
+var generatedCode = regexp.MustCompile(`^// Code generated .* DO NOT EDIT\.$`)
+
+// ParsedFile struct with prepared info we extract from source code,
+// it's either a single file or all files of a contract's package
 type ParsedFile struct {
 	name    string
+	dir     string // set when the whole package is parsed
 	code    []byte
 	fileSet *token.FileSet
-	node    *ast.File
+	node    *ast.File // file with the contract type
+	files   []*ast.File
+	sources map[string][]byte
 
 	types        map[string]*ast.TypeSpec
 	methods      map[string][]*ast.FuncDecl
 	constructors map[string][]*ast.FuncDecl
 	contract     string
+	warnings     []string
 }
 
 // ParseFile parses a file as Go source code of a smart contract
 // and returns it as `ParsedFile`
 func ParseFile(fileName string) (*ParsedFile, error) {
 	res := &ParsedFile{
-		name: fileName,
+		name:    fileName,
+		fileSet: token.NewFileSet(),
+		sources: make(map[string][]byte),
+	}
+	if _, err := res.parseSource(fileName); err != nil {
+		return nil, err
+	}
+
+	err := res.parse()
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// ParsePackage parses all Go files in the directory as source code of a smart contract
+// and returns it as `ParsedFile`. The package must have exactly one contract type,
+// tests and generated files (e.g. wrappers) are skipped.
+func ParsePackage(dir string) (*ParsedFile, error) {
+	res := &ParsedFile{
+		name:    dir,
+		dir:     dir,
+		fileSet: token.NewFileSet(),
+		sources: make(map[string][]byte),
 	}
+
+	fileNames, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, errors.Wrap(err, "Can't list files")
+	}
+	for _, fileName := range fileNames {
+		if strings.HasSuffix(fileName, "_test.go") {
+			continue
+		}
+		node, err := res.parseSource(fileName)
+		if err != nil {
+			return nil, err
+		}
+		if isGenerated(node) {
+			res.files = res.files[:len(res.files)-1]
+			delete(res.sources, fileName)
+			continue
+		}
+		if first := res.files[0]; first.Name.Name != node.Name.Name {
+			return nil, errors.Errorf(
+				"Files of different packages %q and %q in %s", first.Name.Name, node.Name.Name, dir,
+			)
+		}
+	}
+	if len(res.files) == 0 {
+		return nil, errors.Errorf("No Go files in %s", dir)
+	}
+
+	err = res.parse()
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// parseSource reads and parses a file of the contract
+func (pf *ParsedFile) parseSource(fileName string) (*ast.File, error) {
 	sourceCode, err := slurpFile(fileName)
 	if err != nil {
 		return nil, errors.Wrap(err, "Can't read file")
 	}
-	res.code = sourceCode
 
-	res.fileSet = token.NewFileSet()
-	node, err := parser.ParseFile(res.fileSet, res.name, res.code, parser.ParseComments)
+	node, err := parser.ParseFile(pf.fileSet, fileName, sourceCode, parser.ParseComments)
 	if err != nil {
 		return nil, errors.Wrapf(err, "Can't parse %s", fileName)
 	}
-	res.node = node
+	pf.sources[fileName] = sourceCode
+	pf.files = append(pf.files, node)
+	return node, nil
+}
 
-	err = res.parseTypes()
+// parse finds the contract, its methods, constructors and helper types in parsed files
+func (pf *ParsedFile) parse() error {
+	err := pf.parseTypes()
 	if err != nil {
-		return nil, errors.Wrap(err, "")
+		return errors.Wrap(err, "")
 	}
 
-	err = res.parseFunctionsAndMethods()
+	err = pf.parseFunctionsAndMethods()
 	if err != nil {
-		return nil, errors.Wrap(err, "")
+		return errors.Wrap(err, "")
 	}
-	if res.contract == "" {
-		return nil, errors.New("Only one smart contract must exist")
+	if pf.contract == "" {
+		return errors.New("Only one smart contract must exist")
 	}
 
-	return res, nil
+	err = pf.checkSignatures()
+	if err != nil {
+		return err
+	}
+
+	pf.checkDeterminism()
+
+	return nil
+}
+
+// isGenerated checks if the file is marked as generated code
+func isGenerated(node *ast.File) bool {
+	for _, group := range node.Comments {
+		if group.Pos() >= node.Package {
+			break
+		}
+		for _, c := range group.List {
+			if generatedCode.MatchString(c.Text) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// fileOf returns parsed file that contains the position
+func (pf *ParsedFile) fileOf(pos token.Pos) *ast.File {
+	tf := pf.fileSet.File(pos)
+	for _, f := range pf.files {
+		if pf.fileSet.File(f.Package) == tf {
+			return f
+		}
+	}
+	return pf.node
+}
+
+// Warnings returns problems of the contract code found while parsing, that don't prevent compilation
+func (pf *ParsedFile) Warnings() []string {
+	return pf.warnings
+}
+
+func (pf *ParsedFile) warn(pos token.Pos, msg string) {
+	w := fmt.Sprintf("%s: %s", pf.fileSet.Position(pos), msg)
+	pf.warnings = append(pf.warnings, w)
+	log.Warn(w)
+}
+
+// checkDeterminism warns about use of the wall clock and math/rand in the contract,
+// validators replaying such code get different results
+func (pf *ParsedFile) checkDeterminism() {
+	for _, f := range pf.files {
+		pf.checkFileDeterminism(f)
+	}
+}
+
+func (pf *ParsedFile) checkFileDeterminism(node *ast.File) {
+	for _, imp := range node.Imports {
+		if strings.Trim(imp.Path.Value, `"`) == "math/rand" {
+			pf.warn(imp.Pos(), "math/rand isn't deterministic, use foundation.GetRandom() instead")
+		}
+	}
+	wallClockUses(node, func(pos token.Pos, name string) {
+		pf.warn(pos, "time."+name+" depends on the wall clock, use foundation.Now() instead")
+	})
 }
 
 func (pf *ParsedFile) parseTypes() error {
 	pf.types = make(map[string]*ast.TypeSpec)
-	for _, decl := range pf.node.Decls {
-		tDecl, ok := decl.(*ast.GenDecl)
-		if !ok || tDecl.Tok != token.TYPE {
-			continue
-		}
+	for _, f := range pf.files {
+		for _, decl := range f.Decls {
+			tDecl, ok := decl.(*ast.GenDecl)
+			if !ok || tDecl.Tok != token.TYPE {
+				continue
+			}
 
-		for _, e := range tDecl.Specs {
-			typeNode := e.(*ast.TypeSpec)
+			for _, e := range tDecl.Specs {
+				typeNode := e.(*ast.TypeSpec)
 
-			err := pf.parseTypeSpec(typeNode)
-			if err != nil {
-				return err
+				err := pf.parseTypeSpec(f, typeNode)
+				if err != nil {
+					return err
+				}
 			}
 		}
 	}
@@ -111,12 +247,17 @@ func (pf *ParsedFile) parseTypes() error {
 	return nil
 }
 
-func (pf *ParsedFile) parseTypeSpec(typeSpec *ast.TypeSpec) error {
+func (pf *ParsedFile) parseTypeSpec(f *ast.File, typeSpec *ast.TypeSpec) error {
 	if isContractTypeSpec(typeSpec) {
 		if pf.contract != "" {
+			if pf.dir != "" {
+				return errors.New("more than one contract in a package")
+			}
 			return errors.New("more than one contract in a file")
 		}
 		pf.contract = typeSpec.Name.Name
+		pf.node = f
+		pf.code = pf.sources[pf.fileSet.File(f.Package).Name()]
 	} else {
 		pf.types[typeSpec.Name.Name] = typeSpec
 	}
@@ -127,17 +268,19 @@ func (pf *ParsedFile) parseTypeSpec(typeSpec *ast.TypeSpec) error {
 func (pf *ParsedFile) parseFunctionsAndMethods() error {
 	pf.methods = make(map[string][]*ast.FuncDecl)
 	pf.constructors = make(map[string][]*ast.FuncDecl)
-	for _, decl := range pf.node.Decls {
-		fd, ok := decl.(*ast.FuncDecl)
-		if !ok || !fd.Name.IsExported() {
-			continue
-		}
+	for _, f := range pf.files {
+		for _, decl := range f.Decls {
+			fd, ok := decl.(*ast.FuncDecl)
+			if !ok || !fd.Name.IsExported() {
+				continue
+			}
 
-		if fd.Recv == nil || fd.Recv.NumFields() == 0 {
-			pf.parseConstructor(fd)
-		} else {
-			typename := typeName(fd.Recv.List[0].Type)
-			pf.methods[typename] = append(pf.methods[typename], fd)
+			if fd.Recv == nil || fd.Recv.NumFields() == 0 {
+				pf.parseConstructor(fd)
+			} else {
+				typename := typeName(fd.Recv.List[0].Type)
+				pf.methods[typename] = append(pf.methods[typename], fd)
+			}
 		}
 	}
 
@@ -163,16 +306,130 @@ func (pf *ParsedFile) parseConstructor(fd *ast.FuncDecl) {
 	pf.constructors[typename] = append(pf.constructors[typename], fd)
 }
 
+// unserializableTypes are builtin types that can't be passed to or returned from contract's methods
+var unserializableTypes = map[string]bool{
+	"complex64":  true,
+	"complex128": true,
+	"uintptr":    true,
+}
+
+// checkSignatures checks that arguments and results of contract's methods and constructors
+// can be serialized, so problems show up when the contract is compiled and not when it's called
+func (pf *ParsedFile) checkSignatures() error {
+	var problems []string
+	check := func(list *ast.FieldList) {
+		if list == nil {
+			return
+		}
+		for _, f := range list.List {
+			if msg := pf.unsupportedType(f.Type, make(map[string]bool)); msg != "" {
+				problems = append(problems, fmt.Sprintf("%s: %s", pf.fileSet.Position(f.Type.Pos()), msg))
+			}
+		}
+	}
+
+	for _, fd := range pf.methods[pf.contract] {
+		check(fd.Type.Params)
+		check(fd.Type.Results)
+	}
+	for _, fd := range pf.constructors[pf.contract] {
+		check(fd.Type.Params)
+	}
+
+	if len(problems) > 0 {
+		return errors.New("unsupported types in contract's methods:\n" + strings.Join(problems, "\n"))
+	}
+	return nil
+}
+
+// unsupportedType describes why values of the type can't be passed between contracts,
+// returns empty string for supported types. Types of other packages are left to the compiler.
+func (pf *ParsedFile) unsupportedType(t ast.Expr, seen map[string]bool) string {
+	switch t := t.(type) {
+	case *ast.Ident:
+		if unserializableTypes[t.Name] {
+			return t.Name + " can't be serialized"
+		}
+		spec, ok := pf.types[t.Name]
+		if !ok || seen[t.Name] {
+			return ""
+		}
+		seen[t.Name] = true
+		return pf.unsupportedType(spec.Type, seen)
+	case *ast.StarExpr:
+		return pf.unsupportedType(t.X, seen)
+	case *ast.ArrayType:
+		return pf.unsupportedType(t.Elt, seen)
+	case *ast.MapType:
+		if msg := pf.unsupportedType(t.Key, seen); msg != "" {
+			return msg
+		}
+		return pf.unsupportedType(t.Value, seen)
+	case *ast.StructType:
+		for _, f := range t.Fields.List {
+			for _, name := range f.Names {
+				if !name.IsExported() {
+					return "unexported field " + name.Name + " isn't serialized"
+				}
+			}
+			if msg := pf.unsupportedType(f.Type, seen); msg != "" {
+				return msg
+			}
+		}
+	case *ast.InterfaceType:
+		if t.Methods.NumFields() > 0 {
+			return "interfaces can't be serialized, use concrete types"
+		}
+	case *ast.ChanType:
+		return "channels can't be serialized"
+	case *ast.FuncType:
+		return "functions can't be serialized"
+	case *ast.SelectorExpr:
+		if pkg, ok := t.X.(*ast.Ident); ok && pkg.Name == "unsafe" {
+			return "unsafe.Pointer can't be serialized"
+		}
+	}
+	return ""
+}
+
+// readOnlyDirective marks contract's method that doesn't change object's state, the flag is declared
+// in the contract's ABI and the runner executes such methods without saving state:
+//
+//	// GetName returns name of the member
+//	//
+//	//ins:readonly
+//	func (m *Member) GetName() string {
+const readOnlyDirective = "//ins:readonly"
+
+// isReadOnly checks if method is marked with read-only directive
+func isReadOnly(fd *ast.FuncDecl) bool {
+	if fd.Doc == nil {
+		return false
+	}
+	for _, c := range fd.Doc.List {
+		if strings.TrimSpace(c.Text) == readOnlyDirective {
+			return true
+		}
+	}
+	return false
+}
+
 // ProxyPackageName guesses user friendly contract "name" from file name
 // and/or package in the file
 func (pf *ParsedFile) ProxyPackageName() (string, error) {
+	packageName := pf.node.Name.Name
+	if pf.dir != "" {
+		if packageName == "main" {
+			return filepath.Base(filepath.Clean(pf.dir)), nil
+		}
+		return packageName, nil
+	}
+
 	match := regexp.MustCompile("([^/]+)/([^/]+).(go|insgoc)$").FindStringSubmatch(pf.name)
 	if match == nil {
 		return "", errors.New("couldn't match filename without extension and path")
 	}
 
-	packageName := pf.node.Name.Name
-
 	proxyPackageName := packageName
 	if proxyPackageName == "main" {
 		proxyPackageName = match[2]
@@ -282,7 +539,9 @@ func (pf *ParsedFile) functionInfoForProxy(list []*ast.FuncDecl) []map[string]st
 
 // ChangePackageToMain changes package of the parsed code to "main"
 func (pf *ParsedFile) ChangePackageToMain() {
-	pf.node.Name.Name = "main"
+	for _, f := range pf.files {
+		f.Name.Name = "main"
+	}
 }
 
 // Write prints `out` contract's code, it could be changed with a few methods
@@ -290,9 +549,31 @@ func (pf *ParsedFile) Write(out io.Writer) error {
 	return printer.Fprint(out, pf.fileSet, pf.node)
 }
 
+// WriteFiles prints code of all contract's files into the directory,
+// files keep their names with ".go" extension
+func (pf *ParsedFile) WriteFiles(dir string) error {
+	for _, f := range pf.files {
+		name := filepath.Base(pf.fileSet.File(f.Package).Name())
+		name = strings.TrimSuffix(name, filepath.Ext(name)) + ".go"
+		out, err := os.Create(filepath.Join(dir, name))
+		if err != nil {
+			return errors.Wrap(err, "couldn't create file for contract's code")
+		}
+		err = printer.Fprint(out, pf.fileSet, f)
+		if cerr := out.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return errors.Wrapf(err, "couldn't write %s", name)
+		}
+	}
+	return nil
+}
+
 // codeOfNode returns source code of an AST node
 func (pf *ParsedFile) codeOfNode(n ast.Node) string {
-	return string(pf.code[n.Pos()-1 : n.End()-1])
+	tf := pf.fileSet.File(n.Pos())
+	return string(pf.sources[tf.Name()][tf.Offset(n.Pos()):tf.Offset(n.End())])
 }
 
 func (pf *ParsedFile) generateImports(wrapper bool) map[string]bool {
@@ -410,61 +691,79 @@ func extendImportsMap(parsed *ParsedFile, params *ast.FieldList, imports map[str
 	}
 
 	for _, e := range params.List {
-		tname := parsed.codeOfNode(e.Type)
-		tname = strings.Trim(tname, "*")
-		tnameFrom := strings.Split(tname, ".")
+		for _, pkg := range typePackages(e.Type) {
+			extendImportsWithPackage(parsed.fileOf(e.Pos()), pkg, imports)
+		}
+	}
+}
 
-		if len(tnameFrom) < 2 {
-			continue
+// typePackages returns names of packages the type expression refers to,
+// e.g. "big" and "core" for `map[string]*big.Int` and `[]core.RecordRef`
+func typePackages(t ast.Expr) []string {
+	var res []string
+	ast.Inspect(t, func(n ast.Node) bool {
+		sel, ok := n.(*ast.SelectorExpr)
+		if !ok {
+			return true
 		}
+		if x, ok := sel.X.(*ast.Ident); ok {
+			res = append(res, x.Name)
+		}
+		return false
+	})
+	return res
+}
 
-		for _, imp := range parsed.node.Imports {
-			var importAlias string
-			var impValue string
+// extendImportsWithPackage adds import of the file that provides the package name
+func extendImportsWithPackage(file *ast.File, pkg string, imports map[string]bool) {
+	for _, imp := range file.Imports {
+		var importAlias string
+		var impValue string
 
-			if imp.Name != nil {
-				importAlias = imp.Name.Name
-				impValue = fmt.Sprintf(`%s %s`, importAlias, imp.Path.Value)
-			} else {
-				impValue = imp.Path.Value
-				importString := strings.Trim(impValue, `"`)
-				importAlias = filepath.Base(importString)
-			}
+		if imp.Name != nil {
+			importAlias = imp.Name.Name
+			impValue = fmt.Sprintf(`%s %s`, importAlias, imp.Path.Value)
+		} else {
+			impValue = imp.Path.Value
+			importString := strings.Trim(impValue, `"`)
+			importAlias = filepath.Base(importString)
+		}
 
-			if importAlias == tnameFrom[0] {
-				imports[impValue] = true
-				break
-			}
+		if importAlias == pkg {
+			imports[impValue] = true
+			return
 		}
 	}
 }
 
+// generateZeroListOfTypes declares a variable for every type of the list and slice `name` with
+// pointers to them, values are deserialized right into typed variables. Returns the declarations
+// and the list of variables, errors travel as *foundation.Error and turn back into error.
 func generateZeroListOfTypes(parsed *ParsedFile, name string, list *ast.FieldList) (string, string) {
-	text := fmt.Sprintf("%s := [%d]interface{}{}\n", name, list.NumFields())
-
-	if list == nil {
-		return text, ""
+	if list == nil || list.NumFields() == 0 {
+		return fmt.Sprintf("%s := []interface{}{}\n", name), ""
 	}
 
+	text := ""
+	var pointers, values []string
 	for i, arg := range list.List {
 		tname := parsed.codeOfNode(arg.Type)
+		value := fmt.Sprintf("a%d", i)
 		if tname == "error" {
 			tname = "*foundation.Error"
+			value = fmt.Sprintf("foundation.ErrorOrNil(a%d)", i)
 		}
 
-		text += fmt.Sprintf("\tvar a%d %s\n", i, tname)
-		text += fmt.Sprintf("\t%s[%d] = a%d\n", name, i, i)
-	}
-
-	listCode := ""
-	for i, arg := range list.List {
 		if i > 0 {
-			listCode += ", "
+			text += "\t"
 		}
-		listCode += fmt.Sprintf("%s[%d].(%s)", name, i, parsed.codeOfNode(arg.Type))
+		text += fmt.Sprintf("var a%d %s\n", i, tname)
+		pointers = append(pointers, fmt.Sprintf("&a%d", i))
+		values = append(values, value)
 	}
+	text += fmt.Sprintf("\t%s := []interface{}{%s}\n", name, strings.Join(pointers, ", "))
 
-	return text, listCode
+	return text, strings.Join(values, ", ")
 }
 
 func genFieldList(parsed *ParsedFile, params *ast.FieldList, withNames bool) string {
@@ -18,14 +18,20 @@ package preprocessor
 
 import (
 	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/parser"
+	"go/token"
 	"io/ioutil"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/insolar/insolar/core"
 	"github.com/insolar/insolar/logicrunner/goplugin/testutil"
 )
 
@@ -319,16 +325,10 @@ func ( a *A )Get( a int, b bool, c string, d foundation.Reference ) ( int, bool,
 	err = parsed.WriteProxy("testRef", &bufProxy)
 	assert.NoError(t, err)
 	assert.Contains(t, bufProxy.String(), "var a0 int")
-	assert.Contains(t, bufProxy.String(), "resList[0] = a0")
-
 	assert.Contains(t, bufProxy.String(), "var a1 bool")
-	assert.Contains(t, bufProxy.String(), "resList[1] = a1")
-
 	assert.Contains(t, bufProxy.String(), "var a2 string")
-	assert.Contains(t, bufProxy.String(), "resList[2] = a2")
-
 	assert.Contains(t, bufProxy.String(), "var a3 foundation.Reference")
-	assert.Contains(t, bufProxy.String(), "resList[3] = a3")
+	assert.Contains(t, bufProxy.String(), "resList := []interface{}{&a0, &a1, &a2, &a3}")
 }
 
 func TestInitializationFunctionParamsWrapper(t *testing.T) {
@@ -359,16 +359,10 @@ func ( a *A )Get( a int, b bool, c string, d foundation.Reference ) ( int, bool,
 	err = parsed.WriteWrapper(&bufWrapper)
 	assert.NoError(t, err)
 	assert.Contains(t, bufWrapper.String(), "var a0 int")
-	assert.Contains(t, bufWrapper.String(), "args[0] = a0")
-
 	assert.Contains(t, bufWrapper.String(), "var a1 bool")
-	assert.Contains(t, bufWrapper.String(), "args[1] = a1")
-
 	assert.Contains(t, bufWrapper.String(), "var a2 string")
-	assert.Contains(t, bufWrapper.String(), "args[2] = a2")
-
 	assert.Contains(t, bufWrapper.String(), "var a3 foundation.Reference")
-	assert.Contains(t, bufWrapper.String(), "args[3] = a3")
+	assert.Contains(t, bufWrapper.String(), "args := []interface{}{&a0, &a1, &a2, &a3}")
 }
 
 func TestContractOnlyIfEmbedBaseContract(t *testing.T) {
@@ -616,3 +610,519 @@ type A struct{
 	err = parsed.WriteProxy("testRef", &bufProxy)
 	assert.EqualError(t, err, "couldn't match filename without extension and path")
 }
+
+func TestNondeterminismWarnings(t *testing.T) {
+	t.Parallel()
+	tmpDir, err := ioutil.TempDir("", "test-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmpDir) // nolint: errcheck
+
+	code := `
+package main
+
+import (
+	"math/rand"
+	clock "time"
+
+	"github.com/insolar/insolar/logicrunner/goplugin/foundation"
+)
+
+type Lottery struct {
+	foundation.BaseContract
+}
+
+func (l *Lottery) Draw() int {
+	if clock.Now().Unix() > 0 {
+		return rand.Int()
+	}
+	return foundation.GetRandom().Int()
+}
+
+func (l *Lottery) Deadline() clock.Time {
+	return foundation.Now().Add(clock.Hour)
+}
+`
+	err = testutil.WriteFile(tmpDir, "main.go", code)
+	assert.NoError(t, err)
+
+	parsed, err := ParseFile(filepath.Join(tmpDir, "main.go"))
+	assert.NoError(t, err)
+
+	warnings := parsed.Warnings()
+	assert.Len(t, warnings, 2)
+	assert.Contains(t, warnings[0], "main.go:5:2: math/rand")
+	assert.Contains(t, warnings[1], "main.go:16:5: time.Now")
+}
+
+func TestReadOnlyMethodsABI(t *testing.T) {
+	t.Parallel()
+	tmpDir, err := ioutil.TempDir("", "test-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmpDir) // nolint: errcheck
+
+	code := `
+package main
+
+import "github.com/insolar/insolar/logicrunner/goplugin/foundation"
+
+type Counter struct {
+	foundation.BaseContract
+	N int
+}
+
+// Get returns value of the counter
+//
+//ins:readonly
+func (c *Counter) Get() int {
+	return c.N
+}
+
+func (c *Counter) Inc() {
+	c.N++
+}
+`
+	err = testutil.WriteFile(tmpDir, "main.go", code)
+	assert.NoError(t, err)
+
+	parsed, err := ParseFile(filepath.Join(tmpDir, "main.go"))
+	assert.NoError(t, err)
+
+	readOnly := map[string]bool{}
+	for _, m := range parsed.ABI().Methods {
+		readOnly[m.Name] = m.ReadOnly
+	}
+	assert.Equal(t, map[string]bool{"Get": true, "Inc": false}, readOnly)
+}
+
+func TestRichTypesProxy(t *testing.T) {
+	t.Parallel()
+	tmpDir, err := ioutil.TempDir("", "test-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmpDir) // nolint: errcheck
+
+	code := `
+package main
+
+import (
+	"math/big"
+
+	"github.com/insolar/insolar/core"
+	"github.com/insolar/insolar/logicrunner/goplugin/foundation"
+)
+
+type Item struct {
+	Owner core.RecordRef
+	Tags  map[string][]byte
+}
+
+type Store struct {
+	foundation.BaseContract
+}
+
+func (s *Store) Balances(items []Item) (map[string]*big.Int, error, []core.RecordRef) {
+	return nil, nil, nil
+}
+`
+	err = testutil.WriteFile(tmpDir, "main.go", code)
+	assert.NoError(t, err)
+
+	parsed, err := ParseFile(filepath.Join(tmpDir, "main.go"))
+	assert.NoError(t, err)
+
+	var buf bytes.Buffer
+	err = parsed.WriteProxy("testRef", &buf)
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), `"math/big"`)
+	assert.Contains(t, buf.String(), "var a0 map[string]*big.Int")
+	assert.Contains(t, buf.String(), "var a1 *foundation.Error")
+	assert.Contains(t, buf.String(), "var a2 []core.RecordRef")
+	assert.Contains(t, buf.String(), "return a0, foundation.ErrorOrNil(a1), a2")
+
+	buf.Reset()
+	err = parsed.WriteWrapper(&buf)
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), "var a0 []Item")
+	assert.Contains(t, buf.String(), "ret1 = ph.MakeErrorSerializable(ret1)")
+}
+
+func TestUnsupportedTypes(t *testing.T) {
+	t.Parallel()
+	tmpDir, err := ioutil.TempDir("", "test-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmpDir) // nolint: errcheck
+
+	code := `
+package main
+
+import "github.com/insolar/insolar/logicrunner/goplugin/foundation"
+
+type Secret struct {
+	value string
+}
+
+type Store struct {
+	foundation.BaseContract
+}
+
+func (s *Store) Listen(c chan int) complex128 {
+	return 0
+}
+
+func (s *Store) Keep(secret Secret) {
+}
+
+func (s *Store) Fine(data []byte, m map[string]interface{}) {
+}
+`
+	err = testutil.WriteFile(tmpDir, "main.go", code)
+	assert.NoError(t, err)
+
+	_, err = ParseFile(filepath.Join(tmpDir, "main.go"))
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "main.go:14:26: channels can't be serialized")
+		assert.Contains(t, err.Error(), "main.go:14:36: complex128 can't be serialized")
+		assert.Contains(t, err.Error(), "unexported field value isn't serialized")
+		assert.NotContains(t, err.Error(), "main.go:21")
+	}
+}
+
+func TestCheckSafety(t *testing.T) {
+	t.Parallel()
+	tmpDir, err := ioutil.TempDir("", "test-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmpDir) // nolint: errcheck
+
+	code := `
+package main
+
+import (
+	"os"
+	"reflect"
+	"unsafe"
+
+	"github.com/insolar/insolar/logicrunner/goplugin/foundation"
+)
+
+var counter int
+
+type Worker struct {
+	foundation.BaseContract
+}
+
+func (w *Worker) Run() {
+	done := make(chan bool)
+	go func() {
+		os.Exit(0)
+		done <- true
+	}()
+	<-done
+	_ = reflect.ValueOf(w).Elem().FieldByName("secret")
+	_ = unsafe.Sizeof(w)
+}
+`
+	err = testutil.WriteFile(tmpDir, "main.go", code)
+	assert.NoError(t, err)
+
+	parsed, err := ParseFile(filepath.Join(tmpDir, "main.go"))
+	assert.NoError(t, err)
+
+	err = parsed.CheckSafety(nil)
+	assert.Error(t, err)
+	for _, problem := range []string{
+		`main.go:5:2: import of "os" isn't allowed`,
+		`main.go:6:2: import of "reflect" isn't allowed`,
+		"main.go:7:2: unsafe isn't allowed",
+		"main.go:12:1: global variables aren't allowed",
+		"main.go:19:15: channels aren't allowed",
+		"main.go:20:2: go statements aren't allowed",
+		"main.go:22:3: channels aren't allowed",
+		"main.go:24:2: channels aren't allowed",
+		"main.go:25:6: access to fields with reflection isn't allowed",
+	} {
+		assert.Contains(t, err.Error(), problem)
+	}
+
+	err = parsed.CheckSafety([]string{"os", "reflect"})
+	assert.Error(t, err)
+	assert.NotContains(t, err.Error(), `import of "os"`)
+	assert.Contains(t, err.Error(), "unsafe isn't allowed", "unsafe can't be allowed")
+}
+
+func TestCheckSafety_Determinism(t *testing.T) {
+	t.Parallel()
+	tmpDir, err := ioutil.TempDir("", "test-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmpDir) // nolint: errcheck
+
+	code := `
+package main
+
+import (
+	"math/rand"
+	r "reflect"
+	clock "time"
+
+	"github.com/insolar/insolar/logicrunner/goplugin/foundation"
+)
+
+type Worker struct {
+	foundation.BaseContract
+	Field int
+}
+
+func (w *Worker) Run() int {
+	clock.Sleep(clock.Second)
+	_ = r.TypeOf(w).Field(0)
+	_ = clock.Unix(0, 0)
+	return w.Field + rand.Int()
+}
+`
+	err = testutil.WriteFile(tmpDir, "main.go", code)
+	assert.NoError(t, err)
+
+	parsed, err := ParseFile(filepath.Join(tmpDir, "main.go"))
+	assert.NoError(t, err)
+
+	err = parsed.CheckSafety([]string{"reflect"})
+	assert.Error(t, err)
+	problems := strings.Split(err.Error(), "\n")[1:]
+	assert.Len(t, problems, 3)
+	assert.Contains(t, problems[0], `main.go:5:2: import of "math/rand" isn't allowed`)
+	assert.Contains(t, problems[1], "main.go:18:2: time.Sleep depends on the wall clock")
+	assert.Contains(t, problems[2], "main.go:19:6: access to fields with reflection isn't allowed")
+}
+
+func TestWallClockUses(t *testing.T) {
+	code := `package main
+
+import (
+	. "time"
+	tm "time"
+)
+
+type Timer struct {
+	Now int
+}
+
+func (t *Timer) Sleep() {}
+
+func (t *Timer) Run(d Duration) {
+	ticker := NewTicker(d)
+	_ = tm.Tick(d)
+	_ = Timer{Now: 1}
+	t.Sleep()
+	_ = t.Now
+	_ = ticker
+	_ = Unix(0, 0)
+	AfterFunc(d, t.Sleep)
+}
+`
+	fileSet := token.NewFileSet()
+	node, err := parser.ParseFile(fileSet, "main.go", code, 0)
+	assert.NoError(t, err)
+
+	var found []string
+	wallClockUses(node, func(pos token.Pos, name string) {
+		found = append(found, fmt.Sprintf("%d:%s", fileSet.Position(pos).Line, name))
+	})
+	assert.Equal(t, []string{"15:NewTicker", "16:Tick", "22:AfterFunc"}, found)
+}
+
+func TestImportAllowed(t *testing.T) {
+	allowed := []string{"strings", "github.com/insolar/insolar/genesis/proxy/..."}
+	assert.True(t, importAllowed("strings", allowed))
+	assert.False(t, importAllowed("strings/sub", allowed))
+	assert.True(t, importAllowed("github.com/insolar/insolar/genesis/proxy", allowed))
+	assert.True(t, importAllowed("github.com/insolar/insolar/genesis/proxy/wallet", allowed))
+	assert.False(t, importAllowed("github.com/insolar/insolar/genesis/proxyx", allowed))
+	assert.False(t, importAllowed("os", allowed))
+}
+
+func TestParsePackage(t *testing.T) {
+	t.Parallel()
+	tmpDir, err := ioutil.TempDir("", "test-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmpDir) // nolint: errcheck
+
+	dir := filepath.Join(tmpDir, "shop")
+	err = testutil.WriteFile(dir, "shop.go", `
+package main
+
+import "github.com/insolar/insolar/logicrunner/goplugin/foundation"
+
+type Shop struct {
+	foundation.BaseContract
+	Items []Item
+}
+
+func New() *Shop {
+	return &Shop{}
+}
+`)
+	assert.NoError(t, err)
+	err = testutil.WriteFile(dir, "item.go", `
+package main
+
+import "some/test/import/path"
+
+type Item struct {
+	Name  string
+	Price int
+}
+
+func (s *Shop) Add(item Item, tag path.Tag) {
+	s.Items = append(s.Items, item)
+}
+`)
+	assert.NoError(t, err)
+	err = testutil.WriteFile(dir, "shop_wrapper.go", "// Code generated by insgocc. DO NOT EDIT.\n\npackage main\n")
+	assert.NoError(t, err)
+	err = testutil.WriteFile(dir, "shop_test.go", "package main\n")
+	assert.NoError(t, err)
+
+	parsed, err := ParsePackage(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, "Shop", parsed.contract)
+	assert.Len(t, parsed.files, 2)
+
+	name, err := parsed.ProxyPackageName()
+	assert.NoError(t, err)
+	assert.Equal(t, "shop", name)
+
+	var bufProxy bytes.Buffer
+	err = parsed.WriteProxy("testRef", &bufProxy)
+	assert.NoError(t, err)
+	assert.Contains(t, bufProxy.String(), "type Item struct")
+	assert.Contains(t, bufProxy.String(), "Add( item Item, tag path.Tag )")
+	assert.Contains(t, bufProxy.String(), `"some/test/import/path"`)
+
+	var bufWrapper bytes.Buffer
+	err = parsed.WriteWrapper(&bufWrapper)
+	assert.NoError(t, err)
+	assert.Contains(t, bufWrapper.String(), "func INSMETHOD_Add(")
+	assert.Contains(t, bufWrapper.String(), "func INSCONSTRUCTOR_New(")
+
+	err = testutil.WriteFile(dir, "other.go", `
+package main
+
+type Other struct {
+	foundation.BaseContract
+}
+`)
+	assert.NoError(t, err)
+	_, err = ParsePackage(dir)
+	assert.EqualError(t, err, ": more than one contract in a package")
+}
+
+func TestABI(t *testing.T) {
+	t.Parallel()
+	tmpDir, err := ioutil.TempDir("", "test-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmpDir) // nolint: errcheck
+
+	code := `
+package main
+
+import (
+	"github.com/insolar/insolar/core"
+	"github.com/insolar/insolar/logicrunner/goplugin/foundation"
+)
+
+type Wallet struct {
+	foundation.BaseContract
+	Balance uint
+}
+
+type Payment struct {
+	To     core.RecordRef
+	Amount uint
+	Memo   []byte
+	Next   *Payment
+}
+
+func New(balance uint) *Wallet {
+	return &Wallet{Balance: balance}
+}
+
+// GetBalance returns balance of the wallet
+//
+//ins:readonly
+func (w *Wallet) GetBalance() uint {
+	return w.Balance
+}
+
+func (w *Wallet) Transfer(amount uint, from, to *core.RecordRef) error {
+	return nil
+}
+
+func (w *Wallet) History(limit int) (map[string][]Payment, error) {
+	return nil, nil
+}
+`
+	err = testutil.WriteFile(tmpDir, "main.go", code)
+	assert.NoError(t, err)
+
+	parsed, err := ParseFile(filepath.Join(tmpDir, "main.go"))
+	assert.NoError(t, err)
+
+	var buf bytes.Buffer
+	err = parsed.WriteABI(&buf)
+	assert.NoError(t, err)
+
+	var abi core.ContractABI
+	err = json.Unmarshal(buf.Bytes(), &abi)
+	assert.NoError(t, err)
+	uintABI := core.TypeABI{Kind: core.KindUint, Bits: 64}
+	refABI := core.TypeABI{Kind: core.KindOptional, Elem: &core.TypeABI{Kind: core.KindReference}}
+	errABI := core.TypeABI{Kind: core.KindError}
+	paymentABI := core.TypeABI{Kind: core.KindStruct, Name: "Payment", Fields: []core.FieldABI{
+		{Name: "To", Type: core.TypeABI{Kind: core.KindReference}},
+		{Name: "Amount", Type: uintABI},
+		{Name: "Memo", Type: core.TypeABI{Kind: core.KindBytes}},
+		{Name: "Next", Type: core.TypeABI{
+			Kind: core.KindOptional, Elem: &core.TypeABI{Kind: core.KindStruct, Name: "Payment"},
+		}},
+	}}
+	assert.Equal(t, core.ContractABI{
+		Contract: "Wallet",
+		Methods: []core.MethodABI{
+			{
+				Name:      "GetBalance",
+				Arguments: []core.ArgumentABI{},
+				Results:   []core.ArgumentABI{{Type: uintABI}},
+				ReadOnly:  true,
+			},
+			{
+				Name: "Transfer",
+				Arguments: []core.ArgumentABI{
+					{Name: "amount", Type: uintABI},
+					{Name: "from", Type: refABI},
+					{Name: "to", Type: refABI},
+				},
+				Results: []core.ArgumentABI{{Type: errABI}},
+			},
+			{
+				Name:      "History",
+				Arguments: []core.ArgumentABI{{Name: "limit", Type: core.TypeABI{Kind: core.KindInt, Bits: 64}}},
+				Results: []core.ArgumentABI{
+					{Type: core.TypeABI{
+						Kind: core.KindMap,
+						Key:  &core.TypeABI{Kind: core.KindString},
+						Elem: &core.TypeABI{Kind: core.KindList, Elem: &paymentABI},
+					}},
+					{Type: errABI},
+				},
+			},
+		},
+		Constructors: []core.MethodABI{
+			{
+				Name:      "New",
+				Arguments: []core.ArgumentABI{{Name: "balance", Type: uintABI}},
+				Results: []core.ArgumentABI{{Type: core.TypeABI{
+					Kind: core.KindOptional,
+					Elem: &core.TypeABI{Kind: core.KindReference, Name: "Wallet"},
+				}}},
+			},
+		},
+	}, abi)
+}
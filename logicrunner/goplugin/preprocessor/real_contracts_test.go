@@ -89,6 +89,7 @@ func TestGenerateWrappersForRealSmartContracts(t *testing.T) {
 			t.Parallel()
 			parsed, err := ParseFile(file)
 			assert.NoError(t, err)
+			assert.NoError(t, parsed.CheckSafety(nil))
 
 			var buf bytes.Buffer
 			err = parsed.WriteWrapper(&buf)
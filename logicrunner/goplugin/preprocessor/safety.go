@@ -0,0 +1,275 @@
+/*
+ *    Copyright 2018 Insolar
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package preprocessor
+
+import (
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/token"
+	"go/types"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultAllowedImports is the list of packages a contract may import, a path
+// ending with "/..." allows all packages under it
+var DefaultAllowedImports = []string{
+	"bytes",
+	"crypto/sha256",
+	"encoding/base64",
+	"encoding/hex",
+	"encoding/json",
+	"errors",
+	"fmt",
+	"math",
+	"math/big",
+	"sort",
+	"strconv",
+	"strings",
+	"time",
+	"unicode",
+	"unicode/utf8",
+	corePath,
+	foundationPath,
+	"github.com/insolar/insolar/cryptohelpers/ecdsa",
+	"github.com/insolar/insolar/genesis/experiment/...",
+	"github.com/insolar/insolar/genesis/proxy/...",
+}
+
+// forbiddenImports can't be allowed, they give access to memory and code outside of the runtime
+var forbiddenImports = map[string]string{
+	"C":      "cgo isn't allowed in contracts",
+	"unsafe": "unsafe isn't allowed in contracts",
+}
+
+// reflectFieldAccess are functions and methods of the reflect package that reach struct fields,
+// including unexported ones
+var reflectFieldAccess = map[string]bool{
+	"Field":           true,
+	"FieldByIndex":    true,
+	"FieldByName":     true,
+	"FieldByNameFunc": true,
+	"NewAt":           true,
+	"UnsafeAddr":      true,
+}
+
+// importAllowed checks if the import path matches one of allowed paths
+func importAllowed(path string, allowed []string) bool {
+	for _, a := range allowed {
+		if a == path {
+			return true
+		}
+		if prefix := strings.TrimSuffix(a, "/..."); prefix != a {
+			if path == prefix || strings.HasPrefix(path, prefix+"/") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// CheckSafety checks that the contract doesn't break isolation and determinism of execution:
+// imports only allowed packages (DefaultAllowedImports and extraImports), doesn't start
+// goroutines, doesn't use channels, global variables, cgo, the wall clock and reflection on fields.
+// Returned error lists all problems with their positions in the file.
+func (pf *ParsedFile) CheckSafety(extraImports []string) error {
+	var problems []string
+	report := func(pos token.Pos, format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf("%s: %s", pf.fileSet.Position(pos), fmt.Sprintf(format, args...)))
+	}
+
+	allowed := append(append([]string{}, DefaultAllowedImports...), extraImports...)
+	info := pf.reflectInfo()
+	for _, f := range pf.files {
+		pf.checkFileSafety(f, allowed, info, report)
+	}
+
+	if len(problems) > 0 {
+		return errors.New("unsafe contract code:\n" + strings.Join(problems, "\n"))
+	}
+	return nil
+}
+
+func (pf *ParsedFile) checkFileSafety(
+	node *ast.File, allowed []string, info *types.Info, report func(token.Pos, string, ...interface{}),
+) {
+	for _, imp := range node.Imports {
+		path := strings.Trim(imp.Path.Value, `"`)
+		if msg, ok := forbiddenImports[path]; ok {
+			report(imp.Pos(), msg)
+			continue
+		}
+		if !importAllowed(path, allowed) {
+			report(imp.Pos(), "import of %q isn't allowed", path)
+		}
+	}
+
+	for _, decl := range node.Decls {
+		if gd, ok := decl.(*ast.GenDecl); ok && gd.Tok == token.VAR {
+			report(gd.Pos(), "global variables aren't allowed, use constants or fields of the contract")
+		}
+	}
+
+	wallClockUses(node, func(pos token.Pos, name string) {
+		report(pos, "time.%s depends on the wall clock, use foundation.Now() instead", name)
+	})
+
+	ast.Inspect(node, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.GoStmt:
+			report(n.Pos(), "go statements aren't allowed")
+		case *ast.ChanType:
+			report(n.Pos(), "channels aren't allowed")
+		case *ast.SendStmt:
+			report(n.Pos(), "channels aren't allowed")
+		case *ast.SelectStmt:
+			report(n.Pos(), "select statements aren't allowed")
+		case *ast.UnaryExpr:
+			if n.Op == token.ARROW {
+				report(n.Pos(), "channels aren't allowed")
+			}
+		case *ast.SelectorExpr:
+			if info != nil && isReflectFieldAccess(info, n) {
+				report(n.Pos(), "access to fields with reflection isn't allowed, it reaches unexported fields")
+			}
+		}
+		return true
+	})
+}
+
+// wallClockFuncs are functions of the time package that depend on the wall clock or wait for it
+var wallClockFuncs = map[string]bool{
+	"Now":       true,
+	"Since":     true,
+	"Until":     true,
+	"Sleep":     true,
+	"After":     true,
+	"AfterFunc": true,
+	"Tick":      true,
+	"NewTimer":  true,
+	"NewTicker": true,
+}
+
+// wallClockUses calls found for every use of wall clock functions of the time package in the file,
+// the package may be imported under other name or with a dot
+func wallClockUses(node *ast.File, found func(pos token.Pos, name string)) {
+	timeName, dot := "", false
+	for _, imp := range node.Imports {
+		if strings.Trim(imp.Path.Value, `"`) != "time" {
+			continue
+		}
+		switch {
+		case imp.Name == nil:
+			timeName = "time"
+		case imp.Name.Name == ".":
+			dot = true
+		case imp.Name.Name != "_":
+			timeName = imp.Name.Name
+		}
+	}
+	if timeName == "" && !dot {
+		return
+	}
+
+	// identifiers declared in the file are resolved by the parser, functions of dot imported package aren't
+	isTimeFunc := func(id *ast.Ident) bool {
+		return dot && id.Obj == nil && wallClockFuncs[id.Name]
+	}
+	var inspect func(n ast.Node) bool
+	inspect = func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.SelectorExpr:
+			if x, ok := n.X.(*ast.Ident); ok && x.Obj == nil && x.Name == timeName && wallClockFuncs[n.Sel.Name] {
+				found(n.Pos(), n.Sel.Name)
+				return false
+			}
+			// selected name is a field or a method, not a function of the package
+			ast.Inspect(n.X, inspect)
+			return false
+		case *ast.KeyValueExpr:
+			if _, ok := n.Key.(*ast.Ident); ok {
+				ast.Inspect(n.Value, inspect) // key is a field name
+				return false
+			}
+		case *ast.FuncDecl:
+			// names of methods aren't resolved
+			if n.Recv != nil {
+				ast.Inspect(n.Recv, inspect)
+			}
+			ast.Inspect(n.Type, inspect)
+			if n.Body != nil {
+				ast.Inspect(n.Body, inspect)
+			}
+			return false
+		case *ast.Ident:
+			if isTimeFunc(n) {
+				found(n.Pos(), n.Name)
+			}
+		}
+		return true
+	}
+	ast.Inspect(node, inspect)
+}
+
+// reflectInfo resolves identifiers of the contract if it imports reflect, nil is returned otherwise.
+// Only the standard library is imported, identifiers of other packages stay unresolved.
+func (pf *ParsedFile) reflectInfo() *types.Info {
+	usesReflect := false
+	for _, f := range pf.files {
+		for _, imp := range f.Imports {
+			usesReflect = usesReflect || strings.Trim(imp.Path.Value, `"`) == "reflect"
+		}
+	}
+	if !usesReflect || len(pf.files) == 0 {
+		return nil
+	}
+
+	info := &types.Info{Uses: make(map[*ast.Ident]types.Object)}
+	conf := types.Config{
+		Importer: stdImporter{importer.For("source", nil)},
+		Error:    func(error) {}, // unresolved imports and their uses are expected
+	}
+	conf.Check(pf.files[0].Name.Name, pf.fileSet, pf.files, info) // nolint: errcheck
+	return info
+}
+
+// stdImporter imports packages of the standard library only
+type stdImporter struct {
+	types.Importer
+}
+
+func (i stdImporter) Import(path string) (*types.Package, error) {
+	if strings.Contains(strings.SplitN(path, "/", 2)[0], ".") {
+		return nil, errors.Errorf("package %s isn't resolved", path)
+	}
+	return i.Importer.Import(path)
+}
+
+// isReflectFieldAccess checks if the selector is a function or a method of the reflect package reaching
+// struct fields, the selector is treated as such if its receiver can't be resolved
+func isReflectFieldAccess(info *types.Info, sel *ast.SelectorExpr) bool {
+	if !reflectFieldAccess[sel.Sel.Name] {
+		return false
+	}
+	obj, ok := info.Uses[sel.Sel]
+	if !ok {
+		return true
+	}
+	return obj.Pkg() != nil && obj.Pkg().Path() == "reflect"
+}
@@ -17,6 +17,8 @@
 package rpctypes
 
 import (
+	"time"
+
 	"github.com/insolar/insolar/core"
 )
 
@@ -35,13 +37,15 @@ type DownCallMethodReq struct { // todo it may use foundation.Context
 
 // DownCallMethodResp is response from CallMethod RPC in the runner
 type DownCallMethodResp struct {
-	Data []byte
-	Ret  core.Arguments
+	Data  []byte
+	Ret   core.Arguments
+	Usage core.CallUsage // resources consumed by the call
 }
 
 // DownCallConstructorReq is a set of arguments for CallConstructor RPC
 // in the runner
 type DownCallConstructorReq struct {
+	Context   *core.LogicCallContext
 	Code      core.RecordRef
 	Name      string
 	Arguments core.Arguments
@@ -49,12 +53,23 @@ type DownCallConstructorReq struct {
 
 // DownCallConstructorResp is response from CallConstructor RPC in the runner
 type DownCallConstructorResp struct {
-	Ret core.Arguments
+	Ret   core.Arguments
+	Usage core.CallUsage // resources consumed by the call
 }
 
+// DownPingReq is a set of arguments for Ping RPC in the runner
+type DownPingReq struct{}
+
+// DownPingResp is response from Ping RPC in the runner
+type DownPingResp struct{}
+
 // UpBaseReq  is a base type for all insgorund -> logicrunner requests
 type UpBaseReq struct {
-	Me core.RecordRef
+	Me       core.RecordRef
+	Chain    core.RecordRef // registered request of the top-level call of the chain
+	Deadline time.Time      // deadline of the call that made this request
+	Trace    []byte         // span of the call that made this request
+	ReadOnly bool           // request is made by read-only call, it isn't recorded in case bind
 }
 
 // UpRespIface interface for UpBaseReq descendant responses
@@ -70,6 +85,7 @@ type UpGetCodeReq struct {
 // UpGetCodeResp is response from GetCode RPC in goplugin
 type UpGetCodeResp struct {
 	Code []byte
+	Hash []byte // hash of the code stored in the ledger
 }
 
 // UpRouteReq is a set of arguments for Send RPC in goplugin
@@ -137,3 +153,14 @@ type UpGetDelegateReq struct {
 type UpGetDelegateResp struct {
 	Object core.RecordRef
 }
+
+// UpEmitEventReq is a set of arguments for EmitEvent RPC in goplugin
+type UpEmitEventReq struct {
+	UpBaseReq
+	Name    string
+	Payload []byte
+}
+
+// UpEmitEventResp is response from EmitEvent RPC in goplugin
+type UpEmitEventResp struct {
+}
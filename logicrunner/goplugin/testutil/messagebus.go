@@ -17,6 +17,8 @@
 package testutil
 
 import (
+	"context"
+
 	"github.com/insolar/insolar/core"
 )
 
@@ -37,8 +39,8 @@ func (*TestMessageBus) Start(components core.Components) error { return nil }
 func (*TestMessageBus) Stop() error { return nil }
 
 // Send executes message on LogicRunner.
-func (eb *TestMessageBus) Send(msg core.Message) (resp core.Reply, err error) {
-	return eb.LogicRunner.Execute(msg)
+func (eb *TestMessageBus) Send(ctx context.Context, msg core.Message) (resp core.Reply, err error) {
+	return eb.LogicRunner.Execute(ctx, msg)
 }
 
 // SendAsync sends message async
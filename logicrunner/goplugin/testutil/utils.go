@@ -17,6 +17,7 @@
 package testutil
 
 import (
+	"context"
 	"crypto/rand"
 	"go/build"
 	"io/ioutil"
@@ -26,6 +27,8 @@ import (
 	"regexp"
 	"testing"
 
+	"github.com/insolar/insolar/core/message"
+	"github.com/insolar/insolar/cryptohelpers/hash"
 	"github.com/insolar/insolar/log"
 	"github.com/pkg/errors"
 
@@ -94,11 +97,17 @@ func (t *TestCodeDescriptor) Code() []byte {
 	return t.ACode
 }
 
+// Hash implementation for tests
+func (t *TestCodeDescriptor) Hash() []byte {
+	return hash.SHA3Bytes(t.ACode)
+}
+
 // TestClassDescriptor ...
 type TestClassDescriptor struct {
 	AM    *TestArtifactManager
 	ARef  *core.RecordRef
 	ACode *core.RecordRef
+	AType *core.RecordRef
 }
 
 // HeadRef ...
@@ -120,12 +129,18 @@ func (t *TestClassDescriptor) CodeDescriptor(machinePref []core.MachineType) (co
 	return res, nil
 }
 
+// TypeRef ...
+func (t *TestClassDescriptor) TypeRef() *core.RecordRef {
+	return t.AType
+}
+
 // TestObjectDescriptor implementation for tests
 type TestObjectDescriptor struct {
 	AM                *TestArtifactManager
 	Data              []byte
 	Code              *core.RecordRef
 	Class             *core.RecordRef
+	DomainRef         *core.RecordRef
 	Delegates         map[core.RecordRef]core.RecordRef
 	ChildrenContainer []core.RecordRef
 }
@@ -140,6 +155,14 @@ func (t *TestObjectDescriptor) StateID() *core.RecordID {
 	panic("not implemented")
 }
 
+// Domain implementation for tests
+func (t *TestObjectDescriptor) Domain() *core.RecordRef {
+	if t.DomainRef == nil {
+		return &core.RecordRef{}
+	}
+	return t.DomainRef
+}
+
 // Memory implementation for tests
 func (t *TestObjectDescriptor) Memory() []byte {
 	return t.Data
@@ -169,10 +192,13 @@ type TestArtifactManager struct {
 	Codes   map[core.RecordRef]*TestCodeDescriptor
 	Objects map[core.RecordRef]*TestObjectDescriptor
 	Classes map[core.RecordRef]*TestClassDescriptor
+	Events  []core.Event
+
+	Declarations map[core.RecordRef][]byte
 }
 
 // GetChildren implementation for tests
-func (t *TestArtifactManager) GetChildren(parent core.RecordRef, pulse *core.PulseNumber) (core.RefIterator, error) {
+func (t *TestArtifactManager) GetChildren(ctx context.Context, parent core.RecordRef, pulse *core.PulseNumber) (core.RefIterator, error) {
 	panic("implement me")
 }
 
@@ -182,6 +208,8 @@ func NewTestArtifactManager() *TestArtifactManager {
 		Codes:   make(map[core.RecordRef]*TestCodeDescriptor),
 		Objects: make(map[core.RecordRef]*TestObjectDescriptor),
 		Classes: make(map[core.RecordRef]*TestClassDescriptor),
+
+		Declarations: make(map[core.RecordRef][]byte),
 	}
 }
 
@@ -195,12 +223,12 @@ func (t *TestArtifactManager) Stop() error { return nil }
 func (t *TestArtifactManager) RootRef() *core.RecordRef { return &core.RecordRef{} }
 
 // RegisterRequest implementation for tests
-func (t *TestArtifactManager) RegisterRequest(message core.Message) (*core.RecordRef, error) {
-	return nil, errors.New("RegisterRequest not implemented onTestArtifactManager ")
+func (t *TestArtifactManager) RegisterRequest(ctx context.Context, msg core.Message) (*core.RecordRef, error) {
+	return core.GenRequest(0, message.MustSerializeBytes(msg)), nil
 }
 
 // GetClass implementation for tests
-func (t *TestArtifactManager) GetClass(object core.RecordRef, state *core.RecordRef) (core.ClassDescriptor, error) {
+func (t *TestArtifactManager) GetClass(ctx context.Context, object core.RecordRef, state *core.RecordRef) (core.ClassDescriptor, error) {
 	res, ok := t.Classes[object]
 	if !ok {
 		return nil, errors.New("No object")
@@ -209,7 +237,7 @@ func (t *TestArtifactManager) GetClass(object core.RecordRef, state *core.Record
 }
 
 // GetObject implementation for tests
-func (t *TestArtifactManager) GetObject(object core.RecordRef, state *core.RecordRef) (core.ObjectDescriptor, error) {
+func (t *TestArtifactManager) GetObject(ctx context.Context, object core.RecordRef, state *core.RecordRef) (core.ObjectDescriptor, error) {
 	res, ok := t.Objects[object]
 	if !ok {
 		return nil, errors.New("No object")
@@ -218,7 +246,7 @@ func (t *TestArtifactManager) GetObject(object core.RecordRef, state *core.Recor
 }
 
 // GetDelegate implementation for tests
-func (t *TestArtifactManager) GetDelegate(head, asClass core.RecordRef) (*core.RecordRef, error) {
+func (t *TestArtifactManager) GetDelegate(ctx context.Context, head, asClass core.RecordRef) (*core.RecordRef, error) {
 	obj, ok := t.Objects[head]
 	if !ok {
 		return nil, errors.New("No object")
@@ -233,12 +261,40 @@ func (t *TestArtifactManager) GetDelegate(head, asClass core.RecordRef) (*core.R
 }
 
 // DeclareType implementation for tests
-func (t *TestArtifactManager) DeclareType(domain core.RecordRef, request core.RecordRef, typeDec []byte) (*core.RecordRef, error) {
-	panic("not implemented")
+func (t *TestArtifactManager) DeclareType(ctx context.Context, domain core.RecordRef, request core.RecordRef, typeDec []byte) (*core.RecordRef, error) {
+	ref, err := randomRef()
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to generate ref")
+	}
+	t.Declarations[*ref] = typeDec
+	return ref, nil
+}
+
+// DeclareClassType implementation for tests
+func (t *TestArtifactManager) DeclareClassType(ctx context.Context, domain core.RecordRef, request core.RecordRef, class core.RecordRef, typeDec []byte) (*core.RecordRef, error) {
+	classDesc, ok := t.Classes[class]
+	if !ok {
+		return nil, errors.New("No class")
+	}
+	ref, err := t.DeclareType(ctx, domain, request, typeDec)
+	if err != nil {
+		return nil, err
+	}
+	classDesc.AType = ref
+	return ref, nil
+}
+
+// GetType implementation for tests
+func (t *TestArtifactManager) GetType(ctx context.Context, ref core.RecordRef) ([]byte, error) {
+	res, ok := t.Declarations[ref]
+	if !ok {
+		return nil, errors.New("No type")
+	}
+	return res, nil
 }
 
 // DeployCode implementation for tests
-func (t *TestArtifactManager) DeployCode(domain core.RecordRef, request core.RecordRef, codeMap map[core.MachineType][]byte) (*core.RecordRef, error) {
+func (t *TestArtifactManager) DeployCode(ctx context.Context, domain core.RecordRef, request core.RecordRef, codeMap map[core.MachineType][]byte) (*core.RecordRef, error) {
 	ref, err := randomRef()
 	if err != nil {
 		return nil, errors.Wrap(err, "Failed to generate ref")
@@ -252,7 +308,7 @@ func (t *TestArtifactManager) DeployCode(domain core.RecordRef, request core.Rec
 }
 
 // GetCode implementation for tests
-func (t *TestArtifactManager) GetCode(code core.RecordRef, machinePref []core.MachineType) (core.CodeDescriptor, error) {
+func (t *TestArtifactManager) GetCode(ctx context.Context, code core.RecordRef, machinePref []core.MachineType) (core.CodeDescriptor, error) {
 	res, ok := t.Codes[code]
 	if !ok {
 		return nil, errors.New("No code")
@@ -261,7 +317,7 @@ func (t *TestArtifactManager) GetCode(code core.RecordRef, machinePref []core.Ma
 }
 
 // ActivateClass implementation for tests
-func (t *TestArtifactManager) ActivateClass(domain core.RecordRef, request core.RecordRef) (*core.RecordRef, error) {
+func (t *TestArtifactManager) ActivateClass(ctx context.Context, domain core.RecordRef, request core.RecordRef) (*core.RecordRef, error) {
 	ref, err := randomRef()
 	if err != nil {
 		return nil, errors.Wrap(err, "Failed to generate ref")
@@ -274,17 +330,18 @@ func (t *TestArtifactManager) ActivateClass(domain core.RecordRef, request core.
 }
 
 // DeactivateClass implementation for tests
-func (t *TestArtifactManager) DeactivateClass(domain core.RecordRef, request core.RecordRef, class core.RecordRef) (*core.RecordID, error) {
+func (t *TestArtifactManager) DeactivateClass(ctx context.Context, domain core.RecordRef, request core.RecordRef, class core.RecordRef) (*core.RecordID, error) {
 	panic("not implemented")
 }
 
 // UpdateClass implementation for tests
-func (t *TestArtifactManager) UpdateClass(domain core.RecordRef, request core.RecordRef, class core.RecordRef, code core.RecordRef, migrationRefs []core.RecordRef) (*core.RecordID, error) {
+func (t *TestArtifactManager) UpdateClass(ctx context.Context, domain core.RecordRef, request core.RecordRef, class core.RecordRef, code core.RecordRef, migrationRefs []core.RecordRef) (*core.RecordID, error) {
 	classDesc, ok := t.Classes[class]
 	if !ok {
 		return nil, errors.New("wrong class")
 	}
 	classDesc.ACode = &code
+	classDesc.AType = nil // ABI is declared for each state of the class
 
 	return randomID()
 }
@@ -314,7 +371,7 @@ func randomID() (*core.RecordID, error) {
 }
 
 // ActivateObject implementation for tests
-func (t *TestArtifactManager) ActivateObject(domain core.RecordRef, request core.RecordRef, class core.RecordRef, parent core.RecordRef, memory []byte) (*core.RecordRef, error) {
+func (t *TestArtifactManager) ActivateObject(ctx context.Context, domain core.RecordRef, request core.RecordRef, class core.RecordRef, parent core.RecordRef, memory []byte) (*core.RecordRef, error) {
 	codeRef := t.Classes[class].ACode
 
 	ref, err := randomRef()
@@ -327,14 +384,15 @@ func (t *TestArtifactManager) ActivateObject(domain core.RecordRef, request core
 		Data:      memory,
 		Code:      codeRef,
 		Class:     &class,
+		DomainRef: &domain,
 		Delegates: make(map[core.RecordRef]core.RecordRef),
 	}
 	return ref, nil
 }
 
 // ActivateObjectDelegate implementation for tests
-func (t *TestArtifactManager) ActivateObjectDelegate(domain, request, class, parent core.RecordRef, memory []byte) (*core.RecordRef, error) {
-	ref, err := t.ActivateObject(domain, request, class, parent, memory)
+func (t *TestArtifactManager) ActivateObjectDelegate(ctx context.Context, domain, request, class, parent core.RecordRef, memory []byte) (*core.RecordRef, error) {
+	ref, err := t.ActivateObject(ctx, domain, request, class, parent, memory)
 	if err != nil {
 		return nil, errors.Wrap(err, "Failed to generate ref")
 	}
@@ -349,12 +407,12 @@ func (t *TestArtifactManager) ActivateObjectDelegate(domain, request, class, par
 }
 
 // DeactivateObject implementation for tests
-func (t *TestArtifactManager) DeactivateObject(domain core.RecordRef, request core.RecordRef, obj core.RecordRef) (*core.RecordID, error) {
+func (t *TestArtifactManager) DeactivateObject(ctx context.Context, domain core.RecordRef, request core.RecordRef, obj core.RecordRef) (*core.RecordID, error) {
 	panic("not implemented")
 }
 
 // UpdateObject implementation for tests
-func (t *TestArtifactManager) UpdateObject(domain core.RecordRef, request core.RecordRef, obj core.RecordRef, memory []byte) (*core.RecordID, error) {
+func (t *TestArtifactManager) UpdateObject(ctx context.Context, domain core.RecordRef, request core.RecordRef, obj core.RecordRef, memory []byte) (*core.RecordID, error) {
 	objDesc, ok := t.Objects[obj]
 	if !ok {
 		return nil, errors.New("No object to update")
@@ -366,6 +424,65 @@ func (t *TestArtifactManager) UpdateObject(domain core.RecordRef, request core.R
 	return &core.RecordID{}, nil
 }
 
+// UpdateObjects implementation for tests
+func (t *TestArtifactManager) UpdateObjects(ctx context.Context, writes []core.ObjectWrite) error {
+	created := map[core.RecordRef]bool{}
+	for _, w := range writes {
+		_, exists := t.Objects[w.Object]
+		if !w.Created && !exists {
+			return errors.New("No object to update")
+		}
+		if !w.Created {
+			continue
+		}
+		if exists || created[w.Object] {
+			return errors.New("Object already exists")
+		}
+		if _, ok := t.Classes[w.Class]; !ok {
+			return errors.New("No class")
+		}
+		if _, ok := t.Objects[w.Parent]; !ok && !created[w.Parent] {
+			return errors.New("No parent")
+		}
+		created[w.Object] = true
+	}
+	for _, w := range writes {
+		if w.Created {
+			class, domain := w.Class, w.Domain
+			t.Objects[w.Object] = &TestObjectDescriptor{
+				AM:        t,
+				Data:      w.Memory,
+				Code:      t.Classes[class].ACode,
+				Class:     &class,
+				DomainRef: &domain,
+				Delegates: make(map[core.RecordRef]core.RecordRef),
+			}
+			if w.Delegate {
+				t.Objects[w.Parent].Delegates[class] = w.Object
+			}
+		} else {
+			t.Objects[w.Object].Data = w.Memory
+		}
+		for _, ev := range w.Events {
+			ev.Object = w.Object
+			ev.Request = w.Request
+			t.Events = append(t.Events, ev)
+		}
+	}
+	return nil
+}
+
+// GetEvents implementation for tests
+func (t *TestArtifactManager) GetEvents(ctx context.Context, obj core.RecordRef, from, to core.PulseNumber) ([]core.Event, error) {
+	var res []core.Event
+	for _, ev := range t.Events {
+		if ev.Object == obj && ev.Pulse >= from && (to == 0 || ev.Pulse <= to) {
+			res = append(res, ev)
+		}
+	}
+	return res, nil
+}
+
 // CBORMarshal - testing serialize helper
 func CBORMarshal(t testing.TB, o interface{}) []byte {
 	ch := new(codec.CborHandle)
@@ -406,13 +523,13 @@ func AMPublishCode(
 	err error,
 ) {
 	codeRef, err = am.DeployCode(
-		domain, request, map[core.MachineType][]byte{mtype: code},
+		context.Background(), domain, request, map[core.MachineType][]byte{mtype: code},
 	)
 	assert.NoError(t, err, "create code on ledger")
 
-	classRef, err = am.ActivateClass(domain, request)
+	classRef, err = am.ActivateClass(context.Background(), domain, request)
 	assert.NoError(t, err, "create template for contract data")
-	_, err = am.UpdateClass(domain, request, *classRef, *codeRef, nil)
+	_, err = am.UpdateClass(context.Background(), domain, request, *classRef, *codeRef, nil)
 	assert.NoError(t, err, "create template for contract data")
 
 	return typeRef, codeRef, classRef, err
@@ -463,7 +580,7 @@ func (cb *ContractsBuilder) Build(contracts map[string]string) error {
 			return errors.Wrap(err, "Failed to generate ref")
 		}
 		class, err := cb.ArtifactManager.ActivateClass(
-			core.RecordRef{}, *ref,
+			context.Background(), core.RecordRef{}, *ref,
 		)
 		if err != nil {
 			return err
@@ -504,7 +621,7 @@ func (cb *ContractsBuilder) Build(contracts map[string]string) error {
 		}
 
 		code, err := cb.ArtifactManager.DeployCode(
-			core.RecordRef{}, core.RecordRef{},
+			context.Background(), core.RecordRef{}, core.RecordRef{},
 			map[core.MachineType][]byte{core.MachineTypeGoPlugin: pluginBinary},
 		)
 		if err != nil {
@@ -514,7 +631,7 @@ func (cb *ContractsBuilder) Build(contracts map[string]string) error {
 		cb.Codes[name] = code
 
 		_, err = cb.ArtifactManager.UpdateClass(
-			core.RecordRef{}, core.RecordRef{},
+			context.Background(), core.RecordRef{}, core.RecordRef{},
 			*cb.Classes[name],
 			*code,
 			[]core.RecordRef{},
@@ -522,6 +639,19 @@ func (cb *ContractsBuilder) Build(contracts map[string]string) error {
 		if err != nil {
 			return err
 		}
+
+		abi, err := cb.abi(name)
+		if err != nil {
+			return err
+		}
+		_, err = cb.ArtifactManager.DeclareClassType(
+			context.Background(), core.RecordRef{}, core.RecordRef{},
+			*cb.Classes[name],
+			abi,
+		)
+		if err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -561,6 +691,16 @@ func (cb *ContractsBuilder) wrapper(name string) error {
 }
 
 // Plugin ...
+func (cb *ContractsBuilder) abi(name string) ([]byte, error) {
+	contractPath := filepath.Join(cb.root, "src/contract", name, "main.go")
+
+	out, err := exec.Command(cb.IccPath, "abi", contractPath).CombinedOutput()
+	if err != nil {
+		return nil, errors.Wrap(err, "can't generate ABI for contract '"+name+"': "+string(out))
+	}
+	return out, nil
+}
+
 func (cb *ContractsBuilder) plugin(name string) error {
 	dstDir := filepath.Join(cb.root, "plugins")
 
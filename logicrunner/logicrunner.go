@@ -18,18 +18,26 @@
 package logicrunner
 
 import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
 	"net"
 	"sync"
 	"time"
 
+	"github.com/insolar/insolar/tracing"
 	"github.com/pkg/errors"
 
 	"github.com/insolar/insolar/configuration"
 	"github.com/insolar/insolar/core"
 	"github.com/insolar/insolar/core/message"
 	"github.com/insolar/insolar/core/reply"
+	ecdsa_helper "github.com/insolar/insolar/cryptohelpers/ecdsa"
 	"github.com/insolar/insolar/log"
 	"github.com/insolar/insolar/logicrunner/builtin"
+	_ "github.com/insolar/insolar/logicrunner/builtin/helloworld" // registers builtin contract
+	"github.com/insolar/insolar/logicrunner/external"
 	"github.com/insolar/insolar/logicrunner/goplugin"
 )
 
@@ -38,13 +46,29 @@ type LogicRunner struct {
 	Executors            [core.MachineTypesLastID]core.MachineLogicExecutor
 	ArtifactManager      core.ArtifactManager
 	MessageBus           core.MessageBus
+	JetCoordinator       core.JetCoordinator
 	machinePrefs         []core.MachineType
 	Cfg                  *configuration.LogicRunner
 	caseBind             core.CaseBind
 	caseBindMutex        sync.Mutex
+	caseCaptures         map[core.RecordRef][]*[]core.CaseRecord
 	caseBindReplays      map[core.RecordRef]core.CaseBindReplay
 	caseBindReplaysMutex sync.Mutex
+	objectQueues         map[core.RecordRef]*objectQueue
+	objectQueuesMutex    sync.Mutex
+	transactions         map[core.RecordRef]*transaction
+	transactionsMutex    sync.Mutex
+	abis                 map[core.RecordRef]*core.ContractABI // ABI of classes by type reference
+	abisMutex            sync.Mutex
 	sock                 net.Listener
+
+	node                   core.RecordRef            // node validation results are signed as
+	standalone             bool                      // node isn't connected to network and executes all objects
+	key                    *ecdsa.PrivateKey         // signs validation results
+	validators             map[core.RecordRef]string // known public keys of validators
+	validationResults      map[core.PulseNumber]map[core.RecordRef][]message.ValidationResults
+	validationWaiters      map[core.RecordRef]chan *message.ValidationResults
+	validationResultsMutex sync.Mutex
 }
 
 // NewLogicRunner is constructor for LogicRunner
@@ -52,27 +76,85 @@ func NewLogicRunner(cfg *configuration.LogicRunner) (*LogicRunner, error) {
 	if cfg == nil {
 		return nil, errors.New("LogicRunner have nil configuration")
 	}
+	key, err := validatorKey(cfg.PrivateKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't load key of validator")
+	}
+	validators := make(map[core.RecordRef]string)
+	for _, v := range cfg.Validation.Validators {
+		if _, err := ecdsa_helper.ImportPublicKey(v.PublicKey); err != nil {
+			return nil, errors.Wrapf(err, "couldn't load key of validator %s", v.Node)
+		}
+		validators[core.NewRefFromBase58(v.Node)] = v.PublicKey
+	}
 	res := LogicRunner{
-		ArtifactManager: nil,
-		Cfg:             cfg,
-		caseBindReplays: make(map[core.RecordRef]core.CaseBindReplay),
+		ArtifactManager:   nil,
+		Cfg:               cfg,
+		caseBindReplays:   make(map[core.RecordRef]core.CaseBindReplay),
+		caseCaptures:      make(map[core.RecordRef][]*[]core.CaseRecord),
+		objectQueues:      make(map[core.RecordRef]*objectQueue),
+		transactions:      make(map[core.RecordRef]*transaction),
+		abis:              make(map[core.RecordRef]*core.ContractABI),
+		key:               key,
+		validators:        validators,
+		validationResults: make(map[core.PulseNumber]map[core.RecordRef][]message.ValidationResults),
+		validationWaiters: make(map[core.RecordRef]chan *message.ValidationResults),
 	}
 	return &res, nil
 }
 
+// validatorKey imports PEM encoded key, new key is generated if none is configured. Results signed by
+// the generated key are accepted only by the node itself and nodes that have its public key configured.
+func validatorKey(pem string) (*ecdsa.PrivateKey, error) {
+	if pem != "" {
+		return ecdsa_helper.ImportPrivateKey(pem)
+	}
+	key, err := ecdsa_helper.GeneratePrivateKey()
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't generate key")
+	}
+	pub, err := ecdsa_helper.ExportPublicKey(&key.PublicKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't export generated key")
+	}
+	log.Warnf("private key of validator isn't configured, generated key will be lost on restart, its public key is:\n%s", pub)
+	return key, nil
+}
+
+// trustSelf adds the node's own key to known validators, so calls validated by the node itself
+// need no configuration
+func (lr *LogicRunner) trustSelf() error {
+	if _, ok := lr.validators[lr.node]; ok {
+		return nil
+	}
+	pub, err := ecdsa_helper.ExportPublicKey(&lr.key.PublicKey)
+	if err != nil {
+		return errors.Wrap(err, "couldn't export key of validator")
+	}
+	lr.validators[lr.node] = pub
+	return nil
+}
+
 // Start starts logic runner component
 func (lr *LogicRunner) Start(c core.Components) error {
 	am := c.Ledger.GetArtifactManager()
 	lr.ArtifactManager = am
+	lr.JetCoordinator = c.Ledger.GetJetCoordinator()
 	messageBus := c.MessageBus
 	lr.MessageBus = messageBus
+	if c.Network != nil {
+		lr.node = c.Network.GetNodeID()
+	}
+	lr.standalone = c.Network == nil
+	if err := lr.trustSelf(); err != nil {
+		return err
+	}
 
 	if lr.Cfg.BuiltIn != nil {
 		bi := builtin.NewBuiltIn(messageBus, am)
 		if err := lr.RegisterExecutor(core.MachineTypeBuiltin, bi); err != nil {
 			return err
 		}
-		lr.machinePrefs = append(lr.machinePrefs, core.MachineTypeBuiltin)
 	}
 
 	if lr.Cfg.GoPlugin != nil {
@@ -87,7 +169,16 @@ func (lr *LogicRunner) Start(c core.Components) error {
 		if err := lr.RegisterExecutor(core.MachineTypeGoPlugin, gp); err != nil {
 			return err
 		}
-		lr.machinePrefs = append(lr.machinePrefs, core.MachineTypeGoPlugin)
+	}
+
+	if lr.Cfg.External != nil {
+		ex, err := external.NewExecutor(lr.Cfg.External, NewRPC(lr))
+		if err != nil {
+			return err
+		}
+		if err := lr.RegisterExecutor(core.MachineTypeExternal, ex); err != nil {
+			return err
+		}
 	}
 
 	// TODO: use separate handlers
@@ -97,6 +188,12 @@ func (lr *LogicRunner) Start(c core.Components) error {
 	if err := messageBus.Register(core.TypeCallConstructor, lr.Execute); err != nil {
 		return err
 	}
+	if err := messageBus.Register(core.TypeValidateCaseBind, lr.ValidateCaseBind); err != nil {
+		return err
+	}
+	if err := messageBus.Register(core.TypeValidationResults, lr.ProcessValidationResults); err != nil {
+		return err
+	}
 
 	return nil
 }
@@ -123,8 +220,12 @@ func (lr *LogicRunner) Stop() error {
 	return reterr
 }
 
-// RegisterExecutor registers an executor for particular `MachineType`
+// RegisterExecutor registers an executor for particular `MachineType`,
+// code of the machine type is preferred after code of executors registered earlier
 func (lr *LogicRunner) RegisterExecutor(t core.MachineType, e core.MachineLogicExecutor) error {
+	if lr.Executors[int(t)] == nil {
+		lr.machinePrefs = append(lr.machinePrefs, t)
+	}
 	lr.Executors[int(t)] = e
 	return nil
 }
@@ -140,13 +241,26 @@ func (lr *LogicRunner) GetExecutor(t core.MachineType) (core.MachineLogicExecuto
 }
 
 // Execute runs a method on an object, ATM just thin proxy to `GoPlugin.Exec`
-func (lr *LogicRunner) Execute(inmsg core.Message) (core.Reply, error) {
+func (lr *LogicRunner) Execute(ctx context.Context, inmsg core.Message) (core.Reply, error) {
 	msg, ok := inmsg.(message.IBaseLogicMessage)
 	if !ok {
 		return nil, errors.New("Execute( ! message.IBaseLogicMessage )")
 	}
+	return lr.execute(ctx, msg, nil)
+}
+
+// execute runs the call, tx is the transaction of the chain the call is nested into, nil for top-level calls
+func (lr *LogicRunner) execute(ctx context.Context, msg message.IBaseLogicMessage, tx *transaction) (core.Reply, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, errors.Wrap(err, "caller is not waiting for the result")
+	}
 
 	ref := msg.GetReference()
+	ctx, span := tracing.StartSpan(ctx, "logicrunner.Execute")
+	defer span.End()
+	span.SetAttribute("reference", ref.String())
+	tracing.Logger(ctx).Debugf("executing %s on %s", msg.Type(), ref)
+
 	lr.caseBindReplaysMutex.Lock()
 	cb, validate := lr.caseBindReplays[ref]
 	lr.caseBindReplaysMutex.Unlock()
@@ -158,35 +272,113 @@ func (lr *LogicRunner) Execute(inmsg core.Message) (core.Reply, error) {
 		vb = ValidationSaver{lr: lr}
 	}
 
-	ctx := core.LogicCallContext{
+	pulse := lr.pulse()
+	lctx := core.LogicCallContext{
 		Caller: msg.GetCaller(),
-		Time:   time.Now(), // TODO: probably we should take it from e
-		Pulse:  lr.caseBind.Pulse,
+		Time:   pulse.PulseNumber.Time(),
+		Pulse:  pulse,
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		lctx.Deadline = deadline
+	}
+	lctx.Trace = tracing.Inject(ctx)
+	lctx.Limits = lr.callLimits()
+	if tx != nil {
+		lctx.Chain = &tx.request
 	}
 
+	// read-only calls don't change the object, so they aren't registered or recorded,
+	// calls that must be validated are executed as regular ones to be replayed by validators
+	if m, ok := msg.(*message.CallMethod); ok && m.ReturnMode != message.ReturnValidated {
+		readOnly, err := lr.readOnly(ctx, tx, m.ObjectRef, m.Method)
+		if err != nil {
+			span.SetError(err)
+			return nil, err
+		}
+		if readOnly {
+			span.SetAttribute("method", m.Method)
+			re, err := lr.executeReadOnlyMethod(ctx, lctx, m, tx)
+			span.SetError(err)
+			return re, err
+		}
+	}
+
+	// validators replay registered requests and don't write to the ledger
+	if vb.NeedSave() {
+		request, err := lr.ArtifactManager.RegisterRequest(ctx, msg)
+		if err != nil {
+			span.SetError(err)
+			return nil, errors.Wrap(err, "couldn't register request")
+		}
+		lctx.Request = request
+		if tx == nil {
+			lctx.Chain = request
+		}
+	}
+
+	var re core.Reply
+	var err error
 	switch m := msg.(type) {
 	case *message.CallMethod:
-		re, err := lr.executeMethodCall(ctx, m, vb)
-		return re, err
+		span.SetAttribute("method", m.Method)
+		re, err = lr.executeMethodCall(ctx, lctx, m, vb, tx)
 
 	case *message.CallConstructor:
-		re, err := lr.executeConstructorCall(ctx, m, vb)
-		return re, err
+		span.SetAttribute("constructor", m.Name)
+		re, err = lr.executeConstructorCall(ctx, lctx, m, vb, tx)
 
 	default:
 		panic("Unknown e type")
 	}
+	if err != nil {
+		tracing.Logger(ctx).Debugf("call of %s failed: %s", ref, err)
+	}
+	span.SetError(err)
+	return re, err
+}
+
+// callLimits returns resource limits of contract calls from configuration
+func (lr *LogicRunner) callLimits() core.CallLimits {
+	l := lr.Cfg.Limits
+	return core.CallLimits{
+		Time:        time.Duration(l.Time) * time.Millisecond,
+		Memory:      l.Memory,
+		NestedCalls: l.NestedCalls,
+		StateSize:   l.StateSize,
+	}
+}
+
+// callDeadline sets deadline of the executor call, that is the caller's deadline
+// or the end of call's time limit, whichever is earlier
+func callDeadline(lctx *core.LogicCallContext) {
+	if lctx.Limits.Time <= 0 {
+		return
+	}
+	limit := time.Now().Add(lctx.Limits.Time)
+	if lctx.Deadline.IsZero() || limit.Before(lctx.Deadline) {
+		lctx.Deadline = limit
+		lctx.TimeLimited = true
+	}
 }
 
 type objectBody struct {
 	Body        []byte
+	State       *core.RecordID
 	Code        core.RecordRef
 	Class       core.RecordRef
+	Domain      core.RecordRef
 	MachineType core.MachineType
 }
 
-func (lr *LogicRunner) getObjectMessage(objref core.RecordRef) (*objectBody, error) {
-	objDesc, err := lr.ArtifactManager.GetObject(objref, nil)
+// getObjectMessage fetches the object in the given state, the latest one if state is nil
+func (lr *LogicRunner) getObjectMessage(ctx context.Context, objref core.RecordRef, state *core.RecordID) (*objectBody, error) {
+	var stateRef *core.RecordRef
+	if state != nil {
+		stateRef = &core.RecordRef{}
+		*stateRef = objref
+		stateRef.SetRecord(*state)
+	}
+	objDesc, err := lr.ArtifactManager.GetObject(ctx, objref, stateRef)
 	if err != nil {
 		return nil, errors.Wrap(err, "couldn't get object")
 	}
@@ -203,85 +395,353 @@ func (lr *LogicRunner) getObjectMessage(objref core.RecordRef) (*objectBody, err
 
 	return &objectBody{
 		Body:        objDesc.Memory(),
+		State:       objDesc.StateID(),
 		Code:        *codeDesc.Ref(),
 		Class:       *classDesc.HeadRef(),
+		Domain:      *objDesc.Domain(),
 		MachineType: codeDesc.MachineType(),
 	}, nil
 }
 
-func (lr *LogicRunner) executeMethodCall(ctx core.LogicCallContext, e *message.CallMethod, vb ValidationBehaviour) (core.Reply, error) {
-	vb.Begin(e.ObjectRef, core.CaseRecord{
-		Type: core.CaseRecordTypeStart,
-		Resp: e,
-	})
+// chainObject fetches the object as the call chain sees it, with unsaved changes of the chain.
+// Object created by the chain has no state, it isn't activated until the chain saves its changes.
+func (lr *LogicRunner) chainObject(ctx context.Context, tx *transaction, objref core.RecordRef) (*objectBody, error) {
+	var w *core.ObjectWrite
+	if tx != nil {
+		w = tx.write(objref)
+	}
+	if w == nil {
+		return lr.getObjectMessage(ctx, objref, nil)
+	}
+	if !w.Created {
+		objbody, err := lr.getObjectMessage(ctx, objref, w.Base)
+		if err != nil {
+			return nil, err
+		}
+		objbody.Body = w.Memory
+		return objbody, nil
+	}
 
-	objbody, err := lr.getObjectMessage(e.ObjectRef)
+	classDesc, err := lr.ArtifactManager.GetClass(ctx, w.Class, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't get object's class")
+	}
+	codeDesc, err := classDesc.CodeDescriptor(lr.machinePrefs)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't get object's code descriptor")
+	}
+	return &objectBody{
+		Body:        w.Memory,
+		Code:        *codeDesc.Ref(),
+		Class:       *classDesc.HeadRef(),
+		Domain:      w.Domain,
+		MachineType: codeDesc.MachineType(),
+	}, nil
+}
+
+func (lr *LogicRunner) executeMethodCall(ctx context.Context, lctx core.LogicCallContext, e *message.CallMethod, vb ValidationBehaviour, tx *transaction) (core.Reply, error) {
+	switch e.ReturnMode {
+	case message.ReturnResult, message.ReturnValidated:
+		return lr.executeMethod(ctx, lctx, e, vb, tx)
+	case message.ReturnNoWait:
+		// caller doesn't wait, so execution is not bound to its context and
+		// is queued on the object as a separate call chain
+		lctx.Deadline = time.Time{}
+		lctx.Chain = lctx.Request
+		bgctx, span := tracing.StartSpan(context.Background(), "logicrunner.NoWait")
+		span.SetAttribute("caller_trace", tracing.FromContext(ctx).TraceID.String())
+		lctx.Trace = tracing.Inject(bgctx)
+		go func() {
+			defer span.End()
+			_, err := lr.executeMethod(bgctx, lctx, e, vb, nil)
+			if err != nil {
+				span.SetError(err)
+				tracing.Logger(bgctx).Error(err)
+			}
+		}()
+		return &reply.CallMethod{}, nil
+	}
+	return nil, errors.Errorf("Invalid ReturnMode #%d", e.ReturnMode)
+}
+
+// executeMethod executes method in turn with other calls to the object,
+// so the method gets the latest object state and its result isn't overwritten by concurrent call.
+// In ReturnValidated mode the result is saved and returned only after validators confirmed it,
+// the object is unlocked while validators replay such call as a regular one.
+//
+// Top-level call (tx is nil) starts a transaction of its call chain, calls nested into the chain add their
+// changes to it and the top-level call saves them together with its own change when it succeeds.
+// If any call of the chain fails, nothing is saved.
+func (lr *LogicRunner) executeMethod(ctx context.Context, lctx core.LogicCallContext, e *message.CallMethod, vb ValidationBehaviour, tx *transaction) (core.Reply, error) {
+	unlock, err := lr.lockObject(ctx, e.ObjectRef, lctx.Chain)
+	if err != nil {
+		return nil, errors.Wrap(err, "caller gave up waiting for the object")
+	}
+	var unlockOnce sync.Once
+	defer unlockOnce.Do(unlock)
+
+	validated := e.ReturnMode == message.ReturnValidated && vb.NeedSave()
+	var captured func() []core.CaseRecord
+	if vb.NeedSave() {
+		captured = lr.captureCaseRecords(e.ObjectRef)
+		defer captured()
+	}
+	var objbody *objectBody
+	if base := vb.BaseState(); base != nil {
+		objbody, err = lr.getObjectMessage(ctx, e.ObjectRef, base)
+	} else {
+		objbody, err = lr.chainObject(ctx, tx, e.ObjectRef)
+	}
 	if err != nil {
 		return nil, errors.Wrap(err, "couldn't get object")
 	}
 
-	ctx.Callee = &e.ObjectRef
-	ctx.Class = &objbody.Class
-	vb.ModifyContext(&ctx)
+	start := startRecord(lctx, e, objbody.State)
+	vb.Begin(e.ObjectRef, start)
+	ended := false // records of the call without result are dropped
+	defer func() {
+		if !ended {
+			vb.Drop(e.ObjectRef, start)
+		}
+	}()
+
+	nested := tx != nil
+	if vb.NeedSave() && !nested {
+		var endTx func()
+		tx, endTx = lr.beginTransaction(*lctx.Request)
+		defer endTx()
+	}
+	if tx != nil {
+		tx.call(e.ObjectRef)
+	}
+
+	lctx.Callee = &e.ObjectRef
+	lctx.Class = &objbody.Class
+	vb.ModifyContext(&lctx)
 
 	executor, err := lr.GetExecutor(objbody.MachineType)
 	if err != nil {
 		return nil, errors.Wrap(err, "no executor registered")
 	}
 
-	executer := func() (*reply.CallMethod, error) {
-		newData, result, err := executor.CallMethod(
-			&ctx, objbody.Code, objbody.Body, e.Method, e.Arguments,
-		)
+	_, span := tracing.StartSpan(ctx, "logicrunner.CallMethod")
+	callDeadline(&lctx)
+	newData, result, err := executor.CallMethod(
+		&lctx, objbody.Code, objbody.Body, e.Method, e.Arguments,
+	)
+	span.SetError(err)
+	span.End()
+	if le, ok := err.(*core.LimitExceededError); ok {
+		return &reply.LimitExceeded{LimitExceededError: *le}, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "executor error")
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, errors.Wrap(err, "caller gave up, result is not saved")
+	}
+	if tx != nil {
+		if err := tx.failed(); err != nil {
+			return nil, errors.Wrap(err, "nested call failed, changes are discarded")
+		}
+	}
+
+	re := &reply.CallMethod{Data: newData, Result: result}
+	end := core.CaseRecord{
+		Type: core.CaseRecordTypeResult,
+		Resp: re,
+	}
+
+	if validated {
+		// other calls aren't blocked while validators replay the call, if one of them changes
+		// the object meanwhile the ledger rejects the result as based on the outdated state
+		unlockOnce.Do(unlock)
+		rejected, err := lr.validateCall(ctx, e.ObjectRef, lctx.Pulse, append(captured(), end), re)
+		if err != nil {
+			return nil, errors.Wrap(err, "couldn't validate result")
+		}
+		if rejected != nil {
+			return rejected, nil
+		}
+	}
+
+	if !vb.NeedSave() {
+		vb.End(e.ObjectRef, end)
+		ended = true
+		return re, nil
+	}
+
+	err = tx.update(core.ObjectWrite{
+		Domain:  objbody.Domain,
+		Request: *lctx.Request,
+		Object:  e.ObjectRef,
+		Base:    objbody.State,
+		Memory:  newData,
+		Events:  emittedEvents(captured(), e.ObjectRef, lctx),
+	}, objbody.Body)
+	if err != nil {
+		return nil, err
+	}
+	if !nested {
+		err = tx.commit(ctx, lr.ArtifactManager)
 		if err != nil {
-			return nil, errors.Wrap(err, "executor error")
+			return nil, errors.Wrap(err, "couldn't update objects")
 		}
+	}
+	vb.End(e.ObjectRef, end)
+	ended = true
 
-		if vb.NeedSave() {
-			_, err = lr.ArtifactManager.UpdateObject(
-				core.RecordRef{}, core.RecordRef{}, e.ObjectRef, newData,
-			)
+	return re, nil
+}
+
+// readOnly tells whether the method is declared read-only in the ABI of the object's class,
+// methods of classes without ABI change the state
+func (lr *LogicRunner) readOnly(ctx context.Context, tx *transaction, obj core.RecordRef, method string) (bool, error) {
+	classDesc, err := lr.objectClass(ctx, tx, obj)
+	if err != nil {
+		return false, err
+	}
+	if classDesc.TypeRef() == nil {
+		return false, nil
+	}
+	abi, err := lr.classABI(ctx, *classDesc.TypeRef())
+	if err != nil {
+		return false, err
+	}
+	for _, m := range abi.Methods {
+		if m.Name == method {
+			return m.ReadOnly, nil
+		}
+	}
+	return false, nil
+}
+
+// objectClass fetches class of the object, the object may be created by the chain and not activated yet
+func (lr *LogicRunner) objectClass(ctx context.Context, tx *transaction, obj core.RecordRef) (core.ClassDescriptor, error) {
+	if tx != nil {
+		if w := tx.write(obj); w != nil && w.Created {
+			classDesc, err := lr.ArtifactManager.GetClass(ctx, w.Class, nil)
 			if err != nil {
-				return nil, errors.Wrap(err, "couldn't update object")
+				return nil, errors.Wrap(err, "couldn't get object's class")
 			}
+			return classDesc, nil
 		}
+	}
+	objDesc, err := lr.ArtifactManager.GetObject(ctx, obj, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't get object")
+	}
+	classDesc, err := objDesc.ClassDescriptor(nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't get object's class")
+	}
+	return classDesc, nil
+}
 
-		re := &reply.CallMethod{Data: newData, Result: result}
-
-		vb.End(e.ObjectRef, core.CaseRecord{
-			Type: core.CaseRecordTypeResult,
-			Resp: re,
-		})
+// classABI fetches ABI of the class by reference of its type, type records never change so they are cached
+func (lr *LogicRunner) classABI(ctx context.Context, typeRef core.RecordRef) (*core.ContractABI, error) {
+	lr.abisMutex.Lock()
+	abi, ok := lr.abis[typeRef]
+	lr.abisMutex.Unlock()
+	if ok {
+		return abi, nil
+	}
 
-		return re, nil
+	typeDec, err := lr.ArtifactManager.GetType(ctx, typeRef)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't get ABI of the class")
+	}
+	abi = &core.ContractABI{}
+	if err := json.Unmarshal(typeDec, abi); err != nil {
+		return nil, errors.Wrap(err, "couldn't decode ABI of the class")
 	}
 
+	lr.abisMutex.Lock()
+	lr.abis[typeRef] = abi
+	lr.abisMutex.Unlock()
+	return abi, nil
+}
+
+// executeReadOnlyMethod executes method that doesn't change object's state, such calls run concurrently
+// with other calls to the object and their results aren't saved. Call that changed the state is rejected.
+// Call nested into a chain sees unsaved changes of the chain.
+func (lr *LogicRunner) executeReadOnlyMethod(ctx context.Context, lctx core.LogicCallContext, e *message.CallMethod, tx *transaction) (core.Reply, error) {
 	switch e.ReturnMode {
 	case message.ReturnResult:
-		return executer()
 	case message.ReturnNoWait:
-		go func() {
-			_, err := executer()
-			if err != nil {
-				log.Error(err)
-			}
-		}()
-		return &reply.CallMethod{}, nil
+		return &reply.CallMethod{}, nil // nobody waits for the result and there is nothing to save
+	default:
+		return nil, errors.Errorf("Invalid ReturnMode #%d", e.ReturnMode)
 	}
-	return nil, errors.Errorf("Invalid ReturnMode #%d", e.ReturnMode)
+
+	objbody, err := lr.chainObject(ctx, tx, e.ObjectRef)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't get object")
+	}
+
+	lctx.Callee = &e.ObjectRef
+	lctx.Class = &objbody.Class
+	lctx.ReadOnly = true
+
+	executor, err := lr.GetExecutor(objbody.MachineType)
+	if err != nil {
+		return nil, errors.Wrap(err, "no executor registered")
+	}
+
+	_, span := tracing.StartSpan(ctx, "logicrunner.CallMethod")
+	span.SetAttribute("read_only", "true")
+	callDeadline(&lctx)
+	newData, result, err := executor.CallMethod(
+		&lctx, objbody.Code, objbody.Body, e.Method, e.Arguments,
+	)
+	span.SetError(err)
+	span.End()
+	if le, ok := err.(*core.LimitExceededError); ok {
+		return &reply.LimitExceeded{LimitExceededError: *le}, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "executor error")
+	}
+	if !bytes.Equal(newData, objbody.Body) {
+		return nil, errors.Errorf("read-only method %s changed state of the object", e.Method)
+	}
+
+	return &reply.CallMethod{Data: newData, Result: result}, nil
 }
 
-func (lr *LogicRunner) executeConstructorCall(ctx core.LogicCallContext, m *message.CallConstructor, vb ValidationBehaviour) (core.Reply, error) {
-	vb.Begin(m.ClassRef, core.CaseRecord{
-		Type: core.CaseRecordTypeStart,
-		Resp: m,
-	})
+// executeConstructorCall creates an object, it is activated when changes of the call chain are saved.
+// Top-level call (tx is nil) starts a transaction of its call chain like executeMethod does.
+func (lr *LogicRunner) executeConstructorCall(ctx context.Context, lctx core.LogicCallContext, m *message.CallConstructor, vb ValidationBehaviour, tx *transaction) (core.Reply, error) {
+	start := startRecord(lctx, m, nil)
+	vb.Begin(m.ClassRef, start)
+	ended := false // records of the call without result are dropped
+	defer func() {
+		if !ended {
+			vb.Drop(m.ClassRef, start)
+		}
+	}()
+	vb.ModifyContext(&lctx)
+	var captured func() []core.CaseRecord
+	if vb.NeedSave() {
+		captured = lr.captureCaseRecords(m.ClassRef)
+		defer captured()
+	}
+	if m.SaveAs != message.Child && m.SaveAs != message.Delegate {
+		return nil, errors.New("unsupported type of save object")
+	}
 
-	classDesc, err := lr.ArtifactManager.GetClass(m.ClassRef, nil)
+	nested := tx != nil
+	if vb.NeedSave() && !nested {
+		var endTx func()
+		tx, endTx = lr.beginTransaction(*lctx.Request)
+		defer endTx()
+	}
+
+	classDesc, err := lr.ArtifactManager.GetClass(ctx, m.ClassRef, nil)
 	if err != nil {
 		return nil, errors.Wrap(err, "couldn't get class")
 	}
-	ctx.Class = classDesc.HeadRef()
+	lctx.Class = classDesc.HeadRef()
 
 	codeDesc, err := classDesc.CodeDescriptor(lr.machinePrefs)
 	if err != nil {
@@ -293,45 +753,110 @@ func (lr *LogicRunner) executeConstructorCall(ctx core.LogicCallContext, m *mess
 		return nil, errors.Wrap(err, "no executer registered")
 	}
 
-	newData, err := executor.CallConstructor(&ctx, *codeDesc.Ref(), m.Name, m.Arguments)
+	_, span := tracing.StartSpan(ctx, "logicrunner.CallConstructor")
+	callDeadline(&lctx)
+	newData, err := executor.CallConstructor(&lctx, *codeDesc.Ref(), m.Name, m.Arguments)
+	span.SetError(err)
+	span.End()
+	if le, ok := err.(*core.LimitExceededError); ok {
+		return &reply.LimitExceeded{LimitExceededError: *le}, nil
+	}
 	if err != nil {
 		return nil, errors.Wrap(err, "executer error")
 	}
+	if err := ctx.Err(); err != nil {
+		return nil, errors.Wrap(err, "caller gave up, object is not saved")
+	}
 
-	switch m.SaveAs {
-	case message.Child:
-		ref, err := lr.ArtifactManager.ActivateObject(
-			core.RecordRef{}, core.RandomRef(), m.ClassRef, m.ParentRef, newData,
-		)
-
+	if !vb.NeedSave() { // validator doesn't create the object again
+		re := &reply.CallConstructor{}
 		vb.End(m.ClassRef, core.CaseRecord{
 			Type: core.CaseRecordTypeResult,
-			Resp: &reply.CallConstructor{Object: ref},
+			Resp: re,
 		})
+		ended = true
+		return re, nil
+	}
+	if err := tx.failed(); err != nil {
+		return nil, errors.Wrap(err, "nested call failed, object is not created")
+	}
 
-		return &reply.CallConstructor{Object: ref}, err
-	case message.Delegate:
-		ref, err := lr.ArtifactManager.ActivateObjectDelegate(
-			core.RecordRef{}, core.RandomRef(), m.ClassRef, m.ParentRef, newData,
-		)
+	parentDomain, err := lr.objectDomain(ctx, tx, m.ParentRef)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't get parent")
+	}
 
-		vb.End(m.ClassRef, core.CaseRecord{
-			Type: core.CaseRecordTypeResult,
-			Resp: &reply.CallConstructor{Object: ref},
-		})
+	ref := tx.newObject(lctx.Pulse.PulseNumber)
+	err = tx.create(core.ObjectWrite{
+		Domain:   newObjectDomain(m.ParentRef, parentDomain),
+		Request:  *lctx.Request,
+		Object:   ref,
+		Memory:   newData,
+		Events:   emittedEvents(captured(), ref, lctx),
+		Class:    m.ClassRef,
+		Parent:   m.ParentRef,
+		Delegate: m.SaveAs == message.Delegate,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !nested {
+		err = tx.commit(ctx, lr.ArtifactManager)
+		if err != nil {
+			return nil, errors.Wrap(err, "couldn't activate object")
+		}
+	}
 
-		return &reply.CallConstructor{Object: ref}, err
-	default:
-		return nil, errors.New("unsupported type of save object")
+	re := &reply.CallConstructor{Object: &ref}
+	vb.End(m.ClassRef, core.CaseRecord{
+		Type: core.CaseRecordTypeResult,
+		Resp: re,
+	})
+	ended = true
+	return re, nil
+}
+
+// objectDomain returns domain of the object, the object may be created by the chain and not activated yet
+func (lr *LogicRunner) objectDomain(ctx context.Context, tx *transaction, obj core.RecordRef) (core.RecordRef, error) {
+	if w := tx.write(obj); w != nil && w.Created {
+		return w.Domain, nil
+	}
+	objDesc, err := lr.ArtifactManager.GetObject(ctx, obj, nil)
+	if err != nil {
+		return core.RecordRef{}, err
 	}
+	if domain := objDesc.Domain(); domain != nil {
+		return *domain, nil
+	}
+	return core.RecordRef{}, nil
 }
 
+// newObjectDomain returns domain of the object created as a child or a delegate of the parent,
+// that is the parent's domain or the parent itself if it doesn't belong to any domain
+func newObjectDomain(parentRef core.RecordRef, parentDomain core.RecordRef) core.RecordRef {
+	if parentDomain != (core.RecordRef{}) {
+		return parentDomain
+	}
+	return parentRef
+}
+
+// pulse returns current pulse, OnPulse changes it concurrently with executed calls
+func (lr *LogicRunner) pulse() core.Pulse {
+	lr.caseBindMutex.Lock()
+	defer lr.caseBindMutex.Unlock()
+	return lr.caseBind.Pulse
+}
+
+// OnPulse starts new CaseBind, the finished one is stored and sent to validators
 func (lr *LogicRunner) OnPulse(pulse core.Pulse) error {
 	lr.caseBindMutex.Lock()
+	finished := lr.caseBind
 	lr.caseBind = core.CaseBind{
 		Pulse:   pulse,
 		Records: make(map[core.RecordRef][]core.CaseRecord),
 	}
 	lr.caseBindMutex.Unlock()
-	return nil
+
+	lr.dropValidationResults(finished.Pulse.PulseNumber)
+	return lr.finishCaseBind(finished)
 }
@@ -18,6 +18,7 @@ package logicrunner
 
 import (
 	"bytes"
+	"context"
 
 	"crypto/ecdsa"
 	"crypto/rand"
@@ -39,6 +40,7 @@ import (
 	"github.com/insolar/insolar/log"
 	"github.com/insolar/insolar/logicrunner/goplugin/foundation"
 	"github.com/insolar/insolar/logicrunner/goplugin/preprocessor"
+	"github.com/insolar/insolar/logicrunner/goplugin/rpctypes"
 	"github.com/insolar/insolar/logicrunner/goplugin/testutil"
 	"github.com/insolar/insolar/pulsar"
 	"github.com/insolar/insolar/testutils"
@@ -73,6 +75,7 @@ func PrepareLrAmCb(t testing.TB) (core.LogicRunner, core.ArtifactManager, *testu
 
 	l, cleaner := ledgertestutil.TmpLedger(t, "")
 	lr, err := NewLogicRunner(&configuration.LogicRunner{
+		PrivateKey:  testutils.PrivateKey(),
 		RPCListen:   lrSock,
 		RPCProtocol: "unix",
 		GoPlugin: &configuration.GoPlugin{
@@ -159,7 +162,7 @@ func TestBasics(t *testing.T) {
 	if parallel {
 		t.Parallel()
 	}
-	lr, err := NewLogicRunner(&configuration.LogicRunner{})
+	lr, err := NewLogicRunner(&configuration.LogicRunner{PrivateKey: testutils.PrivateKey()})
 	assert.NoError(t, err)
 	lr.OnPulse(*pulsar.NewPulse(configuration.NewPulsar().NumberDelta, 0, &pulsar.StandardEntropyGenerator{}))
 
@@ -185,6 +188,7 @@ func TestBasics(t *testing.T) {
 
 type testLedger struct {
 	am core.ArtifactManager
+	jc core.JetCoordinator
 }
 
 func (r *testLedger) GetPulseManager() core.PulseManager {
@@ -192,7 +196,7 @@ func (r *testLedger) GetPulseManager() core.PulseManager {
 }
 
 func (r *testLedger) GetJetCoordinator() core.JetCoordinator {
-	panic("implement me")
+	return r.jc
 }
 
 func (r *testLedger) Start(components core.Components) error   { return nil }
@@ -216,8 +220,8 @@ func (eb *testMessageBus) MustRegister(p core.MessageType, handler core.MessageH
 
 func (*testMessageBus) Start(components core.Components) error { return nil }
 func (*testMessageBus) Stop() error                            { return nil }
-func (eb *testMessageBus) Send(event core.Message) (resp core.Reply, err error) {
-	return eb.LogicRunner.Execute(event)
+func (eb *testMessageBus) Send(ctx context.Context, event core.Message) (resp core.Reply, err error) {
+	return eb.LogicRunner.Execute(ctx, event)
 }
 func (*testMessageBus) SendAsync(msg core.Message) {}
 
@@ -228,7 +232,7 @@ func TestExecution(t *testing.T) {
 	am := testutil.NewTestArtifactManager()
 	ld := &testLedger{am: am}
 	eb := &testMessageBus{}
-	lr, err := NewLogicRunner(&configuration.LogicRunner{})
+	lr, err := NewLogicRunner(&configuration.LogicRunner{PrivateKey: testutils.PrivateKey()})
 	assert.NoError(t, err)
 	lr.Start(core.Components{
 		Ledger:     ld,
@@ -255,14 +259,122 @@ func TestExecution(t *testing.T) {
 	err = lr.RegisterExecutor(core.MachineTypeGoPlugin, te)
 	assert.NoError(t, err)
 
-	resp, err := lr.Execute(&message.CallMethod{ObjectRef: dataRef})
+	resp, err := lr.Execute(context.Background(), &message.CallMethod{ObjectRef: dataRef})
 	assert.NoError(t, err)
 	assert.Equal(t, []byte("data"), resp.(*reply.CallMethod).Data)
 	assert.Equal(t, []byte("res"), resp.(*reply.CallMethod).Result)
 
 	te.constructorResponses = append(te.constructorResponses, &testResp{data: []byte("data"), res: core.Arguments("res")})
-	resp, err = lr.Execute(&message.CallConstructor{ClassRef: classRef})
+	resp, err = lr.Execute(context.Background(), &message.CallConstructor{ClassRef: classRef, ParentRef: dataRef})
 	assert.NoError(t, err)
+	child := am.Objects[*resp.(*reply.CallConstructor).Object]
+	assert.Equal(t, dataRef, *child.Domain(), "parent without domain becomes the domain of its children")
+}
+
+func TestExecutionOnPulseChange(t *testing.T) {
+	if parallel {
+		t.Parallel()
+	}
+	am := testutil.NewTestArtifactManager()
+	eb := &testMessageBus{}
+	lr, err := NewLogicRunner(&configuration.LogicRunner{PrivateKey: testutils.PrivateKey()})
+	assert.NoError(t, err)
+	lr.Start(core.Components{
+		Ledger:     &testLedger{am: am},
+		MessageBus: eb,
+	})
+	eb.LogicRunner = lr
+
+	codeRef := core.NewRefFromBase58("someCode")
+	dataRef := core.NewRefFromBase58("someObject")
+	classRef := core.NewRefFromBase58("someClass")
+	am.Objects[dataRef] = &testutil.TestObjectDescriptor{
+		AM:    am,
+		Data:  []byte("origData"),
+		Code:  &codeRef,
+		Class: &classRef,
+	}
+	am.Classes[classRef] = &testutil.TestClassDescriptor{AM: am, ARef: &classRef, ACode: &codeRef}
+	am.Codes[codeRef] = &testutil.TestCodeDescriptor{ARef: codeRef, AMachineType: core.MachineTypeGoPlugin}
+
+	te := newTestExecutor()
+	const calls = 10
+	for i := 0; i < calls; i++ {
+		te.methodResponses = append(te.methodResponses, &testResp{data: []byte("data"), res: core.Arguments("res")})
+	}
+	assert.NoError(t, lr.RegisterExecutor(core.MachineTypeGoPlugin, te))
+
+	// pulse changes concurrently with calls, run with -race
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < calls; i++ {
+			assert.NoError(t, lr.OnPulse(core.Pulse{PulseNumber: core.FirstPulseNumber + core.PulseNumber(i)}))
+		}
+	}()
+	for i := 0; i < calls; i++ {
+		_, err := lr.Execute(context.Background(), &message.CallMethod{ObjectRef: dataRef})
+		assert.NoError(t, err)
+	}
+	<-done
+}
+
+func TestReadOnlyExecution(t *testing.T) {
+	if parallel {
+		t.Parallel()
+	}
+	am := testutil.NewTestArtifactManager()
+	ld := &testLedger{am: am}
+	eb := &testMessageBus{}
+	lr, err := NewLogicRunner(&configuration.LogicRunner{PrivateKey: testutils.PrivateKey()})
+	assert.NoError(t, err)
+	lr.Start(core.Components{
+		Ledger:     ld,
+		MessageBus: eb,
+	})
+	lr.OnPulse(*pulsar.NewPulse(configuration.NewPulsar().NumberDelta, 0, &pulsar.StandardEntropyGenerator{}))
+	eb.LogicRunner = lr
+
+	codeRef := core.NewRefFromBase58("someCode")
+	dataRef := core.NewRefFromBase58("someObject")
+	classRef := core.NewRefFromBase58("someClass")
+	am.Objects[dataRef] = &testutil.TestObjectDescriptor{
+		AM:    am,
+		Data:  []byte("origData"),
+		Code:  &codeRef,
+		Class: &classRef,
+	}
+	typeRef := core.NewRefFromBase58("someType")
+	am.Declarations[typeRef] = []byte(`{"methods": [{"name": "Get", "readonly": true}, {"name": "Set"}]}`)
+	am.Classes[classRef] = &testutil.TestClassDescriptor{AM: am, ARef: &classRef, ACode: &codeRef, AType: &typeRef}
+	am.Codes[codeRef] = &testutil.TestCodeDescriptor{ARef: codeRef, AMachineType: core.MachineTypeGoPlugin}
+
+	te := newTestExecutor()
+	te.methodResponses = append(te.methodResponses,
+		&testResp{data: []byte("origData"), res: core.Arguments("res")},
+		&testResp{data: []byte("newData"), res: core.Arguments("res")},
+	)
+	err = lr.RegisterExecutor(core.MachineTypeGoPlugin, te)
+	assert.NoError(t, err)
+
+	resp, err := lr.Execute(context.Background(), &message.CallMethod{ObjectRef: dataRef, Method: "Get"})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("res"), resp.(*reply.CallMethod).Result)
+
+	_, err = lr.Execute(context.Background(), &message.CallMethod{ObjectRef: dataRef, Method: "Get"})
+	assert.Error(t, err, "read-only call changed the state")
+	assert.Equal(t, []byte("origData"), am.Objects[dataRef].Data, "state isn't saved")
+
+	assert.Empty(t, lr.caseBind.Records[dataRef], "read-only calls aren't recorded")
+
+	// read-only call can't call a method changing state
+	err = NewRPC(lr).RouteCall(rpctypes.UpRouteReq{
+		UpBaseReq: rpctypes.UpBaseReq{Me: dataRef, ReadOnly: true},
+		Wait:      true,
+		Object:    dataRef,
+		Method:    "Set",
+	}, &rpctypes.UpRouteResp{})
+	assert.Error(t, err)
 }
 
 func TestContractCallingContract(t *testing.T) {
@@ -324,7 +436,7 @@ func (r *Two) Hello(s string) string {
 	assert.NoError(t, err)
 
 	obj, err := am.ActivateObject(
-		core.RecordRef{}, core.RecordRef{},
+		context.Background(), core.RecordRef{}, core.RecordRef{},
 		*cb.Classes["one"],
 		*am.RootRef(),
 		data,
@@ -404,7 +516,7 @@ func (r *Two) Hello(s string) string {
 	assert.NoError(t, err)
 
 	obj, err := am.ActivateObject(
-		core.RecordRef{}, core.RecordRef{},
+		context.Background(), core.RecordRef{}, core.RecordRef{},
 		*cb.Classes["one"],
 		*am.RootRef(),
 		data,
@@ -486,7 +598,7 @@ func (r *Two) Hello() string {
 	assert.NoError(t, err)
 
 	obj, err := am.ActivateObject(
-		core.RecordRef{}, core.RecordRef{},
+		context.Background(), core.RecordRef{}, core.RecordRef{},
 		*cb.Classes["one"],
 		*am.RootRef(),
 		data,
@@ -614,11 +726,11 @@ func New(n int) *Child {
 	assert.NoError(t, err)
 
 	domain := core.NewRefFromBase58("c1")
-	contract, err := am.ActivateObject(core.NewRefFromBase58("r1"), domain, *cb.Classes["contract"], *am.RootRef(), testutil.CBORMarshal(t, nil))
+	contract, err := am.ActivateObject(context.Background(), core.NewRefFromBase58("r1"), domain, *cb.Classes["contract"], *am.RootRef(), testutil.CBORMarshal(t, nil))
 	assert.NoError(t, err, "create contract")
 	assert.NotEqual(t, contract, nil, "contract created")
 
-	resp, err := lr.Execute(&message.CallMethod{
+	resp, err := lr.Execute(context.Background(), &message.CallMethod{
 		ObjectRef: *contract,
 		Method:    "NewChilds",
 		Arguments: testutil.CBORMarshal(t, []interface{}{10}),
@@ -627,7 +739,7 @@ func New(n int) *Child {
 	r := testutil.CBORUnMarshal(t, resp.(*reply.CallMethod).Result)
 	assert.Equal(t, []interface{}([]interface{}{uint64(45)}), r)
 
-	resp, err = lr.Execute(&message.CallMethod{
+	resp, err = lr.Execute(context.Background(), &message.CallMethod{
 		ObjectRef: *contract,
 		Method:    "SumChilds",
 		Arguments: testutil.CBORMarshal(t, []interface{}{}),
@@ -694,11 +806,11 @@ func (r *Two) AnError() error {
 	assert.NoError(t, err)
 
 	domain := core.NewRefFromBase58("c1")
-	contract, err := am.ActivateObject(core.NewRefFromBase58("r1"), domain, *cb.Classes["one"], *am.RootRef(), testutil.CBORMarshal(t, nil))
+	contract, err := am.ActivateObject(context.Background(), core.NewRefFromBase58("r1"), domain, *cb.Classes["one"], *am.RootRef(), testutil.CBORMarshal(t, nil))
 	assert.NoError(t, err, "create contract")
 	assert.NotEqual(t, contract, nil, "contract created")
 
-	resp, err := lr.Execute(&message.CallMethod{
+	resp, err := lr.Execute(context.Background(), &message.CallMethod{
 		ObjectRef: *contract,
 		Method:    "AnError",
 		Arguments: testutil.CBORMarshal(t, []interface{}{}),
@@ -731,7 +843,7 @@ func (s *Caller) SignedCall(ref string, method string, params []interface{}) int
 
 	sign, err := cryptoHelper.Sign(buf, s.key)
 	assert.NoError(s.t, err)
-	resp, err := s.lr.Execute(&message.CallMethod{
+	resp, err := s.lr.Execute(context.Background(), &message.CallMethod{
 		ObjectRef: core.NewRefFromBase58(s.member),
 		Method:    "AuthorizedCall",
 		Arguments: testutil.CBORMarshal(s.t, []interface{}{ref, method, params, seed, sign}),
@@ -772,7 +884,7 @@ func TestRootDomainContract(t *testing.T) {
 	// Initializing Root Domain
 	domain := core.NewRefFromBase58("c1")
 	request := core.NewRefFromBase58("c2")
-	contract, err := am.ActivateObject(domain, request, *cb.Classes["rootdomain"], *am.RootRef(), testutil.CBORMarshal(t, nil))
+	contract, err := am.ActivateObject(context.Background(), domain, request, *cb.Classes["rootdomain"], *am.RootRef(), testutil.CBORMarshal(t, nil))
 	assert.NoError(t, err, "create contract")
 	assert.NotEqual(t, contract, nil, "contract created")
 
@@ -781,7 +893,7 @@ func TestRootDomainContract(t *testing.T) {
 	assert.NoError(t, err)
 	rootPubKey, err := cryptoHelper.ExportPublicKey(&rootKey.PublicKey)
 	assert.NoError(t, err)
-	resp, err := lr.Execute(&message.CallMethod{
+	resp, err := lr.Execute(context.Background(), &message.CallMethod{
 		ObjectRef: *contract,
 		Method:    "SetRoot",
 		Arguments: testutil.CBORMarshal(t, []interface{}{rootPubKey}),
@@ -859,16 +971,16 @@ func (c *Child) GetNum() int {
 	assert.NoError(b, err)
 
 	domain := core.NewRefFromBase58("c1")
-	parent, err := am.ActivateObject(core.NewRefFromBase58("r1"), domain, *cb.Classes["parent"], *am.RootRef(), testutil.CBORMarshal(b, nil))
+	parent, err := am.ActivateObject(context.Background(), core.NewRefFromBase58("r1"), domain, *cb.Classes["parent"], *am.RootRef(), testutil.CBORMarshal(b, nil))
 	assert.NoError(b, err, "create parent")
 	assert.NotEqual(b, parent, nil, "parent created")
-	child, err := am.ActivateObject(core.NewRefFromBase58("r2"), domain, *cb.Classes["child"], *am.RootRef(), testutil.CBORMarshal(b, nil))
+	child, err := am.ActivateObject(context.Background(), core.NewRefFromBase58("r2"), domain, *cb.Classes["child"], *am.RootRef(), testutil.CBORMarshal(b, nil))
 	assert.NoError(b, err, "create child")
 	assert.NotEqual(b, child, nil, "child created")
 
 	b.N = 1000
 	for i := 0; i < b.N; i++ {
-		resp, err := lr.Execute(&message.CallMethod{
+		resp, err := lr.Execute(context.Background(), &message.CallMethod{
 			ObjectRef: *parent,
 			Method:    "CCC",
 			Arguments: testutil.CBORMarshal(b, []interface{}{child}),
@@ -911,3 +1023,165 @@ func TestProxyGeneration(t *testing.T) {
 		})
 	}
 }
+
+func TestEmitEvent(t *testing.T) {
+	if parallel {
+		t.Parallel()
+	}
+	var code = `
+package main
+
+import "github.com/insolar/insolar/logicrunner/goplugin/foundation"
+
+type One struct {
+	foundation.BaseContract
+	Paid int
+}
+
+func New(paid int) *One {
+	r := &One{Paid: paid}
+	err := r.EmitEvent("created", paid)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+func (r *One) Pay(amount int) {
+	r.Paid += amount
+	err := r.EmitEvent("paid", amount)
+	if err != nil {
+		panic(err)
+	}
+}
+`
+	lr, am, cb, cleaner := PrepareLrAmCb(t)
+	defer cleaner()
+
+	err := cb.Build(map[string]string{"one": code})
+	assert.NoError(t, err)
+
+	domain := core.NewRefFromBase58("c1")
+	contract, err := am.ActivateObject(context.Background(), core.NewRefFromBase58("r1"), domain, *cb.Classes["one"], *am.RootRef(), testutil.CBORMarshal(t, nil))
+	assert.NoError(t, err, "create contract")
+
+	_, err = lr.Execute(context.Background(), &message.CallMethod{
+		ObjectRef: *contract,
+		Method:    "Pay",
+		Arguments: testutil.CBORMarshal(t, []interface{}{10}),
+	})
+	assert.NoError(t, err, "contract call")
+
+	ValidateAllResults(t, lr)
+
+	events, err := am.GetEvents(context.Background(), *contract, 0, 0)
+	assert.NoError(t, err)
+	assert.Len(t, events, 1)
+	assert.Equal(t, "paid", events[0].Name)
+	assert.Equal(t, *contract, events[0].Object)
+	assert.NotEqual(t, core.RecordRef{}, events[0].Request, "event is linked to the request")
+	assert.Equal(t, core.PulseNumber(configuration.NewPulsar().NumberDelta), events[0].Pulse)
+	assert.Equal(t, uint64(10), testutil.CBORUnMarshal(t, events[0].Payload))
+
+	resp, err := lr.Execute(context.Background(), &message.CallConstructor{
+		ClassRef:  *cb.Classes["one"],
+		ParentRef: *contract,
+		Name:      "New",
+		Arguments: testutil.CBORMarshal(t, []interface{}{5}),
+	})
+	assert.NoError(t, err, "constructor call")
+	created := *resp.(*reply.CallConstructor).Object
+
+	ValidateAllResults(t, lr)
+
+	events, err = am.GetEvents(context.Background(), created, 0, 0)
+	assert.NoError(t, err)
+	assert.Len(t, events, 1)
+	assert.Equal(t, "created", events[0].Name)
+	assert.Equal(t, created, events[0].Object)
+	assert.Equal(t, uint64(5), testutil.CBORUnMarshal(t, events[0].Payload))
+}
+
+func TestRunnerCrashRecovery(t *testing.T) {
+	if parallel {
+		t.Parallel()
+	}
+	var code = `
+package main
+
+import "github.com/insolar/insolar/logicrunner/goplugin/foundation"
+
+type One struct {
+	foundation.BaseContract
+}
+
+func (r *One) Hello() string {
+	return "Hi"
+}
+
+func (r *One) Crash() {
+	panic("crash")
+}
+`
+	lrSock := os.TempDir() + "/" + core.RandomRef().String()[0:10] + ".sock"
+	rundSock := os.TempDir() + "/" + core.RandomRef().String()[0:10] + ".sock"
+
+	l, cleaner := ledgertestutil.TmpLedger(t, "")
+	defer cleaner()
+	lr, err := NewLogicRunner(&configuration.LogicRunner{
+		PrivateKey:  testutils.PrivateKey(),
+		RPCListen:   lrSock,
+		RPCProtocol: "unix",
+		GoPlugin: &configuration.GoPlugin{
+			RunnerListen:        rundSock,
+			RunnerProtocol:      "unix",
+			RunnerPath:          runnerbin,
+			RunnerCount:         2,
+			HealthCheckInterval: 100,
+		},
+	})
+	assert.NoError(t, err, "Initialize runner")
+	assert.NoError(t, lr.Start(core.Components{
+		Ledger:     l,
+		MessageBus: &testMessageBus{LogicRunner: lr},
+	}), "starting logicrunner")
+	defer lr.Stop()
+	lr.OnPulse(*pulsar.NewPulse(configuration.NewPulsar().NumberDelta, 0, &pulsar.StandardEntropyGenerator{}))
+
+	am := l.GetArtifactManager()
+	cb := testutil.NewContractBuilder(am, icc)
+	defer cb.Clean()
+	err = cb.Build(map[string]string{"one": code})
+	assert.NoError(t, err)
+
+	domain := core.NewRefFromBase58("c1")
+	contract, err := am.ActivateObject(context.Background(), core.NewRefFromBase58("r1"), domain, *cb.Classes["one"], *am.RootRef(), testutil.CBORMarshal(t, nil))
+	assert.NoError(t, err, "create contract")
+
+	_, err = lr.Execute(context.Background(), &message.CallMethod{
+		ObjectRef: *contract,
+		Method:    "Crash",
+		Arguments: testutil.CBORMarshal(t, []interface{}{}),
+	})
+	assert.Error(t, err, "runner crashed")
+
+	for i := 0; i < 4; i++ {
+		resp, err := lr.Execute(context.Background(), &message.CallMethod{
+			ObjectRef: *contract,
+			Method:    "Hello",
+			Arguments: testutil.CBORMarshal(t, []interface{}{}),
+		})
+		assert.NoError(t, err, "call after crash")
+		if err == nil {
+			assert.Equal(t, []interface{}{"Hi"}, testutil.CBORUnMarshal(t, resp.(*reply.CallMethod).Result))
+		}
+	}
+}
+
+func TestNewObjectDomain(t *testing.T) {
+	parentRef := core.NewRefFromBase58("parent")
+	domainRef := core.NewRefFromBase58("domain")
+
+	assert.Equal(t, domainRef, newObjectDomain(parentRef, domainRef))
+	assert.Equal(t, parentRef, newObjectDomain(parentRef, core.RecordRef{}))
+}
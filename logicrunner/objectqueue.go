@@ -0,0 +1,114 @@
+/*
+ *    Copyright 2018 Insolar
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package logicrunner
+
+import (
+	"context"
+	"sync"
+
+	"github.com/insolar/insolar/core"
+)
+
+// objectQueue orders calls to one object, calls are executed one at a time in order of arrival.
+//
+// Calls of one call chain are reentrant: object calling itself directly or through other objects
+// isn't blocked by its own outer call. The chain is identified by the registered request of its top-level call,
+// calls without registered requests are never reentrant. Note that the outer call doesn't see memory changes
+// made by the nested call, see transaction.update.
+type objectQueue struct {
+	turn  chan struct{}
+	mutex sync.Mutex
+	owner core.RecordRef
+	depth int
+	users int // calls that hold or wait for the queue, guarded by LogicRunner.objectQueuesMutex
+}
+
+func newObjectQueue() *objectQueue {
+	return &objectQueue{
+		turn: make(chan struct{}, 1),
+	}
+}
+
+// lock waits for turn of the call chain, it fails if the context is done while waiting.
+func (q *objectQueue) lock(ctx context.Context, chain *core.RecordRef) error {
+	q.mutex.Lock()
+	if q.depth > 0 && chain != nil && q.owner == *chain {
+		q.depth++
+		q.mutex.Unlock()
+		return nil
+	}
+	q.mutex.Unlock()
+
+	select {
+	case q.turn <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	q.mutex.Lock()
+	q.owner = core.RecordRef{}
+	if chain != nil {
+		q.owner = *chain
+	}
+	q.depth = 1
+	q.mutex.Unlock()
+	return nil
+}
+
+// unlock passes turn to the next call when the outermost call of the chain is finished.
+func (q *objectQueue) unlock() {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	q.depth--
+	if q.depth == 0 {
+		q.owner = core.RecordRef{}
+		<-q.turn
+	}
+}
+
+// lockObject waits until the call of the chain can be executed on the object,
+// returned function must be called when the call is finished.
+func (lr *LogicRunner) lockObject(ctx context.Context, ref core.RecordRef, chain *core.RecordRef) (func(), error) {
+	lr.objectQueuesMutex.Lock()
+	q, ok := lr.objectQueues[ref]
+	if !ok {
+		q = newObjectQueue()
+		lr.objectQueues[ref] = q
+	}
+	q.users++
+	lr.objectQueuesMutex.Unlock()
+
+	release := func() {
+		lr.objectQueuesMutex.Lock()
+		q.users--
+		if q.users == 0 {
+			delete(lr.objectQueues, ref)
+		}
+		lr.objectQueuesMutex.Unlock()
+	}
+
+	err := q.lock(ctx, chain)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	return func() {
+		q.unlock()
+		release()
+	}, nil
+}
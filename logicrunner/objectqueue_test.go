@@ -0,0 +1,113 @@
+/*
+ *    Copyright 2018 Insolar
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package logicrunner
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/insolar/insolar/configuration"
+	"github.com/insolar/insolar/core"
+	"github.com/insolar/insolar/testutils"
+)
+
+func TestLogicRunner_lockObject(t *testing.T) {
+	lr, err := NewLogicRunner(&configuration.LogicRunner{PrivateKey: testutils.PrivateKey()})
+	assert.NoError(t, err)
+	ref := core.NewRefFromBase58("object")
+	ctx := context.Background()
+
+	first := core.NewRefFromBase58("first")
+	second := core.NewRefFromBase58("second")
+
+	unlock, err := lr.lockObject(ctx, ref, &first)
+	assert.NoError(t, err)
+
+	// nested call of the same chain isn't blocked
+	unlockNested, err := lr.lockObject(ctx, ref, &first)
+	assert.NoError(t, err)
+
+	// other objects aren't blocked
+	unlockOther, err := lr.lockObject(ctx, core.NewRefFromBase58("other"), &second)
+	assert.NoError(t, err)
+	unlockOther()
+
+	// other chain waits until the outermost call is finished
+	acquired := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		unlock, err := lr.lockObject(ctx, ref, &second)
+		assert.NoError(t, err)
+		close(acquired)
+		unlock()
+		close(done)
+	}()
+
+	unlockNested()
+	select {
+	case <-acquired:
+		t.Fatal("other chain entered the object before the outermost call finished")
+	default:
+	}
+	unlock()
+
+	<-done
+	assert.Empty(t, lr.objectQueues)
+}
+
+func TestLogicRunner_lockObject_NoChain(t *testing.T) {
+	lr, err := NewLogicRunner(&configuration.LogicRunner{PrivateKey: testutils.PrivateKey()})
+	assert.NoError(t, err)
+	ref := core.NewRefFromBase58("object")
+
+	unlock, err := lr.lockObject(context.Background(), ref, nil)
+	assert.NoError(t, err)
+
+	// calls without registered requests don't share the turn
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = lr.lockObject(ctx, ref, nil)
+	assert.Equal(t, context.Canceled, err)
+
+	empty := core.RecordRef{}
+	_, err = lr.lockObject(ctx, ref, &empty)
+	assert.Equal(t, context.Canceled, err)
+
+	unlock()
+	assert.Empty(t, lr.objectQueues)
+}
+
+func TestLogicRunner_lockObject_Cancel(t *testing.T) {
+	lr, err := NewLogicRunner(&configuration.LogicRunner{PrivateKey: testutils.PrivateKey()})
+	assert.NoError(t, err)
+	ref := core.NewRefFromBase58("object")
+
+	first := core.NewRefFromBase58("first")
+	unlock, err := lr.lockObject(context.Background(), ref, &first)
+	assert.NoError(t, err)
+
+	second := core.NewRefFromBase58("second")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = lr.lockObject(ctx, ref, &second)
+	assert.Equal(t, context.Canceled, err)
+
+	unlock()
+	assert.Empty(t, lr.objectQueues)
+}
@@ -20,6 +20,7 @@ package logicrunner
 
 import (
 	"bytes"
+	"context"
 	"net"
 	"net/rpc"
 
@@ -28,12 +29,19 @@ import (
 	"github.com/insolar/insolar/core/reply"
 	"github.com/insolar/insolar/log"
 	"github.com/insolar/insolar/logicrunner/goplugin/rpctypes"
+	"github.com/insolar/insolar/tracing"
 	"github.com/pkg/errors"
 )
 
+// NewRPC creates RPC service of the logic runner without serving it, executors running
+// contracts in-process call its methods directly
+func NewRPC(lr *LogicRunner) *RPC {
+	return &RPC{lr: lr}
+}
+
 // StartRPC starts RPC server for isolated executors to use
 func StartRPC(lr *LogicRunner) *RPC {
-	rpcService := &RPC{lr: lr}
+	rpcService := NewRPC(lr)
 
 	rpcServer := rpc.NewServer()
 	err := rpcServer.Register(rpcService)
@@ -63,12 +71,15 @@ type RPC struct {
 
 // GetCode is an RPC retrieving a code by its reference
 func (gpr *RPC) GetCode(req rpctypes.UpGetCodeReq, reply *rpctypes.UpGetCodeResp) error {
+	ctx, cancel := MakeContext(req.UpBaseReq)
+	defer cancel()
 	am := gpr.lr.ArtifactManager
-	codeDescriptor, err := am.GetCode(req.Code, []core.MachineType{req.MType})
+	codeDescriptor, err := am.GetCode(ctx, req.Code, []core.MachineType{req.MType})
 	if err != nil {
 		return err
 	}
 	reply.Code = codeDescriptor.Code()
+	reply.Hash = codeDescriptor.Hash()
 	return nil
 }
 
@@ -76,12 +87,94 @@ func (gpr *RPC) GetCode(req rpctypes.UpGetCodeReq, reply *rpctypes.UpGetCodeResp
 func MakeBaseMessage(req rpctypes.UpBaseReq) message.BaseLogicMessage {
 	return message.BaseLogicMessage{
 		Caller: req.Me,
+		Chain:  req.Chain,
+	}
+}
+
+// MakeContext makes context of the up request, it expires together with the call that made the request
+// and continues its trace
+func MakeContext(req rpctypes.UpBaseReq) (context.Context, context.CancelFunc) {
+	// requests come from contracts executed by this node while it handles messages
+	ctx := message.NestedContext(tracing.Extract(context.Background(), req.Trace))
+	if req.Deadline.IsZero() {
+		return context.WithCancel(ctx)
+	}
+	return context.WithDeadline(ctx, req.Deadline)
+}
+
+// validationStep returns the next case record of the calling object if it's being validated,
+// read-only calls aren't recorded in case bind so they are never validated
+func (gpr *RPC) validationStep(req rpctypes.UpBaseReq) (*core.CaseRecord, int) {
+	if req.ReadOnly {
+		return nil, -1
+	}
+	return gpr.lr.getNextValidationStep(req.Me)
+}
+
+// addCaseRecord records request of the calling object in case bind unless it's made by read-only call
+func (gpr *RPC) addCaseRecord(req rpctypes.UpBaseReq, cr core.CaseRecord) {
+	if req.ReadOnly {
+		return
 	}
+	gpr.lr.addObjectCaseRecord(req.Me, cr)
 }
 
-// RouteCall routes call from a contract to a contract through event bus.
+// transaction returns transaction of the chain that made the request, nil if the chain doesn't execute on this node
+func (gpr *RPC) transaction(req rpctypes.UpBaseReq) *transaction {
+	return gpr.lr.transaction(req.Chain)
+}
+
+// send executes the call nested into the chain on this node, so the call sees unsaved changes of the chain
+// and adds its own changes to them. Calls outside of a chain and calls of objects executed by other nodes
+// are routed through the message bus, the latter save their changes on their own.
+// Failure of the nested call fails the chain.
+func (gpr *RPC) send(ctx context.Context, tx *transaction, msg message.IBaseLogicMessage) (core.Reply, error) {
+	if tx == nil {
+		return gpr.lr.MessageBus.Send(ctx, msg)
+	}
+	local, err := gpr.local(tx, msg)
+	if err != nil {
+		tx.fail(err)
+		return nil, err
+	}
+	if !local {
+		return gpr.lr.MessageBus.Send(ctx, msg)
+	}
+
+	res, err := gpr.lr.execute(ctx, msg, tx)
+	if err != nil {
+		tx.fail(err)
+		return nil, err
+	}
+	switch r := res.(type) {
+	case *reply.LimitExceeded:
+		tx.fail(&r.LimitExceededError)
+	case *reply.ValidationRejected:
+		tx.fail(r)
+	}
+	return res, nil
+}
+
+// local checks that the callee of the nested call is executed by this node on the current pulse.
+// Constructors create objects of the chain, so they are always local as well as calls to created objects.
+func (gpr *RPC) local(tx *transaction, msg message.IBaseLogicMessage) (bool, error) {
+	m, ok := msg.(*message.CallMethod)
+	if !ok || gpr.lr.standalone || createdBy(tx, m.ObjectRef) {
+		return true, nil
+	}
+	local, err := gpr.lr.JetCoordinator.IsAuthorized(core.RoleVirtualExecutor, m.ObjectRef, gpr.lr.pulse().PulseNumber, gpr.lr.node)
+	if err != nil {
+		return false, errors.Wrap(err, "couldn't get executor of the object")
+	}
+	return local, nil
+}
+
+// RouteCall routes call from a contract to a contract. Calls the caller waits for are nested into its chain
+// when this node executes the callee, changes made by them are saved together with changes of the chain.
+// Callees of other nodes get calls through event bus, calls nobody waits for are sent through its outbox,
+// such calls save their changes on their own.
 func (gpr *RPC) RouteCall(req rpctypes.UpRouteReq, rep *rpctypes.UpRouteResp) error {
-	cr, step := gpr.lr.getNextValidationStep(req.Me)
+	cr, step := gpr.validationStep(req.UpBaseReq)
 	if step >= 0 { // validate
 		if core.CaseRecordTypeRouteCall != cr.Type {
 			return errors.New("Wrong validation type on RouteCall")
@@ -96,6 +189,22 @@ func (gpr *RPC) RouteCall(req rpctypes.UpRouteReq, rep *rpctypes.UpRouteResp) er
 
 	}
 
+	ctx, cancel := MakeContext(req.UpBaseReq)
+	defer cancel()
+
+	var tx *transaction
+	if req.Wait {
+		tx = gpr.transaction(req.UpBaseReq)
+	}
+	// whether the method changes state is declared by the callee's class, read-only call can't change anything
+	readOnly, err := gpr.lr.readOnly(ctx, tx, req.Object, req.Method)
+	if err != nil {
+		return err
+	}
+	if req.ReadOnly && !readOnly {
+		return errors.Errorf("read-only call can't call method %s changing state", req.Method)
+	}
+
 	var mode message.MethodReturnMode
 	if req.Wait {
 		mode = message.ReturnResult
@@ -111,13 +220,31 @@ func (gpr *RPC) RouteCall(req rpctypes.UpRouteReq, rep *rpctypes.UpRouteResp) er
 		Arguments:        req.Arguments,
 	}
 
-	res, err := gpr.lr.MessageBus.Send(msg)
+	if !req.Wait {
+		// nobody waits for the result, the call is stored in the outbox and retried until it's delivered
+		gpr.lr.MessageBus.SendAsync(msg)
+		gpr.addCaseRecord(req.UpBaseReq, core.CaseRecord{
+			Type:   core.CaseRecordTypeRouteCall,
+			ReqSig: HashInterface(req),
+			Resp:   rep.Result,
+		})
+		return nil
+	}
+
+	res, err := gpr.send(ctx, tx, msg)
 	if err != nil {
 		return errors.Wrap(err, "couldn't dispatch event")
 	}
+	if le, ok := res.(*reply.LimitExceeded); ok {
+		return &le.LimitExceededError
+	}
+	if vr, ok := res.(*reply.ValidationRejected); ok {
+		return vr
+	}
 
-	rep.Result = res.(*reply.CallMethod).Result
-	gpr.lr.addObjectCaseRecord(req.Me, core.CaseRecord{
+	re := res.(*reply.CallMethod)
+	rep.Result = re.Result
+	gpr.addCaseRecord(req.UpBaseReq, core.CaseRecord{
 		Type:   core.CaseRecordTypeRouteCall,
 		ReqSig: HashInterface(req),
 		Resp:   rep.Result,
@@ -131,8 +258,11 @@ func (gpr *RPC) SaveAsChild(req rpctypes.UpSaveAsChildReq, rep *rpctypes.UpSaveA
 	if gpr.lr.MessageBus == nil {
 		return errors.New("event bus was not set during initialization")
 	}
+	if req.ReadOnly {
+		return errors.New("read-only method can't create objects")
+	}
 
-	cr, step := gpr.lr.getNextValidationStep(req.Me)
+	cr, step := gpr.validationStep(req.UpBaseReq)
 	if step >= 0 { // validate
 		if core.CaseRecordTypeSaveAsChild != cr.Type {
 			return errors.New("Wrong validation type on SaveAsChild")
@@ -142,7 +272,8 @@ func (gpr *RPC) SaveAsChild(req rpctypes.UpSaveAsChildReq, rep *rpctypes.UpSaveA
 			return errors.New("Wrong validation sig on SaveAsChild")
 		}
 
-		rep.Reference = cr.Resp.(*core.RecordRef)
+		ref := cr.Resp.(core.RecordRef)
+		rep.Reference = &ref
 		return nil
 	}
 
@@ -155,17 +286,23 @@ func (gpr *RPC) SaveAsChild(req rpctypes.UpSaveAsChildReq, rep *rpctypes.UpSaveA
 		SaveAs:           message.Child,
 	}
 
-	res, err := gpr.lr.MessageBus.Send(msg)
+	ctx, cancel := MakeContext(req.UpBaseReq)
+	defer cancel()
+	res, err := gpr.send(ctx, gpr.transaction(req.UpBaseReq), msg)
 	if err != nil {
 		return errors.Wrap(err, "couldn't save new object as child")
 	}
+	if le, ok := res.(*reply.LimitExceeded); ok {
+		return &le.LimitExceededError
+	}
 
-	rep.Reference = res.(*reply.CallConstructor).Object
+	re := res.(*reply.CallConstructor)
+	rep.Reference = re.Object
 
-	gpr.lr.addObjectCaseRecord(req.Me, core.CaseRecord{
+	gpr.addCaseRecord(req.UpBaseReq, core.CaseRecord{
 		Type:   core.CaseRecordTypeSaveAsChild,
 		ReqSig: HashInterface(req),
-		Resp:   rep.Reference,
+		Resp:   *rep.Reference,
 	})
 
 	return nil
@@ -175,7 +312,7 @@ func (gpr *RPC) SaveAsChild(req rpctypes.UpSaveAsChildReq, rep *rpctypes.UpSaveA
 func (gpr *RPC) GetObjChildren(req rpctypes.UpGetObjChildrenReq, rep *rpctypes.UpGetObjChildrenResp) error {
 	// TODO: INS-408
 
-	cr, step := gpr.lr.getNextValidationStep(req.Me)
+	cr, step := gpr.validationStep(req.UpBaseReq)
 	if step >= 0 { // validate
 		if core.CaseRecordTypeGetObjChildren != cr.Type {
 			return errors.New("Wrong validation type on GetObjChildren")
@@ -189,30 +326,43 @@ func (gpr *RPC) GetObjChildren(req rpctypes.UpGetObjChildrenReq, rep *rpctypes.U
 		return nil
 	}
 
+	ctx, cancel := MakeContext(req.UpBaseReq)
+	defer cancel()
 	am := gpr.lr.ArtifactManager
-	i, err := am.GetChildren(req.Obj, nil)
-	if err != nil {
-		return err
-	}
-	for i.HasNext() {
-		r, err := i.Next()
+	tx := gpr.transaction(req.UpBaseReq)
+	// object created by the chain isn't activated yet, so it has no children on the ledger
+	if !createdBy(tx, req.Obj) {
+		i, err := am.GetChildren(ctx, req.Obj, nil)
 		if err != nil {
 			return err
 		}
-		o, err := am.GetObject(*r, nil)
-		if err != nil {
-			return errors.Wrap(err, "Have ref, have no object")
-		}
-		cd, err := o.ClassDescriptor(nil)
-		if err != nil {
-			return errors.Wrap(err, "Have ref, have no object")
+		for i.HasNext() {
+			r, err := i.Next()
+			if err != nil {
+				return err
+			}
+			o, err := am.GetObject(ctx, *r, nil)
+			if err != nil {
+				return errors.Wrap(err, "Have ref, have no object")
+			}
+			cd, err := o.ClassDescriptor(nil)
+			if err != nil {
+				return errors.Wrap(err, "Have ref, have no object")
+			}
+			ref := cd.HeadRef()
+			if ref.Equal(req.Class) {
+				rep.Children = append(rep.Children, *r)
+			}
 		}
-		ref := cd.HeadRef()
-		if ref.Equal(req.Class) {
-			rep.Children = append(rep.Children, *r)
+	}
+	if tx != nil {
+		for _, w := range tx.find(req.Obj, false) {
+			if w.Class.Equal(req.Class) {
+				rep.Children = append(rep.Children, w.Object)
+			}
 		}
 	}
-	gpr.lr.addObjectCaseRecord(req.Me, core.CaseRecord{ // bad idea, we can store gadzillion of children
+	gpr.addCaseRecord(req.UpBaseReq, core.CaseRecord{ // bad idea, we can store gadzillion of children
 		Type:   core.CaseRecordTypeGetObjChildren,
 		ReqSig: HashInterface(req),
 		Resp:   rep.Children,
@@ -220,9 +370,22 @@ func (gpr *RPC) GetObjChildren(req rpctypes.UpGetObjChildrenReq, rep *rpctypes.U
 	return nil
 }
 
+// createdBy tells whether the object is created by the chain and isn't activated yet
+func createdBy(tx *transaction, obj core.RecordRef) bool {
+	if tx == nil {
+		return false
+	}
+	w := tx.write(obj)
+	return w != nil && w.Created
+}
+
 // SaveAsDelegate is an RPC saving data as memory of a contract as child a parent
 func (gpr *RPC) SaveAsDelegate(req rpctypes.UpSaveAsDelegateReq, rep *rpctypes.UpSaveAsDelegateResp) error {
-	cr, step := gpr.lr.getNextValidationStep(req.Me)
+	if req.ReadOnly {
+		return errors.New("read-only method can't create objects")
+	}
+
+	cr, step := gpr.validationStep(req.UpBaseReq)
 	if step >= 0 { // validate
 		if core.CaseRecordTypeSaveAsDelegate != cr.Type {
 			return errors.New("Wrong validation type on SaveAsDelegate")
@@ -232,7 +395,8 @@ func (gpr *RPC) SaveAsDelegate(req rpctypes.UpSaveAsDelegateReq, rep *rpctypes.U
 			return errors.New("Wrong validation sig on SaveAsDelegate")
 		}
 
-		rep.Reference = cr.Resp.(*core.RecordRef)
+		ref := cr.Resp.(core.RecordRef)
+		rep.Reference = &ref
 		return nil
 	}
 
@@ -245,17 +409,22 @@ func (gpr *RPC) SaveAsDelegate(req rpctypes.UpSaveAsDelegateReq, rep *rpctypes.U
 		SaveAs:           message.Delegate,
 	}
 
-	res, err := gpr.lr.MessageBus.Send(msg)
-
+	ctx, cancel := MakeContext(req.UpBaseReq)
+	defer cancel()
+	res, err := gpr.send(ctx, gpr.transaction(req.UpBaseReq), msg)
 	if err != nil {
 		return errors.Wrap(err, "couldn't save new object as delegate")
 	}
+	if le, ok := res.(*reply.LimitExceeded); ok {
+		return &le.LimitExceededError
+	}
 
-	rep.Reference = res.(*reply.CallConstructor).Object
-	gpr.lr.addObjectCaseRecord(req.Me, core.CaseRecord{
+	re := res.(*reply.CallConstructor)
+	rep.Reference = re.Object
+	gpr.addCaseRecord(req.UpBaseReq, core.CaseRecord{
 		Type:   core.CaseRecordTypeSaveAsDelegate,
 		ReqSig: HashInterface(req),
-		Resp:   rep.Reference,
+		Resp:   *rep.Reference,
 	})
 
 	return nil
@@ -263,7 +432,7 @@ func (gpr *RPC) SaveAsDelegate(req rpctypes.UpSaveAsDelegateReq, rep *rpctypes.U
 
 // GetDelegate is an RPC saving data as memory of a contract as child a parent
 func (gpr *RPC) GetDelegate(req rpctypes.UpGetDelegateReq, rep *rpctypes.UpGetDelegateResp) error {
-	cr, step := gpr.lr.getNextValidationStep(req.Me)
+	cr, step := gpr.validationStep(req.UpBaseReq)
 	if step >= 0 { // validate
 		if core.CaseRecordTypeGetDelegate != cr.Type {
 			return errors.New("Wrong validation type on RouteCall")
@@ -276,16 +445,68 @@ func (gpr *RPC) GetDelegate(req rpctypes.UpGetDelegateReq, rep *rpctypes.UpGetDe
 		rep.Object = cr.Resp.(core.RecordRef)
 		return nil
 	}
-	am := gpr.lr.ArtifactManager
-	ref, err := am.GetDelegate(req.Object, req.OfType)
-	if err != nil {
+	ctx, cancel := MakeContext(req.UpBaseReq)
+	defer cancel()
+	if err := gpr.delegate(ctx, req, rep); err != nil {
 		return err
 	}
-	rep.Object = *ref
-	gpr.lr.addObjectCaseRecord(req.Me, core.CaseRecord{
+	gpr.addCaseRecord(req.UpBaseReq, core.CaseRecord{
 		Type:   core.CaseRecordTypeGetDelegate,
 		ReqSig: HashInterface(req),
 		Resp:   rep.Object,
 	})
 	return nil
 }
+
+// delegate finds delegate of the class, the delegate may be created by the chain and not activated yet
+func (gpr *RPC) delegate(ctx context.Context, req rpctypes.UpGetDelegateReq, rep *rpctypes.UpGetDelegateResp) error {
+	if tx := gpr.transaction(req.UpBaseReq); tx != nil {
+		for _, w := range tx.find(req.Object, true) {
+			if w.Class.Equal(req.OfType) {
+				rep.Object = w.Object
+				return nil
+			}
+		}
+		if createdBy(tx, req.Object) {
+			return errors.New("object has no delegate of the class")
+		}
+	}
+	ref, err := gpr.lr.ArtifactManager.GetDelegate(ctx, req.Object, req.OfType)
+	if err != nil {
+		return err
+	}
+	rep.Object = *ref
+	return nil
+}
+
+// EmitEvent is an RPC saving event emitted by contract in the case bind, events are stored
+// in ledger when the call succeeds
+func (gpr *RPC) EmitEvent(req rpctypes.UpEmitEventReq, rep *rpctypes.UpEmitEventResp) error {
+	if req.ReadOnly {
+		return errors.New("read-only method can't emit events")
+	}
+
+	cr, step := gpr.validationStep(req.UpBaseReq)
+	if step >= 0 { // validate
+		if core.CaseRecordTypeEmitEvent != cr.Type {
+			return errors.New("Wrong validation type on EmitEvent")
+		}
+		sig := HashInterface(req)
+		if !bytes.Equal(cr.ReqSig, sig) {
+			return errors.New("Wrong validation sig on EmitEvent")
+		}
+		return nil
+	}
+
+	gpr.addCaseRecord(req.UpBaseReq, core.CaseRecord{
+		Type:   core.CaseRecordTypeEmitEvent,
+		ReqSig: HashInterface(req),
+		Resp: core.Event{
+			Object:  req.Me,
+			Request: req.Chain, // replaced by the request of the call when the event is stored
+			Name:    req.Name,
+			Payload: req.Payload,
+		},
+	})
+	return nil
+}
@@ -0,0 +1,198 @@
+/*
+ *    Copyright 2018 Insolar
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package logicrunner
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"sync"
+
+	"github.com/insolar/insolar/core"
+	"github.com/pkg/errors"
+)
+
+// transaction collects changes made by a call chain: the top-level call and calls nested into it that wait
+// for results. Nested calls of a chain are executed by the node of the top-level call, they see unsaved changes
+// of the chain and add their own changes to them, so unsaved changes never leave the node. The top-level call
+// saves changes of the whole chain together, objects created by the chain are activated by the same save.
+// If the chain fails, nothing is saved.
+//
+// Failure of a nested call fails the whole chain, even if the contract handles the error.
+// Calls that don't wait for results (NoWait) start separate call chains and save their changes on their own.
+type transaction struct {
+	request core.RecordRef // registered request of the top-level call
+
+	mutex   sync.Mutex
+	called  map[core.RecordRef]bool // objects called or created by the chain, changes of other objects are rejected
+	writes  []core.ObjectWrite
+	created uint64 // number of objects created by the chain
+	failure error  // the first failure of a nested call
+}
+
+// beginTransaction starts collecting changes of the chain of the registered request, returned function stops collecting
+func (lr *LogicRunner) beginTransaction(request core.RecordRef) (*transaction, func()) {
+	tx := &transaction{
+		request: request,
+		called:  make(map[core.RecordRef]bool),
+	}
+	lr.transactionsMutex.Lock()
+	lr.transactions[request] = tx
+	lr.transactionsMutex.Unlock()
+
+	return tx, func() {
+		lr.transactionsMutex.Lock()
+		delete(lr.transactions, request)
+		lr.transactionsMutex.Unlock()
+	}
+}
+
+// transaction returns transaction of the chain executing on this node, nil if there is no such
+func (lr *LogicRunner) transaction(chain core.RecordRef) *transaction {
+	lr.transactionsMutex.Lock()
+	defer lr.transactionsMutex.Unlock()
+	return lr.transactions[chain]
+}
+
+// call marks the object as called by the chain
+func (tx *transaction) call(obj core.RecordRef) {
+	tx.mutex.Lock()
+	tx.called[obj] = true
+	tx.mutex.Unlock()
+}
+
+// newObject returns reference of the next object created by the chain, references are derived from the chain's
+// request, so objects created by identical calls differ
+func (tx *transaction) newObject(pulse core.PulseNumber) core.RecordRef {
+	tx.mutex.Lock()
+	defer tx.mutex.Unlock()
+	tx.created++
+	seq := make([]byte, 8)
+	binary.BigEndian.PutUint64(seq, tx.created)
+	ref := *core.GenRequest(pulse, append(tx.request[:], seq...))
+	tx.called[ref] = true
+	return ref
+}
+
+// write returns the latest unsaved change of the object, nil if the chain didn't change it
+func (tx *transaction) write(obj core.RecordRef) *core.ObjectWrite {
+	tx.mutex.Lock()
+	defer tx.mutex.Unlock()
+	for i := len(tx.writes) - 1; i >= 0; i-- {
+		if tx.writes[i].Object == obj {
+			w := tx.writes[i]
+			return &w
+		}
+	}
+	return nil
+}
+
+// find returns other objects created by the chain as children or delegates of the parent
+func (tx *transaction) find(parent core.RecordRef, delegate bool) []core.ObjectWrite {
+	tx.mutex.Lock()
+	defer tx.mutex.Unlock()
+	var res []core.ObjectWrite
+	for _, w := range tx.writes {
+		if w.Created && w.Parent == parent && w.Delegate == delegate {
+			res = append(res, w)
+		}
+	}
+	return res
+}
+
+// create adds object created by the chain
+func (tx *transaction) create(w core.ObjectWrite) error {
+	tx.mutex.Lock()
+	defer tx.mutex.Unlock()
+	if !tx.called[w.Object] {
+		return errors.Errorf("object %s isn't created by the chain", w.Object)
+	}
+	w.Created = true
+	w.Base = nil
+	tx.writes = append(tx.writes, w)
+	return nil
+}
+
+// update adds change of the object called by the chain, before is the memory the call started from.
+// Calls of the chain that reentered the object started from the same memory, so saving the call's memory as is
+// would lose their changes. Memory changed only by the call or only by the reentered calls is kept, the call fails
+// if both changed it. Changes of objects created by the chain are merged into their activation.
+func (tx *transaction) update(w core.ObjectWrite, before []byte) error {
+	tx.mutex.Lock()
+	defer tx.mutex.Unlock()
+	if !tx.called[w.Object] {
+		return errors.Errorf("object %s isn't called by the chain", w.Object)
+	}
+
+	var latest *core.ObjectWrite
+	for i := range tx.writes {
+		if tx.writes[i].Object == w.Object {
+			latest = &tx.writes[i]
+		}
+	}
+	if latest == nil {
+		if w.Base == nil {
+			return errors.Errorf("change of object %s has no base state", w.Object)
+		}
+		tx.writes = append(tx.writes, w)
+		return nil
+	}
+
+	if !bytes.Equal(latest.Memory, before) {
+		if !bytes.Equal(w.Memory, before) {
+			return errors.New("object is changed both by the call and by a nested call of the same chain")
+		}
+		w.Memory = latest.Memory
+	}
+	if latest.Created {
+		latest.Memory = w.Memory
+		latest.Events = append(latest.Events, w.Events...)
+		return nil
+	}
+	if w.Base == nil || *w.Base != *latest.Base {
+		return errors.Errorf("object %s is changed by another chain while the chain is executing", w.Object)
+	}
+	tx.writes = append(tx.writes, w)
+	return nil
+}
+
+// fail records failure of a nested call
+func (tx *transaction) fail(err error) {
+	tx.mutex.Lock()
+	if tx.failure == nil {
+		tx.failure = err
+	}
+	tx.mutex.Unlock()
+}
+
+// failed returns failure of a nested call, nil if all nested calls succeeded
+func (tx *transaction) failed() error {
+	tx.mutex.Lock()
+	defer tx.mutex.Unlock()
+	return tx.failure
+}
+
+// commit saves changes of the chain in one ledger transaction
+func (tx *transaction) commit(ctx context.Context, am core.ArtifactManager) error {
+	tx.mutex.Lock()
+	writes := append([]core.ObjectWrite(nil), tx.writes...)
+	tx.mutex.Unlock()
+	if len(writes) == 0 {
+		return nil
+	}
+	return am.UpdateObjects(ctx, writes)
+}
@@ -0,0 +1,116 @@
+/*
+ *    Copyright 2018 Insolar
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package logicrunner
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/insolar/insolar/core"
+	"github.com/insolar/insolar/core/message"
+)
+
+func TestTransaction_Update(t *testing.T) {
+	lr := &LogicRunner{transactions: make(map[core.RecordRef]*transaction)}
+	request := core.NewRefFromBase58("request")
+	tx, end := lr.beginTransaction(request)
+	assert.Equal(t, tx, lr.transaction(request))
+
+	obj := core.NewRefFromBase58("object")
+	base := core.RecordID{1}
+	before := []byte("before")
+
+	// changes of objects the chain didn't call are rejected
+	err := tx.update(core.ObjectWrite{Object: obj, Base: &base, Memory: []byte("after")}, before)
+	assert.Error(t, err)
+
+	// change of an existing object must have base state
+	tx.call(obj)
+	err = tx.update(core.ObjectWrite{Object: obj, Memory: []byte("after")}, before)
+	assert.Error(t, err)
+
+	// reentered call changed the object, outer call didn't change it and keeps the change
+	err = tx.update(core.ObjectWrite{Object: obj, Base: &base, Memory: []byte("nested")}, before)
+	assert.NoError(t, err)
+	err = tx.update(core.ObjectWrite{Object: obj, Base: &base, Memory: before}, before)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("nested"), tx.write(obj).Memory)
+
+	// both changed it
+	err = tx.update(core.ObjectWrite{Object: obj, Base: &base, Memory: []byte("after")}, before)
+	assert.Error(t, err)
+
+	// another chain changed the object while the chain was executing
+	other := core.RecordID{2}
+	err = tx.update(core.ObjectWrite{Object: obj, Base: &other, Memory: []byte("last")}, []byte("nested"))
+	assert.Error(t, err)
+
+	// changes of created object are merged into its activation
+	created := tx.newObject(core.FirstPulseNumber)
+	assert.NotEqual(t, created, tx.newObject(core.FirstPulseNumber))
+	err = tx.create(core.ObjectWrite{Object: created, Memory: []byte("new"), Parent: obj})
+	assert.NoError(t, err)
+	err = tx.update(core.ObjectWrite{Object: created, Memory: []byte("changed"), Events: []core.Event{{Name: "changed"}}}, []byte("new"))
+	assert.NoError(t, err)
+	children := tx.find(obj, false)
+	if assert.Len(t, children, 1) {
+		assert.True(t, children[0].Created)
+		assert.Equal(t, []byte("changed"), children[0].Memory)
+		assert.Len(t, children[0].Events, 1)
+	}
+
+	end()
+	assert.Nil(t, lr.transaction(request))
+}
+
+func TestRPC_local(t *testing.T) {
+	lr := &LogicRunner{transactions: make(map[core.RecordRef]*transaction)}
+	lr.node = core.NewRefFromBase58("node")
+	lr.JetCoordinator = &testJetCoordinator{}
+	rpc := NewRPC(lr)
+	tx, end := lr.beginTransaction(core.NewRefFromBase58("request"))
+	defer end()
+
+	// the object is executed by other node
+	obj := core.NewRefFromBase58("object")
+	local, err := rpc.local(tx, &message.CallMethod{ObjectRef: obj})
+	assert.NoError(t, err)
+	assert.False(t, local)
+
+	// objects created by the chain and constructors are executed with the chain
+	created := tx.newObject(core.FirstPulseNumber)
+	assert.NoError(t, tx.create(core.ObjectWrite{Object: created, Parent: obj}))
+	local, err = rpc.local(tx, &message.CallMethod{ObjectRef: created})
+	assert.NoError(t, err)
+	assert.True(t, local)
+	local, err = rpc.local(tx, &message.CallConstructor{ParentRef: obj})
+	assert.NoError(t, err)
+	assert.True(t, local)
+
+	lr.JetCoordinator = &testJetCoordinator{validators: []core.RecordRef{lr.node}}
+	local, err = rpc.local(tx, &message.CallMethod{ObjectRef: obj})
+	assert.NoError(t, err)
+	assert.True(t, local)
+
+	// node without network executes everything
+	lr.JetCoordinator = &testJetCoordinator{}
+	lr.standalone = true
+	local, err = rpc.local(tx, &message.CallMethod{ObjectRef: obj})
+	assert.NoError(t, err)
+	assert.True(t, local)
+}
@@ -0,0 +1,331 @@
+/*
+ *    Copyright 2018 Insolar
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package logicrunner
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/insolar/insolar/core"
+	"github.com/insolar/insolar/core/message"
+	"github.com/insolar/insolar/core/reply"
+	ecdsa_helper "github.com/insolar/insolar/cryptohelpers/ecdsa"
+	"github.com/insolar/insolar/tracing"
+)
+
+const caseBindFileExt = ".casebind"
+
+// finishCaseBind stores CaseBind of the finished pulse and sends it to validators
+func (lr *LogicRunner) finishCaseBind(cb core.CaseBind) error {
+	if len(cb.Records) == 0 {
+		return nil
+	}
+	if err := lr.storeCaseBind(cb); err != nil {
+		return errors.Wrap(err, "couldn't store casebind")
+	}
+	lr.sendCaseBind(cb)
+	return nil
+}
+
+// storeCaseBind writes CaseBind to the CaseBindDirectory, one file per pulse
+func (lr *LogicRunner) storeCaseBind(cb core.CaseBind) error {
+	if lr.Cfg.CaseBindDirectory == "" {
+		return nil
+	}
+	if err := os.MkdirAll(lr.Cfg.CaseBindDirectory, 0755); err != nil {
+		return err
+	}
+
+	buff := &bytes.Buffer{}
+	if err := gob.NewEncoder(buff).Encode(cb); err != nil {
+		return err
+	}
+	path := lr.caseBindPath(cb.Pulse.PulseNumber)
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, buff.Bytes(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// LoadCaseBind reads CaseBind of the pulse from the CaseBindDirectory
+func (lr *LogicRunner) LoadCaseBind(pn core.PulseNumber) (*core.CaseBind, error) {
+	if lr.Cfg.CaseBindDirectory == "" {
+		return nil, errors.New("casebind directory isn't configured")
+	}
+	buff, err := ioutil.ReadFile(lr.caseBindPath(pn))
+	if err != nil {
+		return nil, err
+	}
+	cb := &core.CaseBind{}
+	if err := gob.NewDecoder(bytes.NewReader(buff)).Decode(cb); err != nil {
+		return nil, errors.Wrap(err, "couldn't decode casebind")
+	}
+	return cb, nil
+}
+
+func (lr *LogicRunner) caseBindPath(pn core.PulseNumber) string {
+	return filepath.Join(lr.Cfg.CaseBindDirectory, strconv.FormatUint(uint64(pn), 10)+caseBindFileExt)
+}
+
+// sendCaseBind sends records of every object in the CaseBind to validators of the object
+func (lr *LogicRunner) sendCaseBind(cb core.CaseBind) {
+	for ref, records := range cb.Records {
+		lr.MessageBus.SendAsync(&message.ValidateCaseBind{
+			RecordRef:   ref,
+			CaseRecords: records,
+			Pulse:       cb.Pulse,
+		})
+	}
+}
+
+// ValidateCaseBind validates records of the object sent by executor and sends signed results back
+func (lr *LogicRunner) ValidateCaseBind(ctx context.Context, inmsg core.Message) (core.Reply, error) {
+	msg, ok := inmsg.(*message.ValidateCaseBind)
+	if !ok {
+		return nil, errors.New("ValidateCaseBind( ! message.ValidateCaseBind )")
+	}
+	ctx, span := tracing.StartSpan(ctx, "logicrunner.ValidateCaseBind")
+	defer span.End()
+	span.SetAttribute("reference", msg.RecordRef.String())
+
+	passed, results, err := lr.validate(ctx, msg.RecordRef, msg.Pulse, msg.CaseRecords)
+	res := &message.ValidationResults{
+		RecordRef:        msg.RecordRef,
+		PulseNumber:      msg.Pulse.PulseNumber,
+		Request:          msg.Request,
+		PassedStepsCount: passed,
+		ResultHash:       resultsHash(results),
+	}
+	if err != nil {
+		span.SetError(err)
+		res.Error = err.Error()
+	}
+	if err := lr.signValidationResults(res); err != nil {
+		return nil, errors.Wrap(err, "couldn't sign validation results")
+	}
+
+	lr.MessageBus.SendAsync(res)
+	return &reply.OK{}, nil
+}
+
+func (lr *LogicRunner) signValidationResults(res *message.ValidationResults) error {
+	var err error
+	res.Validator = lr.node
+	res.Signature, err = ecdsa_helper.Sign(res.SignedData(), lr.key)
+	return err
+}
+
+// checkValidator checks that results are signed by a validator chosen for the object on the validated pulse,
+// the node always knows its own key, so a single node network validates its calls without configuration
+func (lr *LogicRunner) checkValidator(res *message.ValidationResults) error {
+	chosen, err := lr.JetCoordinator.QueryRole(core.RoleVirtualValidator, res.RecordRef, res.PulseNumber)
+	if err != nil {
+		return errors.Wrap(err, "couldn't get validators of the object")
+	}
+	isChosen := false
+	for _, node := range chosen {
+		if node.Equal(res.Validator) {
+			isChosen = true
+			break
+		}
+	}
+	if !isChosen {
+		return errors.Errorf("node %s isn't a validator of the object on pulse %d", res.Validator, res.PulseNumber)
+	}
+
+	// network doesn't bind keys to node ids yet, so keys of other nodes come from configuration
+	key, ok := lr.validators[res.Validator]
+	if !ok {
+		return errors.Errorf("key of validator %s is unknown, it should be added to logicrunner.validation.validators", res.Validator)
+	}
+	valid, err := ecdsa_helper.Verify(res.SignedData(), res.Signature, key)
+	if err != nil {
+		return errors.Wrap(err, "couldn't verify validation results")
+	}
+	if !valid {
+		return errors.New("validation results have wrong signature")
+	}
+	return nil
+}
+
+// ProcessValidationResults checks signature of validation results and collects them
+func (lr *LogicRunner) ProcessValidationResults(ctx context.Context, inmsg core.Message) (core.Reply, error) {
+	msg, ok := inmsg.(*message.ValidationResults)
+	if !ok {
+		return nil, errors.New("ProcessValidationResults( ! message.ValidationResults )")
+	}
+
+	if err := lr.checkValidator(msg); err != nil {
+		return nil, err
+	}
+	if msg.Request != (core.RecordRef{}) {
+		lr.validationResultsMutex.Lock()
+		waiter, ok := lr.validationWaiters[msg.Request]
+		lr.validationResultsMutex.Unlock()
+		if !ok {
+			return nil, errors.New("nobody waits for validation of the request")
+		}
+		select {
+		case waiter <- msg:
+		default:
+			return nil, errors.New("too many validation results for the request")
+		}
+		return &reply.OK{}, nil
+	}
+
+	if msg.Error != "" {
+		tracing.Logger(ctx).Warnf("validation of %s on pulse %d failed on step %d: %s", msg.RecordRef, msg.PulseNumber, msg.PassedStepsCount, msg.Error)
+	}
+
+	lr.validationResultsMutex.Lock()
+	defer lr.validationResultsMutex.Unlock()
+	byRef, ok := lr.validationResults[msg.PulseNumber]
+	if !ok {
+		byRef = make(map[core.RecordRef][]message.ValidationResults)
+		lr.validationResults[msg.PulseNumber] = byRef
+	}
+	byRef[msg.RecordRef] = append(byRef[msg.RecordRef], *msg)
+	return &reply.OK{}, nil
+}
+
+// ValidationResults returns results of the object's validation on the pulse collected so far,
+// results are kept till the end of the pulse next to the validated one
+func (lr *LogicRunner) ValidationResults(pn core.PulseNumber, ref core.RecordRef) []message.ValidationResults {
+	lr.validationResultsMutex.Lock()
+	defer lr.validationResultsMutex.Unlock()
+	return append([]message.ValidationResults(nil), lr.validationResults[pn][ref]...)
+}
+
+// dropValidationResults forgets results of the pulses before the given one
+func (lr *LogicRunner) dropValidationResults(before core.PulseNumber) {
+	lr.validationResultsMutex.Lock()
+	defer lr.validationResultsMutex.Unlock()
+	for pn := range lr.validationResults {
+		if pn < before {
+			delete(lr.validationResults, pn)
+		}
+	}
+}
+
+// validationWaiterBuffer is a number of validation results of one request that may be queued
+const validationWaiterBuffer = 16
+
+// validateCall sends case records of the call to validators and waits till quorum of validators
+// chosen for the pulse confirms the call's result, returns rejection if validators disagree with the result
+func (lr *LogicRunner) validateCall(
+	ctx context.Context, ref core.RecordRef, p core.Pulse, records []core.CaseRecord, re core.Reply,
+) (*reply.ValidationRejected, error) {
+	ctx, span := tracing.StartSpan(ctx, "logicrunner.validateCall")
+	defer span.End()
+
+	request := core.RandomRef()
+	results := make(chan *message.ValidationResults, validationWaiterBuffer)
+	lr.validationResultsMutex.Lock()
+	lr.validationWaiters[request] = results
+	lr.validationResultsMutex.Unlock()
+	defer func() {
+		lr.validationResultsMutex.Lock()
+		delete(lr.validationWaiters, request)
+		lr.validationResultsMutex.Unlock()
+	}()
+
+	msg := &message.ValidateCaseBind{
+		RecordRef:   ref,
+		CaseRecords: records,
+		Pulse:       p,
+		Request:     request,
+	}
+	go func() {
+		if _, err := lr.MessageBus.Send(ctx, msg); err != nil {
+			tracing.Logger(ctx).Errorln("couldn't send call to validators: ", err)
+		}
+	}()
+
+	quorum := lr.Cfg.Validation.Quorum
+	if quorum < 1 {
+		quorum = 1
+	}
+	validators, err := lr.JetCoordinator.QueryRole(core.RoleVirtualValidator, ref, p.PulseNumber)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't get validators of the object")
+	}
+	if len(validators) < quorum {
+		return nil, errors.Errorf("quorum of %d can't be reached by %d validators", quorum, len(validators))
+	}
+	chosen := make(map[core.RecordRef]bool, len(validators))
+	for _, v := range validators {
+		chosen[v] = true
+	}
+
+	var timeout <-chan time.Time
+	if lr.Cfg.Validation.Timeout > 0 {
+		timer := time.NewTimer(time.Duration(lr.Cfg.Validation.Timeout) * time.Millisecond)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	expected := resultsHash([]core.Reply{re})
+	rejected := &reply.ValidationRejected{Quorum: quorum}
+	seen := map[core.RecordRef]bool{}
+	for {
+		select {
+		case res := <-results:
+			// every validator chosen for the pulse is counted once
+			if res.PulseNumber != p.PulseNumber || !chosen[res.Validator] || seen[res.Validator] {
+				continue
+			}
+			seen[res.Validator] = true
+
+			switch {
+			case res.Error != "":
+				rejected.Reasons = append(rejected.Reasons, res.Error)
+			case res.PassedStepsCount != len(records):
+				rejected.Reasons = append(rejected.Reasons, fmt.Sprintf("passed %d of %d steps", res.PassedStepsCount, len(records)))
+			case !bytes.Equal(res.ResultHash, expected):
+				rejected.Reasons = append(rejected.Reasons, "different result")
+			default:
+				rejected.Confirmed++
+				if rejected.Confirmed >= quorum {
+					return nil, nil
+				}
+				continue
+			}
+			if len(rejected.Reasons) >= quorum {
+				span.SetError(rejected)
+				return rejected, nil
+			}
+		case <-timeout:
+			if len(rejected.Reasons) > 0 {
+				span.SetError(rejected)
+				return rejected, nil
+			}
+			return nil, errors.Errorf("validators haven't confirmed results in time, %d of %d confirmed", rejected.Confirmed, quorum)
+		case <-ctx.Done():
+			return nil, errors.Wrap(ctx.Err(), "caller gave up waiting for validators")
+		}
+	}
+}
@@ -0,0 +1,369 @@
+/*
+ *    Copyright 2018 Insolar
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package logicrunner
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/insolar/insolar/configuration"
+	"github.com/insolar/insolar/core"
+	"github.com/insolar/insolar/core/message"
+	"github.com/insolar/insolar/core/reply"
+	ecdsa_helper "github.com/insolar/insolar/cryptohelpers/ecdsa"
+	"github.com/insolar/insolar/testutils"
+)
+
+type asyncMessageBus struct {
+	testMessageBus
+	sent []core.Message
+}
+
+func (eb *asyncMessageBus) SendAsync(msg core.Message) {
+	eb.sent = append(eb.sent, msg)
+}
+
+func testCaseBind(pn core.PulseNumber, ref core.RecordRef) core.CaseBind {
+	child := core.NewRefFromBase58("child")
+	return core.CaseBind{
+		Pulse: core.Pulse{PulseNumber: pn, NextPulseNumber: pn + 10},
+		Records: map[core.RecordRef][]core.CaseRecord{
+			ref: {
+				{Type: core.CaseRecordTypeStart, Resp: &message.CallMethod{ObjectRef: ref, Method: "Do"}},
+				{Type: core.CaseRecordTypeSaveAsChild, ReqSig: []byte{1}, Resp: child},
+				{Type: core.CaseRecordTypeGetObjChildren, ReqSig: []byte{2}, Resp: []core.RecordRef{child}},
+				{Type: core.CaseRecordTypeResult, Resp: &reply.CallMethod{Data: []byte("data")}},
+			},
+		},
+	}
+}
+
+func TestLogicRunner_OnPulse_StoresAndSendsCaseBind(t *testing.T) {
+	dir, err := ioutil.TempDir("", "casebind")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	lr, err := NewLogicRunner(&configuration.LogicRunner{CaseBindDirectory: dir, PrivateKey: testutils.PrivateKey()})
+	assert.NoError(t, err)
+	eb := &asyncMessageBus{}
+	lr.MessageBus = eb
+
+	ref := core.NewRefFromBase58("object")
+	lr.caseBind = testCaseBind(core.FirstPulseNumber, ref)
+	assert.NoError(t, lr.OnPulse(core.Pulse{PulseNumber: core.FirstPulseNumber + 10}))
+
+	stored, err := lr.LoadCaseBind(core.FirstPulseNumber)
+	assert.NoError(t, err)
+	assert.Equal(t, testCaseBind(core.FirstPulseNumber, ref), *stored)
+
+	assert.Len(t, eb.sent, 1)
+	msg := eb.sent[0].(*message.ValidateCaseBind)
+	assert.Equal(t, ref, msg.RecordRef)
+	assert.Equal(t, core.PulseNumber(core.FirstPulseNumber), msg.Pulse.PulseNumber)
+	assert.Len(t, msg.CaseRecords, 4)
+	assert.Equal(t, core.RoleVirtualValidator, msg.TargetRole())
+
+	// message with casebind survives serialization
+	decoded, err := message.Deserialize(bytes.NewReader(message.MustSerializeBytes(msg)))
+	assert.NoError(t, err)
+	assert.Equal(t, msg, decoded)
+
+	// empty casebind is neither stored nor sent
+	assert.NoError(t, lr.OnPulse(core.Pulse{PulseNumber: core.FirstPulseNumber + 20}))
+	assert.Len(t, eb.sent, 1)
+	_, err = lr.LoadCaseBind(core.FirstPulseNumber + 10)
+	assert.Error(t, err)
+}
+
+type testJetCoordinator struct {
+	validators []core.RecordRef
+}
+
+func (jc *testJetCoordinator) IsAuthorized(role core.JetRole, obj core.RecordRef, pulse core.PulseNumber, node core.RecordRef) (bool, error) {
+	for _, v := range jc.validators {
+		if v.Equal(node) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (jc *testJetCoordinator) QueryRole(role core.JetRole, obj core.RecordRef, pulse core.PulseNumber) ([]core.RecordRef, error) {
+	return jc.validators, nil
+}
+
+// newTestValidator creates validator known to the executor, chosen one is returned by executor's JetCoordinator
+func newTestValidator(t *testing.T, executor *LogicRunner, name string, chosen bool) *LogicRunner {
+	validator, err := NewLogicRunner(&configuration.LogicRunner{PrivateKey: testutils.PrivateKey()})
+	assert.NoError(t, err)
+	validator.node = core.NewRefFromBase58(name)
+
+	key, err := ecdsa_helper.ExportPublicKey(&validator.key.PublicKey)
+	assert.NoError(t, err)
+	executor.validators[validator.node] = key
+	if executor.JetCoordinator == nil {
+		executor.JetCoordinator = &testJetCoordinator{}
+	}
+	if chosen {
+		jc := executor.JetCoordinator.(*testJetCoordinator)
+		jc.validators = append(jc.validators, validator.node)
+	}
+	return validator
+}
+
+func TestLogicRunner_ValidationResults(t *testing.T) {
+	executor, err := NewLogicRunner(&configuration.LogicRunner{PrivateKey: testutils.PrivateKey()})
+	assert.NoError(t, err)
+	validator := newTestValidator(t, executor, "validator", true)
+
+	ref := core.NewRefFromBase58("object")
+	res := &message.ValidationResults{
+		RecordRef:        ref,
+		PulseNumber:      core.FirstPulseNumber,
+		PassedStepsCount: 2,
+	}
+	assert.NoError(t, validator.signValidationResults(res))
+
+	rep, err := executor.ProcessValidationResults(context.Background(), res)
+	assert.NoError(t, err)
+	assert.Equal(t, &reply.OK{}, rep)
+	assert.Equal(t, []message.ValidationResults{*res}, executor.ValidationResults(core.FirstPulseNumber, ref))
+
+	forged := *res
+	forged.PassedStepsCount = 4
+	_, err = executor.ProcessValidationResults(context.Background(), &forged)
+	assert.Error(t, err)
+
+	// results signed by a node that isn't a validator of the object are rejected
+	stranger := newTestValidator(t, executor, "stranger", false)
+	assert.NoError(t, stranger.signValidationResults(&forged))
+	_, err = executor.ProcessValidationResults(context.Background(), &forged)
+	assert.Error(t, err)
+
+	// as well as results signed by a chosen node with unknown key
+	impostor, err := NewLogicRunner(&configuration.LogicRunner{PrivateKey: testutils.PrivateKey()})
+	assert.NoError(t, err)
+	impostor.node = validator.node
+	assert.NoError(t, impostor.signValidationResults(&forged))
+	_, err = executor.ProcessValidationResults(context.Background(), &forged)
+	assert.Error(t, err)
+	assert.Len(t, executor.ValidationResults(core.FirstPulseNumber, ref), 1)
+
+	executor.dropValidationResults(core.FirstPulseNumber + 1)
+	assert.Empty(t, executor.ValidationResults(core.FirstPulseNumber, ref))
+}
+
+func TestLogicRunner_trustSelf(t *testing.T) {
+	// key is generated when none is configured
+	lr, err := NewLogicRunner(&configuration.LogicRunner{})
+	assert.NoError(t, err)
+	other, err := NewLogicRunner(&configuration.LogicRunner{})
+	assert.NoError(t, err)
+	assert.NotEqual(t, lr.key.D, other.key.D)
+
+	_, err = NewLogicRunner(&configuration.LogicRunner{PrivateKey: "garbage"})
+	assert.Error(t, err)
+
+	// node validating its own calls accepts own results without configuration
+	lr.node = core.NewRefFromBase58("self")
+	lr.JetCoordinator = &testJetCoordinator{validators: []core.RecordRef{lr.node}}
+	assert.NoError(t, lr.trustSelf())
+	res := &message.ValidationResults{RecordRef: core.NewRefFromBase58("object"), PulseNumber: core.FirstPulseNumber}
+	assert.NoError(t, lr.signValidationResults(res))
+	_, err = lr.ProcessValidationResults(context.Background(), res)
+	assert.NoError(t, err)
+
+	// configured key of the node isn't replaced
+	other.validators[other.node] = "configured"
+	assert.NoError(t, other.trustSelf())
+	assert.Equal(t, "configured", other.validators[other.node])
+}
+
+type validatorsBus struct {
+	testMessageBus
+	respond func(msg *message.ValidateCaseBind)
+}
+
+func (eb *validatorsBus) Send(ctx context.Context, msg core.Message) (core.Reply, error) {
+	eb.respond(msg.(*message.ValidateCaseBind))
+	return nil, nil
+}
+
+func TestLogicRunner_validateCall(t *testing.T) {
+	executor, err := NewLogicRunner(&configuration.LogicRunner{
+		PrivateKey: testutils.PrivateKey(),
+		Validation: configuration.Validation{Quorum: 2, Timeout: 1000},
+	})
+	assert.NoError(t, err)
+
+	ref := core.NewRefFromBase58("object")
+	re := &reply.CallMethod{Data: []byte("data"), Result: []byte("result")}
+	records := []core.CaseRecord{
+		{Type: core.CaseRecordTypeStart, Resp: &message.CallMethod{ObjectRef: ref, Method: "Transfer"}},
+		{Type: core.CaseRecordTypeResult, Resp: re},
+	}
+
+	chosen := []*LogicRunner{
+		newTestValidator(t, executor, "v1", true),
+		newTestValidator(t, executor, "v2", true),
+		newTestValidator(t, executor, "v3", true),
+	}
+	stranger := newTestValidator(t, executor, "stranger", false)
+
+	// validators answering with the given results
+	validators := func(results ...core.Reply) func(msg *message.ValidateCaseBind) {
+		return func(msg *message.ValidateCaseBind) {
+			assert.Equal(t, records, msg.CaseRecords)
+
+			res := &message.ValidationResults{
+				RecordRef:        msg.RecordRef,
+				Request:          msg.Request,
+				PassedStepsCount: len(msg.CaseRecords),
+				ResultHash:       resultsHash([]core.Reply{re}),
+			}
+			assert.NoError(t, stranger.signValidationResults(res))
+			_, err := executor.ProcessValidationResults(context.Background(), res)
+			assert.Error(t, err)
+
+			for i, result := range results {
+				validator := chosen[i]
+				res := &message.ValidationResults{
+					RecordRef:        msg.RecordRef,
+					Request:          msg.Request,
+					PassedStepsCount: len(msg.CaseRecords),
+					ResultHash:       resultsHash([]core.Reply{result}),
+				}
+				assert.NoError(t, validator.signValidationResults(res))
+				_, err = executor.ProcessValidationResults(context.Background(), res)
+				assert.NoError(t, err)
+			}
+		}
+	}
+	other := &reply.CallMethod{Data: []byte("data"), Result: []byte("other")}
+
+	executor.MessageBus = &validatorsBus{respond: validators(re, other, re)}
+	rejected, err := executor.validateCall(context.Background(), ref, core.Pulse{}, records, re)
+	assert.NoError(t, err)
+	assert.Nil(t, rejected)
+
+	executor.MessageBus = &validatorsBus{respond: validators(other, re, other)}
+	rejected, err = executor.validateCall(context.Background(), ref, core.Pulse{}, records, re)
+	assert.NoError(t, err)
+	assert.Equal(t, &reply.ValidationRejected{
+		Quorum:    2,
+		Confirmed: 1,
+		Reasons:   []string{"different result", "different result"},
+	}, rejected)
+
+	executor.MessageBus = &validatorsBus{respond: validators()}
+	executor.Cfg.Validation.Timeout = 10
+	_, err = executor.validateCall(context.Background(), ref, core.Pulse{}, records, re)
+	assert.Error(t, err)
+	assert.Empty(t, executor.validationWaiters)
+
+	// repeated confirmation of the same validator doesn't make quorum
+	executor.MessageBus = &validatorsBus{respond: func(msg *message.ValidateCaseBind) {
+		for i := 0; i < 2; i++ {
+			res := &message.ValidationResults{
+				RecordRef:        msg.RecordRef,
+				Request:          msg.Request,
+				PassedStepsCount: len(msg.CaseRecords),
+				ResultHash:       resultsHash([]core.Reply{re}),
+			}
+			assert.NoError(t, chosen[0].signValidationResults(res))
+			_, err := executor.ProcessValidationResults(context.Background(), res)
+			assert.NoError(t, err)
+		}
+	}}
+	_, err = executor.validateCall(context.Background(), ref, core.Pulse{}, records, re)
+	assert.Error(t, err)
+
+	// quorum that chosen validators can't reach
+	executor.Cfg.Validation.Quorum = 4
+	_, err = executor.validateCall(context.Background(), ref, core.Pulse{}, records, re)
+	assert.Error(t, err)
+}
+
+func TestLogicRunner_captureCaseRecords(t *testing.T) {
+	lr, err := NewLogicRunner(&configuration.LogicRunner{PrivateKey: testutils.PrivateKey()})
+	assert.NoError(t, err)
+	assert.NoError(t, lr.OnPulse(core.Pulse{PulseNumber: core.FirstPulseNumber}))
+	ref := core.NewRefFromBase58("object")
+
+	lr.addObjectCaseRecord(ref, core.CaseRecord{Type: core.CaseRecordTypeStart})
+	captured := lr.captureCaseRecords(ref)
+	lr.addObjectCaseRecord(ref, core.CaseRecord{Type: core.CaseRecordTypeRouteCall})
+	lr.addObjectCaseRecord(core.NewRefFromBase58("other"), core.CaseRecord{Type: core.CaseRecordTypeStart})
+
+	assert.Equal(t, []core.CaseRecord{{Type: core.CaseRecordTypeRouteCall}}, captured())
+	assert.Empty(t, lr.caseCaptures)
+	assert.Len(t, lr.caseBind.Records[ref], 2)
+}
+
+func TestLogicRunner_dropCaseRecords(t *testing.T) {
+	lr, err := NewLogicRunner(&configuration.LogicRunner{PrivateKey: testutils.PrivateKey()})
+	assert.NoError(t, err)
+	assert.NoError(t, lr.OnPulse(core.Pulse{PulseNumber: core.FirstPulseNumber}))
+	ref := core.NewRefFromBase58("object")
+	base := core.RecordID{1}
+
+	done := startRecord(core.LogicCallContext{}, &message.CallMethod{ObjectRef: ref, Method: "Done"}, &base)
+	lr.addObjectCaseRecord(ref, done)
+	lr.addObjectCaseRecord(ref, core.CaseRecord{Type: core.CaseRecordTypeResult})
+	assert.Equal(t, &base, ValidationChecker{cb: core.CaseBindReplay{Records: []core.CaseRecord{done}, Step: 1}}.BaseState())
+
+	captured := lr.captureCaseRecords(ref)
+	failed := startRecord(core.LogicCallContext{}, &message.CallMethod{ObjectRef: ref, Method: "Fail"}, &base)
+	lr.addObjectCaseRecord(ref, failed)
+	lr.addObjectCaseRecord(ref, core.CaseRecord{Type: core.CaseRecordTypeRouteCall})
+	lr.dropCaseRecords(ref, failed)
+
+	assert.Empty(t, captured())
+	assert.Equal(t, []core.CaseRecord{done, {Type: core.CaseRecordTypeResult}}, lr.caseBind.Records[ref])
+
+	lr.dropCaseRecords(ref, done)
+	assert.NotContains(t, lr.caseBind.Records, ref)
+}
+
+func TestEmittedEvents(t *testing.T) {
+	class := core.NewRefFromBase58("class")
+	obj := core.NewRefFromBase58("object")
+	chain := core.NewRefFromBase58("chain")
+	request := core.NewRefFromBase58("request")
+	records := []core.CaseRecord{
+		{Type: core.CaseRecordTypeEmitEvent, Resp: core.Event{Object: class, Request: chain, Name: "mine"}},
+		{Type: core.CaseRecordTypeRouteCall},
+		{Type: core.CaseRecordTypeEmitEvent, Resp: core.Event{Object: class, Request: core.NewRefFromBase58("other"), Name: "concurrent"}},
+	}
+	lctx := core.LogicCallContext{
+		Request: &request,
+		Chain:   &chain,
+		Pulse:   core.Pulse{PulseNumber: core.FirstPulseNumber},
+	}
+
+	assert.Equal(t, []core.Event{{
+		Object:  obj,
+		Request: request,
+		Pulse:   core.FirstPulseNumber,
+		Name:    "mine",
+	}}, emittedEvents(records, obj, lctx))
+}
@@ -0,0 +1,204 @@
+/*
+ *    Copyright 2018 Insolar
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package messagebus
+
+import (
+	"sync"
+	"time"
+
+	"github.com/insolar/insolar/configuration"
+	"github.com/insolar/insolar/core"
+	"github.com/insolar/insolar/metrics"
+)
+
+const (
+	busyReasonQueue = "queue"
+	busyReasonRate  = "rate"
+)
+
+// typePool limits number of concurrently handled messages of one type,
+// messages above workers wait in the queue, messages above the queue are rejected.
+type typePool struct {
+	label   string
+	workers chan struct{}
+	mutex   sync.Mutex
+	pending int
+	limit   int
+}
+
+func newTypePool(label string, workers, queue int) *typePool {
+	return &typePool{
+		label:   label,
+		workers: make(chan struct{}, workers),
+		limit:   workers + queue,
+	}
+}
+
+// acquire reserves a place in the queue and waits for a free worker,
+// it returns false if the queue is full or the context is done while waiting.
+func (p *typePool) acquire(done <-chan struct{}) bool {
+	p.mutex.Lock()
+	if p.pending >= p.limit {
+		p.mutex.Unlock()
+		return false
+	}
+	p.pending++
+	p.mutex.Unlock()
+	metrics.MessageBusQueueDepth.WithLabelValues(p.label).Inc()
+
+	select {
+	case p.workers <- struct{}{}:
+		return true
+	case <-done:
+		p.leave()
+		return false
+	}
+}
+
+func (p *typePool) release() {
+	<-p.workers
+	p.leave()
+}
+
+func (p *typePool) leave() {
+	p.mutex.Lock()
+	p.pending--
+	p.mutex.Unlock()
+	metrics.MessageBusQueueDepth.WithLabelValues(p.label).Dec()
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// senderLimiter is a token bucket rate limiter per sender host. Buckets refilled up to the burst
+// are the same as new ones, so they are dropped once in a refill period to keep the map small.
+type senderLimiter struct {
+	mutex   sync.Mutex
+	rate    float64
+	burst   float64
+	buckets map[string]*tokenBucket
+	refill  time.Duration
+	swept   time.Time
+	now     func() time.Time
+}
+
+func newSenderLimiter(rate float64, burst int) *senderLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &senderLimiter{
+		rate:    rate,
+		burst:   float64(burst),
+		buckets: map[string]*tokenBucket{},
+		refill:  time.Duration(float64(burst) / rate * float64(time.Second)),
+		swept:   time.Now(),
+		now:     time.Now,
+	}
+}
+
+// allow takes a token from the sender's bucket, it returns false if the bucket is empty.
+func (l *senderLimiter) allow(sender string) bool {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	now := l.now()
+	if now.Sub(l.swept) >= l.refill {
+		l.sweep(now)
+	}
+	b, ok := l.buckets[sender]
+	if !ok {
+		b = &tokenBucket{tokens: l.burst, last: now}
+		l.buckets[sender] = b
+	}
+
+	b.tokens += now.Sub(b.last).Seconds() * l.rate
+	if b.tokens > l.burst {
+		b.tokens = l.burst
+	}
+	b.last = now
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// sweep drops buckets of senders idle long enough to refill them.
+func (l *senderLimiter) sweep(now time.Time) {
+	for sender, b := range l.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*l.rate >= l.burst {
+			delete(l.buckets, sender)
+		}
+	}
+	l.swept = now
+}
+
+// limiter applies delivery limits to messages received from network.
+type limiter struct {
+	cfg     configuration.Delivery
+	mutex   sync.Mutex
+	pools   map[core.MessageType]*typePool
+	senders *senderLimiter
+}
+
+func newLimiter(cfg configuration.Delivery) *limiter {
+	l := &limiter{
+		cfg:   cfg,
+		pools: map[core.MessageType]*typePool{},
+	}
+	if cfg.SenderRate > 0 {
+		l.senders = newSenderLimiter(cfg.SenderRate, cfg.SenderBurst)
+	}
+	return l
+}
+
+func (l *limiter) pool(t core.MessageType) *typePool {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	p, ok := l.pools[t]
+	if !ok {
+		p = newTypePool(t.String(), l.cfg.Workers, l.cfg.QueueSize)
+		l.pools[t] = p
+	}
+	return p
+}
+
+// acquire checks sender's rate and waits for a worker for the message type,
+// on success caller must call returned release function after handling the message.
+// Empty reason means success, otherwise message should be rejected as busy.
+// Nested messages don't take workers: their callers hold workers while waiting for them,
+// so pools exhausted by callers would never be released.
+func (l *limiter) acquire(done <-chan struct{}, sender string, t core.MessageType, nested bool) (release func(), reason string) {
+	if l.senders != nil && sender != "" && !l.senders.allow(sender) {
+		metrics.MessageBusRejectedTotal.WithLabelValues(t.String(), busyReasonRate).Inc()
+		return nil, busyReasonRate
+	}
+	if l.cfg.Workers <= 0 || nested {
+		return func() {}, ""
+	}
+
+	p := l.pool(t)
+	if !p.acquire(done) {
+		metrics.MessageBusRejectedTotal.WithLabelValues(t.String(), busyReasonQueue).Inc()
+		return nil, busyReasonQueue
+	}
+	return p.release, ""
+}
@@ -0,0 +1,140 @@
+/*
+ *    Copyright 2018 Insolar
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package messagebus
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/insolar/insolar/configuration"
+	"github.com/insolar/insolar/core"
+	"github.com/insolar/insolar/core/message"
+	"github.com/insolar/insolar/core/reply"
+)
+
+func TestSenderLimiter(t *testing.T) {
+	now := time.Now()
+	l := newSenderLimiter(10, 2)
+	l.now = func() time.Time { return now }
+
+	a := "a"
+	b := "b"
+
+	assert.True(t, l.allow(a))
+	assert.True(t, l.allow(a))
+	assert.False(t, l.allow(a))
+	assert.True(t, l.allow(b))
+
+	now = now.Add(100 * time.Millisecond)
+	assert.True(t, l.allow(a))
+	assert.False(t, l.allow(a))
+
+	now = now.Add(time.Hour)
+	assert.True(t, l.allow(a))
+	assert.True(t, l.allow(a))
+	assert.False(t, l.allow(a))
+	// idle bucket of b is dropped, a is drained, so it stays
+	assert.Len(t, l.buckets, 1)
+	assert.Contains(t, l.buckets, a)
+}
+
+func TestLimiter_acquire_Nested(t *testing.T) {
+	l := newLimiter(configuration.Delivery{Workers: 1})
+	done := make(chan struct{})
+
+	release, reason := l.acquire(done, "a", core.TypeCallMethod, false)
+	assert.Empty(t, reason)
+
+	// caller holds the only worker waiting for the nested call, which mustn't wait for the worker
+	nestedRelease, reason := l.acquire(done, "a", core.TypeCallMethod, true)
+	assert.Empty(t, reason)
+	nestedRelease()
+	release()
+}
+
+func TestTypePool(t *testing.T) {
+	p := newTypePool("test", 1, 1)
+	done := make(chan struct{})
+
+	assert.True(t, p.acquire(done))
+
+	acquired := make(chan bool)
+	go func() {
+		acquired <- p.acquire(done)
+	}()
+	// second message waits in the queue, so third one is rejected
+	for i := 0; i < 1000; i++ {
+		p.mutex.Lock()
+		pending := p.pending
+		p.mutex.Unlock()
+		if pending == 2 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	assert.False(t, p.acquire(done))
+
+	p.release()
+	assert.True(t, <-acquired)
+	p.release()
+
+	assert.True(t, p.acquire(done))
+	close(done)
+	assert.False(t, p.acquire(done))
+	p.release()
+	assert.Equal(t, 0, p.pending)
+}
+
+func TestMessageBus_deliver_Busy(t *testing.T) {
+	cfg := configuration.NewConfiguration()
+	cfg.MessageBus.Delivery.SenderRate = 1
+	cfg.MessageBus.Delivery.SenderBurst = 1
+	mb, err := NewMessageBus(cfg)
+	assert.NoError(t, err)
+	mb.MustRegister(core.TypeCallMethod, func(ctx context.Context, msg core.Message) (core.Reply, error) {
+		return &reply.CallMethod{}, nil
+	})
+
+	args := [][]byte{
+		message.MustSerializeBytes(&message.CallMethod{}),
+	}
+
+	res, err := mb.deliver("sender", args)
+	assert.NoError(t, err)
+	resp, err := reply.Deserialize(bytes.NewBuffer(res))
+	assert.NoError(t, err)
+	assert.IsType(t, &reply.CallMethod{}, resp)
+
+	res, err = mb.deliver("sender", args)
+	assert.NoError(t, err)
+	resp, err = reply.Deserialize(bytes.NewBuffer(res))
+	assert.NoError(t, err)
+	assert.Equal(t, &reply.Busy{
+		RetryAfter: time.Duration(cfg.MessageBus.Delivery.BusyRetryDelay) * time.Millisecond,
+		Reason:     busyReasonRate,
+	}, resp)
+}
+
+func TestBusyDelay(t *testing.T) {
+	assert.Equal(t, minBusyRetryDelay, busyDelay(&reply.Busy{}, 0))
+	assert.Equal(t, 4*minBusyRetryDelay, busyDelay(&reply.Busy{}, 2))
+	assert.Equal(t, 2*time.Second, busyDelay(&reply.Busy{RetryAfter: time.Second}, 1))
+}
@@ -18,14 +18,17 @@ package messagebus
 
 import (
 	"bytes"
+	"context"
 	"encoding/gob"
 	"errors"
+	"time"
 
 	"github.com/insolar/insolar/configuration"
 	"github.com/insolar/insolar/core"
 	"github.com/insolar/insolar/core/message"
 	"github.com/insolar/insolar/core/reply"
 	"github.com/insolar/insolar/log"
+	"github.com/insolar/insolar/tracing"
 )
 
 const deliverRPCMethodName = "MessageBus.Deliver"
@@ -37,11 +40,26 @@ type MessageBus struct {
 	service     core.Network
 	ledger      core.Ledger
 	handlers    map[core.MessageType]core.MessageHandler
+	cfg         configuration.MessageBus
+	outbox      *outbox
+	recorder    *recorder
+	limiter     *limiter
 }
 
+// minBusyRetryDelay is the least delay before repeating message rejected as busy,
+// so receivers replying without delay aren't flooded with repeats.
+const minBusyRetryDelay = 10 * time.Millisecond
+
+// ErrBusy is returned by Send if receiver rejected the message as busy on every attempt.
+var ErrBusy = errors.New("receiver is busy")
+
 // NewMessageBus is a `MessageBus` constructor
-func NewMessageBus(configuration.Configuration) (*MessageBus, error) {
-	return &MessageBus{handlers: map[core.MessageType]core.MessageHandler{}}, nil
+func NewMessageBus(c configuration.Configuration) (*MessageBus, error) {
+	return &MessageBus{
+		handlers: map[core.MessageType]core.MessageHandler{},
+		cfg:      c.MessageBus,
+		limiter:  newLimiter(c.MessageBus.Delivery),
+	}, nil
 }
 
 // Start initializes message bus
@@ -51,11 +69,40 @@ func (mb *MessageBus) Start(c core.Components) error {
 	mb.service.RemoteProcedureRegister(deliverRPCMethodName, mb.deliver)
 	mb.ledger = c.Ledger
 
+	if mb.cfg.RecordFile != "" {
+		rec, err := newRecorder(mb.cfg.RecordFile)
+		if err != nil {
+			return err
+		}
+		mb.recorder = rec
+	}
+
+	if mb.cfg.Outbox.Directory != "" {
+		ob, err := newOutbox(mb.cfg.Outbox, func(msg core.Message) error {
+			// asynchronous messages are sent by handlers of other messages
+			_, err := mb.Send(message.NestedContext(context.Background()), msg)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		mb.outbox = ob
+		mb.outbox.Start()
+	}
+
 	return nil
 }
 
 // Stop releases resources and stops the bus
-func (mb *MessageBus) Stop() error { return nil }
+func (mb *MessageBus) Stop() error {
+	if mb.outbox != nil {
+		mb.outbox.Stop()
+	}
+	if mb.recorder != nil {
+		return mb.recorder.close()
+	}
+	return nil
+}
 
 // Register sets a function as a hadler for particular message type,
 // only one handler per type is allowed
@@ -78,7 +125,52 @@ func (mb *MessageBus) MustRegister(p core.MessageType, handler core.MessageHandl
 }
 
 // Send an `Message` and get a `Reply` or error from remote host.
-func (mb *MessageBus) Send(msg core.Message) (core.Reply, error) {
+// Message rejected by receiver as busy is repeated with growing delay, ErrBusy is returned
+// if receiver is still busy after configured number of repeats.
+func (mb *MessageBus) Send(ctx context.Context, msg core.Message) (core.Reply, error) {
+	ctx, span := tracing.StartSpan(ctx, "messagebus.Send")
+	defer span.End()
+	span.SetAttribute("type", msg.Type().String())
+	span.SetAttribute("target", msg.Target().String())
+	tracing.Logger(ctx).Debugf("sending %s to %s", msg.Type(), msg.Target())
+
+	for attempt := 0; ; attempt++ {
+		resp, err := mb.send(ctx, msg)
+		if err != nil {
+			tracing.Logger(ctx).Debugf("sending %s failed: %s", msg.Type(), err)
+			span.SetError(err)
+			return nil, err
+		}
+		busy, ok := resp.(*reply.Busy)
+		if !ok {
+			return resp, nil
+		}
+		if attempt >= mb.cfg.Delivery.BusyRetries {
+			span.SetError(ErrBusy)
+			return nil, ErrBusy
+		}
+
+		delay := busyDelay(busy, attempt)
+		tracing.Logger(ctx).Debugf("receiver is busy (%s), repeating %s in %s", busy.Reason, msg.Type(), delay)
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			span.SetError(ctx.Err())
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// busyDelay returns delay before the attempt to repeat the message, it's doubled on every attempt.
+func busyDelay(busy *reply.Busy, attempt int) time.Duration {
+	delay := busy.RetryAfter
+	if delay < minBusyRetryDelay {
+		delay = minBusyRetryDelay
+	}
+	return delay << uint(attempt)
+}
+
+func (mb *MessageBus) send(ctx context.Context, msg core.Message) (core.Reply, error) {
 	jc := mb.ledger.GetJetCoordinator()
 	pm := mb.ledger.GetPulseManager()
 	pulse, err := pm.Current()
@@ -102,7 +194,11 @@ func (mb *MessageBus) Send(msg core.Message) (core.Reply, error) {
 		return nil, err
 	}
 
-	res, err := mb.service.SendMessage(nodes[0], deliverRPCMethodName, msg)
+	if nodes[0].Equal(mb.service.GetNodeID()) {
+		return mb.deliverLocal(ctx, msg)
+	}
+
+	res, err := mb.service.SendMessage(ctx, nodes[0], deliverRPCMethodName, msg)
 	if err != nil {
 		return nil, err
 	}
@@ -110,14 +206,50 @@ func (mb *MessageBus) Send(msg core.Message) (core.Reply, error) {
 	return reply.Deserialize(bytes.NewBuffer(res))
 }
 
-// SendAsync sends a `Message` to remote host.
+// SendAsync sends a `Message` to remote host. Message is stored in the outbox
+// and its delivery is retried until it succeeds or attempts are exhausted,
+// so it can be delivered more than once.
 func (mb *MessageBus) SendAsync(msg core.Message) {
+	if mb.outbox != nil {
+		err := mb.outbox.Put(msg)
+		if err == nil {
+			return
+		}
+		log.Errorln("couldn't store message in the outbox, sending without retries: ", err)
+	}
+
 	go func() {
-		_, err := mb.Send(msg)
-		log.Errorln(err)
+		_, err := mb.Send(context.Background(), msg)
+		if err != nil {
+			log.Errorln(err)
+		}
 	}()
 }
 
+// DeadLetters returns asynchronous messages that weren't delivered.
+func (mb *MessageBus) DeadLetters() ([]core.DeadLetter, error) {
+	if mb.outbox == nil {
+		return nil, errors.New("outbox is disabled")
+	}
+	return mb.outbox.DeadLetters()
+}
+
+// ReplayDeadLetter puts dead letter back to the outbox for another round of delivery attempts.
+func (mb *MessageBus) ReplayDeadLetter(id string) error {
+	if mb.outbox == nil {
+		return errors.New("outbox is disabled")
+	}
+	return mb.outbox.Replay(id)
+}
+
+// DropDeadLetter removes dead letter.
+func (mb *MessageBus) DropDeadLetter(id string) error {
+	if mb.outbox == nil {
+		return errors.New("outbox is disabled")
+	}
+	return mb.outbox.Drop(id)
+}
+
 type serializableError struct {
 	S string
 }
@@ -126,28 +258,141 @@ func (e *serializableError) Error() string {
 	return e.S
 }
 
+// nested reports whether the message is sent by the current node while handling another message,
+// such messages aren't recorded, replay of the message that caused them sends them again.
+// Only the mark of the local context is trusted, messages received from network are never nested.
+func nested(ctx context.Context) bool {
+	return message.IsNested(ctx)
+}
+
+// doDeliver calls registered handler for the message type, the message is recorded if it's required
+// and recording is enabled
+func (mb *MessageBus) doDeliver(ctx context.Context, msg core.Message, record bool) (core.Reply, error) {
+	handler, ok := mb.handlers[msg.Type()]
+	if !ok {
+		return nil, errors.New("no handler for received message type")
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, &serializableError{
+			S: err.Error(),
+		}
+	}
+
+	ctx, span := tracing.StartSpan(ctx, "messagebus.Deliver")
+	defer span.End()
+	span.SetAttribute("type", msg.Type().String())
+
+	record = record && mb.recorder != nil
+	var recorded []byte
+	if record {
+		// message is serialized before handling, so the record isn't affected by handler
+		recorded = message.MustSerializeBytes(msg)
+	}
+
+	tracing.Logger(ctx).Debugf("delivering %s", msg.Type())
+	resp, err := handler(message.NestedContext(ctx), msg)
+	if record {
+		mb.record(ctx, recorded, resp, err)
+	}
+	if err != nil {
+		tracing.Logger(ctx).Debugf("handler of %s failed: %s", msg.Type(), err)
+		span.SetError(err)
+		return nil, &serializableError{
+			S: err.Error(),
+		}
+	}
+	return resp, nil
+}
+
+// record writes delivered message and its reply to the record file, recording errors are only logged
+func (mb *MessageBus) record(ctx context.Context, msg []byte, resp core.Reply, handlerErr error) {
+	var pulse core.Pulse
+	if mb.ledger != nil {
+		current, err := mb.ledger.GetPulseManager().Current()
+		if err != nil {
+			tracing.Logger(ctx).Errorln("couldn't get current pulse for message record: ", err)
+		} else {
+			pulse = *current
+		}
+	}
+	if err := mb.recorder.write(pulse, msg, resp, handlerErr); err != nil {
+		tracing.Logger(ctx).Errorln("couldn't record message: ", err)
+	}
+}
+
+// deliverLocal calls handler of the message on the current node bypassing network,
+// message and reply are copied through serialization if it's required by configuration
+func (mb *MessageBus) deliverLocal(ctx context.Context, msg core.Message) (core.Reply, error) {
+	record := !nested(ctx)
+	if !mb.cfg.SerializeLocal {
+		return mb.doDeliver(ctx, msg, record)
+	}
+
+	rd, err := message.Serialize(msg)
+	if err != nil {
+		return nil, err
+	}
+	msg, err = message.Deserialize(rd)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := mb.doDeliver(ctx, msg, record)
+	if err != nil {
+		return nil, err
+	}
+
+	rd, err = reply.Serialize(resp)
+	if err != nil {
+		return nil, err
+	}
+	return reply.Deserialize(rd)
+}
+
 // Deliver method calls LogicRunner.Execute on local host
-// this method is registered as RPC stub
-func (mb *MessageBus) deliver(args [][]byte) (result []byte, err error) {
+// this method is registered as RPC stub, the optional second argument is the sender's deadline,
+// the optional third one is the sender's span. Message is rejected with Busy reply
+// if delivery limits of the sender host are exceeded.
+func (mb *MessageBus) deliver(sender string, args [][]byte) (result []byte, err error) {
 	if len(args) < 1 {
-		return nil, errors.New("need exactly one argument when mb.deliver()")
+		return nil, errors.New("need at least one argument when mb.deliver()")
 	}
 	msg, err := message.Deserialize(bytes.NewBuffer(args[0]))
 	if err != nil {
 		return nil, err
 	}
 
-	handler, ok := mb.handlers[msg.Type()]
-	if !ok {
-		return nil, errors.New("no handler for received message type")
+	var deadline []byte
+	if len(args) > 1 {
+		deadline = args[1]
+	}
+	ctx, cancel := message.ContextWithDeadlineBytes(context.Background(), deadline)
+	defer cancel()
+	if len(args) > 2 {
+		ctx = tracing.Extract(ctx, args[2])
 	}
 
-	resp, err := handler(msg)
-	if err != nil {
-		return nil, &serializableError{
-			S: err.Error(),
+	var resp core.Reply
+	// context of the network delivery is never nested, so peers can't bypass workers limit by faking the mark
+	release, reason := mb.limiter.acquire(ctx.Done(), sender, msg.Type(), nested(ctx))
+	if reason != "" {
+		if err := ctx.Err(); err != nil {
+			return nil, &serializableError{
+				S: err.Error(),
+			}
+		}
+		resp = &reply.Busy{
+			RetryAfter: time.Duration(mb.cfg.Delivery.BusyRetryDelay) * time.Millisecond,
+			Reason:     reason,
+		}
+	} else {
+		resp, err = mb.doDeliver(ctx, msg, true)
+		release()
+		if err != nil {
+			return nil, err
 		}
 	}
+
 	rd, err := reply.Serialize(resp)
 	if err != nil {
 		return nil, err
@@ -17,11 +17,14 @@
 package messagebus
 
 import (
+	"context"
 	"testing"
+	"time"
 
 	"github.com/insolar/insolar/configuration"
 	"github.com/insolar/insolar/core"
 	"github.com/insolar/insolar/core/message"
+	"github.com/insolar/insolar/core/reply"
 	"github.com/insolar/insolar/network/servicenetwork"
 	"github.com/stretchr/testify/assert"
 )
@@ -46,7 +49,7 @@ func (r *runner) OnPulse(pulse core.Pulse) error { return nil }
 func (r *runner) Start(components core.Components) error { return nil }
 func (r *runner) Stop() error                            { return nil }
 
-func (r *runner) Execute(msg core.Message) (core.Reply, error) {
+func (r *runner) Execute(ctx context.Context, msg core.Message) (core.Reply, error) {
 	if len(r.responses) == 0 {
 		panic("no request expected")
 	}
@@ -79,6 +82,76 @@ func TestNew(t *testing.T) {
 	}
 }
 
+func TestMessageBus_deliverLocal(t *testing.T) {
+	mutatingHandler := func(ctx context.Context, msg core.Message) (core.Reply, error) {
+		m := msg.(*message.CallMethod)
+		m.Method = "Mutated"
+		return &reply.CallMethod{Result: []byte(m.Method)}, nil
+	}
+
+	t.Run("serialized", func(t *testing.T) {
+		mb, err := NewMessageBus(configuration.NewConfiguration())
+		assert.NoError(t, err)
+		mb.MustRegister(core.TypeCallMethod, mutatingHandler)
+
+		msg := &message.CallMethod{Method: "Original"}
+		res, err := mb.deliverLocal(context.Background(), msg)
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("Mutated"), res.(*reply.CallMethod).Result)
+		assert.Equal(t, "Original", msg.Method)
+	})
+
+	t.Run("in-place", func(t *testing.T) {
+		cfg := configuration.NewConfiguration()
+		cfg.MessageBus.SerializeLocal = false
+		mb, err := NewMessageBus(cfg)
+		assert.NoError(t, err)
+		mb.MustRegister(core.TypeCallMethod, mutatingHandler)
+
+		msg := &message.CallMethod{Method: "Original"}
+		_, err = mb.deliverLocal(context.Background(), msg)
+		assert.NoError(t, err)
+		assert.Equal(t, "Mutated", msg.Method)
+	})
+
+	t.Run("no handler", func(t *testing.T) {
+		mb, err := NewMessageBus(configuration.NewConfiguration())
+		assert.NoError(t, err)
+
+		_, err = mb.deliverLocal(context.Background(), &message.CallMethod{})
+		assert.Error(t, err)
+	})
+}
+
+func TestMessageBus_deliver_Deadline(t *testing.T) {
+	mb, err := NewMessageBus(configuration.NewConfiguration())
+	assert.NoError(t, err)
+
+	var handlerDeadline time.Time
+	mb.MustRegister(core.TypeCallMethod, func(ctx context.Context, msg core.Message) (core.Reply, error) {
+		handlerDeadline, _ = ctx.Deadline()
+		return &reply.CallMethod{}, nil
+	})
+
+	deadline := time.Now().Add(time.Minute)
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+	_, err = mb.deliver("", [][]byte{
+		message.MustSerializeBytes(&message.CallMethod{}),
+		message.DeadlineBytes(ctx),
+	})
+	assert.NoError(t, err)
+	assert.True(t, deadline.Equal(handlerDeadline))
+
+	expired, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	_, err = mb.deliver("", [][]byte{
+		message.MustSerializeBytes(&message.CallMethod{}),
+		message.DeadlineBytes(expired),
+	})
+	assert.Error(t, err)
+}
+
 // TODO: fix network interaction
 // func TestRoute(t *testing.T) {
 // 	r := new(runner)
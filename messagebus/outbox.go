@@ -0,0 +1,346 @@
+/*
+ *    Copyright 2018 Insolar
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package messagebus
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/insolar/insolar/configuration"
+	"github.com/insolar/insolar/core"
+	"github.com/insolar/insolar/core/message"
+	"github.com/insolar/insolar/log"
+)
+
+const deadLettersSubdir = "dead"
+
+// entryName matches names of outbox entries, see Put
+var entryName = regexp.MustCompile(`^[0-9]{20}-[0-9]{10}$`)
+
+// outboxEntry is a message waiting for delivery, it's stored in a separate file of the outbox directory.
+type outboxEntry struct {
+	Message   []byte
+	Attempts  int
+	LastError string
+	NextTry   time.Time
+}
+
+// outbox persists asynchronous messages and retries their delivery with exponential backoff,
+// messages that exhausted all attempts are moved to dead letters directory.
+//
+// Delivery is at-least-once: message is retried when its reply is lost, e.g. the receiver restarted
+// after handling it, so handlers of asynchronous messages must tolerate duplicates.
+type outbox struct {
+	cfg  configuration.Outbox
+	send func(core.Message) error
+
+	mutex sync.Mutex
+	seq   uint64
+	wake  chan struct{}
+	stop  chan struct{}
+	done  chan struct{}
+}
+
+func newOutbox(cfg configuration.Outbox, send func(core.Message) error) (*outbox, error) {
+	if cfg.MaxAttempts < 1 {
+		return nil, errors.New("outbox max attempts should be positive")
+	}
+	err := os.MkdirAll(filepath.Join(cfg.Directory, deadLettersSubdir), 0755)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't create outbox directory")
+	}
+	return &outbox{
+		cfg:  cfg,
+		send: send,
+		wake: make(chan struct{}, 1),
+	}, nil
+}
+
+// Start launches delivery of the stored messages, including ones left from previous run.
+func (o *outbox) Start() {
+	o.stop = make(chan struct{})
+	o.done = make(chan struct{})
+	go o.loop()
+}
+
+// Stop waits for current delivery attempt to finish and stops delivery,
+// undelivered messages stay in the outbox.
+func (o *outbox) Stop() {
+	if o.stop == nil {
+		return
+	}
+	close(o.stop)
+	<-o.done
+	o.stop = nil
+}
+
+// Put stores the message in the outbox and schedules its delivery.
+func (o *outbox) Put(msg core.Message) error {
+	rd, err := message.Serialize(msg)
+	if err != nil {
+		return err
+	}
+	buff, err := ioutil.ReadAll(rd)
+	if err != nil {
+		return err
+	}
+
+	o.mutex.Lock()
+	o.seq++
+	id := fmt.Sprintf("%020d-%010d", time.Now().UnixNano(), o.seq)
+	o.mutex.Unlock()
+
+	err = writeEntry(o.entryPath(id), &outboxEntry{Message: buff, NextTry: time.Now()})
+	if err != nil {
+		return err
+	}
+
+	select {
+	case o.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// DeadLetters returns messages that exhausted all delivery attempts.
+func (o *outbox) DeadLetters() ([]core.DeadLetter, error) {
+	ids, err := listEntries(o.deadPath(""))
+	if err != nil {
+		return nil, err
+	}
+
+	letters := make([]core.DeadLetter, 0, len(ids))
+	for _, id := range ids {
+		entry, err := readEntry(o.deadPath(id))
+		if err != nil {
+			return nil, err
+		}
+		msg, err := message.Deserialize(bytes.NewBuffer(entry.Message))
+		if err != nil {
+			return nil, errors.Wrapf(err, "broken dead letter %s", id)
+		}
+		letters = append(letters, core.DeadLetter{
+			ID:        id,
+			Message:   msg,
+			Attempts:  entry.Attempts,
+			LastError: entry.LastError,
+		})
+	}
+	return letters, nil
+}
+
+// Replay moves dead letter back to the outbox resetting its attempts counter.
+func (o *outbox) Replay(id string) error {
+	if err := checkID(id); err != nil {
+		return err
+	}
+	entry, err := readEntry(o.deadPath(id))
+	if err != nil {
+		return err
+	}
+	entry.Attempts = 0
+	entry.NextTry = time.Now()
+	if err := writeEntry(o.entryPath(id), entry); err != nil {
+		return err
+	}
+	if err := os.Remove(o.deadPath(id)); err != nil {
+		return err
+	}
+
+	select {
+	case o.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Drop removes dead letter permanently.
+func (o *outbox) Drop(id string) error {
+	if err := checkID(id); err != nil {
+		return err
+	}
+	return os.Remove(o.deadPath(id))
+}
+
+func (o *outbox) loop() {
+	defer close(o.done)
+	for {
+		next := o.process()
+		var timer <-chan time.Time
+		if !next.IsZero() {
+			timer = time.After(time.Until(next))
+		}
+
+		select {
+		case <-o.stop:
+			return
+		case <-o.wake:
+		case <-timer:
+		}
+	}
+}
+
+// process tries to deliver all due messages and returns time of the next scheduled try,
+// zero time means that outbox is empty
+func (o *outbox) process() time.Time {
+	ids, err := listEntries(o.cfg.Directory)
+	if err != nil {
+		log.Errorln("failed to list outbox: ", err)
+		return time.Now().Add(o.delay(1))
+	}
+
+	var next time.Time
+	for _, id := range ids {
+		select {
+		case <-o.stop:
+			return next
+		default:
+		}
+
+		tryAt, err := o.deliver(id)
+		if err != nil {
+			log.Errorf("outbox entry %s: %s", id, err)
+		}
+		if !tryAt.IsZero() && (next.IsZero() || tryAt.Before(next)) {
+			next = tryAt
+		}
+	}
+	return next
+}
+
+// deliver makes delivery attempt if it's time to, returns time of the next attempt if the message stays in the outbox
+func (o *outbox) deliver(id string) (time.Time, error) {
+	path := o.entryPath(id)
+	entry, err := readEntry(path)
+	if err != nil {
+		// keep broken entry for investigation, but out of the way of the dead letters
+		if rerr := os.Rename(path, o.deadPath(id)+".broken"); rerr != nil {
+			return time.Time{}, rerr
+		}
+		return time.Time{}, err
+	}
+	if time.Now().Before(entry.NextTry) {
+		return entry.NextTry, nil
+	}
+
+	msg, err := message.Deserialize(bytes.NewBuffer(entry.Message))
+	if err == nil {
+		err = o.send(msg)
+	}
+	if err == nil {
+		return time.Time{}, os.Remove(path)
+	}
+
+	entry.Attempts++
+	entry.LastError = err.Error()
+	if entry.Attempts >= o.cfg.MaxAttempts {
+		log.Warnf("outbox entry %s is moved to dead letters after %d attempts: %s", id, entry.Attempts, err)
+		return time.Time{}, o.bury(id, entry)
+	}
+
+	entry.NextTry = time.Now().Add(o.delay(entry.Attempts))
+	return entry.NextTry, writeEntry(path, entry)
+}
+
+// bury moves entry to dead letters
+func (o *outbox) bury(id string, entry *outboxEntry) error {
+	if err := writeEntry(o.deadPath(id), entry); err != nil {
+		return err
+	}
+	return os.Remove(o.entryPath(id))
+}
+
+// delay returns backoff before the next attempt after given number of failed attempts
+func (o *outbox) delay(attempts int) time.Duration {
+	max := time.Duration(o.cfg.RetryMaxDelay) * time.Millisecond
+	d := time.Duration(o.cfg.RetryDelay) * time.Millisecond
+	for i := 1; i < attempts && d < max; i++ {
+		d *= 2
+	}
+	if max > 0 && d > max {
+		d = max
+	}
+	return d
+}
+
+func (o *outbox) entryPath(id string) string {
+	return filepath.Join(o.cfg.Directory, id)
+}
+
+func (o *outbox) deadPath(id string) string {
+	return filepath.Join(o.cfg.Directory, deadLettersSubdir, id)
+}
+
+// checkID rejects ids that aren't plain entry names, they come from API and must not escape outbox directory
+func checkID(id string) error {
+	if filepath.Base(id) != id || !entryName.MatchString(id) {
+		return errors.Errorf("invalid dead letter id %q", id)
+	}
+	return nil
+}
+
+// listEntries returns sorted names of regular files in the directory, i.e. in order messages were sent
+func listEntries(dir string) ([]string, error) {
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for _, info := range infos {
+		if info.Mode().IsRegular() && filepath.Ext(info.Name()) == "" {
+			ids = append(ids, info.Name())
+		}
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+func readEntry(path string) (*outboxEntry, error) {
+	buff, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	entry := &outboxEntry{}
+	err = gob.NewDecoder(bytes.NewBuffer(buff)).Decode(entry)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't decode outbox entry")
+	}
+	return entry, nil
+}
+
+// writeEntry writes entry to temporary file and renames it, so entries are never partially written
+func writeEntry(path string, entry *outboxEntry) error {
+	buff := &bytes.Buffer{}
+	if err := gob.NewEncoder(buff).Encode(entry); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, buff.Bytes(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,194 @@
+/*
+ *    Copyright 2018 Insolar
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package messagebus
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/insolar/insolar/configuration"
+	"github.com/insolar/insolar/core"
+	"github.com/insolar/insolar/core/message"
+)
+
+type flakySender struct {
+	mutex    sync.Mutex
+	failures int
+	sent     []core.Message
+}
+
+func (s *flakySender) send(msg core.Message) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.failures > 0 {
+		s.failures--
+		return errors.New("peer is down")
+	}
+	s.sent = append(s.sent, msg)
+	return nil
+}
+
+func (s *flakySender) fail(n int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.failures = n
+}
+
+func (s *flakySender) sentCount() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return len(s.sent)
+}
+
+func tmpOutboxConfig(t *testing.T, attempts int) (configuration.Outbox, func()) {
+	dir, err := ioutil.TempDir("", "outbox")
+	assert.NoError(t, err)
+	cfg := configuration.Outbox{
+		Directory:     dir,
+		MaxAttempts:   attempts,
+		RetryDelay:    1,
+		RetryMaxDelay: 10,
+	}
+	return cfg, func() { os.RemoveAll(dir) }
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	for i := 0; i < 200; i++ {
+		if cond() {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("condition is not met in time")
+}
+
+func TestOutbox_Retries(t *testing.T) {
+	cfg, cleanup := tmpOutboxConfig(t, 5)
+	defer cleanup()
+
+	sender := &flakySender{failures: 2}
+	ob, err := newOutbox(cfg, sender.send)
+	assert.NoError(t, err)
+	ob.Start()
+	defer ob.Stop()
+
+	err = ob.Put(&message.CallMethod{Method: "Notify"})
+	assert.NoError(t, err)
+
+	waitFor(t, func() bool { return sender.sentCount() == 1 })
+	assert.Equal(t, "Notify", sender.sent[0].(*message.CallMethod).Method)
+
+	ids, err := listEntries(cfg.Directory)
+	assert.NoError(t, err)
+	assert.Empty(t, ids)
+}
+
+func TestOutbox_SurvivesRestart(t *testing.T) {
+	cfg, cleanup := tmpOutboxConfig(t, 5)
+	defer cleanup()
+
+	sender := &flakySender{}
+	ob, err := newOutbox(cfg, sender.send)
+	assert.NoError(t, err)
+	err = ob.Put(&message.CallMethod{Method: "Notify"})
+	assert.NoError(t, err)
+
+	restarted, err := newOutbox(cfg, sender.send)
+	assert.NoError(t, err)
+	restarted.Start()
+	defer restarted.Stop()
+
+	waitFor(t, func() bool { return sender.sentCount() == 1 })
+}
+
+func TestOutbox_DeadLetters(t *testing.T) {
+	cfg, cleanup := tmpOutboxConfig(t, 2)
+	defer cleanup()
+
+	sender := &flakySender{failures: 2}
+	ob, err := newOutbox(cfg, sender.send)
+	assert.NoError(t, err)
+	ob.Start()
+	defer ob.Stop()
+
+	err = ob.Put(&message.CallMethod{Method: "First"})
+	assert.NoError(t, err)
+
+	var letters []core.DeadLetter
+	waitFor(t, func() bool {
+		letters, err = ob.DeadLetters()
+		return err == nil && len(letters) == 1
+	})
+	assert.Equal(t, 2, letters[0].Attempts)
+	assert.Equal(t, "peer is down", letters[0].LastError)
+	assert.Equal(t, "First", letters[0].Message.(*message.CallMethod).Method)
+
+	err = ob.Replay(letters[0].ID)
+	assert.NoError(t, err)
+	waitFor(t, func() bool { return sender.sentCount() == 1 })
+
+	sender.fail(2)
+	err = ob.Put(&message.CallMethod{Method: "Second"})
+	assert.NoError(t, err)
+	waitFor(t, func() bool {
+		letters, err = ob.DeadLetters()
+		return err == nil && len(letters) == 1
+	})
+	err = ob.Drop(letters[0].ID)
+	assert.NoError(t, err)
+	letters, err = ob.DeadLetters()
+	assert.NoError(t, err)
+	assert.Empty(t, letters)
+	assert.Equal(t, 1, sender.sentCount())
+}
+
+func TestOutbox_RejectsForeignIDs(t *testing.T) {
+	cfg, cleanup := tmpOutboxConfig(t, 1)
+	defer cleanup()
+	ob, err := newOutbox(cfg, (&flakySender{}).send)
+	assert.NoError(t, err)
+
+	victim := filepath.Join(cfg.Directory, "victim")
+	assert.NoError(t, ioutil.WriteFile(victim, []byte("data"), 0644))
+
+	for _, id := range []string{"../victim", "victim", "", "..", "/etc/passwd", "00000000000000000001-0000000001/.."} {
+		assert.Error(t, ob.Drop(id), id)
+		assert.Error(t, ob.Replay(id), id)
+	}
+	_, err = os.Stat(victim)
+	assert.NoError(t, err)
+}
+
+func TestMessageBus_DeadLetterQueue(t *testing.T) {
+	var _ core.DeadLetterQueue = (*MessageBus)(nil)
+	assert.Empty(t, configuration.NewMessageBus().Outbox.Directory, "outbox writes files, it must be enabled explicitly")
+}
+
+func TestOutbox_delay(t *testing.T) {
+	ob := &outbox{cfg: configuration.Outbox{RetryDelay: 100, RetryMaxDelay: 1000}}
+	assert.Equal(t, 100*time.Millisecond, ob.delay(1))
+	assert.Equal(t, 200*time.Millisecond, ob.delay(2))
+	assert.Equal(t, 800*time.Millisecond, ob.delay(4))
+	assert.Equal(t, time.Second, ob.delay(10))
+}
@@ -0,0 +1,122 @@
+/*
+ *    Copyright 2018 Insolar
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package messagebus
+
+import (
+	"bytes"
+	"encoding/gob"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/insolar/insolar/core"
+	"github.com/insolar/insolar/core/message"
+	"github.com/insolar/insolar/core/reply"
+)
+
+// Record is a message delivered to the node together with its reply and the pulse it was delivered on.
+type Record struct {
+	Pulse   core.Pulse
+	Message []byte // serialized message
+	Reply   []byte // serialized reply, empty if handler returned an error
+	Error   string
+}
+
+// GetMessage returns recorded message.
+func (r *Record) GetMessage() (core.Message, error) {
+	return message.Deserialize(bytes.NewBuffer(r.Message))
+}
+
+// GetReply returns recorded reply, nil if message handling failed.
+func (r *Record) GetReply() (core.Reply, error) {
+	if len(r.Reply) == 0 {
+		return nil, nil
+	}
+	return reply.Deserialize(bytes.NewBuffer(r.Reply))
+}
+
+// recorder writes records to the file as a stream of gob values.
+type recorder struct {
+	mutex sync.Mutex
+	file  *os.File
+	enc   *gob.Encoder
+}
+
+func newRecorder(path string) (*recorder, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't create record file")
+	}
+	return &recorder{file: f, enc: gob.NewEncoder(f)}, nil
+}
+
+func (r *recorder) write(pulse core.Pulse, msg []byte, rep core.Reply, handlerErr error) error {
+	rec := Record{Pulse: pulse, Message: msg}
+	var err error
+	if handlerErr != nil {
+		rec.Error = handlerErr.Error()
+	} else if rep != nil {
+		rec.Reply, err = readAllSerialized(reply.Serialize(rep))
+		if err != nil {
+			return err
+		}
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.enc.Encode(&rec)
+}
+
+func (r *recorder) close() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.file.Close()
+}
+
+func readAllSerialized(rd io.Reader, err error) ([]byte, error) {
+	if err != nil {
+		return nil, err
+	}
+	buff := new(bytes.Buffer)
+	_, err = buff.ReadFrom(rd)
+	return buff.Bytes(), err
+}
+
+// ReadRecording reads all records from the file written by MessageBus recorder.
+func ReadRecording(path string) ([]Record, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close() // nolint: errcheck
+
+	var records []Record
+	dec := gob.NewDecoder(f)
+	for {
+		var rec Record
+		err := dec.Decode(&rec)
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return records, errors.Wrapf(err, "couldn't read record #%d", len(records))
+		}
+		records = append(records, rec)
+	}
+}
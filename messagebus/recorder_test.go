@@ -0,0 +1,90 @@
+/*
+ *    Copyright 2018 Insolar
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package messagebus
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/insolar/insolar/configuration"
+	"github.com/insolar/insolar/core"
+	"github.com/insolar/insolar/core/message"
+	"github.com/insolar/insolar/core/reply"
+)
+
+func TestMessageBus_Record(t *testing.T) {
+	dir, err := ioutil.TempDir("", "recorder")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "messages")
+
+	mb, err := NewMessageBus(configuration.NewConfiguration())
+	assert.NoError(t, err)
+	mb.recorder, err = newRecorder(path)
+	assert.NoError(t, err)
+
+	mb.MustRegister(core.TypeCallMethod, func(ctx context.Context, msg core.Message) (core.Reply, error) {
+		m := msg.(*message.CallMethod)
+		if m.Method == "Fail" {
+			return nil, errors.New("method failed")
+		}
+		m.Method = "Mutated"
+		return &reply.CallMethod{Result: []byte("result")}, nil
+	})
+
+	_, err = mb.doDeliver(context.Background(), &message.CallMethod{Method: "Ok"}, true)
+	assert.NoError(t, err)
+	_, err = mb.doDeliver(context.Background(), &message.CallMethod{Method: "Fail"}, true)
+	assert.Error(t, err)
+
+	// messages caused by handled ones aren't recorded
+	_, err = mb.deliverLocal(message.NestedContext(context.Background()), &message.CallMethod{Method: "Ok"})
+	assert.NoError(t, err)
+	// caller is set by the sender, it doesn't make the message nested
+	_, err = mb.deliverLocal(context.Background(), &message.CallMethod{
+		BaseLogicMessage: message.BaseLogicMessage{Caller: core.RandomRef()},
+		Method:           "Caller",
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, mb.recorder.close())
+
+	records, err := ReadRecording(path)
+	assert.NoError(t, err)
+	assert.Len(t, records, 3)
+
+	msg, err := records[0].GetMessage()
+	assert.NoError(t, err)
+	assert.Equal(t, "Ok", msg.(*message.CallMethod).Method)
+	rep, err := records[0].GetReply()
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("result"), rep.(*reply.CallMethod).Result)
+
+	rep, err = records[1].GetReply()
+	assert.NoError(t, err)
+	assert.Nil(t, rep)
+	assert.Equal(t, "method failed", records[1].Error)
+
+	msg, err = records[2].GetMessage()
+	assert.NoError(t, err)
+	assert.Equal(t, "Mutated", msg.(*message.CallMethod).Method)
+}
@@ -0,0 +1,252 @@
+/*
+ *    Copyright 2018 Insolar
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+// Package replay reproduces message traffic recorded by MessageBus on a LogicRunner
+// and a ledger built on top of a local storage.
+package replay
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+
+	"github.com/insolar/insolar/configuration"
+	"github.com/insolar/insolar/core"
+	"github.com/insolar/insolar/core/reply"
+	"github.com/insolar/insolar/ledger"
+	"github.com/insolar/insolar/ledger/artifactmanager"
+	"github.com/insolar/insolar/ledger/jetcoordinator"
+	"github.com/insolar/insolar/ledger/pulsemanager"
+	"github.com/insolar/insolar/ledger/storage"
+	"github.com/insolar/insolar/logicrunner"
+	"github.com/insolar/insolar/messagebus"
+)
+
+// localBus delivers every message to the handler registered on it, nothing leaves the process.
+type localBus struct {
+	handlers map[core.MessageType]core.MessageHandler
+}
+
+func (b *localBus) Register(p core.MessageType, handler core.MessageHandler) error {
+	if _, ok := b.handlers[p]; ok {
+		return errors.New("handler for this type already exists")
+	}
+	b.handlers[p] = handler
+	return nil
+}
+
+func (b *localBus) MustRegister(p core.MessageType, handler core.MessageHandler) {
+	if err := b.Register(p, handler); err != nil {
+		panic(err)
+	}
+}
+
+func (b *localBus) Send(ctx context.Context, msg core.Message) (core.Reply, error) {
+	handler, ok := b.handlers[msg.Type()]
+	if !ok {
+		return nil, errors.Errorf("no handler for message type %s", msg.Type())
+	}
+	return handler(ctx, msg)
+}
+
+func (b *localBus) SendAsync(msg core.Message) {
+	go func() {
+		_, _ = b.Send(context.Background(), msg)
+	}()
+}
+
+// Mismatch describes a record whose replayed result differs from the recorded one.
+type Mismatch struct {
+	Index    int
+	Type     core.MessageType
+	Expected string
+	Actual   string
+}
+
+// Report is a result of the replay.
+type Report struct {
+	Total      int
+	Replayed   int
+	Mismatches []Mismatch
+}
+
+// Harness is a LogicRunner and a ledger connected with in-process message bus.
+type Harness struct {
+	db          *storage.DB
+	ledger      *ledger.Ledger
+	logicRunner *logicrunner.LogicRunner
+	bus         *localBus
+	pulse       core.PulseNumber
+}
+
+// NewHarness creates ledger in the configured data directory and LogicRunner on top of it.
+func NewHarness(cfg configuration.Configuration) (*Harness, error) {
+	db, err := storage.NewDB(cfg.Ledger, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "DB creation failed")
+	}
+	am, err := artifactmanager.NewArtifactManger(db)
+	if err != nil {
+		return nil, err
+	}
+	jc, err := jetcoordinator.NewJetCoordinator(db, cfg.Ledger.JetCoordinator)
+	if err != nil {
+		return nil, err
+	}
+	pm, err := pulsemanager.NewPulseManager(db, jc)
+	if err != nil {
+		return nil, err
+	}
+	handler, err := artifactmanager.NewMessageHandler(db)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Bootstrap(); err != nil {
+		return nil, err
+	}
+
+	lr, err := logicrunner.NewLogicRunner(&cfg.LogicRunner)
+	if err != nil {
+		return nil, err
+	}
+
+	h := &Harness{
+		db:          db,
+		ledger:      ledger.NewTestLedger(db, am, pm, jc, handler),
+		logicRunner: lr,
+		bus:         &localBus{handlers: map[core.MessageType]core.MessageHandler{}},
+	}
+
+	components := core.Components{
+		Ledger:      h.ledger,
+		LogicRunner: h.logicRunner,
+		MessageBus:  h.bus,
+	}
+	if err := h.ledger.Start(components); err != nil {
+		return nil, err
+	}
+	if err := h.logicRunner.Start(components); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// Stop stops LogicRunner and closes the ledger.
+func (h *Harness) Stop() error {
+	if err := h.logicRunner.Stop(); err != nil {
+		return err
+	}
+	return h.ledger.Stop()
+}
+
+// setPulse switches ledger and LogicRunner to the recorded pulse
+func (h *Harness) setPulse(pulse core.Pulse) error {
+	if pulse.PulseNumber == h.pulse {
+		return nil
+	}
+	if err := h.db.SetEntropy(pulse.PulseNumber, pulse.Entropy); err != nil {
+		return err
+	}
+	h.db.SetCurrentPulse(pulse.PulseNumber)
+	h.pulse = pulse.PulseNumber
+	return h.logicRunner.OnPulse(pulse)
+}
+
+// LogicTypes are types of contract calls, they are replayed by default. Ledger messages sent by
+// the recorded node itself aren't recorded, but messages of other nodes are, e.g. ledger writes
+// of remote executors, so replaying them together with calls applies the calls' writes twice.
+var LogicTypes = []core.MessageType{core.TypeCallMethod, core.TypeCallConstructor}
+
+// Run delivers recorded messages of the given types (LogicTypes if none provided) in recorded order
+// and compares results with the recorded ones.
+func (h *Harness) Run(records []messagebus.Record, types ...core.MessageType) (*Report, error) {
+	if len(types) == 0 {
+		types = LogicTypes
+	}
+	filter := map[core.MessageType]bool{}
+	for _, t := range types {
+		filter[t] = true
+	}
+
+	report := &Report{Total: len(records)}
+	for i, rec := range records {
+		msg, err := rec.GetMessage()
+		if err != nil {
+			return report, errors.Wrapf(err, "broken record #%d", i)
+		}
+		if !filter[msg.Type()] {
+			continue
+		}
+		if rec.Pulse.PulseNumber != 0 {
+			if err := h.setPulse(rec.Pulse); err != nil {
+				return report, errors.Wrapf(err, "couldn't set pulse of record #%d", i)
+			}
+		}
+
+		rep, err := h.bus.Send(context.Background(), msg)
+		report.Replayed++
+
+		expected := describe(rec.Reply, rec.Error)
+		actual, derr := describeResult(rep, err)
+		if derr != nil {
+			return report, errors.Wrapf(derr, "record #%d", i)
+		}
+		if expected != actual {
+			report.Mismatches = append(report.Mismatches, Mismatch{
+				Index:    i,
+				Type:     msg.Type(),
+				Expected: expected,
+				Actual:   actual,
+			})
+		}
+	}
+	return report, nil
+}
+
+func describeResult(rep core.Reply, err error) (string, error) {
+	if err != nil {
+		return describe(nil, err.Error()), nil
+	}
+	if rep == nil {
+		return describe(nil, ""), nil
+	}
+	rd, serr := reply.Serialize(rep)
+	if serr != nil {
+		return "", serr
+	}
+	buff := new(bytes.Buffer)
+	if _, serr := buff.ReadFrom(rd); serr != nil {
+		return "", serr
+	}
+	return describe(buff.Bytes(), ""), nil
+}
+
+// describe makes comparable representation of the reply or error
+func describe(rep []byte, errStr string) string {
+	if errStr != "" {
+		return "error: " + errStr
+	}
+	if len(rep) == 0 {
+		return "no reply"
+	}
+	r, err := reply.Deserialize(bytes.NewBuffer(rep))
+	if err != nil {
+		return fmt.Sprintf("broken reply %x", rep)
+	}
+	return fmt.Sprintf("%T%+v", r, r)
+}
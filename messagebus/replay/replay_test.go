@@ -0,0 +1,87 @@
+/*
+ *    Copyright 2018 Insolar
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package replay
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/insolar/insolar/configuration"
+	"github.com/insolar/insolar/core"
+	"github.com/insolar/insolar/core/message"
+	"github.com/insolar/insolar/messagebus"
+	"github.com/insolar/insolar/testutils"
+)
+
+func TestHarness_Run(t *testing.T) {
+	dir, err := ioutil.TempDir("", "replay")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	cfg := configuration.NewConfiguration()
+	cfg.Ledger.Storage.DataDirectory = dir
+	cfg.LogicRunner.GoPlugin = nil
+	cfg.LogicRunner.CaseBindDirectory = ""
+	cfg.LogicRunner.PrivateKey = testutils.PrivateKey()
+	h, err := NewHarness(cfg)
+	assert.NoError(t, err)
+	defer h.Stop()
+
+	pulse := core.Pulse{PulseNumber: core.FirstPulseNumber}
+	records := []messagebus.Record{
+		{
+			Pulse:   pulse,
+			Message: message.MustSerializeBytes(&message.GetCode{Code: core.RandomRef()}),
+			Error:   "code isn't found",
+		},
+		{
+			Pulse:   pulse,
+			Message: message.MustSerializeBytes(&message.CallMethod{ObjectRef: core.RandomRef(), Method: "Get"}),
+			Error:   "recorded error",
+		},
+	}
+
+	// only contract calls are replayed by default
+	report, err := h.Run(records)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, report.Total)
+	assert.Equal(t, 1, report.Replayed)
+	if assert.Len(t, report.Mismatches, 1) {
+		assert.Equal(t, 1, report.Mismatches[0].Index)
+		assert.Equal(t, core.TypeCallMethod, report.Mismatches[0].Type)
+		assert.Equal(t, "error: recorded error", report.Mismatches[0].Expected)
+	}
+
+	report, err = h.Run(records, core.TypeGetCode)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, report.Replayed)
+	if assert.Len(t, report.Mismatches, 1) {
+		assert.Equal(t, 0, report.Mismatches[0].Index)
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	assert.Equal(t, "error: failed", describe([]byte("ignored"), "failed"))
+	assert.Equal(t, "no reply", describe(nil, ""))
+
+	actual, err := describeResult(nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "no reply", actual)
+}
@@ -0,0 +1,37 @@
+/*
+ *    Copyright 2018 Insolar
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// MessageBusQueueDepth is current number of incoming messages being handled or waiting for a worker
+var MessageBusQueueDepth = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name:      "queue_depth",
+	Help:      "Current number of incoming messages being handled or waiting for a worker",
+	Namespace: insolarNamespace,
+	Subsystem: "messagebus",
+}, []string{"messageType"})
+
+// MessageBusRejectedTotal is total number of incoming messages rejected as busy
+var MessageBusRejectedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name:      "rejected_total",
+	Help:      "Total number of incoming messages rejected as busy",
+	Namespace: insolarNamespace,
+	Subsystem: "messagebus",
+}, []string{"messageType", "reason"})
@@ -56,6 +56,8 @@ func NewMetrics(cfg configuration.Metrics) (*Metrics, error) {
 	m.registry.MustRegister(NetworkFutures)
 	m.registry.MustRegister(NetworkPacketSentTotal)
 	m.registry.MustRegister(NetworkPacketReceivedTotal)
+	m.registry.MustRegister(MessageBusQueueDepth)
+	m.registry.MustRegister(MessageBusRejectedTotal)
 
 	return &m, nil
 }
@@ -835,7 +835,7 @@ func (dht *DHT) CheckNodeRole(domainID string) error {
 // RemoteProcedureRegister registers procedure for remote call on this host
 func (dht *DHT) RemoteProcedureRegister(name string, method core.RemoteProcedure) {
 	rp := func(sender *host.Host, args [][]byte) ([]byte, error) {
-		return method(args)
+		return method(sender.ID.String(), args)
 	}
 
 	dht.ncf.GetRPC().RegisterMethod(name, rp)
@@ -1017,7 +1017,7 @@ func TestDHT_RemoteProcedureCall(t *testing.T) {
 	msg1, _ := ioutil.ReadAll(reqBuff)
 
 	dht2.RemoteProcedureCall(GetDefaultCtx(dht1), dht2.GetOriginHost().IDs[0].String(), "test", [][]byte{msg1})
-	dht1.RemoteProcedureRegister("test", func(args [][]byte) ([]byte, error) {
+	dht1.RemoteProcedureRegister("test", func(sender string, args [][]byte) ([]byte, error) {
 		return nil, nil
 	})
 
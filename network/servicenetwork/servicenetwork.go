@@ -17,6 +17,7 @@
 package servicenetwork
 
 import (
+	"context"
 	"io/ioutil"
 	"strings"
 
@@ -29,6 +30,7 @@ import (
 	"github.com/insolar/insolar/network/hostnetwork"
 	"github.com/insolar/insolar/network/hostnetwork/hosthandler"
 	"github.com/insolar/insolar/network/nodenetwork"
+	"github.com/insolar/insolar/tracing"
 	"github.com/pkg/errors"
 )
 
@@ -73,23 +75,43 @@ func (network *ServiceNetwork) GetNodeID() core.RecordRef {
 	return network.nodeNetwork.GetID()
 }
 
-// SendMessage sends a message from MessageBus.
-func (network *ServiceNetwork) SendMessage(nodeID core.RecordRef, method string, msg core.Message) ([]byte, error) {
+type rpcResult struct {
+	data []byte
+	err  error
+}
+
+// SendMessage sends a message from MessageBus. Message is followed by the deadline of the context
+// in the arguments of the remote procedure, call is abandoned when the context is done.
+func (network *ServiceNetwork) SendMessage(ctx context.Context, nodeID core.RecordRef, method string, msg core.Message) ([]byte, error) {
 	if msg == nil {
 		return nil, errors.New("message is nil")
 	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	hostID := network.nodeNetwork.ResolveHostID(nodeID)
 	buff, err := messageToBytes(msg)
 	if err != nil {
 		return nil, errors.Wrap(err, "Failed to serialize event")
 	}
 
-	log.Debugf("SendMessage with nodeID = %s method = %s, message reference = %s", nodeID.String(),
+	tracing.Logger(ctx).Debugf("SendMessage with nodeID = %s method = %s, message reference = %s", nodeID.String(),
 		method, msg.Target().String())
 
 	metrics.NetworkMessageSentTotal.Inc()
-	res, err := network.hostNetwork.RemoteProcedureCall(createContext(network.hostNetwork), hostID, method, [][]byte{buff})
-	return res, err
+	args := [][]byte{buff, message.DeadlineBytes(ctx), tracing.Inject(ctx)}
+	done := make(chan rpcResult, 1)
+	go func() {
+		res, err := network.hostNetwork.RemoteProcedureCall(createContext(network.hostNetwork), hostID, method, args)
+		done <- rpcResult{data: res, err: err}
+	}()
+
+	select {
+	case res := <-done:
+		return res.data, res.err
+	case <-ctx.Done():
+		return nil, errors.Wrap(ctx.Err(), "SendMessage abandoned")
+	}
 }
 
 // SendCascadeMessage sends a message from MessageBus to a cascade of nodes. Message reference is ignored
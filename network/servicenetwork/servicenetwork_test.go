@@ -17,6 +17,7 @@
 package servicenetwork
 
 import (
+	"context"
 	"strconv"
 	"strings"
 	"sync"
@@ -63,7 +64,7 @@ func TestServiceNetwork_SendMessage(t *testing.T) {
 		Arguments: []byte("test"),
 	}
 
-	network.SendMessage(core.NewRefFromBase58("test"), "test", e)
+	network.SendMessage(context.Background(), core.NewRefFromBase58("test"), "test", e)
 }
 
 func TestServiceNetwork_Start(t *testing.T) {
@@ -144,7 +145,7 @@ func TestServiceNetwork_SendMessage2(t *testing.T) {
 	var wg sync.WaitGroup
 	wg.Add(1)
 
-	secondNode.RemoteProcedureRegister("test", func(args [][]byte) ([]byte, error) {
+	secondNode.RemoteProcedureRegister("test", func(sender string, args [][]byte) ([]byte, error) {
 		wg.Done()
 		return nil, nil
 	})
@@ -155,7 +156,7 @@ func TestServiceNetwork_SendMessage2(t *testing.T) {
 		Arguments: []byte("test"),
 	}
 
-	firstNode.SendMessage(core.NewRefFromBase58(secondNodeId), "test", e)
+	firstNode.SendMessage(context.Background(), core.NewRefFromBase58(secondNodeId), "test", e)
 	success := waitTimeout(&wg, 20*time.Millisecond)
 
 	assert.True(t, success)
@@ -185,7 +186,7 @@ func TestServiceNetwork_SendCascadeMessage(t *testing.T) {
 	var wg sync.WaitGroup
 	wg.Add(1)
 
-	secondNode.RemoteProcedureRegister("test", func(args [][]byte) ([]byte, error) {
+	secondNode.RemoteProcedureRegister("test", func(sender string, args [][]byte) ([]byte, error) {
 		wg.Done()
 		return nil, nil
 	})
@@ -254,7 +255,7 @@ func TestServiceNetwork_SendCascadeMessage2(t *testing.T) {
 		host = prefix + strconv.Itoa(port)
 		service, _ = NewServiceNetwork(mockServiceConfiguration(host, bHosts, node))
 		service.Start(core.Components{})
-		service.RemoteProcedureRegister("test", func(args [][]byte) ([]byte, error) {
+		service.RemoteProcedureRegister("test", func(sender string, args [][]byte) ([]byte, error) {
 			wg.Done()
 			return nil, nil
 		})
@@ -0,0 +1,34 @@
+/*
+ *    Copyright 2018 Insolar
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package testutils
+
+import (
+	ecdsa_helper "github.com/insolar/insolar/cryptohelpers/ecdsa"
+)
+
+// PrivateKey generates new private key and returns it PEM encoded
+func PrivateKey() string {
+	key, err := ecdsa_helper.GeneratePrivateKey()
+	if err != nil {
+		panic(err)
+	}
+	pem, err := ecdsa_helper.ExportPrivateKey(key)
+	if err != nil {
+		panic(err)
+	}
+	return pem
+}
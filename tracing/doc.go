@@ -0,0 +1,32 @@
+/*
+ *    Copyright 2018 Insolar
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+/*
+Package tracing correlates work done for a single request across API, MessageBus, LogicRunner and ledger.
+
+Trace is started at the entry point (e.g. API call), every component that does some work for the request
+starts a span as a child of the span found in the context. Span context is passed to other nodes and to
+contract runners as bytes (see Inject and Extract). Finished spans are exported in OpenTelemetry format
+(OTLP JSON) to a file and/or to a collector, ids of the trace and the span are written to logs.
+
+Example:
+
+	ctx, span := tracing.StartSpan(ctx, "messagebus.Send")
+	defer span.End()
+	span.SetAttribute("type", msg.Type().String())
+	tracing.Logger(ctx).Debugln("sending message")
+*/
+package tracing
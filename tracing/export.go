@@ -0,0 +1,312 @@
+/*
+ *    Copyright 2018 Insolar
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package tracing
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"os"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/insolar/insolar/configuration"
+	"github.com/insolar/insolar/log"
+)
+
+const (
+	scopeName = "github.com/insolar/insolar/tracing"
+
+	spanKindInternal = 1
+	statusCodeError  = 2
+
+	collectorTimeout = 10 * time.Second
+)
+
+var (
+	exporterMutex sync.RWMutex
+	current       *exporter
+)
+
+// exporter collects finished spans in batches and writes them to the file and/or to the collector.
+type exporter struct {
+	cfg     configuration.Tracing
+	spans   chan *Span
+	dropped uint64
+	file    *os.File
+	client  *http.Client
+	stop    chan struct{}
+	stopped chan struct{}
+}
+
+// Start starts export of finished spans, it does nothing if neither file nor collector is configured.
+// Previously started export is stopped.
+func Start(cfg configuration.Tracing) error {
+	Stop()
+	if cfg.File == "" && cfg.CollectorEndpoint == "" {
+		return nil
+	}
+	if cfg.BatchSize <= 0 {
+		return errors.New("tracing batch size must be positive")
+	}
+	if cfg.FlushInterval <= 0 {
+		return errors.New("tracing flush interval must be positive")
+	}
+
+	e := &exporter{
+		cfg:     cfg,
+		spans:   make(chan *Span, cfg.BatchSize*4),
+		stop:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	if cfg.File != "" {
+		f, err := os.OpenFile(cfg.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return errors.Wrap(err, "couldn't open tracing file")
+		}
+		e.file = f
+	}
+	if cfg.CollectorEndpoint != "" {
+		e.client = &http.Client{Timeout: collectorTimeout}
+	}
+	go e.loop()
+
+	exporterMutex.Lock()
+	current = e
+	exporterMutex.Unlock()
+	return nil
+}
+
+// Stop exports remaining spans and stops export.
+func Stop() {
+	exporterMutex.Lock()
+	e := current
+	current = nil
+	exporterMutex.Unlock()
+
+	if e == nil {
+		return
+	}
+	close(e.stop)
+	<-e.stopped
+	if e.file != nil {
+		if err := e.file.Close(); err != nil {
+			log.Errorln("couldn't close tracing file: ", err)
+		}
+	}
+}
+
+// export passes finished span to the exporter, span is dropped if exporter can't keep up.
+func export(s *Span) {
+	exporterMutex.RLock()
+	defer exporterMutex.RUnlock()
+	if current == nil {
+		return
+	}
+
+	select {
+	case current.spans <- s:
+	default:
+		atomic.AddUint64(&current.dropped, 1)
+	}
+}
+
+func (e *exporter) loop() {
+	defer close(e.stopped)
+	ticker := time.NewTicker(time.Duration(e.cfg.FlushInterval) * time.Millisecond)
+	defer ticker.Stop()
+
+	batch := make([]*Span, 0, e.cfg.BatchSize)
+	flush := func() {
+		if len(batch) > 0 {
+			e.flush(batch)
+			batch = make([]*Span, 0, e.cfg.BatchSize)
+		}
+		if dropped := atomic.SwapUint64(&e.dropped, 0); dropped > 0 {
+			log.Warnf("tracing exporter is overloaded, %d spans dropped", dropped)
+		}
+	}
+
+	for {
+		select {
+		case s := <-e.spans:
+			batch = append(batch, s)
+			if len(batch) >= e.cfg.BatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-e.stop:
+			for {
+				select {
+				case s := <-e.spans:
+					batch = append(batch, s)
+					if len(batch) >= e.cfg.BatchSize {
+						flush()
+					}
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+// flush writes batch of spans, export errors are only logged
+func (e *exporter) flush(batch []*Span) {
+	data, err := json.Marshal(encode(e.cfg.ServiceName, batch))
+	if err != nil {
+		log.Errorln("couldn't encode spans: ", err)
+		return
+	}
+
+	if e.file != nil {
+		_, err := e.file.Write(append(data, '\n'))
+		if err != nil {
+			log.Errorln("couldn't write spans to file: ", err)
+		}
+	}
+
+	if e.client != nil {
+		err := e.post(data)
+		if err != nil {
+			log.Errorln("couldn't send spans to collector: ", err)
+		}
+	}
+}
+
+func (e *exporter) post(data []byte) error {
+	resp, err := e.client.Post(e.cfg.CollectorEndpoint, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() // nolint: errcheck
+
+	if resp.StatusCode/100 != 2 {
+		return errors.Errorf("collector responded with %s", resp.Status)
+	}
+	return nil
+}
+
+// Types below follow JSON mapping of OTLP ExportTraceServiceRequest.
+
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	ParentSpanID      string         `json:"parentSpanId,omitempty"`
+	Name              string         `json:"name"`
+	Kind              int            `json:"kind"`
+	StartTimeUnixNano uint64         `json:"startTimeUnixNano,string"`
+	EndTimeUnixNano   uint64         `json:"endTimeUnixNano,string"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Status            otlpStatus     `json:"status"`
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpAnyValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+func encode(service string, spans []*Span) otlpRequest {
+	encoded := make([]otlpSpan, 0, len(spans))
+	for _, s := range spans {
+		encoded = append(encoded, encodeSpan(s))
+	}
+
+	return otlpRequest{
+		ResourceSpans: []otlpResourceSpans{{
+			Resource: otlpResource{
+				Attributes: []otlpKeyValue{stringAttribute("service.name", service)},
+			},
+			ScopeSpans: []otlpScopeSpans{{
+				Scope: otlpScope{Name: scopeName},
+				Spans: encoded,
+			}},
+		}},
+	}
+}
+
+func encodeSpan(s *Span) otlpSpan {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	res := otlpSpan{
+		TraceID:           s.context.TraceID.String(),
+		SpanID:            s.context.SpanID.String(),
+		Name:              s.name,
+		Kind:              spanKindInternal,
+		StartTimeUnixNano: uint64(s.start.UnixNano()),
+		EndTimeUnixNano:   uint64(s.end.UnixNano()),
+	}
+	if s.parent != (SpanID{}) {
+		res.ParentSpanID = s.parent.String()
+	}
+
+	keys := make([]string, 0, len(s.attributes))
+	for k := range s.attributes {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		res.Attributes = append(res.Attributes, stringAttribute(k, s.attributes[k]))
+	}
+
+	if s.err != "" {
+		res.Status = otlpStatus{Code: statusCodeError, Message: s.err}
+	}
+	return res
+}
+
+func stringAttribute(key, value string) otlpKeyValue {
+	return otlpKeyValue{Key: key, Value: otlpAnyValue{StringValue: value}}
+}
@@ -0,0 +1,186 @@
+/*
+ *    Copyright 2018 Insolar
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/insolar/insolar/core"
+	"github.com/insolar/insolar/log"
+)
+
+// TraceID identifies all spans of a single request.
+type TraceID [16]byte
+
+// SpanID identifies a span within a trace.
+type SpanID [8]byte
+
+// String returns hex representation of the trace id.
+func (id TraceID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// String returns hex representation of the span id.
+func (id SpanID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// SpanContext is a part of a span that is passed to children spans, including remote ones.
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+}
+
+// IsValid checks if span context refers to some span.
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != TraceID{} && sc.SpanID != SpanID{}
+}
+
+// Span is a named and timed operation, a part of a trace.
+type Span struct {
+	mutex      sync.Mutex
+	name       string
+	context    SpanContext
+	parent     SpanID
+	start      time.Time
+	end        time.Time
+	attributes map[string]string
+	err        string
+	ended      bool
+}
+
+type spanKey struct{}
+
+// StartSpan starts a child of the span from the context, new trace is started if context has no span.
+// Returned context carries the new span, span must be finished with End.
+func StartSpan(ctx context.Context, name string) (context.Context, *Span) {
+	parent := FromContext(ctx)
+	span := &Span{
+		name:       name,
+		start:      time.Now(),
+		attributes: map[string]string{},
+	}
+	if parent.IsValid() {
+		span.context.TraceID = parent.TraceID
+		span.parent = parent.SpanID
+	} else {
+		randomBytes(span.context.TraceID[:])
+	}
+	randomBytes(span.context.SpanID[:])
+
+	return context.WithValue(ctx, spanKey{}, span.context), span
+}
+
+// FromContext returns span context of the current span, zero span context is returned if context has no span.
+func FromContext(ctx context.Context) SpanContext {
+	sc, _ := ctx.Value(spanKey{}).(SpanContext)
+	return sc
+}
+
+// ContextWithParent returns context that carries span context received from other node or process,
+// so spans started with it become children of the remote span.
+func ContextWithParent(ctx context.Context, sc SpanContext) context.Context {
+	if !sc.IsValid() {
+		return ctx
+	}
+	return context.WithValue(ctx, spanKey{}, sc)
+}
+
+// Inject encodes span context of the context for passing it along with a message,
+// returns nil if context has no span.
+func Inject(ctx context.Context) []byte {
+	sc := FromContext(ctx)
+	if !sc.IsValid() {
+		return nil
+	}
+	buff := make([]byte, 0, len(sc.TraceID)+len(sc.SpanID))
+	buff = append(buff, sc.TraceID[:]...)
+	return append(buff, sc.SpanID[:]...)
+}
+
+// Extract returns child of the parent context that carries span context encoded by Inject.
+// Empty or malformed data results in the parent context.
+func Extract(parent context.Context, buff []byte) context.Context {
+	var sc SpanContext
+	if len(buff) != len(sc.TraceID)+len(sc.SpanID) {
+		return parent
+	}
+	copy(sc.TraceID[:], buff)
+	copy(sc.SpanID[:], buff[len(sc.TraceID):])
+	return ContextWithParent(parent, sc)
+}
+
+// Logger returns logger that adds ids of the current trace and span to every message.
+func Logger(ctx context.Context) core.Logger {
+	fields := map[string]interface{}{}
+	if sc := FromContext(ctx); sc.IsValid() {
+		fields["trace_id"] = sc.TraceID.String()
+		fields["span_id"] = sc.SpanID.String()
+	}
+	return log.WithFields(fields)
+}
+
+// Context returns span context of the span.
+func (s *Span) Context() SpanContext {
+	return s.context
+}
+
+// SetAttribute adds key-value attribute to the span.
+func (s *Span) SetAttribute(key, value string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.attributes[key] = value
+}
+
+// SetError marks span as failed, nil error is ignored.
+func (s *Span) SetError(err error) {
+	if err == nil {
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.err = err.Error()
+}
+
+// End finishes the span and passes it to the exporter, subsequent calls do nothing.
+func (s *Span) End() {
+	s.mutex.Lock()
+	if s.ended {
+		s.mutex.Unlock()
+		return
+	}
+	s.ended = true
+	s.end = time.Now()
+	s.mutex.Unlock()
+
+	log.WithFields(map[string]interface{}{
+		"trace_id": s.context.TraceID.String(),
+		"span_id":  s.context.SpanID.String(),
+	}).Debugf("span %s finished in %s", s.name, s.end.Sub(s.start))
+	export(s)
+}
+
+func randomBytes(b []byte) {
+	_, err := rand.Read(b)
+	if err != nil {
+		panic(err)
+	}
+}
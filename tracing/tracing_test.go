@@ -0,0 +1,106 @@
+/*
+ *    Copyright 2018 Insolar
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package tracing
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/insolar/insolar/configuration"
+)
+
+func TestStartSpan(t *testing.T) {
+	ctx, root := StartSpan(context.Background(), "root")
+	assert.True(t, root.Context().IsValid())
+	assert.Equal(t, root.Context(), FromContext(ctx))
+	assert.Equal(t, SpanID{}, root.parent)
+
+	_, child := StartSpan(ctx, "child")
+	assert.Equal(t, root.Context().TraceID, child.Context().TraceID)
+	assert.NotEqual(t, root.Context().SpanID, child.Context().SpanID)
+	assert.Equal(t, root.Context().SpanID, child.parent)
+
+	_, other := StartSpan(context.Background(), "other")
+	assert.NotEqual(t, root.Context().TraceID, other.Context().TraceID)
+}
+
+func TestInjectExtract(t *testing.T) {
+	assert.Nil(t, Inject(context.Background()))
+	assert.False(t, FromContext(Extract(context.Background(), nil)).IsValid())
+	assert.False(t, FromContext(Extract(context.Background(), []byte{1, 2, 3})).IsValid())
+
+	ctx, span := StartSpan(context.Background(), "sender")
+	remote := Extract(context.Background(), Inject(ctx))
+	assert.Equal(t, span.Context(), FromContext(remote))
+
+	_, child := StartSpan(remote, "receiver")
+	assert.Equal(t, span.Context().TraceID, child.Context().TraceID)
+	assert.Equal(t, span.Context().SpanID, child.parent)
+}
+
+func TestExport_File(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tracing")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir) // nolint: errcheck
+
+	cfg := configuration.NewTracing()
+	cfg.File = filepath.Join(dir, "spans.json")
+	err = Start(cfg)
+	assert.NoError(t, err)
+
+	ctx, root := StartSpan(context.Background(), "root")
+	_, child := StartSpan(ctx, "child")
+	child.SetAttribute("key", "value")
+	child.SetError(errors.New("failed"))
+	child.End()
+	child.End()
+	root.End()
+	Stop()
+
+	f, err := os.Open(cfg.File)
+	assert.NoError(t, err)
+	defer f.Close() // nolint: errcheck
+
+	var spans []otlpSpan
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var req otlpRequest
+		err := json.Unmarshal(scanner.Bytes(), &req)
+		assert.NoError(t, err)
+		assert.Equal(t, cfg.ServiceName, req.ResourceSpans[0].Resource.Attributes[0].Value.StringValue)
+		spans = append(spans, req.ResourceSpans[0].ScopeSpans[0].Spans...)
+	}
+	assert.NoError(t, scanner.Err())
+
+	assert.Len(t, spans, 2)
+	assert.Equal(t, "child", spans[0].Name)
+	assert.Equal(t, root.Context().SpanID.String(), spans[0].ParentSpanID)
+	assert.Equal(t, []otlpKeyValue{stringAttribute("key", "value")}, spans[0].Attributes)
+	assert.Equal(t, otlpStatus{Code: statusCodeError, Message: "failed"}, spans[0].Status)
+	assert.Equal(t, "root", spans[1].Name)
+	assert.Equal(t, "", spans[1].ParentSpanID)
+	assert.Equal(t, spans[0].TraceID, spans[1].TraceID)
+	assert.True(t, spans[1].EndTimeUnixNano >= spans[1].StartTimeUnixNano)
+}